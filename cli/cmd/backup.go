@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/backup_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/cluster_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helm_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
+	"github.com/spf13/cobra"
+)
+
+const minBackupPassphraseLength = 8
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Export and import encrypted cluster backups",
+	Long:  "Export and import encrypted cluster backups. A backup holds the Talos configs, bbe.yaml, the storage encryption key, a kubeconfig, the node inventory and the values of the installed packages, everything needed to manage the cluster from another workstation.",
+}
+
+var backupExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write an encrypted backup of the active cluster",
+	Long:  fmt.Sprintf("Write an encrypted backup of the active cluster. The passphrase is read from %s or asked for interactively.", constants.BackupPassphraseEnvVar),
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		talosService := talos_service.TalosService{}
+		helmService := helm_service.HelmService{}
+		uiService := ui_service.UiService{}
+		backupService := backup_service.BackupService{}
+
+		file, _ := cmd.Flags().GetString("file")
+
+		return backupExportCommand(helperService, configService, talosService, helmService, uiService, backupService, file)
+	},
+}
+
+var backupImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Restore a backup as a cluster on this workstation",
+	Long:  "Restore a backup as a cluster on this workstation. The contents are listed and verified before anything is written, an existing cluster is only replaced with --force.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		clusterService := cluster_service.ClusterService{}
+		uiService := ui_service.UiService{}
+		backupService := backup_service.BackupService{}
+
+		name, _ := cmd.Flags().GetString("name")
+		force, _ := cmd.Flags().GetBool("force")
+
+		return backupImportCommand(helperService, clusterService, uiService, backupService, args[0], name, force)
+	},
+}
+
+var backupInspectCmd = &cobra.Command{
+	Use:   "inspect <file>",
+	Short: "Verify a backup and list its contents",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		uiService := ui_service.UiService{}
+		backupService := backup_service.BackupService{}
+
+		return backupInspectCommand(uiService, backupService, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupExportCmd)
+	backupCmd.AddCommand(backupImportCmd)
+	backupCmd.AddCommand(backupInspectCmd)
+
+	backupExportCmd.Flags().StringP("file", "f", "", "File to write the backup to, defaults to bbe-<cluster>-<timestamp>.tar.gz.age")
+	backupImportCmd.Flags().String("name", "", "Name of the cluster to restore into, defaults to the name stored in the backup")
+	backupImportCmd.Flags().Bool("force", false, "Replace the configuration of an existing cluster")
+}
+
+func backupExportCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, helmService interfaces.HelmServiceInterface, uiService interfaces.UiServiceInterface, backupService interfaces.BackupServiceInterface, file string) error {
+	backup, err := backupService.Create(helperService, configService, talosService, helmService)
+	if err != nil {
+		if errors.Is(err, constants.ConfigNotFoundError) {
+			return noClusterError
+		}
+		return fmt.Errorf("Failed to create the backup: %w", err)
+	}
+
+	if file == "" {
+		file = fmt.Sprintf("bbe-%s-%s.tar.gz.age", backup.Manifest.Cluster, backup.Manifest.CreatedAt.Format("20060102-150405"))
+	}
+
+	passphrase, err := getBackupPassphrase(uiService, true)
+	if err != nil {
+		return err
+	}
+
+	err = backupService.Write(backup, passphrase, file)
+	if err != nil {
+		return fmt.Errorf("Failed to write the backup: %w", err)
+	}
+
+	logger.Infof("Backup contents:\n%s", formatBackupContents(backup.Manifest))
+	logger.Infof("Backup of cluster `%s` written to %s, keep the passphrase, it cannot be recovered", backup.Manifest.Cluster, file)
+	return nil
+}
+
+func backupImportCommand(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface, uiService interfaces.UiServiceInterface, backupService interfaces.BackupServiceInterface, input string, name string, force bool) error {
+	passphrase, err := getBackupPassphrase(uiService, false)
+	if err != nil {
+		return err
+	}
+
+	backup, err := backupService.Read(input, passphrase)
+	if err != nil {
+		return fmt.Errorf("Failed to read the backup: %w", err)
+	}
+
+	target := name
+	if target == "" {
+		target = backup.Manifest.Cluster
+	}
+	if target == "" {
+		target = constants.DefaultClusterName
+	}
+	err = cluster_service.ValidateName(target)
+	if err != nil {
+		return err
+	}
+
+	exists := clusterService.Exists(helperService, target)
+	if exists && !force {
+		return fmt.Errorf("Cluster `%s` already exists, use --force to replace its configuration or --name to restore into another cluster", target)
+	}
+
+	logger.Infof("Backup of cluster `%s` created %s with bbe %s:\n%s", backup.Manifest.Cluster, backup.Manifest.CreatedAt.Local().Format(time.DateTime), backup.Manifest.CliVersion, formatBackupContents(backup.Manifest))
+
+	answer, err := uiService.CreateSelect(fmt.Sprintf("Restore these files into cluster `%s`?", target), []string{"Yes", "No"})
+	if err != nil {
+		return err
+	}
+	if answer != "Yes" {
+		return nil
+	}
+
+	if exists {
+		err = clusterService.Use(helperService, target)
+	} else {
+		err = clusterService.Create(helperService, target)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Setenv(constants.ClusterEnvVar, target)
+	if err != nil {
+		return err
+	}
+
+	err = backupService.Restore(helperService, backup)
+	if err != nil {
+		return fmt.Errorf("Failed to restore the backup: %w", err)
+	}
+
+	logger.Infof("Restored cluster `%s` and made it the active cluster, run 'bbe config' to sync it with the remote storage", target)
+	if _, found := backup.Files[constants.KubeConfigFile]; found {
+		logger.Infof("The kubeconfig was saved to %s", filepath.Join(helperService.GetConfigDir(), constants.KubeConfigFile))
+	}
+	return nil
+}
+
+func backupInspectCommand(uiService interfaces.UiServiceInterface, backupService interfaces.BackupServiceInterface, input string) error {
+	passphrase, err := getBackupPassphrase(uiService, false)
+	if err != nil {
+		return err
+	}
+
+	backup, err := backupService.Read(input, passphrase)
+	if err != nil {
+		return fmt.Errorf("Failed to read the backup: %w", err)
+	}
+
+	logger.Infof("Backup of cluster `%s` created %s with bbe %s, all checksums match:\n%s", backup.Manifest.Cluster, backup.Manifest.CreatedAt.Local().Format(time.DateTime), backup.Manifest.CliVersion, formatBackupContents(backup.Manifest))
+	return nil
+}
+
+// getBackupPassphrase reads the passphrase from the environment or asks for it, a new passphrase has to be entered twice
+func getBackupPassphrase(uiService interfaces.UiServiceInterface, confirm bool) (string, error) {
+	if passphrase := os.Getenv(constants.BackupPassphraseEnvVar); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := uiService.CreatePasswordInput("Backup passphrase")
+	if err != nil {
+		return "", err
+	}
+
+	if !confirm {
+		return passphrase, nil
+	}
+
+	if len(passphrase) < minBackupPassphraseLength {
+		return "", fmt.Errorf("The passphrase must be at least %d characters long", minBackupPassphraseLength)
+	}
+
+	confirmation, err := uiService.CreatePasswordInput("Repeat the backup passphrase")
+	if err != nil {
+		return "", err
+	}
+	if confirmation != passphrase {
+		return "", errors.New("The passphrases do not match")
+	}
+
+	return passphrase, nil
+}
+
+func formatBackupContents(manifest models.BackupManifest) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "FILE\tSIZE\tSHA256")
+	for _, file := range manifest.Files {
+		fmt.Fprintf(writer, "%s\t%d\t%s\n", file.Path, file.Size, file.Sha256[:min(12, len(file.Sha256))])
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
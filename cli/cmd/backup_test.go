@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testBackup(cluster string) *models.Backup {
+	return &models.Backup{
+		Manifest: models.BackupManifest{
+			Version: constants.BackupFormatVersion,
+			Cluster: cluster,
+			Files:   []models.BackupFile{{Path: constants.BbeConfigFile, Size: 4, Sha256: "0123456789abcdef"}},
+		},
+		Files: map[string][]byte{constants.BbeConfigFile: []byte("bbe:")},
+	}
+}
+
+func Test_backupExportCommand_Succeeds_WithPassphraseFromEnvironment(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "correct horse")
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	talosService := &mocks.MockTalosService{}
+	helmService := &mocks.MockHelmService{}
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+
+	backup := testBackup("home")
+	backupService.On("Create", helperService, configService, talosService, helmService).Return(backup, nil)
+	backupService.On("Write", backup, "correct horse", "home.tar.gz.age").Return(nil)
+
+	err := backupExportCommand(helperService, configService, talosService, helmService, uiService, backupService, "home.tar.gz.age")
+
+	assert.Nil(t, err)
+	backupService.AssertCalled(t, "Write", backup, "correct horse", "home.tar.gz.age")
+	uiService.AssertNotCalled(t, "CreatePasswordInput", mock.Anything)
+}
+
+func Test_backupExportCommand_Fails_WhenPassphrasesDoNotMatch(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "")
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	talosService := &mocks.MockTalosService{}
+	helmService := &mocks.MockHelmService{}
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+
+	backupService.On("Create", helperService, configService, talosService, helmService).Return(testBackup("home"), nil)
+	uiService.On("CreatePasswordInput", "Backup passphrase").Return("correct horse", nil)
+	uiService.On("CreatePasswordInput", "Repeat the backup passphrase").Return("battery staple", nil)
+
+	err := backupExportCommand(helperService, configService, talosService, helmService, uiService, backupService, "")
+
+	assert.ErrorContains(t, err, "do not match")
+	backupService.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_backupExportCommand_Fails_WithShortPassphrase(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "")
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+
+	backupService.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testBackup("home"), nil)
+	uiService.On("CreatePasswordInput", "Backup passphrase").Return("short", nil)
+
+	err := backupExportCommand(&mocks.MockHelperService{}, &mocks.MockConfigService{}, &mocks.MockTalosService{}, &mocks.MockHelmService{}, uiService, backupService, "")
+
+	assert.ErrorContains(t, err, "at least 8 characters")
+}
+
+func Test_backupExportCommand_Fails_WithNoCluster(t *testing.T) {
+	backupService := &mocks.MockBackupService{}
+	backupService.On("Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return((*models.Backup)(nil), constants.ConfigNotFoundError)
+
+	err := backupExportCommand(&mocks.MockHelperService{}, &mocks.MockConfigService{}, &mocks.MockTalosService{}, &mocks.MockHelmService{}, &mocks.MockUiService{}, backupService, "")
+
+	assert.ErrorContains(t, err, "No BBE cluster found")
+	assert.Equal(t, clierror.ExitConfigInvalid, clierror.ExitCode(err))
+	backupService.AssertNotCalled(t, "Write", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_backupImportCommand_Succeeds_CreatesClusterFromBackup(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "correct horse")
+	t.Setenv(constants.ClusterEnvVar, "")
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+
+	backup := testBackup("home")
+	backupService.On("Read", "home.tar.gz.age", "correct horse").Return(backup, nil)
+	backupService.On("Restore", helperService, backup).Return(nil)
+	clusterService.On("Exists", helperService, "home").Return(false)
+	clusterService.On("Create", helperService, "home").Return(nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Yes", "No"}).Return("Yes", nil)
+
+	err := backupImportCommand(helperService, clusterService, uiService, backupService, "home.tar.gz.age", "", false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "home", os.Getenv(constants.ClusterEnvVar))
+	backupService.AssertCalled(t, "Restore", helperService, backup)
+}
+
+func Test_backupImportCommand_Succeeds_WithoutRestoringWhenDeclined(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "correct horse")
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+
+	backupService.On("Read", mock.Anything, mock.Anything).Return(testBackup("home"), nil)
+	clusterService.On("Exists", helperService, "lab").Return(false)
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("No", nil)
+
+	err := backupImportCommand(helperService, clusterService, uiService, backupService, "home.tar.gz.age", "lab", false)
+
+	assert.Nil(t, err)
+	clusterService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	backupService.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func Test_backupImportCommand_Fails_WhenClusterExistsWithoutForce(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "correct horse")
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+
+	backupService.On("Read", mock.Anything, mock.Anything).Return(testBackup("home"), nil)
+	clusterService.On("Exists", helperService, "home").Return(true)
+
+	err := backupImportCommand(helperService, clusterService, uiService, backupService, "home.tar.gz.age", "", false)
+
+	assert.ErrorContains(t, err, "use --force")
+	backupService.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func Test_backupImportCommand_Fails_WithInvalidBackup(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "correct horse")
+	backupService := &mocks.MockBackupService{}
+	backupService.On("Read", mock.Anything, mock.Anything).Return((*models.Backup)(nil), constants.BackupIntegrityError)
+
+	err := backupImportCommand(&mocks.MockHelperService{}, &mocks.MockClusterService{}, &mocks.MockUiService{}, backupService, "home.tar.gz.age", "", false)
+
+	assert.ErrorIs(t, err, constants.BackupIntegrityError)
+}
+
+func Test_backupInspectCommand_Fails_WhenReadFails(t *testing.T) {
+	t.Setenv(constants.BackupPassphraseEnvVar, "")
+	uiService := &mocks.MockUiService{}
+	backupService := &mocks.MockBackupService{}
+	uiService.On("CreatePasswordInput", "Backup passphrase").Return("wrong", nil)
+	backupService.On("Read", "home.tar.gz.age", "wrong").Return((*models.Backup)(nil), errors.New("Wrong passphrase"))
+
+	err := backupInspectCommand(uiService, backupService, "home.tar.gz.age")
+
+	assert.ErrorContains(t, err, "Wrong passphrase")
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/cluster_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
+	"github.com/spf13/cobra"
+)
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "Manage the clusters configured on this workstation",
+	Long:  "Manage the clusters configured on this workstation. Every cluster has its own Talos configs, packages and storage, bbe commands act on the active cluster unless --cluster is given.",
+}
+
+var clusterCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a cluster and make it the active cluster",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		clusterService := cluster_service.ClusterService{}
+
+		return clusterCreateCommand(helperService, clusterService, args[0])
+	},
+}
+
+var clusterUseCmd = &cobra.Command{
+	Use:               "use <name>",
+	Short:             "Make a cluster the active cluster",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: firstArgOnly(completeClusters),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		clusterService := cluster_service.ClusterService{}
+
+		return clusterUseCommand(helperService, clusterService, args[0])
+	},
+}
+
+var clusterListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the configured clusters",
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		clusterService := cluster_service.ClusterService{}
+
+		return clusterListCommand(helperService, clusterService)
+	},
+}
+
+var clusterDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Aliases:           []string{"rm"},
+	Short:             "Delete the local configuration of a cluster",
+	Long:              "Delete the local configuration of a cluster. The cluster nodes and the remote storage are left untouched.",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: firstArgOnly(completeClusters),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		clusterService := cluster_service.ClusterService{}
+		uiService := ui_service.UiService{}
+
+		return clusterDeleteCommand(helperService, clusterService, uiService, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(clusterCreateCmd)
+	clusterCmd.AddCommand(clusterUseCmd)
+	clusterCmd.AddCommand(clusterListCmd)
+	clusterCmd.AddCommand(clusterDeleteCmd)
+}
+
+func clusterCreateCommand(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface, name string) error {
+	err := clusterService.Create(helperService, name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Created cluster `%s` and made it the active cluster, run 'bbe setup' to create its first node", name)
+	return nil
+}
+
+func clusterUseCommand(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface, name string) error {
+	err := clusterService.Use(helperService, name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Switched to cluster `%s`", name)
+	return nil
+}
+
+func clusterListCommand(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface) error {
+	clusters, err := clusterService.List(helperService)
+	if err != nil {
+		return fmt.Errorf("Failed to list clusters: %w", err)
+	}
+
+	current := helperService.GetClusterName()
+	results := []models.ClusterResult{}
+	for _, cluster := range clusters {
+		results = append(results, models.ClusterResult{Name: cluster, Current: cluster == current})
+	}
+
+	return output.Print(results, func() string {
+		if len(results) == 0 {
+			return "No clusters configured, run 'bbe setup' or 'bbe cluster create <name>' to create one"
+		}
+
+		lines := []string{}
+		for _, result := range results {
+			marker := " "
+			if result.Current {
+				marker = "*"
+			}
+			lines = append(lines, fmt.Sprintf("%s %s", marker, result.Name))
+		}
+
+		return strings.Join(lines, "\n")
+	})
+}
+
+func clusterDeleteCommand(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface, uiService interfaces.UiServiceInterface, name string) error {
+	answer, err := uiService.CreateSelect(fmt.Sprintf("Delete the local configuration of cluster `%s`? The nodes and remote storage are left untouched", name), []string{"No", "Yes"})
+	if err != nil {
+		return err
+	}
+	if answer != "Yes" {
+		return nil
+	}
+
+	err = clusterService.Delete(helperService, name)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Deleted cluster `%s`", name)
+	return nil
+}
+
+// selectCluster moves a single cluster setup into its own directory and applies the --cluster flag
+func selectCluster(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface, name string) error {
+	err := clusterService.MigrateLegacyConfig(helperService)
+	if err != nil {
+		return fmt.Errorf("Failed to migrate the existing configuration: %w", err)
+	}
+
+	if name == "" {
+		return nil
+	}
+
+	if !clusterService.Exists(helperService, name) {
+		return fmt.Errorf("%w: %s, run 'bbe cluster list' to see the configured clusters", constants.ClusterNotFoundError, name)
+	}
+
+	return os.Setenv(constants.ClusterEnvVar, name)
+}
+
+// noClusterError is returned by every command that needs a cluster when bbe.yaml is missing or names no cluster
+var noClusterError = clierror.New(clierror.KindConfigInvalid, errors.New("No BBE cluster found, please run 'bbe setup' to create your cluster"), "")
+
+// getClusterConfig returns bbe.yaml of the current cluster or noClusterError
+func getClusterConfig(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) (*models.BbeConfig, error) {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil || bbeConfig.Bbe.Cluster.Name == "" {
+		return nil, noClusterError
+	}
+
+	return bbeConfig, nil
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_clusterCreateCommand_Succeeds(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("Create", mock.Anything, "lab").Return(nil)
+
+	err := clusterCreateCommand(helperService, clusterService, "lab")
+
+	assert.Nil(t, err)
+	clusterService.AssertCalled(t, "Create", mock.Anything, "lab")
+}
+
+func Test_clusterUseCommand_Fails_WithUnknownCluster(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("Use", mock.Anything, "lab").Return(constants.ClusterNotFoundError)
+
+	err := clusterUseCommand(helperService, clusterService, "lab")
+
+	assert.ErrorIs(t, err, constants.ClusterNotFoundError)
+}
+
+func Test_clusterListCommand_Succeeds(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	helperService.On("GetClusterName").Return("lab")
+	clusterService.On("List", mock.Anything).Return([]string{"home", "lab"}, nil)
+
+	err := clusterListCommand(helperService, clusterService)
+
+	assert.Nil(t, err)
+	helperService.AssertNumberOfCalls(t, "GetClusterName", 1)
+}
+
+func Test_clusterListCommand_Succeeds_WithJsonOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatJson)
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	helperService.On("GetClusterName").Return("lab")
+	clusterService.On("List", mock.Anything).Return([]string{"home", "lab"}, nil)
+
+	err := clusterListCommand(helperService, clusterService)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"name": "home", "current": false}, {"name": "lab", "current": true}]`, buffer.String())
+}
+
+func Test_clusterListCommand_Fails_WhenListingFails(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("List", mock.Anything).Return([]string{}, errors.New("permission denied"))
+
+	err := clusterListCommand(helperService, clusterService)
+
+	assert.NotNil(t, err)
+}
+
+func Test_clusterDeleteCommand_Succeeds_WhenConfirmed(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	uiService := &mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("Yes", nil)
+	clusterService.On("Delete", mock.Anything, "lab").Return(nil)
+
+	err := clusterDeleteCommand(helperService, clusterService, uiService, "lab")
+
+	assert.Nil(t, err)
+	clusterService.AssertCalled(t, "Delete", mock.Anything, "lab")
+}
+
+func Test_clusterDeleteCommand_Succeeds_WhenNotConfirmed(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	uiService := &mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("No", nil)
+
+	err := clusterDeleteCommand(helperService, clusterService, uiService, "lab")
+
+	assert.Nil(t, err)
+	clusterService.AssertNumberOfCalls(t, "Delete", 0)
+}
+
+func Test_selectCluster_Succeeds_WithClusterFlag(t *testing.T) {
+	t.Setenv(constants.ClusterEnvVar, "")
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("MigrateLegacyConfig", mock.Anything).Return(nil)
+	clusterService.On("Exists", mock.Anything, "lab").Return(true)
+
+	err := selectCluster(helperService, clusterService, "lab")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "lab", os.Getenv(constants.ClusterEnvVar))
+}
+
+func Test_selectCluster_Fails_WithUnknownCluster(t *testing.T) {
+	t.Setenv(constants.ClusterEnvVar, "")
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("MigrateLegacyConfig", mock.Anything).Return(nil)
+	clusterService.On("Exists", mock.Anything, "lab").Return(false)
+
+	err := selectCluster(helperService, clusterService, "lab")
+
+	assert.ErrorIs(t, err, constants.ClusterNotFoundError)
+	assert.Equal(t, "", os.Getenv(constants.ClusterEnvVar))
+}
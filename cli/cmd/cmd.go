@@ -1,9 +1,19 @@
 package cmd
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/cluster_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
 	"github.com/spf13/cobra"
 )
 
@@ -11,23 +21,133 @@ var rootCmd = &cobra.Command{
 	Use:   "bbe",
 	Short: "a cli for managing your Talos k8s cluster",
 	Long:  `bbe is a cli for managing your Talos k8s cluster.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:  cobra.NoArgs,
+	// Errors are reported by Execute, with a suggested fix and an exit code matching their kind
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if isCompletionCommand(cmd) {
+			return nil
+		}
+
+		helperService := helper_service.HelperService{}
+		clusterService := cluster_service.ClusterService{}
+
+		name, _ := cmd.Flags().GetString("cluster")
+		format, _ := cmd.Flags().GetString("output")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		logLevel, _ := cmd.Flags().GetString("log-level")
+		logFormat, _ := cmd.Flags().GetString("log-format")
+
+		// The output format decides where log messages go, so it is set before the first message is logged
+		err := setOutputFormat(format)
+		if err != nil {
+			return usageError(cmd, err)
+		}
 
+		err = configureLogging(helperService, verbose, logLevel, logFormat)
+		if err != nil {
+			return usageError(cmd, err)
+		}
+
+		err = selectCluster(helperService, clusterService, name)
+		if err != nil {
+			return err
+		}
+
+		return useClusterKubeConfig(helperService)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
 	},
 }
 
-func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(0)
+func init() {
+	rootCmd.PersistentFlags().String("cluster", "", "Cluster to act on instead of the active cluster")
+	rootCmd.PersistentFlags().StringP("output", "o", output.FormatText, fmt.Sprintf("Output format, one of: %s", strings.Join(output.Formats, ", ")))
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Show debug messages, the same as --log-level debug")
+	rootCmd.PersistentFlags().String("log-level", "", fmt.Sprintf("Lowest level of the messages shown, one of: %s", strings.Join(logger.Levels, ", ")))
+	rootCmd.PersistentFlags().String("log-format", logger.FormatText, fmt.Sprintf("Format of the messages shown, one of: %s", strings.Join(logger.Formats, ", ")))
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "log-level")
+	rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(output.Formats, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions(logger.Levels, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions(logger.Formats, cobra.ShellCompDirectiveNoFileComp))
+
+	rootCmd.SetFlagErrorFunc(usageError)
+}
+
+func usageError(cmd *cobra.Command, err error) error {
+	return clierror.New(clierror.KindUsage, err, fmt.Sprintf("Run '%s --help' for usage", cmd.CommandPath()))
+}
+
+// markUsageErrors turns the argument errors of cmd and its subcommands into usage errors
+func markUsageErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			err := validate(cmd, args)
+			if err != nil {
+				return usageError(cmd, err)
+			}
+			return nil
+		}
+	}
+
+	for _, child := range cmd.Commands() {
+		markUsageErrors(child)
+	}
+}
+
+// configureLogging applies the verbosity flags and starts the log file of this run, a missing log file does not stop the command
+func configureLogging(helperService interfaces.HelperServiceInterface, verbose bool, levelName string, format string) error {
+	level := logger.DefaultLevel()
+	if verbose {
+		level = slog.LevelDebug
 	}
 
-	// No command was provided
-	if len(os.Args) == 1 {
-		err := rootCmd.Help()
+	if levelName != "" {
+		parsed, err := logger.ParseLevel(levelName)
 		if err != nil {
-			logger.Error("Error while printing help message", err)
-			os.Exit(1)
+			return err
 		}
-		os.Exit(0)
+		level = parsed
+	}
+
+	err := logger.Configure(level, format)
+	if err != nil {
+		return err
 	}
+
+	path, err := logger.OpenLogFile(filepath.Join(helperService.GetBaseDir(), constants.LogsDir), constants.LogFileKeep)
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Unable to create a log file: %v", err))
+		return nil
+	}
+
+	logger.Debug(fmt.Sprintf("bbe %s writing to %s, arguments: %s", constants.Version, path, strings.Join(os.Args[1:], " ")))
+	return nil
+}
+
+// setOutputFormat moves log messages to stderr when stdout is reserved for a json or yaml result
+func setOutputFormat(format string) error {
+	err := output.SetFormat(format)
+	if err != nil {
+		return err
+	}
+
+	if output.IsStructured() {
+		logger.SetWriter(os.Stderr)
+	}
+
+	return nil
+}
+
+func Execute() {
+	markUsageErrors(rootCmd)
+
+	err := rootCmd.Execute()
+	if err != nil {
+		logger.ErrorWithHint("", err, clierror.Hint(err))
+	}
+
+	os.Exit(clierror.ExitCode(err))
 }
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureOutput collects the results printed in format until the test ends
+func captureOutput(t *testing.T, format string) *bytes.Buffer {
+	var buffer bytes.Buffer
+	previous := output.SetWriter(&buffer)
+
+	err := output.SetFormat(format)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		output.SetWriter(previous)
+		_ = output.SetFormat(output.FormatText)
+	})
+
+	return &buffer
+}
+
+func Test_setOutputFormat_Succeeds_WithJson(t *testing.T) {
+	captureOutput(t, output.FormatText)
+
+	err := setOutputFormat(output.FormatJson)
+
+	assert.Nil(t, err)
+	assert.True(t, output.IsStructured())
+}
+
+func Test_setOutputFormat_Fails_WithUnknownFormat(t *testing.T) {
+	captureOutput(t, output.FormatText)
+
+	err := setOutputFormat("table")
+
+	assert.ErrorContains(t, err, "Unknown output format `table`")
+	assert.False(t, output.IsStructured())
+}
+
+// captureLogs collects the console messages until the test ends
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buffer bytes.Buffer
+	logger.SetWriter(&buffer)
+
+	t.Cleanup(func() {
+		logger.CloseLogFile()
+		logger.SetWriter(os.Stdout)
+		_ = logger.Configure(logger.DefaultLevel(), logger.FormatText)
+	})
+
+	return &buffer
+}
+
+func Test_configureLogging_Succeeds_WithVerbose(t *testing.T) {
+	buffer := captureLogs(t)
+	baseDir := t.TempDir()
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetBaseDir").Return(baseDir)
+
+	err := configureLogging(helperService, true, "", logger.FormatText)
+	assert.Nil(t, err)
+	logger.Debug("Checking dependencies")
+
+	assert.Contains(t, buffer.String(), "DEBUG: Checking dependencies")
+	files, _ := filepath.Glob(filepath.Join(baseDir, constants.LogsDir, "bbe-*.log"))
+	assert.Len(t, files, 1)
+}
+
+func Test_configureLogging_Succeeds_WithLogLevel(t *testing.T) {
+	buffer := captureLogs(t)
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetBaseDir").Return(t.TempDir())
+
+	err := configureLogging(helperService, false, "error", logger.FormatJson)
+	assert.Nil(t, err)
+	logger.Warning("Unable to load the package library")
+	logger.Error("Failed to reconcile package blocky", nil)
+
+	assert.NotContains(t, buffer.String(), "Unable to load the package library")
+	assert.Contains(t, buffer.String(), `"msg":"Failed to reconcile package blocky"`)
+}
+
+func Test_configureLogging_Succeeds_WhenLogFileCannotBeCreated(t *testing.T) {
+	buffer := captureLogs(t)
+	baseDir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(baseDir, constants.LogsDir), []byte{}, 0600))
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetBaseDir").Return(baseDir)
+
+	err := configureLogging(helperService, false, "", logger.FormatText)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), "Unable to create a log file")
+}
+
+func Test_configureLogging_Fails_WithUnknownLevel(t *testing.T) {
+	captureLogs(t)
+
+	err := configureLogging(&mocks.MockHelperService{}, false, "trace", logger.FormatText)
+
+	assert.ErrorContains(t, err, "Unknown log level `trace`")
+}
+
+func Test_configureLogging_Fails_WithUnknownFormat(t *testing.T) {
+	captureLogs(t)
+
+	err := configureLogging(&mocks.MockHelperService{}, false, "", "xml")
+
+	assert.ErrorContains(t, err, "Unknown log format `xml`")
+}
+
+func Test_markUsageErrors_Succeeds_WithTooManyArguments(t *testing.T) {
+	root := &cobra.Command{Use: "bbe"}
+	child := &cobra.Command{Use: "status", Args: cobra.NoArgs, RunE: func(cmd *cobra.Command, args []string) error { return nil }}
+	root.AddCommand(child)
+
+	markUsageErrors(root)
+	err := child.Args(child, []string{"extra"})
+
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	assert.Equal(t, "Run 'bbe status --help' for usage", clierror.Hint(err))
+}
+
+func Test_markUsageErrors_Succeeds_WithValidArguments(t *testing.T) {
+	root := &cobra.Command{Use: "bbe", Args: cobra.NoArgs}
+
+	markUsageErrors(root)
+
+	assert.Nil(t, root.Args(root, []string{}))
+}
+
+func Test_usageError_Succeeds_KeepsTheMessage(t *testing.T) {
+	err := usageError(&cobra.Command{Use: "bbe"}, errors.New("unknown flag: --foo"))
+
+	assert.EqualError(t, err, "unknown flag: --foo")
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+}
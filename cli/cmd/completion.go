@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/cluster_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/image_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/package_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/spf13/cobra"
+)
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish>",
+	Short: "Generate the completion script for your shell",
+	Long: `Generate the completion script for your shell. Package names, nodes, clusters and device types are completed from the library and the configuration of the active cluster.
+
+  bash: source <(bbe completion bash)
+  zsh:  bbe completion zsh > "${fpath[1]}/_bbe"
+  fish: bbe completion fish > ~/.config/fish/completions/bbe.fish`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: completionShells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return completionCommand(cmd.Root(), args[0], os.Stdout)
+	},
+}
+
+func completionCommand(root *cobra.Command, shell string, writer io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(writer, true)
+	case "zsh":
+		return root.GenZshCompletion(writer)
+	case "fish":
+		return root.GenFishCompletion(writer, true)
+	default:
+		return fmt.Errorf("Unsupported shell `%s`, expected one of: bash, zsh, fish", shell)
+	}
+}
+
+// isCompletionCommand reports whether cmd prints a completion script or completion candidates, both must not be mixed with log messages
+func isCompletionCommand(cmd *cobra.Command) bool {
+	return cmd == completionCmd || cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd
+}
+
+// prepareCompletion silences log messages and applies --cluster, the root command does not set up a run when completing
+func prepareCompletion(cmd *cobra.Command) {
+	logger.SetWriter(io.Discard)
+
+	name, _ := cmd.Flags().GetString("cluster")
+	if name != "" {
+		os.Setenv(constants.ClusterEnvVar, name)
+	}
+}
+
+// firstArgOnly limits a completion function to the first positional argument
+func firstArgOnly(complete cobra.CompletionFunc) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
+
+func completeClusters(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	prepareCompletion(cmd)
+	return clusterCandidates(helper_service.HelperService{}, cluster_service.ClusterService{}), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeLibraryPackages(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	prepareCompletion(cmd)
+	return libraryPackageCandidates(helper_service.HelperService{}, config_service.ConfigService{}, package_service.PackageService{}, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeInstalledPackages(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	prepareCompletion(cmd)
+	return installedPackageCandidates(helper_service.HelperService{}, config_service.ConfigService{}, args), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeNodes(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	prepareCompletion(cmd)
+	return nodeCandidates(helper_service.HelperService{}, talos_service.TalosService{}), cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeDeviceTypes(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	return image_service.DeviceTypes(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func clusterCandidates(helperService interfaces.HelperServiceInterface, clusterService interfaces.ClusterServiceInterface) []cobra.Completion {
+	names, err := clusterService.List(helperService)
+	if err != nil {
+		return nil
+	}
+
+	return names
+}
+
+// libraryPackageCandidates offers the packages of the libraries with their version, except those already given as <package>[@version] arguments
+func libraryPackageCandidates(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, args []string) []cobra.Completion {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return nil
+	}
+
+	charts, err := packageService.GetAll(helperService, *bbeConfig)
+	if err != nil {
+		return nil
+	}
+
+	given := []string{}
+	for _, arg := range args {
+		name, _, _ := strings.Cut(arg, "@")
+		given = append(given, name)
+	}
+
+	candidates := []cobra.Completion{}
+	for _, chart := range charts {
+		if name := packageDisplayName(chart); !slices.Contains(given, name) {
+			candidates = append(candidates, cobra.CompletionWithDesc(name, chart.Version))
+		}
+	}
+
+	return candidates
+}
+
+// installedPackageCandidates offers the packages recorded in bbe.yaml with their version, except those already given as arguments
+func installedPackageCandidates(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, args []string) []cobra.Completion {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return nil
+	}
+
+	candidates := []cobra.Completion{}
+	for _, pkg := range bbeConfig.Bbe.Packages {
+		if !slices.Contains(args, pkg.Name) {
+			candidates = append(candidates, cobra.CompletionWithDesc(pkg.Name, pkg.Version))
+		}
+	}
+
+	return candidates
+}
+
+// nodeCandidates offers the nodes recorded in the talosconfig of the cluster, completing must not wait for unreachable nodes
+func nodeCandidates(helperService interfaces.HelperServiceInterface, talosService interfaces.TalosServiceInterface) []cobra.Completion {
+	inventory, err := talosService.GetNodeInventory(helperService)
+	if err != nil {
+		return nil
+	}
+
+	return inventory.Nodes
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+
+	rootCmd.RegisterFlagCompletionFunc("cluster", completeClusters)
+}
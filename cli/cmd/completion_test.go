@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_completionCommand_Succeeds_ForEverySupportedShell(t *testing.T) {
+	for _, shell := range completionShells {
+		var buffer bytes.Buffer
+
+		err := completionCommand(rootCmd, shell, &buffer)
+
+		assert.NoError(t, err, shell)
+		assert.Contains(t, buffer.String(), "bbe", shell)
+	}
+}
+
+func Test_completionCommand_Fails_WithUnknownShell(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := completionCommand(rootCmd, "powershell", &buffer)
+
+	assert.ErrorContains(t, err, "Unsupported shell `powershell`")
+	assert.Empty(t, buffer.String())
+}
+
+func Test_isCompletionCommand_Succeeds(t *testing.T) {
+	assert.True(t, isCompletionCommand(completionCmd))
+	assert.True(t, isCompletionCommand(&cobra.Command{Use: cobra.ShellCompRequestCmd}))
+	assert.False(t, isCompletionCommand(versionCmd))
+}
+
+func Test_firstArgOnly_Succeeds_OnlyCompletesFirstArgument(t *testing.T) {
+	complete := firstArgOnly(cobra.FixedCompletions([]string{"home"}, cobra.ShellCompDirectiveNoFileComp))
+
+	first, _ := complete(&cobra.Command{}, []string{}, "")
+	second, directive := complete(&cobra.Command{}, []string{"home"}, "")
+
+	assert.Equal(t, []string{"home"}, first)
+	assert.Empty(t, second)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func Test_clusterCandidates_Succeeds(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("List", helperService).Return([]string{"default", "lab"}, nil)
+
+	candidates := clusterCandidates(helperService, clusterService)
+
+	assert.Equal(t, []string{"default", "lab"}, candidates)
+}
+
+func Test_clusterCandidates_Succeeds_WithoutCandidatesOnError(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	clusterService := &mocks.MockClusterService{}
+	clusterService.On("List", helperService).Return([]string{}, errors.New("test error"))
+
+	candidates := clusterCandidates(helperService, clusterService)
+
+	assert.Empty(t, candidates)
+}
+
+func Test_libraryPackageCandidates_Succeeds_SkipsPackagesAlreadyGiven(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", helperService).Return(&models.BbeConfig{}, nil)
+	packageService := &mocks.MockPackageService{}
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "blocky", Version: "0.1.3"},
+		{Name: "jellyfin", Version: "2.1.0"},
+		{Name: "postgres", Version: "16.1.0", Library: "internal"},
+	}, nil)
+
+	candidates := libraryPackageCandidates(helperService, configService, packageService, []string{"blocky@0.1.2"})
+
+	assert.Equal(t, []string{"jellyfin\t2.1.0", "internal/postgres\t16.1.0"}, candidates)
+}
+
+func Test_libraryPackageCandidates_Succeeds_WithoutCandidatesWhenLibraryFails(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", helperService).Return(&models.BbeConfig{}, nil)
+	packageService := &mocks.MockPackageService{}
+	packageService.On("GetAll").Return([]models.ChartEntry{}, errors.New("test error"))
+
+	candidates := libraryPackageCandidates(helperService, configService, packageService, []string{})
+
+	assert.Empty(t, candidates)
+}
+
+func Test_installedPackageCandidates_Succeeds(t *testing.T) {
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Packages = []models.LocalPackage{{Name: "blocky", Version: "0.1.3"}, {Name: "jellyfin", Version: "2.1.0"}}
+
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", helperService).Return(bbeConfig, nil)
+
+	candidates := installedPackageCandidates(helperService, configService, []string{})
+
+	assert.Equal(t, []string{"blocky\t0.1.3", "jellyfin\t2.1.0"}, candidates)
+}
+
+func Test_installedPackageCandidates_Succeeds_WithoutCandidatesWithoutConfig(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", helperService).Return((*models.BbeConfig)(nil), errors.New("test error"))
+
+	candidates := installedPackageCandidates(helperService, configService, []string{})
+
+	assert.Empty(t, candidates)
+}
+
+func Test_nodeCandidates_Succeeds(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	talosService := &mocks.MockTalosService{}
+	talosService.On("GetNodeInventory", helperService).Return(&models.NodeInventory{Nodes: []string{"192.168.1.10", "192.168.1.11"}}, nil)
+
+	candidates := nodeCandidates(helperService, talosService)
+
+	assert.Equal(t, []string{"192.168.1.10", "192.168.1.11"}, candidates)
+}
+
+func Test_nodeCandidates_Succeeds_WithoutCandidatesWithoutTalosConfig(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	talosService := &mocks.MockTalosService{}
+	talosService.On("GetNodeInventory", helperService).Return((*models.NodeInventory)(nil), errors.New("test error"))
+
+	candidates := nodeCandidates(helperService, talosService)
+
+	assert.Empty(t, candidates)
+}
+
+func Test_completeDeviceTypes_Succeeds(t *testing.T) {
+	candidates, directive := completeDeviceTypes(setupCmd, []string{}, "")
+
+	assert.Equal(t, []string{"intel-nuc", "raspberry-pi"}, candidates)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+}
+
+func Test_completeUpgradePolicy_Succeeds_CompletesPolicyAfterPackage(t *testing.T) {
+	candidates, _ := completeUpgradePolicy(upgradePolicyCmd, []string{"blocky"}, "")
+
+	assert.Equal(t, versioning.Policies, candidates)
+}
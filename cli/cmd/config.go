@@ -1,82 +1,633 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/merge"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
+const (
+	conflictKeepLocal  = "Keep local"
+	conflictKeepRemote = "Keep remote"
+	conflictMerge      = "Merge both (comments are not preserved)"
+)
+
+var storageChoices = []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}
+
 var configCmd = &cobra.Command{
 	Use:     "config",
 	Aliases: []string{"c"},
 	Short:   "Setup your BBE-Quest configuration",
 	Args:    cobra.ExactArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		helperService := helper_service.HelperService{}
 		uiService := ui_service.UiService{}
 		configService := config_service.ConfigService{}
 
-		err := configCommand(&helperService, uiService, configService)
-		if err != nil {
-			logger.Error("", err)
-			os.Exit(1)
-		}
+		return configCommand(&helperService, uiService, configService)
 	},
 }
 
 func configCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface) error {
 	bbeConfig, err := configService.GetBbeConfig(helperService)
-	if err != nil {
+	generated := errors.Is(err, constants.ConfigNotFoundError)
+	if generated {
 		bbeConfig, err = getOrGenerateConfig(helperService, uiService, configService)
 		if err != nil {
 			return fmt.Errorf("Error while generating BBE config: %w", err)
 		}
+	} else if err != nil {
+		return fmt.Errorf("Error while reading BBE config: %w", err)
+	}
+
+	if !isRemoteStorage(bbeConfig.Bbe.Storage.Type) {
+		return nil
+	}
+
+	// Encryption is offered once when the config is created, later it is enabled with 'bbe config encryption enable'.
+	// On new storage it is offered before the first upload, plaintext versions would otherwise stay in the storage history,
+	// storage that already holds a bbe.yaml is synced first as encryption may already be enabled there.
+	shared := false
+	if generated {
+		shared, err = hasRemoteConfig(helperService, configService, bbeConfig)
+		if err != nil {
+			return fmt.Errorf("Error while reading remote storage: %w", err)
+		}
+
+		if !shared {
+			err = promptEncryption(helperService, uiService, configService)
+			if err != nil {
+				return fmt.Errorf("Error while enabling encryption: %w", err)
+			}
+
+			bbeConfig, err = configService.GetBbeConfig(helperService)
+			if err != nil {
+				return fmt.Errorf("Error while reading BBE config: %w", err)
+			}
+		}
+	}
+
+	err = syncConfigs(helperService, uiService, configService, bbeConfig)
+	if err != nil {
+		return fmt.Errorf("Error while syncing config with remote storage: %w", err)
 	}
 
-	if bbeConfig.Bbe.Storage.Type == "aws" {
-		err := configService.SyncConfigsWithAws(helperService, bbeConfig)
+	if shared {
+		err = promptEncryption(helperService, uiService, configService)
 		if err != nil {
-			return fmt.Errorf("Error while syncing config with AWS: %w", err)
+			return fmt.Errorf("Error while enabling encryption: %w", err)
 		}
 	}
 
 	return nil
 }
 
-func getOrGenerateConfig(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface) (*models.BbeConfig, error) {
-	choice, err := uiService.CreateSelect("No BBE configuration file found, where would you like to store your config files?", []string{"Local", "AWS"})
+// hasRemoteConfig reports whether remote storage already holds a bbe.yaml, e.g. uploaded from another machine
+func hasRemoteConfig(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, bbeConfig *models.BbeConfig) (bool, error) {
+	versions, err := configService.ConfigHistory(helperService, bbeConfig, constants.BbeConfigFile)
+	if errors.Is(err, constants.StorageObjectNotFoundError) {
+		return false, nil
+	}
 	if err != nil {
-		panic(err)
+		return false, err
 	}
 
+	return len(versions) > 0, nil
+}
+
+// syncConfigs syncs the config files and asks how to resolve files that changed both locally and in remote storage
+func syncConfigs(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, bbeConfig *models.BbeConfig) error {
+	err := configService.SyncConfigs(helperService, bbeConfig)
+
+	var conflictErr *config_service.SyncConflictError
+	if !errors.As(err, &conflictErr) {
+		return err
+	}
+
+	for _, conflict := range conflictErr.Conflicts {
+		err := resolveConflict(helperService, uiService, configService, bbeConfig, conflict)
+		if err != nil {
+			return fmt.Errorf("Failed to resolve conflict in %s: %w", conflict.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func resolveConflict(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, bbeConfig *models.BbeConfig, conflict models.SyncConflict) error {
+	logger.Warning(fmt.Sprintf("%s changed both locally and in remote storage since the last sync", conflict.Name))
+	showConflict(conflict)
+
+	options := []string{conflictKeepLocal, conflictKeepRemote}
+
+	var merged []byte
+	if conflict.Base == nil {
+		logger.Info("The file was never synced from this machine, so the changes cannot be merged automatically")
+	} else {
+		var err error
+		merged, err = merge.Yaml(conflict.Base, conflict.Local, conflict.Remote)
+		if err != nil {
+			logger.Infof("The changes cannot be merged automatically: %v", err)
+		} else {
+			options = append(options, conflictMerge)
+		}
+	}
+
+	choice, err := uiService.CreateSelect(fmt.Sprintf("Which version of %s do you want to keep?", conflict.Name), options)
+	if err != nil {
+		return err
+	}
+
+	content := conflict.Local
 	switch choice {
-	case "Local":
-		err := configService.GenerateBbeConfig(helperService, "local")
+	case conflictKeepRemote:
+		content = conflict.Remote
+	case conflictMerge:
+		content = merged
+	}
+
+	return configService.ResolveConflict(helperService, bbeConfig, conflict, content)
+}
+
+// showConflict prints the differences between both copies, for Talos secrets only the changed keys are named as the values are private keys and tokens
+func showConflict(conflict models.SyncConflict) {
+	if !slices.Contains(constants.SecretConfigFiles, conflict.Name) {
+		logger.Infof("Differences between remote storage (-) and the local copy (+):\n%s", merge.Diff(conflict.Remote, conflict.Local))
+		return
+	}
+
+	paths, err := merge.ChangedPaths(conflict.Remote, conflict.Local)
+	if err != nil {
+		logger.Infof("The copies cannot be compared, their contents are not shown as they hold secrets: %v", err)
+		return
+	}
+
+	logger.Infof("Keys that differ between remote storage and the local copy:\n  %s", strings.Join(paths, "\n  "))
+}
+
+// promptEncryption offers to encrypt the Talos secrets when the configuration does not encrypt them yet
+func promptEncryption(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	// A sync may have downloaded a bbe.yaml from another machine that already enabled encryption
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return err
+	}
+
+	if bbeConfig.Bbe.Storage.Encryption.Recipient != "" {
+		return nil
+	}
+
+	choice, err := uiService.CreateSelect("Encrypt Talos secrets before uploading them to remote storage?", []string{"Yes", "No"})
+	if err != nil {
+		return err
+	}
+
+	if choice != "Yes" {
+		logger.Warning("Talos secrets are stored unencrypted, run 'bbe config encryption enable' to encrypt them")
+		return nil
+	}
+
+	return configService.EnableEncryption(helperService, bbeConfig)
+}
+
+var configEncryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manage client-side encryption of Talos secrets in remote storage",
+}
+
+var configEncryptionEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Generate an encryption key and re-upload the Talos secrets encrypted",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configEncryptionEnableCommand(&helperService, configService)
+	},
+}
+
+var configEncryptionRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replace the encryption key and re-encrypt the Talos secrets",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configEncryptionRotateCommand(&helperService, configService)
+	},
+}
+
+func configEncryptionEnableCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	bbeConfig, err := getRemoteStorageConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	err = configService.EnableEncryption(helperService, bbeConfig)
+	if err != nil {
+		return fmt.Errorf("Error while enabling encryption: %w", err)
+	}
+
+	return nil
+}
+
+func configEncryptionRotateCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	bbeConfig, err := getRemoteStorageConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	err = configService.RotateEncryptionKey(helperService, bbeConfig)
+	if err != nil {
+		return fmt.Errorf("Error while rotating encryption key: %w", err)
+	}
+
+	return nil
+}
+
+var configHistoryCmd = &cobra.Command{
+	Use:   "history <file>",
+	Short: "List the versions of a config file kept in remote storage",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configHistoryCommand(&helperService, configService, args[0])
+	},
+}
+
+var configRestoreCmd = &cobra.Command{
+	Use:   "restore <file> <version>",
+	Short: "Restore a previous version of a config file from remote storage",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configRestoreCommand(&helperService, configService, args[0], args[1])
+	},
+}
+
+func configHistoryCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, name string) error {
+	bbeConfig, err := getRemoteStorageConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	versions, err := configService.ConfigHistory(helperService, bbeConfig, name)
+	if err != nil {
+		return fmt.Errorf("Error while listing versions of %s: %w", name, err)
+	}
+
+	logger.Infof("Versions of %s, newest first:\n%s", name, formatConfigHistory(versions))
+	logger.Infof("Run 'bbe config restore %s <version>' to restore a version", name)
+	return nil
+}
+
+func configRestoreCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, name string, version string) error {
+	bbeConfig, err := getRemoteStorageConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	err = configService.RestoreConfig(helperService, bbeConfig, name, version)
+	if err != nil {
+		return fmt.Errorf("Error while restoring %s: %w", name, err)
+	}
+
+	return nil
+}
+
+func formatConfigHistory(versions []models.StorageObject) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "VERSION\tMODIFIED")
+	for i, version := range versions {
+		modified := "-"
+		if !version.ModTime.IsZero() {
+			modified = version.ModTime.Local().Format("2006-01-02 15:04:05")
+		}
+		if i == 0 {
+			modified += " (current)"
+		}
+		fmt.Fprintf(writer, "%s\t%s\n", version.Version, modified)
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Print the BBE configuration of the current cluster",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configViewCommand(&helperService, configService)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a value from bbe.yaml, e.g. storage.aws.region",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configGetCommand(&helperService, configService, args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a value in bbe.yaml, e.g. storage.aws.profile",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configSetCommand(&helperService, configService, args[0], args[1])
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check bbe.yaml for unknown keys, wrong types and invalid values",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return configValidateCommand(&helperService, configService)
+	},
+}
+
+var configMigrateStorageCmd = &cobra.Command{
+	Use:   "migrate-storage",
+	Short: "Move the config files to another storage",
+	Long:  "Move the config files to another storage, e.g. from local to AWS or to another bucket. The files in the previous storage are left in place.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		uiService := ui_service.UiService{}
+		configService := config_service.ConfigService{}
+
+		return configMigrateStorageCommand(&helperService, uiService, configService)
+	},
+}
+
+func configViewCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	bbeConfig, err := getBbeConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	output, err := yaml.Marshal(bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("# %s\n%s", helperService.GetConfigFilePath(constants.BbeConfigFile), strings.TrimSuffix(string(output), "\n"))
+	return nil
+}
+
+func configGetCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, key string) error {
+	value, err := configService.GetBbeValue(helperService, key)
+	if err != nil {
+		return err
+	}
+
+	logger.Info(value)
+	return nil
+}
+
+func configSetCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, key string, value string) error {
+	err := configService.SetBbeValue(helperService, key, value)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Set %s to `%s`", key, value)
+
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err == nil && isRemoteStorage(bbeConfig.Bbe.Storage.Type) {
+		logger.Info("Run 'bbe config' to sync the change to remote storage")
+	}
+
+	return nil
+}
+
+func configValidateCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	err := configService.ValidateBbeConfig(helperService)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("%s is valid", constants.BbeConfigFile)
+	return nil
+}
+
+func configMigrateStorageCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	bbeConfig, err := getBbeConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	// Start from the latest copies so nothing that only exists in the previous storage is lost
+	if isRemoteStorage(bbeConfig.Bbe.Storage.Type) {
+		err := syncConfigs(helperService, uiService, configService, bbeConfig)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("Error while syncing config with remote storage: %w", err)
 		}
-	case "AWS":
-		err := configService.GenerateBbeConfig(helperService, "aws")
+
+		bbeConfig, err = configService.GetBbeConfig(helperService)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
+	choice, err := uiService.CreateSelect("Where would you like to store your config files?", storageChoices)
+	if err != nil {
+		return err
+	}
+
+	storage, err := promptStorageConfig(uiService, choice)
+	if err != nil {
+		return err
+	}
+
+	previous := bbeConfig.Bbe.Storage.Type
+	err = configService.MigrateStorage(helperService, bbeConfig, storage)
+	if err != nil {
+		return fmt.Errorf("Error while migrating storage: %w", err)
+	}
+
+	logger.Infof("Config files are now stored in %s storage", storage.Type)
+	if isRemoteStorage(previous) {
+		logger.Infof("The copies in the previous %s storage were left in place", previous)
+	}
+
+	return nil
+}
+
+// getBbeConfig loads the BBE config and points at 'bbe config' when there is none yet
+func getBbeConfig(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) (*models.BbeConfig, error) {
 	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if errors.Is(err, constants.ConfigNotFoundError) {
+		return nil, clierror.New(clierror.KindConfigInvalid, errors.New("No BBE configuration found, please run 'bbe config' first"), "")
+	}
+
+	return bbeConfig, err
+}
+
+// getRemoteStorageConfig loads the BBE config and requires it to sync with a storage backend
+func getRemoteStorageConfig(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) (*models.BbeConfig, error) {
+	bbeConfig, err := getBbeConfig(helperService, configService)
 	if err != nil {
 		return nil, err
 	}
 
+	if !isRemoteStorage(bbeConfig.Bbe.Storage.Type) {
+		return nil, errors.New("No remote storage configured, the config files are only stored locally")
+	}
+
 	return bbeConfig, nil
 }
 
+func getOrGenerateConfig(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface) (*models.BbeConfig, error) {
+	choice, err := uiService.CreateSelect("No BBE configuration file found, where would you like to store your config files?", storageChoices)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := promptStorageConfig(uiService, choice)
+	if err != nil {
+		return nil, err
+	}
+
+	err = configService.GenerateBbeConfig(helperService, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return nil, err
+	}
+
+	return bbeConfig, nil
+}
+
+// promptStorageConfig asks for the settings of the storage backends that cannot be discovered automatically
+func promptStorageConfig(uiService interfaces.UiServiceInterface, choice string) (models.StorageConfig, error) {
+	storage := models.StorageConfig{}
+	var err error
+
+	switch choice {
+	case "Local":
+		storage.Type = constants.StorageLocal
+	case "AWS":
+		storage.Type = constants.StorageAws
+		storage.Aws.Region, err = uiService.CreateInput("AWS region", constants.DefaultAwsRegion)
+		if err != nil {
+			return storage, err
+		}
+		storage.Aws.Profile, err = uiService.CreateInput("AWS profile, leave empty to use the default credentials", "")
+		if err != nil {
+			return storage, err
+		}
+		storage.Aws.Endpoint, err = uiService.CreateInput("Custom endpoint URL, leave empty for AWS", "")
+		if err != nil {
+			return storage, err
+		}
+		storage.Aws.BucketName, err = uiService.CreateInput("Bucket name, leave empty to find or create a bucket", "")
+		if err != nil {
+			return storage, err
+		}
+		if storage.Aws.BucketName == "" {
+			storage.Aws.BucketPrefix, err = uiService.CreateInput("Bucket name prefix", constants.DefaultAwsBucketPrefix)
+			if err != nil {
+				return storage, err
+			}
+		}
+	case "S3 compatible":
+		storage.Type = constants.StorageS3
+		storage.S3.Endpoint, err = uiService.CreateInput("S3 endpoint URL", "https://")
+		if err != nil {
+			return storage, err
+		}
+		storage.S3.Region, err = uiService.CreateInput("Region", "us-east-1")
+		if err != nil {
+			return storage, err
+		}
+		storage.S3.BucketName, err = uiService.CreateInput("Bucket name", "bbe-config")
+		if err != nil {
+			return storage, err
+		}
+		pathStyle, err := uiService.CreateSelect("Use path-style addressing? (required by MinIO)", []string{"Yes", "No"})
+		if err != nil {
+			return storage, err
+		}
+		storage.S3.PathStyle = pathStyle == "Yes"
+	case "Git repository":
+		storage.Type = constants.StorageGit
+		storage.Git.Repository, err = uiService.CreateInput("Git repository URL", "")
+		if err != nil {
+			return storage, err
+		}
+		storage.Git.Branch, err = uiService.CreateInput("Branch", constants.DefaultGitStorageBranch)
+		if err != nil {
+			return storage, err
+		}
+	case "Directory":
+		storage.Type = constants.StorageDirectory
+		storage.Directory.Path, err = uiService.CreateInput("Directory path", "")
+		if err != nil {
+			return storage, err
+		}
+	default:
+		return storage, fmt.Errorf("Unknown storage type `%s`", choice)
+	}
+
+	return storage, nil
+}
+
+// isRemoteStorage reports whether config files are synced with a storage backend
+func isRemoteStorage(storageType string) bool {
+	return storageType != "" && storageType != constants.StorageLocal
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configEncryptionCmd)
+	configEncryptionCmd.AddCommand(configEncryptionEnableCmd)
+	configEncryptionCmd.AddCommand(configEncryptionRotateCmd)
+	configCmd.AddCommand(configHistoryCmd)
+	configCmd.AddCommand(configRestoreCmd)
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configMigrateStorageCmd)
 }
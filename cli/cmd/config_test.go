@@ -4,8 +4,10 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -22,7 +24,7 @@ func Test_configCommand_Succeeds_WithPreexistingLocalConfig(t *testing.T) {
 
 	assert.Nil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 }
 
 func Test_configCommand_Succeeds_WithPreexistinAwsConfig(t *testing.T) {
@@ -34,24 +36,26 @@ func Test_configCommand_Succeeds_WithPreexistinAwsConfig(t *testing.T) {
 	bbeConfig := &models.BbeConfig{}
 	bbeConfig.Bbe.Storage.Type = "aws"
 	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
-	configService.On("SyncConfigsWithAws", &helperService, bbeConfig).Return(nil)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(nil)
 
 	err := configCommand(&helperService, &uiService, &configService)
 
 	assert.Nil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 1)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 1)
+	uiService.AssertNumberOfCalls(t, "CreateSelect", 0)
+	configService.AssertNumberOfCalls(t, "EnableEncryption", 0)
 }
 
 func Test_configCommand_Succeeds_WithNoConfig_GeneratesLocalConfig(t *testing.T) {
 	helperService := mocks.MockHelperService{}
 
 	uiService := mocks.MockUiService{}
-	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS"}).Return("Local", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("Local", nil)
 
 	configService := mocks.MockConfigService{}
-	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, errors.New("test error")).Once()
-	configService.On("GenerateBbeConfig", &helperService, "local").Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, models.StorageConfig{Type: "local"}).Return(nil)
 	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil).Once()
 
 	err := configCommand(&helperService, &uiService, &configService)
@@ -66,11 +70,12 @@ func Test_configCommand_Succeeds_WithNoConfig_GeneratesAwsConfig(t *testing.T) {
 	helperService := mocks.MockHelperService{}
 
 	uiService := mocks.MockUiService{}
-	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS"}).Return("AWS", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("AWS", nil)
+	storage := mockAwsStoragePrompts(&uiService)
 
 	configService := mocks.MockConfigService{}
-	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, errors.New("test error")).Once()
-	configService.On("GenerateBbeConfig", &helperService, "aws").Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, storage).Return(nil)
 	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil).Once()
 
 	err := configCommand(&helperService, &uiService, &configService)
@@ -90,24 +95,24 @@ func Test_configCommand_Fails_WithPreexistinAwsConfig_WhenFailingToSync(t *testi
 	bbeConfig := &models.BbeConfig{}
 	bbeConfig.Bbe.Storage.Type = "aws"
 	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
-	configService.On("SyncConfigsWithAws", &helperService, bbeConfig).Return(errors.New("test error"))
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(errors.New("test error"))
 
 	err := configCommand(&helperService, &uiService, &configService)
 
 	assert.NotNil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 1)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 1)
 }
 
 func Test_configCommand_Fails_WithNoConfig_FailsToGenerateLocalConfig(t *testing.T) {
 	helperService := mocks.MockHelperService{}
 
 	uiService := mocks.MockUiService{}
-	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS"}).Return("Local", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("Local", nil)
 
 	configService := mocks.MockConfigService{}
-	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, errors.New("test error")).Once()
-	configService.On("GenerateBbeConfig", &helperService, "local").Return(errors.New("test error"))
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, models.StorageConfig{Type: "local"}).Return(errors.New("test error"))
 	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil).Once()
 
 	err := configCommand(&helperService, &uiService, &configService)
@@ -122,11 +127,12 @@ func Test_configCommand_Fails_WithNoConfig_FailsToGenerateAwsonfig(t *testing.T)
 	helperService := mocks.MockHelperService{}
 
 	uiService := mocks.MockUiService{}
-	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS"}).Return("AWS", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("AWS", nil)
+	storage := mockAwsStoragePrompts(&uiService)
 
 	configService := mocks.MockConfigService{}
-	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, errors.New("test error")).Once()
-	configService.On("GenerateBbeConfig", &helperService, "aws").Return(errors.New("test error"))
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, storage).Return(errors.New("test error"))
 	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil).Once()
 
 	err := configCommand(&helperService, &uiService, &configService)
@@ -141,11 +147,11 @@ func Test_configCommand_Fails_WithNoConfig_FailsToRetrieveConfigAfterGeneration(
 	helperService := mocks.MockHelperService{}
 
 	uiService := mocks.MockUiService{}
-	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS"}).Return("Local", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("Local", nil)
 
 	configService := mocks.MockConfigService{}
-	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, errors.New("test error"))
-	configService.On("GenerateBbeConfig", &helperService, "local").Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError)
+	configService.On("GenerateBbeConfig", &helperService, models.StorageConfig{Type: "local"}).Return(nil)
 
 	err := configCommand(&helperService, &uiService, &configService)
 
@@ -154,3 +160,489 @@ func Test_configCommand_Fails_WithNoConfig_FailsToRetrieveConfigAfterGeneration(
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 2)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 1)
 }
+
+func Test_configCommand_Succeeds_WithNoConfig_GeneratesDirectoryConfig(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("Directory", nil)
+	uiService.On("CreateInput", "Directory path", mock.Anything).Return("/mnt/usb/bbe", nil)
+
+	storage := models.StorageConfig{Type: "directory"}
+	storage.Directory.Path = "/mnt/usb/bbe"
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage = storage
+
+	configService := mocks.MockConfigService{}
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, storage).Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("ConfigHistory", &helperService, bbeConfig, "bbe.yaml").Return([]models.StorageObject(nil), constants.StorageObjectNotFoundError)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(nil)
+
+	err := configCommand(&helperService, &uiService, &configService)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 1)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 1)
+}
+
+func Test_promptStorageConfig_Succeeds_WithS3CompatibleStorage(t *testing.T) {
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateInput", "S3 endpoint URL", mock.Anything).Return("http://minio.local:9000", nil)
+	uiService.On("CreateInput", "Region", mock.Anything).Return("us-east-1", nil)
+	uiService.On("CreateInput", "Bucket name", mock.Anything).Return("bbe", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Yes", "No"}).Return("Yes", nil)
+
+	storage, err := promptStorageConfig(&uiService, "S3 compatible")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "s3", storage.Type)
+	assert.Equal(t, "http://minio.local:9000", storage.S3.Endpoint)
+	assert.Equal(t, "bbe", storage.S3.BucketName)
+	assert.True(t, storage.S3.PathStyle)
+}
+
+func Test_promptStorageConfig_Succeeds_WithGitStorage(t *testing.T) {
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateInput", "Git repository URL", mock.Anything).Return("git@example.com:me/bbe-config.git", nil)
+	uiService.On("CreateInput", "Branch", mock.Anything).Return("main", nil)
+
+	storage, err := promptStorageConfig(&uiService, "Git repository")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "git", storage.Type)
+	assert.Equal(t, "git@example.com:me/bbe-config.git", storage.Git.Repository)
+	assert.Equal(t, "main", storage.Git.Branch)
+}
+
+func Test_configCommand_Succeeds_EnablesEncryptionBeforeFirstSync(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	calls := []string{}
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("AWS", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Yes", "No"}).Return("Yes", nil)
+	storage := mockAwsStoragePrompts(&uiService)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "aws"
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, storage).Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("ConfigHistory", &helperService, bbeConfig, "bbe.yaml").Return([]models.StorageObject(nil), constants.StorageObjectNotFoundError)
+	configService.On("EnableEncryption", &helperService, bbeConfig).Run(func(mock.Arguments) { calls = append(calls, "EnableEncryption") }).Return(nil)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Run(func(mock.Arguments) { calls = append(calls, "SyncConfigs") }).Return(nil)
+
+	err := configCommand(&helperService, &uiService, &configService)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"EnableEncryption", "SyncConfigs"}, calls)
+}
+
+func Test_configCommand_Succeeds_OffersEncryptionAfterSyncWithSharedStorage(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	calls := []string{}
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("AWS", nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"Yes", "No"}).Return("Yes", nil)
+	storage := mockAwsStoragePrompts(&uiService)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "aws"
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, storage).Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("ConfigHistory", &helperService, bbeConfig, "bbe.yaml").Return([]models.StorageObject{{Name: "bbe.yaml", Version: "v1"}}, nil)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Run(func(mock.Arguments) { calls = append(calls, "SyncConfigs") }).Return(nil)
+	configService.On("EnableEncryption", &helperService, bbeConfig).Run(func(mock.Arguments) { calls = append(calls, "EnableEncryption") }).Return(nil)
+
+	err := configCommand(&helperService, &uiService, &configService)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SyncConfigs", "EnableEncryption"}, calls)
+}
+
+func Test_configCommand_Succeeds_SkipsEncryptionPromptWhenAlreadyEnabled(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, []string{"Local", "AWS", "S3 compatible", "Git repository", "Directory"}).Return("AWS", nil)
+	storage := mockAwsStoragePrompts(&uiService)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "aws"
+	bbeConfig.Bbe.Storage.Encryption.Recipient = "age1test"
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
+	configService.On("GenerateBbeConfig", &helperService, storage).Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("ConfigHistory", &helperService, bbeConfig, "bbe.yaml").Return([]models.StorageObject{{Name: "bbe.yaml", Version: "v1"}}, nil)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(nil)
+
+	err := configCommand(&helperService, &uiService, &configService)
+
+	assert.Nil(t, err)
+	uiService.AssertNotCalled(t, "CreateSelect", mock.Anything, []string{"Yes", "No"})
+	configService.AssertNumberOfCalls(t, "EnableEncryption", 0)
+}
+
+func Test_configEncryptionEnableCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "git"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("EnableEncryption", &helperService, bbeConfig).Return(nil)
+
+	err := configEncryptionEnableCommand(&helperService, &configService)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "EnableEncryption", 1)
+}
+
+func Test_configEncryptionEnableCommand_Fails_WithLocalStorage(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "local"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+
+	err := configEncryptionEnableCommand(&helperService, &configService)
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "EnableEncryption", 0)
+}
+
+func Test_configEncryptionRotateCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "aws"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("RotateEncryptionKey", &helperService, bbeConfig).Return(nil)
+
+	err := configEncryptionRotateCommand(&helperService, &configService)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "RotateEncryptionKey", 1)
+}
+
+func Test_configEncryptionRotateCommand_Fails_WhenRotationFails(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "aws"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("RotateEncryptionKey", &helperService, bbeConfig).Return(errors.New("test error"))
+
+	err := configEncryptionRotateCommand(&helperService, &configService)
+
+	assert.NotNil(t, err)
+}
+
+func Test_syncConfigs_Succeeds_ResolvesConflictWithRemoteVersion(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	conflict := models.SyncConflict{
+		Name:          "bbe.yaml",
+		Local:         []byte("a: 1\n"),
+		Remote:        []byte("a: 2\n"),
+		RemoteVersion: "v2",
+	}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, []string{conflictKeepLocal, conflictKeepRemote}).Return(conflictKeepRemote, nil)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(&config_service.SyncConflictError{Conflicts: []models.SyncConflict{conflict}})
+	configService.On("ResolveConflict", &helperService, bbeConfig, conflict, []byte("a: 2\n")).Return(nil)
+
+	err := syncConfigs(&helperService, &uiService, &configService, bbeConfig)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "ResolveConflict", 1)
+}
+
+func Test_syncConfigs_Succeeds_OffersMergeWithSyncedBase(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	conflict := models.SyncConflict{
+		Name:   "bbe.yaml",
+		Base:   []byte("a: 1\nb: 1\n"),
+		Local:  []byte("a: 2\nb: 1\n"),
+		Remote: []byte("a: 1\nb: 2\n"),
+	}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, []string{conflictKeepLocal, conflictKeepRemote, conflictMerge}).Return(conflictMerge, nil)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(&config_service.SyncConflictError{Conflicts: []models.SyncConflict{conflict}})
+	configService.On("ResolveConflict", &helperService, bbeConfig, conflict, []byte("a: 2\nb: 2\n")).Return(nil)
+
+	err := syncConfigs(&helperService, &uiService, &configService, bbeConfig)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "ResolveConflict", 1)
+}
+
+func Test_syncConfigs_Succeeds_HidesSecretValuesOfConflict(t *testing.T) {
+	buffer := captureLogs(t)
+	helperService := mocks.MockHelperService{}
+
+	conflict := models.SyncConflict{
+		Name:   constants.ControlplaneConfigFile,
+		Local:  []byte("machine:\n  token: local-token\n"),
+		Remote: []byte("machine:\n  token: remote-token\n"),
+	}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", mock.Anything, []string{conflictKeepLocal, conflictKeepRemote}).Return(conflictKeepLocal, nil)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(&config_service.SyncConflictError{Conflicts: []models.SyncConflict{conflict}})
+	configService.On("ResolveConflict", &helperService, bbeConfig, conflict, conflict.Local).Return(nil)
+
+	err := syncConfigs(&helperService, &uiService, &configService, bbeConfig)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), ".machine.token")
+	assert.NotContains(t, buffer.String(), "local-token")
+	assert.NotContains(t, buffer.String(), "remote-token")
+}
+
+func Test_syncConfigs_Fails_WhenSyncFails(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	uiService := mocks.MockUiService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(errors.New("test error"))
+
+	err := syncConfigs(&helperService, &uiService, &configService, bbeConfig)
+
+	assert.NotNil(t, err)
+	uiService.AssertNumberOfCalls(t, "CreateSelect", 0)
+}
+
+func Test_configHistoryCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "aws"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("ConfigHistory", &helperService, bbeConfig, "bbe.yaml").Return([]models.StorageObject{
+		{Name: "bbe.yaml", Version: "v2"},
+		{Name: "bbe.yaml", Version: "v1"},
+	}, nil)
+
+	err := configHistoryCommand(&helperService, &configService, "bbe.yaml")
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "ConfigHistory", 1)
+}
+
+func Test_configRestoreCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "git"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("RestoreConfig", &helperService, bbeConfig, "talosconfig", "v1").Return(nil)
+
+	err := configRestoreCommand(&helperService, &configService, "talosconfig", "v1")
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "RestoreConfig", 1)
+}
+
+func Test_configRestoreCommand_Fails_WithLocalStorage(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil)
+
+	err := configRestoreCommand(&helperService, &configService, "talosconfig", "v1")
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "RestoreConfig", 0)
+}
+
+func Test_formatConfigHistory_Succeeds_MarksCurrentVersion(t *testing.T) {
+	output := formatConfigHistory([]models.StorageObject{{Version: "v2"}, {Version: "v1"}})
+
+	assert.Equal(t, "VERSION  MODIFIED\nv2       - (current)\nv1       -", output)
+}
+
+func Test_promptStorageConfig_Succeeds_WithAwsStorage(t *testing.T) {
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateInput", "AWS region", "eu-west-1").Return("us-east-1", nil)
+	uiService.On("CreateInput", "AWS profile, leave empty to use the default credentials", "").Return("homelab", nil)
+	uiService.On("CreateInput", "Custom endpoint URL, leave empty for AWS", "").Return("", nil)
+	uiService.On("CreateInput", "Bucket name, leave empty to find or create a bucket", "").Return("my-bbe-config", nil)
+
+	storage, err := promptStorageConfig(&uiService, "AWS")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "aws", storage.Type)
+	assert.Equal(t, "us-east-1", storage.Aws.Region)
+	assert.Equal(t, "homelab", storage.Aws.Profile)
+	assert.Equal(t, "my-bbe-config", storage.Aws.BucketName)
+	assert.Equal(t, "", storage.Aws.BucketPrefix)
+	uiService.AssertNumberOfCalls(t, "CreateInput", 4)
+}
+
+// mockAwsStoragePrompts accepts the suggested AWS settings and returns the resulting storage config
+func mockAwsStoragePrompts(uiService *mocks.MockUiService) models.StorageConfig {
+	uiService.On("CreateInput", "AWS region", mock.Anything).Return("eu-west-1", nil)
+	uiService.On("CreateInput", "AWS profile, leave empty to use the default credentials", mock.Anything).Return("", nil)
+	uiService.On("CreateInput", "Custom endpoint URL, leave empty for AWS", mock.Anything).Return("", nil)
+	uiService.On("CreateInput", "Bucket name, leave empty to find or create a bucket", mock.Anything).Return("", nil)
+	uiService.On("CreateInput", "Bucket name prefix", mock.Anything).Return("bbe-config", nil)
+
+	storage := models.StorageConfig{Type: "aws"}
+	storage.Aws.Region = "eu-west-1"
+	storage.Aws.BucketPrefix = "bbe-config"
+	return storage
+}
+
+func Test_configViewCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", "bbe.yaml").Return("/home/bbe/.bbe/clusters/default/bbe.yaml")
+
+	configService := mocks.MockConfigService{}
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil)
+
+	err := configViewCommand(&helperService, &configService)
+
+	assert.Nil(t, err)
+}
+
+func Test_configViewCommand_Fails_WithNoConfig(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, constants.ConfigNotFoundError)
+
+	err := configViewCommand(&helperService, &configService)
+
+	assert.ErrorContains(t, err, "please run 'bbe config' first")
+}
+
+func Test_configGetCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	configService.On("GetBbeValue", &helperService, "storage.aws.region").Return("eu-west-1", nil)
+
+	err := configGetCommand(&helperService, &configService, "storage.aws.region")
+
+	assert.Nil(t, err)
+}
+
+func Test_configSetCommand_Succeeds(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	configService.On("SetBbeValue", &helperService, "storage.aws.profile", "home").Return(nil)
+	configService.On("GetBbeConfig", &helperService).Return(&models.BbeConfig{}, nil)
+
+	err := configSetCommand(&helperService, &configService, "storage.aws.profile", "home")
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "SetBbeValue", 1)
+}
+
+func Test_configSetCommand_Fails_WhenSetFails(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	configService.On("SetBbeValue", &helperService, "storage.type", "aws").Return(errors.New("test error"))
+
+	err := configSetCommand(&helperService, &configService, "storage.type", "aws")
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "GetBbeConfig", 0)
+}
+
+func Test_configValidateCommand_Fails_WithInvalidConfig(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	configService := mocks.MockConfigService{}
+	configService.On("ValidateBbeConfig", &helperService).Return(&config_service.ValidationError{Problems: []string{"bbe.library.timeout must not be negative"}})
+
+	err := configValidateCommand(&helperService, &configService)
+
+	assert.ErrorContains(t, err, "bbe.library.timeout must not be negative")
+}
+
+func Test_configMigrateStorageCommand_Succeeds_FromLocalToAws(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", "Where would you like to store your config files?", mock.Anything).Return("AWS", nil)
+	storage := mockAwsStoragePrompts(&uiService)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "local"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("MigrateStorage", &helperService, bbeConfig, storage).Return(nil)
+
+	err := configMigrateStorageCommand(&helperService, &uiService, &configService)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
+	configService.AssertNumberOfCalls(t, "MigrateStorage", 1)
+}
+
+func Test_configMigrateStorageCommand_Succeeds_SyncsRemoteStorageFirst(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	uiService := mocks.MockUiService{}
+	uiService.On("CreateSelect", "Where would you like to store your config files?", mock.Anything).Return("Local", nil)
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "git"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(nil)
+	configService.On("MigrateStorage", &helperService, bbeConfig, models.StorageConfig{Type: "local"}).Return(nil)
+
+	err := configMigrateStorageCommand(&helperService, &uiService, &configService)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 1)
+	configService.AssertNumberOfCalls(t, "MigrateStorage", 1)
+}
+
+func Test_configMigrateStorageCommand_Fails_WhenSyncFails(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+
+	uiService := mocks.MockUiService{}
+
+	configService := mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = "git"
+	configService.On("GetBbeConfig", &helperService).Return(bbeConfig, nil)
+	configService.On("SyncConfigs", &helperService, bbeConfig).Return(errors.New("test error"))
+
+	err := configMigrateStorageCommand(&helperService, &uiService, &configService)
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "MigrateStorage", 0)
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/dependency_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/kubeconfig_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/package_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/spf13/cobra"
+)
+
+var talosVersionPattern = regexp.MustCompile(`\d+\.\d+\.\d+`)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the tools, configuration and cluster bbe depends on",
+	Long:  "Checks the installed tools, the configuration, the remote storage, the package libraries and the health of the active cluster, and suggests a fix for every problem found",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		dependencyService := dependency_service.DependencyService{}
+		configService := config_service.ConfigService{}
+		talosService := talos_service.TalosService{}
+		kubeconfigService := kubeconfig_service.KubeconfigService{}
+		packageService := package_service.PackageService{}
+
+		return doctorCommand(helperService, dependencyService, configService, talosService, kubeconfigService, packageService)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func doctorCommand(helperService interfaces.HelperServiceInterface, dependencyService interfaces.DependencyServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, kubeconfigService interfaces.KubeconfigServiceInterface, packageService interfaces.PackageServiceInterface) error {
+	result := models.DoctorResult{}
+	add := func(check models.DoctorCheck) {
+		logger.Debug(fmt.Sprintf("Doctor check %s: %s, %s", check.Name, check.Status, check.Message))
+		result.Checks = append(result.Checks, check)
+	}
+
+	bbeConfig, check := checkBbeConfig(helperService, configService)
+	add(check)
+
+	storageType := constants.StorageLocal
+	if bbeConfig != nil && bbeConfig.Bbe.Storage.Type != "" {
+		storageType = bbeConfig.Bbe.Storage.Type
+	}
+
+	var libraries []models.LibrarySource
+	if bbeConfig != nil {
+		libraries = append([]models.LibrarySource{{Name: constants.DefaultLibraryName, Source: bbeConfig.Bbe.Library.Source, AllowUnsigned: bbeConfig.Bbe.Library.AllowUnsigned}}, bbeConfig.Bbe.Libraries...)
+	}
+
+	tools := []string{"talosctl", "nmap", "helm"}
+	if storageType == constants.StorageGit {
+		tools = append(tools, "git")
+	}
+	if slices.ContainsFunc(libraries, func(library models.LibrarySource) bool {
+		return strings.HasPrefix(library.Source, "oci://")
+	}) {
+		tools = append(tools, "oras")
+	}
+
+	talosctlVersion := ""
+	for _, tool := range tools {
+		version, check := checkTool(dependencyService, tool)
+		if tool == "talosctl" && check.Status == constants.DoctorPass {
+			talosctlVersion = version
+		}
+		add(check)
+	}
+
+	add(checkPermissions(helperService))
+
+	if bbeConfig != nil {
+		if isRemoteStorage(storageType) {
+			add(checkStorage(helperService, configService, bbeConfig))
+		}
+
+		for _, library := range libraries {
+			add(checkLibrary(packageService, *bbeConfig, library))
+		}
+	}
+
+	for _, check := range checkCluster(helperService, talosService, kubeconfigService, talosctlVersion) {
+		add(check)
+	}
+
+	err := output.Print(result, func() string {
+		return formatDoctor(result)
+	})
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for _, check := range result.Checks {
+		if check.Status == constants.DoctorFail {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d checks failed", failures, len(result.Checks))
+	}
+
+	return nil
+}
+
+func checkBbeConfig(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) (*models.BbeConfig, models.DoctorCheck) {
+	check := models.DoctorCheck{Name: constants.BbeConfigFile}
+
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if errors.Is(err, constants.ConfigNotFoundError) {
+		return nil, doctorWarn(check, fmt.Sprintf("No %s found for cluster `%s`", constants.BbeConfigFile, helperService.GetClusterName()), "Run 'bbe setup' to create it")
+	}
+	if err != nil {
+		return nil, doctorFail(check, err, clierror.Hint(err))
+	}
+
+	err = configService.ValidateBbeConfig(helperService)
+	if err != nil {
+		return bbeConfig, doctorFail(check, err, clierror.Hint(err))
+	}
+
+	return bbeConfig, doctorPass(check, "Valid")
+}
+
+// checkTool returns the installed version of a program when it is new enough
+func checkTool(dependencyService interfaces.DependencyServiceInterface, name string) (string, models.DoctorCheck) {
+	check := models.DoctorCheck{Name: name}
+
+	tool, err := dependencyService.GetToolVersion(name)
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", doctorFail(check, errors.New("Not installed"), fmt.Sprintf("Install %s %s or newer and make sure it is on your PATH", name, tool.Minimum))
+	}
+	if err != nil {
+		return "", doctorFail(check, err, fmt.Sprintf("Run '%s' to check that it works", name))
+	}
+
+	compared, err := versioning.Compare(tool.Version, tool.Minimum)
+	if err != nil {
+		return "", doctorWarn(check, fmt.Sprintf("Unable to compare version %s with the minimum %s", tool.Version, tool.Minimum), "")
+	}
+	if compared < 0 {
+		return "", doctorFail(check, fmt.Errorf("Version %s is older than the minimum %s", tool.Version, tool.Minimum), fmt.Sprintf("Upgrade %s to %s or newer", name, tool.Minimum))
+	}
+
+	return tool.Version, doctorPass(check, fmt.Sprintf("Version %s (minimum %s)", tool.Version, tool.Minimum))
+}
+
+// checkPermissions makes sure other users cannot read the cluster secrets in the config directory
+func checkPermissions(helperService interfaces.HelperServiceInterface) models.DoctorCheck {
+	configDir := helperService.GetConfigDir()
+	check := models.DoctorCheck{Name: "permissions"}
+
+	info, err := os.Stat(configDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return doctorWarn(check, fmt.Sprintf("%s does not exist yet", configDir), "Run 'bbe setup' to create it")
+	}
+	if err != nil {
+		return doctorFail(check, err, "")
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return doctorFail(check, fmt.Errorf("%s is accessible by other users (%s)", configDir, info.Mode().Perm()), fmt.Sprintf("Run 'chmod 700 %s'", configDir))
+	}
+
+	exposed := []string{}
+	for _, name := range slices.Concat(constants.SecretConfigFiles, []string{constants.KubeConfigFile, constants.EncryptionKeyFile}) {
+		info, err := os.Stat(filepath.Join(configDir, name))
+		if err == nil && info.Mode().Perm()&0077 != 0 {
+			exposed = append(exposed, name)
+		}
+	}
+	if len(exposed) > 0 {
+		return doctorWarn(check, fmt.Sprintf("Readable by other users: %s", strings.Join(exposed, ", ")), fmt.Sprintf("Run 'chmod 600' on these files in %s", configDir))
+	}
+
+	return doctorPass(check, fmt.Sprintf("%s is only accessible by you", configDir))
+}
+
+func checkStorage(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, bbeConfig *models.BbeConfig) models.DoctorCheck {
+	storage := bbeConfig.Bbe.Storage
+	check := models.DoctorCheck{Name: "storage"}
+
+	err := configService.CheckStorage(helperService, bbeConfig)
+	if err != nil {
+		hint := clierror.Hint(err)
+		switch storage.Type {
+		case constants.StorageAws:
+			hint = "Check your AWS credentials with 'aws sts get-caller-identity' and that they grant access to the bucket"
+			if storage.Aws.Profile != "" {
+				hint = fmt.Sprintf("Check the credentials of AWS profile `%s` with 'aws sts get-caller-identity --profile %s' and that they grant access to the bucket", storage.Aws.Profile, storage.Aws.Profile)
+			}
+		case constants.StorageS3:
+			hint = fmt.Sprintf("Check the endpoint %s and the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY of the bucket", storage.S3.Endpoint)
+		case constants.StorageGit:
+			hint = fmt.Sprintf("Check that 'git ls-remote %s' works with your credentials", storage.Git.Repository)
+		}
+		return doctorFail(check, fmt.Errorf("%s storage is not accessible: %w", storage.Type, err), hint)
+	}
+
+	if storage.Type == constants.StorageAws && storage.Aws.BucketName == "" {
+		return doctorPass(check, "AWS credentials are valid, the bucket is created on the first sync")
+	}
+
+	return doctorPass(check, fmt.Sprintf("%s storage is accessible", storage.Type))
+}
+
+func checkLibrary(packageService interfaces.PackageServiceInterface, bbeConfig models.BbeConfig, library models.LibrarySource) models.DoctorCheck {
+	check := models.DoctorCheck{Name: fmt.Sprintf("library %s", library.Name)}
+
+	source := library.Source
+	if source == "" {
+		source = constants.BbeLibraryUrl
+	}
+
+	err := packageService.CheckLibrary(bbeConfig, library)
+	if errors.Is(err, constants.LibraryVerificationError) {
+		return doctorFail(check, err, "Check the public key configured for the library, ask its publisher for a new signature, or set allow_unsigned for it in bbe.yaml to skip verification")
+	}
+	if err != nil {
+		return doctorWarn(check, fmt.Sprintf("%s is not reachable, the cached copy is used: %v", source, err), "Check your network connection or 'bbe library' sources")
+	}
+
+	return doctorPass(check, fmt.Sprintf("%s is reachable and verified", source))
+}
+
+// checkCluster checks the kubeconfig, the API server and the nodes, talosctlVersion is empty when talosctl is not usable
+func checkCluster(helperService interfaces.HelperServiceInterface, talosService interfaces.TalosServiceInterface, kubeconfigService interfaces.KubeconfigServiceInterface, talosctlVersion string) []models.DoctorCheck {
+	clusterCheck := models.DoctorCheck{Name: "cluster"}
+	if _, exists := helperService.CheckIfFileExists(helperService.GetConfigFilePath(constants.ControlplaneConfigFile)); !exists {
+		return []models.DoctorCheck{doctorWarn(clusterCheck, fmt.Sprintf("Cluster `%s` has not been set up yet", helperService.GetClusterName()), "Run 'bbe setup' to create the first node")}
+	}
+
+	controlPlaneIp, err := talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
+	if err != nil {
+		return []models.DoctorCheck{doctorFail(clusterCheck, err, clierror.Hint(err))}
+	}
+
+	checks := []models.DoctorCheck{}
+
+	kubeconfigCheck := models.DoctorCheck{Name: "kubeconfig"}
+	apiCheck := models.DoctorCheck{Name: "api server"}
+	server, err := kubeconfigService.VerifyEndpoint(helperService)
+	switch {
+	case err != nil && server == "":
+		checks = append(checks, doctorFail(kubeconfigCheck, err, "Run 'bbe kubeconfig' to generate a new one"))
+	case err != nil:
+		checks = append(checks, doctorPass(kubeconfigCheck, kubeconfigService.Path(helperService)))
+		checks = append(checks, doctorFail(apiCheck, err, fmt.Sprintf("Check that the control plane %s is powered on and reachable from this machine", controlPlaneIp)))
+	default:
+		checks = append(checks, doctorPass(kubeconfigCheck, kubeconfigService.Path(helperService)))
+		checks = append(checks, doctorPass(apiCheck, fmt.Sprintf("%s is reachable", server)))
+	}
+
+	if talosctlVersion == "" {
+		return append(checks, doctorWarn(models.DoctorCheck{Name: "nodes"}, "Skipped, talosctl is not usable", ""))
+	}
+
+	nodesCheck := models.DoctorCheck{Name: "nodes"}
+	members, err := talosService.GetMembers(helperService, controlPlaneIp)
+	if err != nil {
+		return append(checks, doctorFail(nodesCheck, err, fmt.Sprintf("Check that the control plane %s is powered on and reachable from this machine", controlPlaneIp)))
+	}
+
+	checks = append(checks, checkTalosSkew(talosctlVersion, members))
+
+	err = talosService.CheckHealth(helperService, controlPlaneIp)
+	if err != nil {
+		return append(checks, doctorFail(nodesCheck, err, "Run 'talosctl health' to see which check fails"))
+	}
+
+	return append(checks, doctorPass(nodesCheck, fmt.Sprintf("%d node(s) healthy", len(members))))
+}
+
+// checkTalosSkew compares talosctl with the Talos version of every node, talosctl supports Talos one minor version apart
+func checkTalosSkew(talosctlVersion string, members []models.ClusterMember) models.DoctorCheck {
+	check := models.DoctorCheck{Name: "talos version"}
+
+	client, err := versioning.Parse(talosctlVersion)
+	if err != nil {
+		return doctorWarn(check, err.Error(), "")
+	}
+
+	status := constants.DoctorPass
+	versions := []string{}
+	for _, member := range members {
+		nodeVersion := talosVersionPattern.FindString(member.OperatingSystem)
+		server, err := versioning.Parse(nodeVersion)
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(versions, nodeVersion) {
+			versions = append(versions, nodeVersion)
+		}
+
+		skew := int64(client.Minor()) - int64(server.Minor())
+		switch {
+		case client.Major() != server.Major() || skew > 1 || skew < -1:
+			status = constants.DoctorFail
+		case skew != 0 && status == constants.DoctorPass:
+			status = constants.DoctorWarn
+		}
+	}
+
+	if len(versions) == 0 {
+		return doctorWarn(check, "Unable to read the Talos version of the nodes", "")
+	}
+
+	message := fmt.Sprintf("talosctl %s, nodes run Talos %s", talosctlVersion, strings.Join(versions, ", "))
+	hint := fmt.Sprintf("Install the talosctl version matching the nodes, Talos %s", versions[0])
+	switch status {
+	case constants.DoctorFail:
+		return doctorFail(check, errors.New(message), hint)
+	case constants.DoctorWarn:
+		return doctorWarn(check, message, hint)
+	}
+
+	return doctorPass(check, message)
+}
+
+func doctorPass(check models.DoctorCheck, message string) models.DoctorCheck {
+	check.Status = constants.DoctorPass
+	check.Message = message
+	return check
+}
+
+func doctorWarn(check models.DoctorCheck, message string, hint string) models.DoctorCheck {
+	check.Status = constants.DoctorWarn
+	check.Message = message
+	check.Hint = hint
+	return check
+}
+
+func doctorFail(check models.DoctorCheck, err error, hint string) models.DoctorCheck {
+	check.Status = constants.DoctorFail
+	check.Message = err.Error()
+	check.Hint = hint
+	return check
+}
+
+func formatDoctor(result models.DoctorResult) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	for _, check := range result.Checks {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", strings.ToUpper(check.Status), check.Name, strings.ReplaceAll(check.Message, "\n", " "))
+		if check.Hint != "" {
+			fmt.Fprintf(writer, "\t\thint: %s\n", check.Hint)
+		}
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type doctorMocks struct {
+	helperService     *mocks.MockHelperService
+	dependencyService *mocks.MockDependencyService
+	configService     *mocks.MockConfigService
+	talosService      *mocks.MockTalosService
+	kubeconfigService *mocks.MockKubeconfigService
+	packageService    *mocks.MockPackageService
+	bbeConfig         *models.BbeConfig
+}
+
+// initDoctorTest sets up a healthy cluster, tools missing from versions are reported as not installed
+func initDoctorTest(t *testing.T, versions map[string]string) doctorMocks {
+	configDir := filepath.Join(t.TempDir(), "home")
+	assert.Nil(t, os.Mkdir(configDir, 0700))
+
+	m := doctorMocks{
+		helperService:     &mocks.MockHelperService{},
+		dependencyService: &mocks.MockDependencyService{},
+		configService:     &mocks.MockConfigService{},
+		talosService:      &mocks.MockTalosService{},
+		kubeconfigService: &mocks.MockKubeconfigService{},
+		packageService:    &mocks.MockPackageService{},
+		bbeConfig:         &models.BbeConfig{},
+	}
+	m.bbeConfig.Bbe.Storage.Type = constants.StorageLocal
+
+	m.helperService.On("GetConfigDir").Return(configDir)
+	m.helperService.On("GetClusterName").Return("home")
+	m.helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	m.helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, true)
+	m.configService.On("GetBbeConfig", m.helperService).Return(m.bbeConfig, nil)
+	m.configService.On("ValidateBbeConfig", m.helperService).Return(nil)
+	m.packageService.On("CheckLibrary", mock.Anything).Return(nil)
+	m.talosService.On("GetControlPlaneIp", m.helperService, constants.ControlplaneConfigFile).Return("192.168.1.10", nil)
+	m.talosService.On("GetMembers", m.helperService, "192.168.1.10").Return([]models.ClusterMember{{Hostname: "brain", OperatingSystem: "Talos (v1.8.1)"}}, nil)
+	m.talosService.On("CheckHealth", m.helperService, "192.168.1.10").Return(nil)
+	m.kubeconfigService.On("Path", m.helperService).Return(filepath.Join(configDir, "kubeconfig"))
+	m.kubeconfigService.On("VerifyEndpoint", m.helperService).Return("https://192.168.1.10:6443", nil)
+
+	minimums := map[string]string{"talosctl": "1.8.0", "nmap": "7.80", "helm": "3.12.0", "git": "2.20.0", "oras": "1.0.0"}
+	for tool, minimum := range minimums {
+		version, installed := versions[tool]
+		var err error
+		if !installed {
+			err = &exec.Error{Name: tool, Err: exec.ErrNotFound}
+		}
+		m.dependencyService.On("GetToolVersion", tool).Return(models.ToolVersion{Name: tool, Version: version, Minimum: minimum}, err)
+	}
+
+	return m
+}
+
+func (m doctorMocks) run() error {
+	return doctorCommand(m.helperService, m.dependencyService, m.configService, m.talosService, m.kubeconfigService, m.packageService)
+}
+
+var healthyTools = map[string]string{"talosctl": "1.8.2", "nmap": "7.94", "helm": "3.16.1"}
+
+func findCheck(t *testing.T, result models.DoctorResult, name string) models.DoctorCheck {
+	for _, check := range result.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+
+	t.Fatalf("No check named %s", name)
+	return models.DoctorCheck{}
+}
+
+func runDoctorJson(t *testing.T, m doctorMocks) (models.DoctorResult, error) {
+	buffer := captureOutput(t, output.FormatJson)
+
+	err := m.run()
+
+	var result models.DoctorResult
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &result))
+	return result, err
+}
+
+func Test_doctorCommand_Succeeds_WithHealthyCluster(t *testing.T) {
+	m := initDoctorTest(t, healthyTools)
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Nil(t, err)
+	for _, check := range result.Checks {
+		assert.Equal(t, constants.DoctorPass, check.Status, check.Name)
+	}
+	assert.Equal(t, "talosctl 1.8.2, nodes run Talos 1.8.1", findCheck(t, result, "talos version").Message)
+	m.dependencyService.AssertNotCalled(t, "GetToolVersion", "git")
+	m.dependencyService.AssertNotCalled(t, "GetToolVersion", "oras")
+	m.configService.AssertNotCalled(t, "CheckStorage", mock.Anything, mock.Anything)
+}
+
+func Test_doctorCommand_Fails_WithMissingTool(t *testing.T) {
+	m := initDoctorTest(t, map[string]string{"talosctl": "1.8.2", "nmap": "7.94"})
+
+	result, err := runDoctorJson(t, m)
+
+	assert.EqualError(t, err, "1 of 10 checks failed")
+	helm := findCheck(t, result, "helm")
+	assert.Equal(t, constants.DoctorFail, helm.Status)
+	assert.Equal(t, "Install helm 3.12.0 or newer and make sure it is on your PATH", helm.Hint)
+}
+
+func Test_doctorCommand_Fails_WithOutdatedTool(t *testing.T) {
+	m := initDoctorTest(t, map[string]string{"talosctl": "1.8.2", "nmap": "7.70", "helm": "3.16.1"})
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Error(t, err)
+	nmap := findCheck(t, result, "nmap")
+	assert.Equal(t, constants.DoctorFail, nmap.Status)
+	assert.Equal(t, "Version 7.70 is older than the minimum 7.80", nmap.Message)
+}
+
+func Test_doctorCommand_Succeeds_WithoutCluster(t *testing.T) {
+	m := initDoctorTest(t, healthyTools)
+	m.helperService.ExpectedCalls = nil
+	m.helperService.On("GetConfigDir").Return(filepath.Join(t.TempDir(), "missing"))
+	m.helperService.On("GetClusterName").Return("home")
+	m.helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	m.helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, false)
+	m.configService.ExpectedCalls = nil
+	m.configService.On("GetBbeConfig", m.helperService).Return((*models.BbeConfig)(nil), constants.ConfigNotFoundError)
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Nil(t, err)
+	assert.Equal(t, constants.DoctorWarn, findCheck(t, result, "bbe.yaml").Status)
+	assert.Equal(t, constants.DoctorWarn, findCheck(t, result, "cluster").Status)
+	m.packageService.AssertNotCalled(t, "CheckLibrary", mock.Anything)
+	m.talosService.AssertNotCalled(t, "CheckHealth", mock.Anything, mock.Anything)
+}
+
+func Test_doctorCommand_Fails_WhenStorageIsNotAccessible(t *testing.T) {
+	m := initDoctorTest(t, map[string]string{"talosctl": "1.8.2", "nmap": "7.94", "helm": "3.16.1", "git": "2.43.0"})
+	m.bbeConfig.Bbe.Storage.Type = constants.StorageGit
+	m.bbeConfig.Bbe.Storage.Git.Repository = "git@github.com:brains/config.git"
+	m.configService.On("CheckStorage", m.helperService, m.bbeConfig).Return(errors.New("Permission denied (publickey)"))
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Error(t, err)
+	storage := findCheck(t, result, "storage")
+	assert.Equal(t, constants.DoctorFail, storage.Status)
+	assert.Equal(t, "git storage is not accessible: Permission denied (publickey)", storage.Message)
+	assert.Equal(t, "Check that 'git ls-remote git@github.com:brains/config.git' works with your credentials", storage.Hint)
+	assert.Equal(t, constants.DoctorPass, findCheck(t, result, "git").Status)
+}
+
+func Test_doctorCommand_Fails_WithoutOrasForOciLibrary(t *testing.T) {
+	m := initDoctorTest(t, healthyTools)
+	m.bbeConfig.Bbe.Libraries = []models.LibrarySource{{Name: "internal", Source: "oci://registry.example.com/bbe/library:latest", AllowUnsigned: true}}
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Error(t, err)
+	oras := findCheck(t, result, "oras")
+	assert.Equal(t, constants.DoctorFail, oras.Status)
+	assert.Equal(t, "Install oras 1.0.0 or newer and make sure it is on your PATH", oras.Hint)
+}
+
+func Test_doctorCommand_Fails_WhenApiServerIsNotReachable(t *testing.T) {
+	m := initDoctorTest(t, healthyTools)
+	m.kubeconfigService.ExpectedCalls = nil
+	m.kubeconfigService.On("Path", m.helperService).Return("kubeconfig")
+	m.kubeconfigService.On("VerifyEndpoint", m.helperService).Return("https://192.168.1.10:6443", errors.New("The API server https://192.168.1.10:6443 is not reachable"))
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Error(t, err)
+	assert.Equal(t, constants.DoctorPass, findCheck(t, result, "kubeconfig").Status)
+	assert.Equal(t, constants.DoctorFail, findCheck(t, result, "api server").Status)
+}
+
+func Test_doctorCommand_Succeeds_WhenLibraryIsUnreachable(t *testing.T) {
+	m := initDoctorTest(t, healthyTools)
+	m.packageService.ExpectedCalls = nil
+	m.packageService.On("CheckLibrary", mock.Anything).Return(errors.New("connection refused"))
+
+	result, err := runDoctorJson(t, m)
+
+	assert.Nil(t, err)
+	assert.Equal(t, constants.DoctorWarn, findCheck(t, result, "library bbe").Status)
+}
+
+func Test_doctorCommand_Succeeds_WithTextOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatText)
+	m := initDoctorTest(t, map[string]string{"talosctl": "1.8.2", "nmap": "7.94"})
+
+	err := m.run()
+
+	assert.Error(t, err)
+	assert.Contains(t, buffer.String(), "PASS  talosctl")
+	assert.Contains(t, buffer.String(), "FAIL  helm")
+	assert.Contains(t, buffer.String(), "hint: Install helm 3.12.0 or newer")
+}
+
+func Test_checkTalosSkew_Succeeds(t *testing.T) {
+	tests := []struct {
+		talosctl string
+		node     string
+		status   string
+	}{
+		{"1.8.2", "Talos (v1.8.0)", constants.DoctorPass},
+		{"1.9.0", "Talos (v1.8.3)", constants.DoctorWarn},
+		{"1.7.6", "Talos (v1.8.3)", constants.DoctorWarn},
+		{"1.10.0", "Talos (v1.8.3)", constants.DoctorFail},
+		{"2.0.0", "Talos (v1.8.3)", constants.DoctorFail},
+	}
+
+	for _, test := range tests {
+		check := checkTalosSkew(test.talosctl, []models.ClusterMember{{OperatingSystem: test.node}})
+		assert.Equal(t, test.status, check.Status, test.talosctl+" with "+test.node)
+	}
+}
+
+func Test_checkTalosSkew_Succeeds_WithUnknownNodeVersion(t *testing.T) {
+	check := checkTalosSkew("1.8.2", []models.ClusterMember{{OperatingSystem: "unknown"}})
+
+	assert.Equal(t, constants.DoctorWarn, check.Status)
+}
+
+func Test_checkPermissions_Fails_WhenConfigDirIsShared(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "home")
+	assert.Nil(t, os.Mkdir(configDir, 0755))
+	assert.Nil(t, os.Chmod(configDir, 0755))
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigDir").Return(configDir)
+
+	check := checkPermissions(helperService)
+
+	assert.Equal(t, constants.DoctorFail, check.Status)
+	assert.Contains(t, check.Hint, "chmod 700")
+}
+
+func Test_checkPermissions_Succeeds_WarnsAboutReadableSecrets(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "home")
+	assert.Nil(t, os.Mkdir(configDir, 0700))
+	assert.Nil(t, os.WriteFile(filepath.Join(configDir, constants.TalosConfigFile), []byte("talos"), 0600))
+	assert.Nil(t, os.WriteFile(filepath.Join(configDir, constants.ControlplaneConfigFile), []byte("machine"), 0644))
+	assert.Nil(t, os.Chmod(filepath.Join(configDir, constants.ControlplaneConfigFile), 0644))
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigDir").Return(configDir)
+
+	check := checkPermissions(helperService)
+
+	assert.Equal(t, constants.DoctorWarn, check.Status)
+	assert.Equal(t, "Readable by other users: controlplane.yaml", check.Message)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/etcd_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
+	"github.com/spf13/cobra"
+)
+
+var etcdCmd = &cobra.Command{
+	Use:   "etcd",
+	Short: "Back up and restore the etcd database of the cluster",
+	Long:  "Back up and restore the etcd database of the cluster. Snapshots are kept in the storage configured in bbe.yaml, or next to the local config for local storage, and are encrypted like the Talos configs.",
+}
+
+var etcdSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Take an etcd snapshot and apply the retention rules",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		talosService := talos_service.TalosService{}
+		etcdService := etcd_service.EtcdService{}
+
+		return etcdSnapshotCommand(helperService, configService, talosService, etcdService)
+	},
+}
+
+var etcdListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the stored etcd snapshots",
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		etcdService := etcd_service.EtcdService{}
+
+		return etcdListCommand(helperService, configService, etcdService)
+	},
+}
+
+var etcdRestoreCmd = &cobra.Command{
+	Use:   "restore [snapshot]",
+	Short: "Recover the cluster from a snapshot on a fresh control plane",
+	Long:  "Recover the cluster from a snapshot on a freshly installed control plane, defaults to the newest snapshot. Boot the replacement machine from the Talos image and pass its current address with --node, it is configured with the stored controlplane.yaml before etcd is recovered.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		talosService := talos_service.TalosService{}
+		uiService := ui_service.UiService{}
+		etcdService := etcd_service.EtcdService{}
+
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		}
+		nodeIp, _ := cmd.Flags().GetString("node")
+
+		return etcdRestoreCommand(helperService, configService, talosService, uiService, etcdService, name, nodeIp)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(etcdCmd)
+	etcdCmd.AddCommand(etcdSnapshotCmd)
+	etcdCmd.AddCommand(etcdListCmd)
+	etcdCmd.AddCommand(etcdRestoreCmd)
+
+	etcdRestoreCmd.Flags().String("node", "", "Current address of the replacement control plane, defaults to the control plane address in controlplane.yaml")
+	etcdRestoreCmd.RegisterFlagCompletionFunc("node", completeNodes)
+}
+
+func etcdSnapshotCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, etcdService interfaces.EtcdServiceInterface) error {
+	logger.Info("Taking etcd snapshot...")
+
+	snapshot, err := etcdService.Snapshot(helperService, configService, talosService)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Stored snapshot %s (%s)", snapshot.Name, formatSize(len(snapshot.Content)))
+
+	deleted, err := etcdService.Prune(helperService, configService)
+	if err != nil {
+		return fmt.Errorf("The snapshot was stored but applying the retention rules failed: %w", err)
+	}
+	for _, snapshot := range deleted {
+		logger.Infof("Deleted snapshot %s", snapshot.Name)
+	}
+
+	return nil
+}
+
+func etcdListCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, etcdService interfaces.EtcdServiceInterface) error {
+	snapshots, err := etcdService.List(helperService, configService)
+	if err != nil {
+		return fmt.Errorf("Failed to list snapshots: %w", err)
+	}
+
+	if len(snapshots) == 0 {
+		logger.Info("No etcd snapshots found, run 'bbe etcd snapshot' to take one")
+		return nil
+	}
+
+	logger.Infof("Snapshots:\n%s", formatSnapshots(snapshots))
+	return nil
+}
+
+func etcdRestoreCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, uiService interfaces.UiServiceInterface, etcdService interfaces.EtcdServiceInterface, name string, nodeIp string) error {
+	if name == "" {
+		snapshots, err := etcdService.List(helperService, configService)
+		if err != nil {
+			return fmt.Errorf("Failed to list snapshots: %w", err)
+		}
+		if len(snapshots) == 0 {
+			return errors.New("No etcd snapshots found to restore")
+		}
+		name = snapshots[0].Name
+	}
+	name = etcd_service.SnapshotName(name)
+
+	answer, err := uiService.CreateSelect(fmt.Sprintf("Recover the cluster from %s? This only works on a freshly installed control plane", name), []string{"No", "Yes"})
+	if err != nil {
+		return err
+	}
+	if answer != "Yes" {
+		return nil
+	}
+
+	err = etcdService.Restore(helperService, configService, talosService, name, nodeIp)
+	if err != nil {
+		return fmt.Errorf("Failed to restore %s: %w", name, err)
+	}
+
+	logger.Infof("Cluster recovered from %s, the worker nodes rejoin on their own", name)
+	return nil
+}
+
+func formatSnapshots(snapshots []models.StorageObject) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "SNAPSHOT\tCREATED")
+	for _, snapshot := range snapshots {
+		fmt.Fprintf(writer, "%s\t%s\n", strings.TrimPrefix(snapshot.Name, constants.EtcdSnapshotDir), snapshot.ModTime.Local().Format(time.DateTime))
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func formatSize(size int) string {
+	if size < 1<<20 {
+		return fmt.Sprintf("%.1f KiB", float64(size)/(1<<10))
+	}
+
+	return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_etcdSnapshotCommand_Succeeds_AndPrunesOldSnapshots(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	talosService := &mocks.MockTalosService{}
+	etcdService := &mocks.MockEtcdService{}
+	etcdService.On("Snapshot", helperService, configService, talosService).Return(&models.StorageObject{Name: "etcd/home-2.db", Content: []byte("etcd")}, nil)
+	etcdService.On("Prune", helperService, configService).Return([]models.StorageObject{{Name: "etcd/home-1.db"}}, nil)
+
+	err := etcdSnapshotCommand(helperService, configService, talosService, etcdService)
+
+	assert.Nil(t, err)
+	etcdService.AssertCalled(t, "Prune", helperService, configService)
+}
+
+func Test_etcdSnapshotCommand_Fails_WithoutPruningWhenSnapshotFails(t *testing.T) {
+	etcdService := &mocks.MockEtcdService{}
+	etcdService.On("Snapshot", mock.Anything, mock.Anything, mock.Anything).Return((*models.StorageObject)(nil), errors.New("connection refused"))
+
+	err := etcdSnapshotCommand(&mocks.MockHelperService{}, &mocks.MockConfigService{}, &mocks.MockTalosService{}, etcdService)
+
+	assert.ErrorContains(t, err, "connection refused")
+	etcdService.AssertNotCalled(t, "Prune", mock.Anything, mock.Anything)
+}
+
+func Test_etcdListCommand_Succeeds_WithoutSnapshots(t *testing.T) {
+	etcdService := &mocks.MockEtcdService{}
+	etcdService.On("List", mock.Anything, mock.Anything).Return([]models.StorageObject{}, nil)
+
+	err := etcdListCommand(&mocks.MockHelperService{}, &mocks.MockConfigService{}, etcdService)
+
+	assert.Nil(t, err)
+}
+
+func Test_etcdRestoreCommand_Succeeds_WithNewestSnapshot(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	talosService := &mocks.MockTalosService{}
+	uiService := &mocks.MockUiService{}
+	etcdService := &mocks.MockEtcdService{}
+	etcdService.On("List", helperService, configService).Return([]models.StorageObject{{Name: "etcd/home-2.db"}, {Name: "etcd/home-1.db"}}, nil)
+	etcdService.On("Restore", helperService, configService, talosService, "etcd/home-2.db", "192.168.1.50").Return(nil)
+	uiService.On("CreateSelect", mock.Anything, []string{"No", "Yes"}).Return("Yes", nil)
+
+	err := etcdRestoreCommand(helperService, configService, talosService, uiService, etcdService, "", "192.168.1.50")
+
+	assert.Nil(t, err)
+	etcdService.AssertCalled(t, "Restore", helperService, configService, talosService, "etcd/home-2.db", "192.168.1.50")
+}
+
+func Test_etcdRestoreCommand_Succeeds_WithoutRestoringWhenDeclined(t *testing.T) {
+	uiService := &mocks.MockUiService{}
+	etcdService := &mocks.MockEtcdService{}
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("No", nil)
+
+	err := etcdRestoreCommand(&mocks.MockHelperService{}, &mocks.MockConfigService{}, &mocks.MockTalosService{}, uiService, etcdService, "home-1.db", "")
+
+	assert.Nil(t, err)
+	etcdService.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_etcdRestoreCommand_Fails_WithoutSnapshots(t *testing.T) {
+	etcdService := &mocks.MockEtcdService{}
+	etcdService.On("List", mock.Anything, mock.Anything).Return([]models.StorageObject{}, nil)
+
+	err := etcdRestoreCommand(&mocks.MockHelperService{}, &mocks.MockConfigService{}, &mocks.MockTalosService{}, &mocks.MockUiService{}, etcdService, "", "")
+
+	assert.ErrorContains(t, err, "No etcd snapshots found")
+}
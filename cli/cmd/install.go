@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"slices"
+	"strings"
+	"text/tabwriter"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helm_service"
@@ -17,49 +23,87 @@ import (
 )
 
 var installCmd = &cobra.Command{
-	Use:     "install",
-	Aliases: []string{"i"},
-	Short:   "Install BBE packages",
-	Args:    cobra.ExactArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:               "install [package[@version]...]",
+	Aliases:           []string{"i"},
+	Short:             "Install BBE packages",
+	Long:              "Install BBE packages. Without arguments the packages of the libraries are shown to choose from and every package that is not chosen is uninstalled. With arguments only the named packages are installed, packages from additional libraries are named <library>/<package>. A package is installed as a release named after it in a namespace of the same name unless its library sets other defaults, --release and --namespace override both and install a chart again under another release.",
+	ValidArgsFunction: completeLibraryPackages,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		helperService := helper_service.HelperService{}
 		uiService := ui_service.UiService{}
 		configService := config_service.ConfigService{}
 		packageService := package_service.PackageService{}
 		helmService := helm_service.HelmService{}
 
-		err := installCommand(helperService, uiService, configService, packageService, helmService)
-		if err != nil {
-			logger.Error("", err)
-			os.Exit(1)
+		uninteractive, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		release, _ := cmd.Flags().GetString("release")
+		namespace, _ := cmd.Flags().GetString("namespace")
+
+		if len(args) > 0 {
+			return installPackagesCommand(helperService, uiService, configService, packageService, helmService, args, release, namespace, uninteractive, dryRun)
+		}
+		if release != "" || namespace != "" {
+			return clierror.New(clierror.KindUsage, errors.New("--release and --namespace require a package argument"), "Run 'bbe install <package> --release <name>'")
 		}
+
+		return installCommand(helperService, uiService, configService, packageService, helmService, dryRun)
+	},
+}
+
+var uninstallCmd = &cobra.Command{
+	Use:               "uninstall <package>...",
+	Short:             "Uninstall BBE packages",
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeInstalledPackages,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		uiService := ui_service.UiService{}
+		configService := config_service.ConfigService{}
+		packageService := package_service.PackageService{}
+		helmService := helm_service.HelmService{}
+
+		uninteractive, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		return uninstallPackagesCommand(helperService, uiService, configService, packageService, helmService, args, uninteractive, dryRun)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(installCmd)
+	rootCmd.AddCommand(uninstallCmd)
+
+	installCmd.Flags().BoolP("yes", "y", false, "Automatically accept yes/no questions without input.")
+	installCmd.Flags().String("release", "", "Release name of the package, installs the chart again when it is installed under another release")
+	installCmd.Flags().String("namespace", "", "Namespace of the package, defaults to the namespace of the library or the release name")
+	installCmd.Flags().Bool("dry-run", false, "Show the packages that would be installed or uninstalled without changing anything")
+	uninstallCmd.Flags().BoolP("yes", "y", false, "Automatically accept yes/no questions without input.")
+	uninstallCmd.Flags().Bool("dry-run", false, "Show the packages that would be uninstalled without changing anything")
 }
 
-func installCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface) error {
-	bbeConfig, err := configService.GetBbeConfig(helperService)
-	if err != nil || bbeConfig.Bbe.Cluster.Name == "" {
-		logger.Info("No BBE cluster found, please run 'bbe setup' to create your cluster")
-		return nil
+func installCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, dryRun bool) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
 	}
 
-	allPackages, err := packageService.GetAll()
+	allPackages, err := packageService.GetAll(helperService, *bbeConfig)
 	if err != nil {
 		return err
 	}
 
-	selectedIndexes, packageList := buildPackageIndex(allPackages, *bbeConfig)
-
-	chosenPackages, err := uiService.CreateMultiChoose("Select packages to install", packageList, selectedIndexes)
+	chosenPackages, err := uiService.BrowsePackages("Select packages to install", buildPackageListings(allPackages, *bbeConfig))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	packagesToInstall, packagesToUninstall := diffPackages(allPackages, chosenPackages)
+	packagesToInstall, packagesToUninstall := diffPackages(allPackages, chosenPackages, bbeConfig.Bbe.Packages)
+
+	if dryRun {
+		return printPackagePlan(packagePlan(*bbeConfig, packagesToInstall, packagesToUninstall))
+	}
 
 	updatedBbeConfig := *bbeConfig
 	updatedBbeConfig.Bbe.Packages = bbeConfig.Bbe.Packages
@@ -77,47 +121,329 @@ func installCommand(helperService interfaces.HelperServiceInterface, uiService i
 	return nil
 }
 
-func buildPackageIndex(allPackages []models.ChartEntry, bbeConfig models.BbeConfig) (selectedIndexes []int, packageList []string) {
-	for packageIndex, pkg := range allPackages {
-		packageList = append(packageList, pkg.Name)
-		for _, installedPkg := range bbeConfig.Bbe.Packages {
-			if installedPkg.Name == pkg.Name {
-				selectedIndexes = append(selectedIndexes, packageIndex)
+// installPackagesCommand installs the named packages and leaves every other package alone
+func installPackagesCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, args []string, release string, namespace string, uninteractive bool, dryRun bool) error {
+	if (release != "" || namespace != "") && len(args) != 1 {
+		return clierror.New(clierror.KindUsage, errors.New("--release and --namespace apply to a single package"), "Install the packages one at a time")
+	}
+
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	allPackages, err := packageService.GetAll(helperService, *bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	packagesToInstall, err := resolveLibraryPackages(allPackages, args)
+	if err != nil {
+		return err
+	}
+	if release != "" {
+		packagesToInstall[0].Release = release
+	}
+	if namespace != "" {
+		packagesToInstall[0].Namespace = namespace
+	}
+
+	plan := packagePlan(*bbeConfig, packagesToInstall, nil)
+	if dryRun {
+		return printPackagePlan(plan)
+	}
+
+	if len(plan) == 0 {
+		logger.Info("All packages are already installed, run 'bbe upgrade' to change their version")
+		return nil
+	}
+
+	confirmed, err := confirmPackagePlan(uiService, plan, uninteractive)
+	if err != nil || !confirmed {
+		return err
+	}
+
+	err = installPackages(helperService, configService, packageService, helmService, *bbeConfig, packagesToInstall)
+	if err != nil {
+		return fmt.Errorf("Failed to install packages: %w", err)
+	}
+
+	logger.Info(fmt.Sprintf("Installed %s", planPackageNames(plan)))
+	return nil
+}
+
+// uninstallPackagesCommand uninstalls the named packages and removes them from bbe.yaml
+func uninstallPackagesCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, args []string, uninteractive bool, dryRun bool) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	packagesToUninstall, err := resolveInstalledPackages(bbeConfig.Bbe.Packages, args)
+	if err != nil {
+		return err
+	}
+
+	plan := packagePlan(*bbeConfig, nil, packagesToUninstall)
+	if dryRun {
+		return printPackagePlan(plan)
+	}
+
+	confirmed, err := confirmPackagePlan(uiService, plan, uninteractive)
+	if err != nil || !confirmed {
+		return err
+	}
+
+	err = uninstallPackages(helperService, configService, packageService, helmService, *bbeConfig, packagesToUninstall)
+	if err != nil {
+		return fmt.Errorf("Failed to uninstall packages: %w", err)
+	}
+
+	logger.Info(fmt.Sprintf("Uninstalled %s", planPackageNames(plan)))
+	return nil
+}
+
+// resolveLibraryPackages finds the library package of every <package>[@version] argument, <library>/<package> names a library
+// and a bare name is taken from the first library offering it, in order of precedence
+func resolveLibraryPackages(allPackages []models.ChartEntry, args []string) ([]models.ChartEntry, error) {
+	resolved := []models.ChartEntry{}
+	for _, arg := range args {
+		name, version, pinned := strings.Cut(arg, "@")
+
+		var matches []models.ChartEntry
+		for _, pkg := range allPackages {
+			if packageDisplayName(pkg) == name || pkg.Name == name {
+				matches = append(matches, pkg)
+			}
+		}
+
+		if len(matches) == 0 {
+			return nil, clierror.New(clierror.KindUsage, fmt.Errorf("Package `%s` not found in the libraries", name), "Run 'bbe install' without arguments to choose from the packages of the libraries")
+		}
+		if len(matches) > 1 && packageDisplayName(matches[0]) != name {
+			others := []string{}
+			for _, match := range matches[1:] {
+				others = append(others, packageDisplayName(match))
 			}
+			logger.Info(fmt.Sprintf("Using %s, the package is also offered as %s", packageDisplayName(matches[0]), strings.Join(others, ", ")))
+		}
+
+		chart := matches[0]
+		if pinned {
+			if _, err := versioning.Parse(version); err != nil {
+				return nil, clierror.New(clierror.KindUsage, err, fmt.Sprintf("Pass the version as %s@<version>, e.g. %s@%s", name, name, chart.Version))
+			}
+			if chart.LocalChart != "" && version != chart.Version {
+				return nil, fmt.Errorf("Package `%s` is mirrored with version %s only", name, chart.Version)
+			}
+			chart.Version = version
+		}
+
+		if !slices.ContainsFunc(resolved, func(pkg models.ChartEntry) bool {
+			return package_service.ChartRelease(pkg) == package_service.ChartRelease(chart)
+		}) {
+			resolved = append(resolved, chart)
 		}
 	}
 
-	return selectedIndexes, packageList
+	return resolved, nil
 }
 
-func diffPackages(allPackages []models.ChartEntry, chosenPackages []string) (packagesToInstall []models.ChartEntry, packagesToUninstall []models.ChartEntry) {
-	for _, pkg := range allPackages {
+// resolveInstalledPackages finds the package recorded in bbe.yaml for every argument
+func resolveInstalledPackages(installedPackages []models.LocalPackage, args []string) ([]models.ChartEntry, error) {
+	resolved := []models.ChartEntry{}
+	for _, arg := range args {
 		found := false
-		for _, chosenPackage := range chosenPackages {
-			if pkg.Name == chosenPackage {
-				found = true
-				packagesToInstall = append(packagesToInstall, pkg)
+		for _, installed := range installedPackages {
+			if installed.Name != arg && packageDisplayName(models.ChartEntry{Name: installed.Name, Library: installed.Library}) != arg {
+				continue
+			}
+
+			found = true
+			pkg := models.ChartEntry{Name: package_service.PackageChart(installed), Version: installed.Version, Library: installed.Library}
+			pkg = package_service.ChartForPackage(pkg, installed)
+			if !slices.ContainsFunc(resolved, func(existing models.ChartEntry) bool {
+				return package_service.ChartRelease(existing) == package_service.ChartRelease(pkg)
+			}) {
+				resolved = append(resolved, pkg)
 			}
+			break
 		}
 
 		if !found {
-			packagesToUninstall = append(packagesToUninstall, pkg)
+			return nil, clierror.New(clierror.KindUsage, fmt.Errorf("Package `%s` is not installed", arg), "Run 'bbe package list' to see the installed packages")
+		}
+	}
+
+	return resolved, nil
+}
+
+// packagePlan describes the packages that change, packages to install that are already in bbe.yaml and packages to uninstall that are not are left out
+func packagePlan(bbeConfig models.BbeConfig, packagesToInstall []models.ChartEntry, packagesToUninstall []models.ChartEntry) []models.PackagePlanEntry {
+	isInstalled := func(chart models.ChartEntry) bool {
+		release := package_service.ChartRelease(chart)
+		return slices.ContainsFunc(bbeConfig.Bbe.Packages, func(pkg models.LocalPackage) bool { return pkg.Name == release })
+	}
+
+	plan := []models.PackagePlanEntry{}
+	for _, pkg := range packagesToUninstall {
+		if isInstalled(pkg) {
+			plan = append(plan, packagePlanEntry(pkg, "uninstall"))
+		}
+	}
+	for _, pkg := range packagesToInstall {
+		if !isInstalled(pkg) {
+			plan = append(plan, packagePlanEntry(pkg, "install"))
+		}
+	}
+
+	return plan
+}
+
+func packagePlanEntry(pkg models.ChartEntry, action string) models.PackagePlanEntry {
+	library := pkg.Library
+	if library == "" {
+		library = constants.DefaultLibraryName
+	}
+
+	entry := models.PackagePlanEntry{
+		Name:      package_service.ChartRelease(pkg),
+		Version:   pkg.Version,
+		Library:   library,
+		Namespace: package_service.ChartNamespace(pkg),
+		Action:    action,
+	}
+	if entry.Name != pkg.Name {
+		entry.Chart = pkg.Name
+	}
+
+	return entry
+}
+
+func formatPackagePlan(plan []models.PackagePlanEntry) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "ACTION\tPACKAGE\tVERSION\tLIBRARY\tNAMESPACE")
+	for _, entry := range plan {
+		version := entry.Version
+		if entry.Current != "" {
+			version = fmt.Sprintf("%s → %s", entry.Current, entry.Version)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", entry.Action, entry.Name, version, entry.Library, entry.Namespace)
+	}
+	writer.Flush()
+
+	for _, entry := range plan {
+		if entry.Diff != "" {
+			fmt.Fprintf(&builder, "\n%s:\n%s\n", entry.Name, strings.TrimRight(entry.Diff, "\n"))
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func printPackagePlan(plan []models.PackagePlanEntry) error {
+	return output.Print(plan, func() string {
+		if len(plan) == 0 {
+			return "No packages would change"
+		}
+
+		return formatPackagePlan(plan)
+	})
+}
+
+// confirmPackagePlan shows the plan and asks whether to apply it, uninteractive accepts without asking
+func confirmPackagePlan(uiService interfaces.UiServiceInterface, plan []models.PackagePlanEntry, uninteractive bool) (bool, error) {
+	logger.Info(formatPackagePlan(plan))
+	if uninteractive {
+		return true, nil
+	}
+
+	answer, err := uiService.CreateSelect("Do you want to continue?", []string{"Yes", "No"})
+	if err != nil {
+		return false, err
+	}
+
+	if answer != "Yes" {
+		logger.Info("No packages were changed")
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func planPackageNames(plan []models.PackagePlanEntry) string {
+	names := []string{}
+	for _, entry := range plan {
+		names = append(names, entry.Name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// buildPackageListings offers every library package, packages recorded in bbe.yaml carry their installed version
+func buildPackageListings(allPackages []models.ChartEntry, bbeConfig models.BbeConfig) []models.PackageListing {
+	listings := []models.PackageListing{}
+	for _, pkg := range allPackages {
+		listing := models.PackageListing{Name: packageDisplayName(pkg), Chart: pkg}
+		for _, installedPkg := range bbeConfig.Bbe.Packages {
+			if package_service.IsPackageChart(pkg, installedPkg) {
+				listing.Installed = installedPkg.Version
+			}
+		}
+		listings = append(listings, listing)
+	}
+
+	return listings
+}
+
+// diffPackages splits the library packages into those chosen and those not, a package installed in bbe.yaml stands for every release of its chart
+func diffPackages(allPackages []models.ChartEntry, chosenPackages []string, installedPackages []models.LocalPackage) (packagesToInstall []models.ChartEntry, packagesToUninstall []models.ChartEntry) {
+	for _, pkg := range allPackages {
+		charts := installedCharts(pkg, installedPackages)
+		if len(charts) == 0 {
+			charts = []models.ChartEntry{pkg}
+		}
+
+		if slices.Contains(chosenPackages, packageDisplayName(pkg)) {
+			packagesToInstall = append(packagesToInstall, charts...)
+		} else {
+			packagesToUninstall = append(packagesToUninstall, charts...)
 		}
 	}
 
 	return packagesToInstall, packagesToUninstall
 }
 
+// installedCharts returns the library chart once for every package of bbe.yaml it is installed as
+func installedCharts(chart models.ChartEntry, installedPackages []models.LocalPackage) []models.ChartEntry {
+	charts := []models.ChartEntry{}
+	for _, installed := range installedPackages {
+		if package_service.IsPackageChart(chart, installed) {
+			charts = append(charts, package_service.ChartForPackage(chart, installed))
+		}
+	}
+
+	return charts
+}
+
+// packageDisplayName prefixes packages from additional libraries with their library name
+func packageDisplayName(pkg models.ChartEntry) string {
+	if pkg.Library == "" || pkg.Library == constants.DefaultLibraryName {
+		return pkg.Name
+	}
+
+	return fmt.Sprintf("%s/%s", pkg.Library, pkg.Name)
+}
+
 func uninstallPackages(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, updatedBbeConfig models.BbeConfig, uninstalledPackages []models.ChartEntry) error {
 	for _, pkg := range uninstalledPackages {
-		convertToPkg := &models.LocalPackage{
-			Name:    pkg.Name,
-			Version: pkg.Version,
-		}
+		release := package_service.ChartRelease(pkg)
 
 		for i, existingPkg := range updatedBbeConfig.Bbe.Packages {
-			if existingPkg.Name == pkg.Name {
-				err := packageService.UninstallPackage(*convertToPkg, updatedBbeConfig, helmService)
+			if existingPkg.Name == release {
+				err := packageService.UninstallPackage(existingPkg, updatedBbeConfig, helmService)
 				if err != nil {
 					logger.Error("Failed to uninstall package", err)
 					continue
@@ -137,27 +463,35 @@ func uninstallPackages(helperService interfaces.HelperServiceInterface, configSe
 
 func installPackages(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, updatedBbeConfig models.BbeConfig, installedPackages []models.ChartEntry) error {
 	for _, pkg := range installedPackages {
+		version := pkg.Version
+
 		err := packageService.InstallPackage(pkg, updatedBbeConfig, helmService)
-		if err != nil {
+		var alreadyInstalled *package_service.AlreadyInstalledError
+		if errors.As(err, &alreadyInstalled) {
+			if alreadyInstalled.Version != "" {
+				version = alreadyInstalled.Version
+			}
+			if version != pkg.Version {
+				logger.Warning(fmt.Sprintf("Package %s is already installed with version %s, run 'bbe package sync' to reconcile", package_service.ChartRelease(pkg), version))
+			}
+		} else if err != nil {
 			return fmt.Errorf("Failed to install package: %w", err)
 		}
 
 		found := false
-		convertToPkg := &models.LocalPackage{
-			Name:    pkg.Name,
-			Version: pkg.Version,
-		}
+		convertToPkg := package_service.NewLocalPackage(pkg, version)
 
 		for i, existingPkg := range updatedBbeConfig.Bbe.Packages {
-			if existingPkg.Name == pkg.Name {
-				updatedBbeConfig.Bbe.Packages[i] = *convertToPkg
+			if existingPkg.Name == convertToPkg.Name {
+				convertToPkg.Policy = existingPkg.Policy
+				updatedBbeConfig.Bbe.Packages[i] = convertToPkg
 				found = true
 				break
 			}
 		}
 
 		if !found {
-			updatedBbeConfig.Bbe.Packages = append(updatedBbeConfig.Bbe.Packages, *convertToPkg)
+			updatedBbeConfig.Bbe.Packages = append(updatedBbeConfig.Bbe.Packages, convertToPkg)
 		}
 	}
 	err := configService.UpdateBbePackages(helperService, updatedBbeConfig.Bbe.Packages)
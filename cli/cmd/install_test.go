@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/package_service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -15,10 +19,10 @@ func Test_installCommand_Succeeds(t *testing.T) {
 
 	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
 
-	err := installCommand(helperService, uiService, configService, packageService, helmService)
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
 
 	assert.Nil(t, err)
-	uiService.AssertNumberOfCalls(t, "CreateMultiChoose", 1)
+	uiService.AssertNumberOfCalls(t, "BrowsePackages", 1)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 2)
 	packageService.AssertNumberOfCalls(t, "GetAll", 1)
@@ -41,10 +45,11 @@ func Test_installCommand_Fails_WithNoCluster(t *testing.T) {
 
 	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
 
-	err := installCommand(helperService, uiService, configService, packageService, helmService)
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
 
-	assert.Nil(t, err)
-	uiService.AssertNumberOfCalls(t, "CreateMultiChoose", 0)
+	assert.ErrorContains(t, err, "No BBE cluster found")
+	assert.Equal(t, clierror.ExitConfigInvalid, clierror.ExitCode(err))
+	uiService.AssertNumberOfCalls(t, "BrowsePackages", 0)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 0)
 	packageService.AssertNumberOfCalls(t, "GetAll", 0)
@@ -59,10 +64,10 @@ func Test_installCommand_Succeeds_ProceedsWhenFailingToUninstallPackages(t *test
 
 	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
 
-	err := installCommand(helperService, uiService, configService, packageService, helmService)
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
 
 	assert.Nil(t, err)
-	uiService.AssertNumberOfCalls(t, "CreateMultiChoose", 1)
+	uiService.AssertNumberOfCalls(t, "BrowsePackages", 1)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 2)
 	packageService.AssertNumberOfCalls(t, "GetAll", 1)
@@ -85,10 +90,10 @@ func Test_installCommand_Fails_WhenFailingToUpdateBbeConfigurationOnUninstall(t
 
 	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
 
-	err := installCommand(helperService, uiService, configService, packageService, helmService)
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
 
 	assert.NotNil(t, err)
-	uiService.AssertNumberOfCalls(t, "CreateMultiChoose", 1)
+	uiService.AssertNumberOfCalls(t, "BrowsePackages", 1)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 1)
 	packageService.AssertNumberOfCalls(t, "GetAll", 1)
@@ -107,10 +112,10 @@ func Test_installCommand_Fails_WhenFailingToInstallPackage(t *testing.T) {
 
 	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
 
-	err := installCommand(helperService, uiService, configService, packageService, helmService)
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
 
 	assert.NotNil(t, err)
-	uiService.AssertNumberOfCalls(t, "CreateMultiChoose", 1)
+	uiService.AssertNumberOfCalls(t, "BrowsePackages", 1)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 1)
 	packageService.AssertNumberOfCalls(t, "GetAll", 1)
@@ -130,10 +135,10 @@ func Test_installCommand_Fails_WhenFailingToUpdateBbeConfigurationOnInstall(t *t
 
 	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
 
-	err := installCommand(helperService, uiService, configService, packageService, helmService)
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
 
 	assert.NotNil(t, err)
-	uiService.AssertNumberOfCalls(t, "CreateMultiChoose", 1)
+	uiService.AssertNumberOfCalls(t, "BrowsePackages", 1)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 2)
 	packageService.AssertNumberOfCalls(t, "GetAll", 1)
@@ -156,7 +161,7 @@ func initInstallCommand() (*mocks.MockHelperService, *mocks.MockUiService, *mock
 }
 
 func mockSuccessfulInstallFlow(_ *mocks.MockHelperService, uiService *mocks.MockUiService, configService *mocks.MockConfigService, packageService *mocks.MockPackageService) {
-	uiService.On("CreateMultiChoose", mock.Anything, mock.Anything, mock.Anything).Return([]string{
+	uiService.On("BrowsePackages", mock.Anything, mock.Anything).Return([]string{
 		"package_always_installed",
 		"package_to_be_installed",
 	}, nil)
@@ -197,3 +202,380 @@ func mockSuccessfulInstallFlow(_ *mocks.MockHelperService, uiService *mocks.Mock
 	packageService.On("UninstallPackage", mock.Anything).Return(nil)
 	packageService.On("InstallPackage", mock.Anything).Return(nil)
 }
+
+func Test_installCommand_Succeeds_WithPackagesFromAdditionalLibraries(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{
+			Name:    "blocky",
+			Version: "1.0.0",
+			Library: "bbe",
+		},
+		{
+			Name:    "internal-chart",
+			Version: "2.0.0",
+			Library: "internal",
+		},
+	}, nil)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+
+	uiService.On("BrowsePackages", mock.Anything, []models.PackageListing{
+		{Name: "blocky", Chart: models.ChartEntry{Name: "blocky", Version: "1.0.0", Library: "bbe"}},
+		{Name: "internal/internal-chart", Chart: models.ChartEntry{Name: "internal-chart", Version: "2.0.0", Library: "internal"}},
+	}).Return([]string{
+		"internal/internal-chart",
+	}, nil)
+
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 1)
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{
+			Name:    "internal-chart",
+			Version: "2.0.0",
+			Library: "internal",
+		},
+	})
+}
+
+func Test_installCommand_Succeeds_RecordsLiveVersionOfAlreadyInstalledPackage(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{
+			Name:    "blocky",
+			Version: "1.0.0",
+		},
+	}, nil)
+	packageService.On("InstallPackage", mock.Anything).Return(&package_service.AlreadyInstalledError{Name: "blocky", Version: "0.9.0"})
+
+	uiService.On("BrowsePackages", mock.Anything, mock.Anything).Return([]string{"blocky"}, nil)
+
+	err := installCommand(helperService, uiService, configService, packageService, helmService, false)
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{
+			Name:    "blocky",
+			Version: "0.9.0",
+		},
+	})
+}
+
+func Test_installCommand_Succeeds_WithDryRun(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+	buffer := captureOutput(t, output.FormatJson)
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installCommand(helperService, uiService, configService, packageService, helmService, true)
+
+	assert.Nil(t, err)
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+	packageService.AssertNotCalled(t, "UninstallPackage", mock.Anything)
+	configService.AssertNotCalled(t, "UpdateBbePackages", mock.Anything, mock.Anything)
+
+	var plan []models.PackagePlanEntry
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Equal(t, []models.PackagePlanEntry{
+		{Name: "package_to_be_removed", Version: "2.0.0", Library: "bbe", Namespace: "package_to_be_removed", Action: "uninstall"},
+		{Name: "package_to_be_installed", Version: "3.0.0", Library: "bbe", Namespace: "package_to_be_installed", Action: "install"},
+	}, plan)
+}
+
+func Test_installPackagesCommand_Succeeds_OnlyInstallsNamedPackages(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_installed@3.1.0"}, "", "", true, false)
+
+	assert.Nil(t, err)
+	uiService.AssertNotCalled(t, "BrowsePackages", mock.Anything, mock.Anything)
+	packageService.AssertNotCalled(t, "UninstallPackage", mock.Anything)
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 1)
+	packageService.AssertCalled(t, "InstallPackage", models.ChartEntry{
+		Name:    "package_to_be_installed",
+		Version: "3.1.0",
+	})
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "package_always_installed", Version: "1.0.0"},
+		{Name: "package_to_be_removed", Version: "2.0.0"},
+		{Name: "package_to_be_installed", Version: "3.1.0"},
+	})
+}
+
+func Test_installPackagesCommand_Succeeds_AsksForConfirmation(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	uiService.On("CreateSelect", "Do you want to continue?", []string{"Yes", "No"}).Return("No", nil)
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_installed"}, "", "", false, false)
+
+	assert.Nil(t, err)
+	uiService.AssertNumberOfCalls(t, "CreateSelect", 1)
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+	configService.AssertNotCalled(t, "UpdateBbePackages", mock.Anything, mock.Anything)
+}
+
+func Test_installPackagesCommand_Succeeds_WithDryRun(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+	buffer := captureOutput(t, output.FormatJson)
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_installed", "package_always_installed"}, "", "", false, true)
+
+	assert.Nil(t, err)
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+
+	var plan []models.PackagePlanEntry
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Equal(t, []models.PackagePlanEntry{
+		{Name: "package_to_be_installed", Version: "3.0.0", Library: "bbe", Namespace: "package_to_be_installed", Action: "install"},
+	}, plan)
+}
+
+func Test_installPackagesCommand_Succeeds_WhenPackagesAreAlreadyInstalled(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_always_installed"}, "", "", false, false)
+
+	assert.Nil(t, err)
+	uiService.AssertNotCalled(t, "CreateSelect", mock.Anything, mock.Anything)
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+}
+
+func Test_installPackagesCommand_Succeeds_WithPackageFromAdditionalLibrary(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "blocky", Version: "1.0.0", Library: "bbe"},
+		{Name: "blocky", Version: "1.2.0", Library: "internal"},
+	}, nil)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"internal/blocky"}, "", "", true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertCalled(t, "InstallPackage", models.ChartEntry{Name: "blocky", Version: "1.2.0", Library: "internal"})
+}
+
+func Test_installPackagesCommand_Succeeds_PrefersOfficialLibraryForBareName(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "blocky", Version: "1.0.0", Library: "bbe"},
+		{Name: "blocky", Version: "1.2.0", Library: "internal"},
+	}, nil)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"blocky"}, "", "", true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 1)
+	packageService.AssertCalled(t, "InstallPackage", models.ChartEntry{Name: "blocky", Version: "1.0.0", Library: "bbe"})
+}
+
+func Test_installPackagesCommand_Succeeds_WithSecondRelease(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_always_installed"}, "package_always_installed-second", "second", true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 1)
+	packageService.AssertCalled(t, "InstallPackage", models.ChartEntry{
+		Name:      "package_always_installed",
+		Version:   "1.0.0",
+		Release:   "package_always_installed-second",
+		Namespace: "second",
+	})
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "package_always_installed", Version: "1.0.0"},
+		{Name: "package_to_be_removed", Version: "2.0.0"},
+		{Name: "package_always_installed-second", Chart: "package_always_installed", Namespace: "second", Version: "1.0.0"},
+	})
+}
+
+func Test_installPackagesCommand_Succeeds_WithDryRunOfSecondRelease(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+	buffer := captureOutput(t, output.FormatJson)
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_always_installed"}, "package_always_installed-second", "", false, true)
+
+	assert.Nil(t, err)
+
+	var plan []models.PackagePlanEntry
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Equal(t, []models.PackagePlanEntry{
+		{Name: "package_always_installed-second", Chart: "package_always_installed", Version: "1.0.0", Library: "bbe", Namespace: "package_always_installed-second", Action: "install"},
+	}, plan)
+}
+
+func Test_installPackagesCommand_Fails_WithReleaseForSeveralPackages(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_always_installed", "package_to_be_installed"}, "second", "", true, false)
+
+	var cliErr *clierror.Error
+	assert.ErrorAs(t, err, &cliErr)
+	assert.Equal(t, clierror.KindUsage, cliErr.Kind)
+	configService.AssertNotCalled(t, "GetBbeConfig", mock.Anything)
+}
+
+func Test_resolveLibraryPackages_Succeeds_PrefersFirstLibrary(t *testing.T) {
+	allPackages := []models.ChartEntry{
+		{Name: "postgres", Version: "1.0.0", Library: "team-a"},
+		{Name: "postgres", Version: "1.2.0", Library: "team-b"},
+	}
+
+	resolved, err := resolveLibraryPackages(allPackages, []string{"postgres"})
+	assert.NoError(t, err)
+	assert.Equal(t, "team-a", resolved[0].Library)
+
+	resolved, err = resolveLibraryPackages(allPackages, []string{"team-b/postgres"})
+	assert.NoError(t, err)
+	assert.Equal(t, "team-b", resolved[0].Library)
+}
+
+func Test_installPackagesCommand_Fails_WithUnknownPackage(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_installed", "unknown"}, "", "", true, false)
+
+	assert.ErrorContains(t, err, "Package `unknown` not found")
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+}
+
+func Test_installPackagesCommand_Fails_WithInvalidVersion(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_installed@latest"}, "", "", true, false)
+
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+}
+
+func Test_installPackagesCommand_Fails_WithNoCluster(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error"))
+
+	err := installPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"blocky"}, "", "", true, false)
+
+	assert.ErrorContains(t, err, "No BBE cluster found")
+	assert.Equal(t, clierror.ExitConfigInvalid, clierror.ExitCode(err))
+}
+
+func Test_uninstallPackagesCommand_Succeeds_OnlyUninstallsNamedPackages(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := uninstallPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_removed"}, true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "UninstallPackage", 1)
+	packageService.AssertCalled(t, "UninstallPackage", models.LocalPackage{Name: "package_to_be_removed", Version: "2.0.0"})
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "package_always_installed", Version: "1.0.0"},
+	})
+}
+
+func Test_uninstallPackagesCommand_Succeeds_WithDryRun(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+	buffer := captureOutput(t, output.FormatJson)
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := uninstallPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_removed"}, false, true)
+
+	assert.Nil(t, err)
+	packageService.AssertNotCalled(t, "UninstallPackage", mock.Anything)
+	configService.AssertNotCalled(t, "UpdateBbePackages", mock.Anything, mock.Anything)
+
+	var plan []models.PackagePlanEntry
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Equal(t, []models.PackagePlanEntry{
+		{Name: "package_to_be_removed", Version: "2.0.0", Library: "bbe", Namespace: "package_to_be_removed", Action: "uninstall"},
+	}, plan)
+}
+
+func Test_uninstallPackagesCommand_Fails_WhenPackageIsNotInstalled(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initInstallCommand()
+
+	mockSuccessfulInstallFlow(helperService, uiService, configService, packageService)
+
+	err := uninstallPackagesCommand(helperService, uiService, configService, packageService, helmService, []string{"package_to_be_ignored"}, true, false)
+
+	assert.ErrorContains(t, err, "Package `package_to_be_ignored` is not installed")
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	packageService.AssertNotCalled(t, "UninstallPackage", mock.Anything)
+}
+
+func Test_buildPackageListings_Succeeds_MarksInstalledPackages(t *testing.T) {
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Packages = []models.LocalPackage{{Name: "blocky", Version: "0.1.2"}}
+
+	listings := buildPackageListings([]models.ChartEntry{
+		{Name: "blocky", Version: "0.1.3", Category: "network"},
+		{Name: "postgres", Version: "16.1.0", Library: "internal"},
+	}, bbeConfig)
+
+	assert.Equal(t, []models.PackageListing{
+		{Name: "blocky", Chart: models.ChartEntry{Name: "blocky", Version: "0.1.3", Category: "network"}, Installed: "0.1.2"},
+		{Name: "internal/postgres", Chart: models.ChartEntry{Name: "postgres", Version: "16.1.0", Library: "internal"}},
+	}, listings)
+}
+
+func Test_diffPackages_Succeeds_WithReleasesOfTheSameChart(t *testing.T) {
+	allPackages := []models.ChartEntry{
+		{Name: "blocky", Version: "0.1.3"},
+		{Name: "postgres", Version: "16.1.0"},
+	}
+	installedPackages := []models.LocalPackage{
+		{Name: "postgres", Version: "16.0.0"},
+		{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Version: "16.0.0"},
+	}
+
+	packagesToInstall, packagesToUninstall := diffPackages(allPackages, []string{"blocky"}, installedPackages)
+
+	assert.Equal(t, []models.ChartEntry{{Name: "blocky", Version: "0.1.3"}}, packagesToInstall)
+	assert.Equal(t, []models.ChartEntry{
+		{Name: "postgres", Version: "16.1.0"},
+		{Name: "postgres", Version: "16.1.0", Release: "postgres-analytics", Namespace: "analytics"},
+	}, packagesToUninstall)
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/kubeconfig_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
+	"github.com/spf13/cobra"
+)
+
+var kubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "Regenerate the kubeconfig of the active cluster",
+	Long:  "Regenerate the kubeconfig of the active cluster with a new client certificate and record its context in bbe.yaml. The kubeconfig is kept in the config directory of the cluster, use --merge to also add it to ~/.kube/config.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		talosService := talos_service.TalosService{}
+		uiService := ui_service.UiService{}
+		kubeconfigService := kubeconfig_service.KubeconfigService{}
+
+		merge, _ := cmd.Flags().GetBool("merge")
+		target, _ := cmd.Flags().GetString("target")
+		strategy, _ := cmd.Flags().GetString("on-conflict")
+
+		if merge && target == "" {
+			defaultTarget, err := kubeconfig_service.DefaultKubeConfigPath()
+			if err != nil {
+				return err
+			}
+			target = defaultTarget
+		}
+
+		return kubeconfigCommand(helperService, configService, talosService, uiService, kubeconfigService, merge, target, strategy)
+	},
+}
+
+var kubeconfigPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the kubeconfig of the active cluster",
+	Long:  "Print the path of the kubeconfig of the active cluster, e.g. export KUBECONFIG=$(bbe kubeconfig path)",
+	Args:  cobra.ExactArgs(0),
+	Run: func(cmd *cobra.Command, args []string) {
+		helperService := helper_service.HelperService{}
+		kubeconfigService := kubeconfig_service.KubeconfigService{}
+
+		logger.Info(kubeconfigService.Path(helperService))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(kubeconfigCmd)
+	kubeconfigCmd.AddCommand(kubeconfigPathCmd)
+
+	kubeconfigCmd.Flags().Bool("merge", false, "Merge the kubeconfig into ~/.kube/config")
+	kubeconfigCmd.Flags().String("target", "", "Kubeconfig to merge into instead of ~/.kube/config")
+	kubeconfigCmd.Flags().String("on-conflict", "", fmt.Sprintf("What to do with existing entries of the same name, %s or %s, asks when empty", constants.KubeConfigMergeRename, constants.KubeConfigMergeOverwrite))
+	kubeconfigCmd.RegisterFlagCompletionFunc("on-conflict", cobra.FixedCompletions([]string{constants.KubeConfigMergeRename, constants.KubeConfigMergeOverwrite}, cobra.ShellCompDirectiveNoFileComp))
+}
+
+func kubeconfigCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, uiService interfaces.UiServiceInterface, kubeconfigService interfaces.KubeconfigServiceInterface, merge bool, target string, strategy string) error {
+	if strategy != "" && strategy != constants.KubeConfigMergeRename && strategy != constants.KubeConfigMergeOverwrite {
+		return fmt.Errorf("Unknown conflict strategy `%s`, expected %s or %s", strategy, constants.KubeConfigMergeRename, constants.KubeConfigMergeOverwrite)
+	}
+
+	if _, exists := helperService.CheckIfFileExists(helperService.GetConfigFilePath(constants.ControlplaneConfigFile)); !exists {
+		return errors.New("No control plane config found, please run 'bbe setup' or 'bbe config' first")
+	}
+
+	controlPlaneIp, err := talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
+	if err != nil {
+		return err
+	}
+
+	context, err := kubeconfigService.Generate(helperService, configService, talosService, controlPlaneIp)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Kubeconfig for context `%s` written to %s", context, kubeconfigService.Path(helperService))
+
+	server, err := kubeconfigService.VerifyEndpoint(helperService)
+	if err != nil {
+		logger.Warning(err.Error())
+	} else {
+		logger.Infof("The API server %s is reachable", server)
+	}
+
+	if !merge {
+		return nil
+	}
+
+	merged, err := kubeconfigService.Merge(helperService, target, strategy)
+	if errors.Is(err, constants.KubeConfigConflictError) && strategy == "" {
+		logger.Warning(err.Error())
+
+		answer, selectErr := uiService.CreateSelect(fmt.Sprintf("How should the existing entries in %s be handled?", target), []string{"Rename the new entries", "Overwrite the existing entries", "Cancel"})
+		if selectErr != nil {
+			return selectErr
+		}
+
+		switch answer {
+		case "Rename the new entries":
+			strategy = constants.KubeConfigMergeRename
+		case "Overwrite the existing entries":
+			strategy = constants.KubeConfigMergeOverwrite
+		default:
+			return nil
+		}
+
+		merged, err = kubeconfigService.Merge(helperService, target, strategy)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to merge the kubeconfig into %s: %w", target, err)
+	}
+
+	logger.Infof("Merged into %s, use it with 'kubectl --context %s'", target, merged)
+	return nil
+}
+
+// useClusterKubeConfig puts the kubeconfig of the active cluster in front of KUBECONFIG, so helm and kubectl started by bbe find its context
+func useClusterKubeConfig(helperService interfaces.HelperServiceInterface) error {
+	path := helperService.GetConfigFilePath(constants.KubeConfigFile)
+	if _, exists := helperService.CheckIfFileExists(path); !exists {
+		return nil
+	}
+
+	paths := os.Getenv(constants.KubeConfigEnvVar)
+	if paths == "" {
+		defaultPath, err := kubeconfig_service.DefaultKubeConfigPath()
+		if err != nil {
+			return err
+		}
+		paths = defaultPath
+	}
+
+	if strings.HasPrefix(paths, path+string(filepath.ListSeparator)) || paths == path {
+		return nil
+	}
+
+	return os.Setenv(constants.KubeConfigEnvVar, path+string(filepath.ListSeparator)+paths)
+}
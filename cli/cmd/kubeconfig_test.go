@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initKubeconfigCommandTest() (*mocks.MockHelperService, *mocks.MockConfigService, *mocks.MockTalosService, *mocks.MockUiService, *mocks.MockKubeconfigService) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	talosService := &mocks.MockTalosService{}
+	uiService := &mocks.MockUiService{}
+	kubeconfigService := &mocks.MockKubeconfigService{}
+
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, true)
+	talosService.On("GetControlPlaneIp", helperService, constants.ControlplaneConfigFile).Return("192.168.1.10", nil)
+	kubeconfigService.On("Generate", helperService, configService, talosService, "192.168.1.10").Return("admin@home", nil)
+	kubeconfigService.On("Path", helperService).Return("kubeconfig")
+	kubeconfigService.On("VerifyEndpoint", helperService).Return("https://192.168.1.10:6443", nil)
+
+	return helperService, configService, talosService, uiService, kubeconfigService
+}
+
+func Test_kubeconfigCommand_Succeeds_WithoutMerging(t *testing.T) {
+	helperService, configService, talosService, uiService, kubeconfigService := initKubeconfigCommandTest()
+
+	err := kubeconfigCommand(helperService, configService, talosService, uiService, kubeconfigService, false, "", "")
+
+	assert.Nil(t, err)
+	kubeconfigService.AssertCalled(t, "Generate", helperService, configService, talosService, "192.168.1.10")
+	kubeconfigService.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_kubeconfigCommand_Succeeds_RenamesAfterConflict(t *testing.T) {
+	helperService, configService, talosService, uiService, kubeconfigService := initKubeconfigCommandTest()
+	kubeconfigService.On("Merge", helperService, "config", "").Return("", constants.KubeConfigConflictError)
+	kubeconfigService.On("Merge", helperService, "config", constants.KubeConfigMergeRename).Return("admin@home-2", nil)
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("Rename the new entries", nil)
+
+	err := kubeconfigCommand(helperService, configService, talosService, uiService, kubeconfigService, true, "config", "")
+
+	assert.Nil(t, err)
+	kubeconfigService.AssertCalled(t, "Merge", helperService, "config", constants.KubeConfigMergeRename)
+}
+
+func Test_kubeconfigCommand_Succeeds_WithoutMergingWhenCancelled(t *testing.T) {
+	helperService, configService, talosService, uiService, kubeconfigService := initKubeconfigCommandTest()
+	kubeconfigService.On("Merge", helperService, "config", "").Return("", constants.KubeConfigConflictError)
+	uiService.On("CreateSelect", mock.Anything, mock.Anything).Return("Cancel", nil)
+
+	err := kubeconfigCommand(helperService, configService, talosService, uiService, kubeconfigService, true, "config", "")
+
+	assert.Nil(t, err)
+	kubeconfigService.AssertNumberOfCalls(t, "Merge", 1)
+}
+
+func Test_kubeconfigCommand_Fails_WhenMergeFails(t *testing.T) {
+	helperService, configService, talosService, uiService, kubeconfigService := initKubeconfigCommandTest()
+	kubeconfigService.On("Merge", helperService, "config", constants.KubeConfigMergeOverwrite).Return("", errors.New("permission denied"))
+
+	err := kubeconfigCommand(helperService, configService, talosService, uiService, kubeconfigService, true, "config", constants.KubeConfigMergeOverwrite)
+
+	assert.ErrorContains(t, err, "permission denied")
+	uiService.AssertNotCalled(t, "CreateSelect", mock.Anything, mock.Anything)
+}
+
+func Test_kubeconfigCommand_Fails_WithUnknownStrategy(t *testing.T) {
+	helperService, configService, talosService, uiService, kubeconfigService := initKubeconfigCommandTest()
+
+	err := kubeconfigCommand(helperService, configService, talosService, uiService, kubeconfigService, true, "config", "replace")
+
+	assert.ErrorContains(t, err, "Unknown conflict strategy")
+	kubeconfigService.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_kubeconfigCommand_Fails_WithoutControlPlaneConfig(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, false)
+
+	err := kubeconfigCommand(helperService, &mocks.MockConfigService{}, &mocks.MockTalosService{}, &mocks.MockUiService{}, &mocks.MockKubeconfigService{}, false, "", "")
+
+	assert.ErrorContains(t, err, "No control plane config found")
+}
+
+func Test_useClusterKubeConfig_Succeeds_PrependsClusterKubeconfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	t.Setenv(constants.KubeConfigEnvVar, "/home/user/.kube/config")
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.KubeConfigFile).Return(path)
+	helperService.On("CheckIfFileExists", path).Return(nil, true)
+
+	err := useClusterKubeConfig(helperService)
+	assert.Nil(t, err)
+	err = useClusterKubeConfig(helperService)
+	assert.Nil(t, err)
+
+	assert.Equal(t, path+string(filepath.ListSeparator)+"/home/user/.kube/config", os.Getenv(constants.KubeConfigEnvVar))
+}
+
+func Test_useClusterKubeConfig_Succeeds_WithoutClusterKubeconfig(t *testing.T) {
+	t.Setenv(constants.KubeConfigEnvVar, "")
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.KubeConfigFile).Return("kubeconfig")
+	helperService.On("CheckIfFileExists", "kubeconfig").Return(nil, false)
+
+	err := useClusterKubeConfig(helperService)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", os.Getenv(constants.KubeConfigEnvVar))
+}
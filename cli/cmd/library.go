@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/signing"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/spf13/cobra"
+)
+
+var libraryNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+var libraryCmd = &cobra.Command{
+	Use:     "library",
+	Aliases: []string{"l"},
+	Short:   "Manage additional BBE package libraries",
+	Long:    "Manage additional BBE package libraries. Every library keeps its own packages, name one as <library>/<package>. A bare package name resolves to the official library first, a package offered by more than one additional library has to be named with its library.",
+}
+
+var libraryAddCmd = &cobra.Command{
+	Use:   "add <name> <source>",
+	Short: "Register an additional package library by URL, local file or oci:// reference",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		publicKey, _ := cmd.Flags().GetString("public-key")
+		allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+
+		return libraryAddCommand(helperService, configService, args[0], args[1], publicKey, allowUnsigned)
+	},
+}
+
+var libraryRemoveCmd = &cobra.Command{
+	Use:     "remove <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove an additional package library",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return libraryRemoveCommand(helperService, configService, args[0])
+	},
+}
+
+var libraryListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List package libraries in order of precedence",
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return libraryListCommand(helperService, configService)
+	},
+}
+
+var libraryKeygenCmd = &cobra.Command{
+	Use:   "keygen <private-key-file>",
+	Short: "Generate a key pair for signing a package library",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return libraryKeygenCommand(args[0])
+	},
+}
+
+var librarySignCmd = &cobra.Command{
+	Use:   "sign <library-file> <private-key-file>",
+	Short: "Write a detached signature next to a package library file",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return librarySignCommand(args[0], args[1])
+	},
+}
+
+func libraryAddCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, name string, source string, publicKey string, allowUnsigned bool) error {
+	if !libraryNamePattern.MatchString(name) {
+		return fmt.Errorf("Invalid library name `%s`, use lowercase letters, digits and dashes", name)
+	}
+
+	if name == constants.DefaultLibraryName {
+		return fmt.Errorf("The library name `%s` is reserved for the official library", name)
+	}
+
+	if publicKey == "" && !allowUnsigned {
+		return clierror.New(clierror.KindUsage, fmt.Errorf("Library %s needs a public key to verify its signature", name), "Pass --public-key, or --allow-unsigned to add it without verification")
+	}
+
+	if publicKey != "" {
+		if err := signing.ValidatePublicKey(publicKey); err != nil {
+			return err
+		}
+	}
+
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return fmt.Errorf("No BBE configuration found, please run 'bbe config' first: %w", err)
+	}
+
+	for _, library := range bbeConfig.Bbe.Libraries {
+		if library.Name == name {
+			return fmt.Errorf("A library named `%s` already exists", name)
+		}
+	}
+
+	libraries := append(bbeConfig.Bbe.Libraries, models.LibrarySource{Name: name, Source: source, PublicKey: publicKey, AllowUnsigned: allowUnsigned})
+	err = configService.UpdateBbeLibraries(helperService, libraries)
+	if err != nil {
+		return fmt.Errorf("Failed to update BBE configuration: %w", err)
+	}
+
+	if publicKey == "" {
+		logger.Warning(fmt.Sprintf("Library %s has no public key, its contents will not be verified", name))
+	}
+
+	logger.Infof("Library %s added", name)
+	return nil
+}
+
+func libraryRemoveCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, name string) error {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return fmt.Errorf("No BBE configuration found, please run 'bbe config' first: %w", err)
+	}
+
+	for i, library := range bbeConfig.Bbe.Libraries {
+		if library.Name != name {
+			continue
+		}
+
+		for _, pkg := range bbeConfig.Bbe.Packages {
+			if pkg.Library == name {
+				logger.Warning(fmt.Sprintf("Package %s was installed from library %s and will no longer receive upgrades", pkg.Name, name))
+			}
+		}
+
+		libraries := slices.Delete(bbeConfig.Bbe.Libraries, i, i+1)
+		err = configService.UpdateBbeLibraries(helperService, libraries)
+		if err != nil {
+			return fmt.Errorf("Failed to update BBE configuration: %w", err)
+		}
+
+		logger.Infof("Library %s removed", name)
+		return nil
+	}
+
+	return fmt.Errorf("No library named `%s` found", name)
+}
+
+func libraryListCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) error {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		bbeConfig = &models.BbeConfig{}
+	}
+
+	officialSource := bbeConfig.Bbe.Library.Source
+	if officialSource == "" {
+		officialSource = constants.BbeLibraryUrl
+	}
+
+	logger.Infof("1. %s: %s", constants.DefaultLibraryName, officialSource)
+	for i, library := range bbeConfig.Bbe.Libraries {
+		logger.Infof("%d. %s: %s", i+2, library.Name, library.Source)
+	}
+
+	return nil
+}
+
+func libraryKeygenCommand(privateKeyFile string) error {
+	if _, err := os.Stat(privateKeyFile); err == nil {
+		return fmt.Errorf("The file `%s` already exists", privateKeyFile)
+	}
+
+	publicKey, privateKey, err := signing.GenerateKey()
+	if err != nil {
+		return fmt.Errorf("Failed to generate key pair: %w", err)
+	}
+
+	err = os.WriteFile(privateKeyFile, []byte(privateKey), 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to write private key: %w", err)
+	}
+
+	logger.Infof("Private key written to %s, keep it secret", privateKeyFile)
+	logger.Infof("Public key: %s", publicKey)
+	return nil
+}
+
+func librarySignCommand(libraryFile string, privateKeyFile string) error {
+	content, err := os.ReadFile(libraryFile)
+	if err != nil {
+		return fmt.Errorf("Failed to read library: %w", err)
+	}
+
+	privateKey, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return fmt.Errorf("Failed to read private key: %w", err)
+	}
+
+	signature, err := signing.Sign(content, string(privateKey))
+	if err != nil {
+		return err
+	}
+
+	signatureFile := libraryFile + constants.LibrarySignatureExtension
+	err = os.WriteFile(signatureFile, signature, 0644)
+	if err != nil {
+		return fmt.Errorf("Failed to write signature: %w", err)
+	}
+
+	logger.Infof("Signature written to %s", signatureFile)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(libraryCmd)
+	libraryCmd.AddCommand(libraryAddCmd)
+	libraryCmd.AddCommand(libraryRemoveCmd)
+	libraryCmd.AddCommand(libraryListCmd)
+	libraryCmd.AddCommand(libraryKeygenCmd)
+	libraryCmd.AddCommand(librarySignCmd)
+
+	libraryAddCmd.Flags().String("public-key", "", "Base64 encoded ed25519 key the library must be signed with")
+	libraryAddCmd.Flags().Bool("allow-unsigned", false, "Add the library without verifying its signature")
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/signing"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_libraryAddCommand_Succeeds(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Libraries = []models.LibrarySource{{Name: "first", Source: "https://example.com/first.yaml"}}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbeLibraries", mock.Anything, mock.Anything).Return(nil)
+
+	err := libraryAddCommand(helperService, configService, "internal", "https://example.com/library.yaml", "", true)
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbeLibraries", mock.Anything, []models.LibrarySource{
+		{Name: "first", Source: "https://example.com/first.yaml"},
+		{Name: "internal", Source: "https://example.com/library.yaml", AllowUnsigned: true},
+	})
+}
+
+func Test_libraryAddCommand_Fails_WithoutPublicKey(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	err := libraryAddCommand(helperService, configService, "internal", "https://example.com/library.yaml", "", false)
+
+	assert.ErrorContains(t, err, "needs a public key")
+	configService.AssertNotCalled(t, "UpdateBbeLibraries", mock.Anything, mock.Anything)
+}
+
+func Test_libraryAddCommand_Fails_WithReservedName(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	err := libraryAddCommand(helperService, configService, "bbe", "https://example.com/library.yaml", "", false)
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "UpdateBbeLibraries", 0)
+}
+
+func Test_libraryAddCommand_Fails_WithInvalidName(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	err := libraryAddCommand(helperService, configService, "Not Valid/", "https://example.com/library.yaml", "", false)
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "GetBbeConfig", 0)
+}
+
+func Test_libraryAddCommand_Fails_WithDuplicateName(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Libraries = []models.LibrarySource{{Name: "internal", Source: "https://example.com/library.yaml"}}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+
+	err := libraryAddCommand(helperService, configService, "internal", "https://example.com/other.yaml", "", false)
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "UpdateBbeLibraries", 0)
+}
+
+func Test_libraryRemoveCommand_Succeeds(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Libraries = []models.LibrarySource{
+		{Name: "first", Source: "https://example.com/first.yaml"},
+		{Name: "second", Source: "https://example.com/second.yaml"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbeLibraries", mock.Anything, mock.Anything).Return(nil)
+
+	err := libraryRemoveCommand(helperService, configService, "first")
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbeLibraries", mock.Anything, []models.LibrarySource{
+		{Name: "second", Source: "https://example.com/second.yaml"},
+	})
+}
+
+func Test_libraryRemoveCommand_Fails_WhenLibraryNotFound(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, nil)
+
+	err := libraryRemoveCommand(helperService, configService, "missing")
+
+	assert.NotNil(t, err)
+}
+
+func Test_libraryListCommand_Succeeds_WithoutBbeConfig(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error"))
+
+	err := libraryListCommand(helperService, configService)
+
+	assert.Nil(t, err)
+}
+
+func Test_libraryAddCommand_Succeeds_WithPublicKey(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	publicKey, _, err := signing.GenerateKey()
+	assert.NoError(t, err)
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, nil)
+	configService.On("UpdateBbeLibraries", mock.Anything, mock.Anything).Return(nil)
+
+	err = libraryAddCommand(helperService, configService, "internal", "https://example.com/library.yaml", publicKey, false)
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbeLibraries", mock.Anything, []models.LibrarySource{
+		{Name: "internal", Source: "https://example.com/library.yaml", PublicKey: publicKey},
+	})
+}
+
+func Test_libraryAddCommand_Fails_WithInvalidPublicKey(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+
+	err := libraryAddCommand(helperService, configService, "internal", "https://example.com/library.yaml", "not-a-key", false)
+
+	assert.NotNil(t, err)
+	configService.AssertNumberOfCalls(t, "GetBbeConfig", 0)
+}
+
+func Test_librarySignCommand_Succeeds(t *testing.T) {
+	dir := t.TempDir()
+	libraryFile := filepath.Join(dir, "library.yaml")
+	privateKeyFile := filepath.Join(dir, "library.key")
+
+	err := os.WriteFile(libraryFile, []byte("library: []"), 0644)
+	assert.NoError(t, err)
+
+	err = libraryKeygenCommand(privateKeyFile)
+	assert.NoError(t, err)
+
+	err = librarySignCommand(libraryFile, privateKeyFile)
+	assert.NoError(t, err)
+
+	signature, err := os.ReadFile(libraryFile + constants.LibrarySignatureExtension)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, signature)
+}
+
+func Test_libraryKeygenCommand_Fails_WhenFileExists(t *testing.T) {
+	privateKeyFile := filepath.Join(t.TempDir(), "library.key")
+	err := os.WriteFile(privateKeyFile, []byte("existing"), 0600)
+	assert.NoError(t, err)
+
+	err = libraryKeygenCommand(privateKeyFile)
+
+	assert.NotNil(t, err)
+}
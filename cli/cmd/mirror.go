@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helm_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/package_service"
+	"github.com/spf13/cobra"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror <directory>",
+	Short: "Download the BBE library and all of its charts for offline use",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		packageService := package_service.PackageService{}
+		helmService := helm_service.HelmService{}
+
+		return mirrorCommand(helperService, configService, packageService, helmService, args[0])
+	},
+}
+
+func mirrorCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, directory string) error {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		logger.Debug("No BBE configuration found, mirroring the default library")
+		bbeConfig = &models.BbeConfig{}
+	}
+
+	absoluteDirectory, err := filepath.Abs(directory)
+	if err != nil {
+		return err
+	}
+
+	err = packageService.MirrorLibrary(helperService, *bbeConfig, helmService, absoluteDirectory)
+	if err != nil {
+		return fmt.Errorf("Failed to mirror library: %w", err)
+	}
+
+	logger.Infof("Library mirrored to %s", absoluteDirectory)
+	logger.Infof("To install without network access, update %s to use the mirrored libraries:", constants.BbeConfigFile)
+	logger.Infof("  bbe.library.source: %s", filepath.Join(absoluteDirectory, package_service.MirroredLibraryFile(constants.DefaultLibraryName)))
+	for _, library := range bbeConfig.Bbe.Libraries {
+		logger.Infof("  bbe.libraries[%s].source: %s", library.Name, filepath.Join(absoluteDirectory, package_service.MirroredLibraryFile(library.Name)))
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_mirrorCommand_Succeeds_WithoutBbeConfig(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	packageService := &mocks.MockPackageService{}
+	helmService := &mocks.MockHelmService{}
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error"))
+	packageService.On("MirrorLibrary", "/tmp/mirror").Return(nil)
+
+	err := mirrorCommand(helperService, configService, packageService, helmService, "/tmp/mirror")
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "MirrorLibrary", 1)
+}
+
+func Test_mirrorCommand_Fails_WhenMirroringFails(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	packageService := &mocks.MockPackageService{}
+	helmService := &mocks.MockHelmService{}
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, nil)
+	packageService.On("MirrorLibrary", mock.Anything).Return(errors.New("test error"))
+
+	err := mirrorCommand(helperService, configService, packageService, helmService, "/tmp/mirror")
+
+	assert.NotNil(t, err)
+}
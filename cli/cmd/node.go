@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/spf13/cobra"
+)
+
+var nodeCmd = &cobra.Command{
+	Use:   "node",
+	Short: "Inspect the nodes of the cluster",
+}
+
+var nodeListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the nodes of the cluster",
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		talosService := talos_service.TalosService{}
+
+		return nodeListCommand(helperService, talosService)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nodeCmd)
+	nodeCmd.AddCommand(nodeListCmd)
+}
+
+func nodeListCommand(helperService interfaces.HelperServiceInterface, talosService interfaces.TalosServiceInterface) error {
+	if _, exists := helperService.CheckIfFileExists(helperService.GetConfigFilePath(constants.ControlplaneConfigFile)); !exists {
+		return errors.New("No control plane config found, please run 'bbe setup' or 'bbe config' first")
+	}
+
+	controlPlaneIp, err := talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
+	if err != nil {
+		return err
+	}
+
+	members, err := talosService.GetMembers(helperService, controlPlaneIp)
+	if err != nil {
+		return fmt.Errorf("Failed to list the nodes of the cluster: %w", err)
+	}
+
+	return output.Print(members, func() string {
+		return formatNodeList(members)
+	})
+}
+
+func formatNodeList(members []models.ClusterMember) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "NODE\tROLE\tADDRESSES\tOS")
+	for _, member := range members {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", member.Hostname, member.MachineType, strings.Join(member.Addresses, ","), member.OperatingSystem)
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
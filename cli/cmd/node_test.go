@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func initNodeListCommandTest() (*mocks.MockHelperService, *mocks.MockTalosService) {
+	helperService := &mocks.MockHelperService{}
+	talosService := &mocks.MockTalosService{}
+
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, true)
+	talosService.On("GetControlPlaneIp", helperService, constants.ControlplaneConfigFile).Return("192.168.1.10", nil)
+
+	return helperService, talosService
+}
+
+func Test_nodeListCommand_Succeeds_WithYamlOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatYaml)
+	helperService, talosService := initNodeListCommandTest()
+	talosService.On("GetMembers", helperService, "192.168.1.10").Return([]models.ClusterMember{
+		{Hostname: "cp-1", MachineType: "controlplane", Addresses: []string{"192.168.1.10"}, OperatingSystem: "Talos (v1.9.4)"},
+	}, nil)
+
+	err := nodeListCommand(helperService, talosService)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "- hostname: cp-1\n  machine_type: controlplane\n  addresses:\n    - 192.168.1.10\n  operating_system: Talos (v1.9.4)\n", buffer.String())
+}
+
+func Test_nodeListCommand_Succeeds_WithTextOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatText)
+	helperService, talosService := initNodeListCommandTest()
+	talosService.On("GetMembers", helperService, "192.168.1.10").Return([]models.ClusterMember{
+		{Hostname: "cp-1", MachineType: "controlplane", Addresses: []string{"192.168.1.10", "fd00::10"}, OperatingSystem: "Talos (v1.9.4)"},
+	}, nil)
+
+	err := nodeListCommand(helperService, talosService)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), "NODE")
+	assert.Contains(t, buffer.String(), "192.168.1.10,fd00::10")
+}
+
+func Test_nodeListCommand_Fails_WhenTalosFails(t *testing.T) {
+	helperService, talosService := initNodeListCommandTest()
+	talosService.On("GetMembers", helperService, "192.168.1.10").Return([]models.ClusterMember{}, errors.New("connection refused"))
+
+	err := nodeListCommand(helperService, talosService)
+
+	assert.ErrorContains(t, err, "Failed to list the nodes of the cluster")
+}
+
+func Test_nodeListCommand_Fails_WithoutControlPlaneConfig(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, false)
+
+	err := nodeListCommand(helperService, &mocks.MockTalosService{})
+
+	assert.ErrorContains(t, err, "No control plane config found")
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helm_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/package_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
+	"github.com/spf13/cobra"
+)
+
+const (
+	syncModeReport = "report"
+	syncModeAdopt  = "adopt"
+	syncModeApply  = "apply"
+)
+
+var packageCmd = &cobra.Command{
+	Use:     "package",
+	Aliases: []string{"p"},
+	Short:   "Manage installed BBE packages",
+}
+
+var packageSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Detect drift between bbe.yaml and the Helm releases in the cluster",
+	Long:  "Detect drift between bbe.yaml and the Helm releases in the cluster. Use --adopt to record the live releases in bbe.yaml, or --apply to change the cluster to match bbe.yaml. --apply asks before it uninstalls releases missing from bbe.yaml.",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		uiService := ui_service.UiService{}
+		configService := config_service.ConfigService{}
+		packageService := package_service.PackageService{}
+		helmService := helm_service.HelmService{}
+
+		adopt, _ := cmd.Flags().GetBool("adopt")
+		apply, _ := cmd.Flags().GetBool("apply")
+		uninteractive, _ := cmd.Flags().GetBool("yes")
+
+		mode := syncModeReport
+		if adopt {
+			mode = syncModeAdopt
+		}
+		if apply {
+			mode = syncModeApply
+		}
+
+		return packageSyncCommand(helperService, uiService, configService, packageService, helmService, mode, uninteractive)
+	},
+}
+
+var packageListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the packages installed in the cluster",
+	Args:    cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		packageService := package_service.PackageService{}
+
+		return packageListCommand(helperService, configService, packageService)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packageCmd)
+	packageCmd.AddCommand(packageListCmd)
+	packageCmd.AddCommand(packageSyncCmd)
+
+	packageSyncCmd.Flags().Bool("adopt", false, "Record the live releases in bbe.yaml")
+	packageSyncCmd.Flags().Bool("apply", false, "Install, upgrade or uninstall releases to match bbe.yaml")
+	packageSyncCmd.Flags().BoolP("yes", "y", false, "Automatically accept yes/no questions without input.")
+	packageSyncCmd.MarkFlagsMutuallyExclusive("adopt", "apply")
+}
+
+func packageListCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	allPackages, err := packageService.GetAll(helperService, *bbeConfig)
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Unable to load the package library, the latest versions are not shown: %v", err))
+		allPackages = []models.ChartEntry{}
+	}
+
+	results := []models.PackageResult{}
+	for _, pkg := range bbeConfig.Bbe.Packages {
+		result := models.PackageResult{
+			Name:    pkg.Name,
+			Version: pkg.Version,
+			Policy:  pkg.Policy,
+			Library: pkg.Library,
+		}
+		if result.Policy == "" {
+			result.Policy = versioning.PolicyMajor
+		}
+		if result.Library == "" {
+			result.Library = constants.DefaultLibraryName
+		}
+
+		for _, chart := range allPackages {
+			if package_service.IsPackageChart(chart, pkg) {
+				result.Latest = chart.Version
+				break
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return output.Print(results, func() string {
+		if len(results) == 0 {
+			return "No packages installed, run 'bbe install' to install one"
+		}
+
+		return formatPackageList(results)
+	})
+}
+
+func formatPackageList(packages []models.PackageResult) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "PACKAGE\tVERSION\tLATEST\tPOLICY\tLIBRARY")
+	for _, pkg := range packages {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", pkg.Name, pkg.Version, valueOrDash(pkg.Latest), pkg.Policy, pkg.Library)
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func packageSyncCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, mode string, uninteractive bool) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	allPackages, err := packageService.GetAll(helperService, *bbeConfig)
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Unable to load the package library, unmanaged releases will not be detected: %v", err))
+		allPackages = []models.ChartEntry{}
+	}
+
+	drifts, err := packageService.DetectDrift(*bbeConfig, allPackages, helmService)
+	if err != nil {
+		return fmt.Errorf("Failed to detect drift: %w", err)
+	}
+
+	if len(drifts) == 0 {
+		logger.Info("bbe.yaml matches the cluster, no drift detected")
+		return nil
+	}
+
+	logger.Infof("Drift detected:\n%s", formatDriftSummary(drifts))
+
+	switch mode {
+	case syncModeAdopt:
+		return adoptDrift(helperService, configService, *bbeConfig, drifts)
+	case syncModeApply:
+		// Unmanaged releases may have been installed by hand, they are only uninstalled once confirmed
+		if plan := unmanagedPlan(drifts); len(plan) > 0 {
+			confirmed, err := confirmPackagePlan(uiService, plan, uninteractive)
+			if err != nil || !confirmed {
+				return err
+			}
+		}
+
+		return applyDrift(packageService, helmService, *bbeConfig, allPackages, drifts)
+	default:
+		logger.Info("Run 'bbe package sync --adopt' to record the cluster in bbe.yaml, or 'bbe package sync --apply' to apply bbe.yaml to the cluster")
+		return nil
+	}
+}
+
+func formatDriftSummary(drifts []models.PackageDrift) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "PACKAGE\tNAMESPACE\tDRIFT\tBBE.YAML\tCLUSTER\tSTATUS")
+	for _, drift := range drifts {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", drift.Name, valueOrDash(drift.Namespace), drift.Kind, valueOrDash(drift.ConfigVersion), valueOrDash(drift.ClusterVersion), valueOrDash(drift.Status))
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// adoptDrift rewrites the package list in bbe.yaml to match the releases in the cluster
+func adoptDrift(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, bbeConfig models.BbeConfig, drifts []models.PackageDrift) error {
+	packages := []models.LocalPackage{}
+
+	for _, pkg := range bbeConfig.Bbe.Packages {
+		drift, found := findDrift(drifts, pkg.Name)
+		if found && drift.Kind == constants.DriftMissing {
+			logger.Infof("Removing %s from bbe.yaml", pkg.Name)
+			continue
+		}
+
+		if found && drift.Kind == constants.DriftVersion && drift.ClusterVersion == "" {
+			logger.Warning(fmt.Sprintf("Keeping %s version %s in bbe.yaml, the version of its release is unknown", pkg.Name, pkg.Version))
+		} else if found && drift.Kind == constants.DriftVersion {
+			logger.Infof("Recording %s version %s in bbe.yaml", pkg.Name, drift.ClusterVersion)
+			pkg.Version = drift.ClusterVersion
+		}
+
+		packages = append(packages, pkg)
+	}
+
+	for _, drift := range drifts {
+		if drift.Kind != constants.DriftUnmanaged {
+			continue
+		}
+		if drift.ClusterVersion == "" {
+			logger.Warning(fmt.Sprintf("Not adding %s to bbe.yaml, the version of its release is unknown", drift.Name))
+			continue
+		}
+
+		logger.Infof("Adding %s version %s to bbe.yaml", drift.Name, drift.ClusterVersion)
+		pkg := driftPackage(drift)
+		pkg.Version = drift.ClusterVersion
+		pkg.Library = drift.Library
+		packages = append(packages, pkg)
+	}
+
+	err := configService.UpdateBbePackages(helperService, packages)
+	if err != nil {
+		return fmt.Errorf("Failed to update BBE configuration: %w", err)
+	}
+
+	logger.Info("bbe.yaml updated to match the cluster")
+	return nil
+}
+
+// applyDrift installs, upgrades or uninstalls releases so the cluster matches bbe.yaml
+func applyDrift(packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, bbeConfig models.BbeConfig, allPackages []models.ChartEntry, drifts []models.PackageDrift) error {
+	failed := 0
+
+	for _, drift := range drifts {
+		var err error
+
+		switch drift.Kind {
+		case constants.DriftUnmanaged:
+			logger.Infof("Uninstalling %s", drift.Name)
+			pkg := driftPackage(drift)
+			pkg.Version = drift.ClusterVersion
+			err = packageService.UninstallPackage(pkg, bbeConfig, helmService)
+		case constants.DriftMissing, constants.DriftVersion, constants.DriftStatus:
+			pkg := driftPackage(drift)
+			pkg.Library = drift.Library
+			chart, found := findChartForVersion(allPackages, pkg, drift.ConfigVersion)
+			if !found {
+				err = fmt.Errorf("Package `%s` is not available in any library", drift.Name)
+				break
+			}
+			chart = package_service.ChartForPackage(chart, pkg)
+
+			if drift.Kind == constants.DriftMissing {
+				logger.Infof("Installing %s version %s", drift.Name, drift.ConfigVersion)
+				err = packageService.InstallPackage(chart, bbeConfig, helmService)
+			} else {
+				logger.Infof("Applying %s version %s", drift.Name, drift.ConfigVersion)
+				err = packageService.UpgradePackage(chart, bbeConfig, helmService)
+			}
+		}
+
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to reconcile package %s", drift.Name), err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("Failed to reconcile %d of %d packages", failed, len(drifts))
+	}
+
+	logger.Info("Cluster updated to match bbe.yaml")
+	return nil
+}
+
+// unmanagedPlan describes the releases missing from bbe.yaml that applying the drift uninstalls
+func unmanagedPlan(drifts []models.PackageDrift) []models.PackagePlanEntry {
+	plan := []models.PackagePlanEntry{}
+	for _, drift := range drifts {
+		if drift.Kind != constants.DriftUnmanaged {
+			continue
+		}
+
+		library := drift.Library
+		if library == "" {
+			library = constants.DefaultLibraryName
+		}
+
+		plan = append(plan, models.PackagePlanEntry{
+			Name:      drift.Name,
+			Chart:     drift.Chart,
+			Version:   drift.ClusterVersion,
+			Library:   library,
+			Namespace: drift.Namespace,
+			Action:    "uninstall",
+		})
+	}
+
+	return plan
+}
+
+// driftPackage describes the release of a drift the way it is recorded in bbe.yaml
+func driftPackage(drift models.PackageDrift) models.LocalPackage {
+	pkg := models.LocalPackage{Name: drift.Name, Chart: drift.Chart}
+	if drift.Namespace != drift.Name {
+		pkg.Namespace = drift.Namespace
+	}
+
+	return pkg
+}
+
+func findDrift(drifts []models.PackageDrift, name string) (models.PackageDrift, bool) {
+	for _, drift := range drifts {
+		if drift.Name == name {
+			return drift, true
+		}
+	}
+
+	return models.PackageDrift{}, false
+}
+
+// findChartForVersion returns the library chart pinned to the version recorded in bbe.yaml
+func findChartForVersion(allPackages []models.ChartEntry, pkg models.LocalPackage, version string) (models.ChartEntry, bool) {
+	for _, chart := range allPackages {
+		if !package_service.IsPackageChart(chart, pkg) {
+			continue
+		}
+
+		if chart.Version != version {
+			// A mirrored archive only exists for the version listed in the library
+			chart.LocalChart = ""
+			chart.Version = version
+		}
+
+		return chart, true
+	}
+
+	return models.ChartEntry{}, false
+}
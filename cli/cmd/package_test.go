@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_packageSyncCommand_Succeeds_ReportsWithoutChanges(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+	mockDriftedCluster(configService, packageService)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeReport, false)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 0)
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 0)
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 0)
+	packageService.AssertNumberOfCalls(t, "UninstallPackage", 0)
+}
+
+func Test_packageSyncCommand_Succeeds_WithNoDrift(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{}, nil)
+	packageService.On("DetectDrift", mock.Anything, mock.Anything).Return([]models.PackageDrift{}, nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeApply, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 0)
+}
+
+func Test_packageSyncCommand_Succeeds_AdoptsClusterState(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+	mockDriftedCluster(configService, packageService)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeAdopt, false)
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "in-sync", Version: "1.0.0"},
+		{Name: "upgraded", Version: "2.1.0", Policy: "minor"},
+		{Name: "unmanaged", Version: "4.0.0", Library: "bbe"},
+	})
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 0)
+}
+
+func Test_packageSyncCommand_Succeeds_AdoptsWithoutUnknownVersions(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "cert-manager", Version: "v1.14.4"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{}, nil)
+	packageService.On("DetectDrift", mock.Anything, mock.Anything).Return([]models.PackageDrift{
+		{Name: "cert-manager", Kind: constants.DriftVersion, ConfigVersion: "v1.14.4", Status: "deployed"},
+		{Name: "unmanaged", Kind: constants.DriftUnmanaged, Status: "deployed", Library: "bbe"},
+	}, nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeAdopt, false)
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "cert-manager", Version: "v1.14.4"},
+	})
+}
+
+func Test_packageSyncCommand_Succeeds_AppliesBbeConfig(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+	mockDriftedCluster(configService, packageService)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+	packageService.On("UpgradePackage", mock.Anything).Return(nil)
+	packageService.On("UninstallPackage", mock.Anything).Return(nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeApply, true)
+
+	assert.Nil(t, err)
+	packageService.AssertCalled(t, "UpgradePackage", models.ChartEntry{Name: "upgraded", Version: "2.0.0"})
+	packageService.AssertCalled(t, "InstallPackage", models.ChartEntry{Name: "removed", Version: "6.0.0", LocalChart: "removed-6.0.0.tgz"})
+	packageService.AssertCalled(t, "UninstallPackage", models.LocalPackage{Name: "unmanaged", Version: "4.0.0"})
+	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 0)
+}
+
+func Test_packageSyncCommand_Succeeds_AppliesAfterConfirmingUninstalls(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+	mockDriftedCluster(configService, packageService)
+	uiService.On("CreateSelect", "Do you want to continue?", []string{"Yes", "No"}).Return("Yes", nil)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+	packageService.On("UpgradePackage", mock.Anything).Return(nil)
+	packageService.On("UninstallPackage", mock.Anything).Return(nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeApply, false)
+
+	assert.Nil(t, err)
+	uiService.AssertNumberOfCalls(t, "CreateSelect", 1)
+	packageService.AssertCalled(t, "UninstallPackage", models.LocalPackage{Name: "unmanaged", Version: "4.0.0"})
+}
+
+func Test_packageSyncCommand_Succeeds_WhenUninstallsAreDeclined(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+	mockDriftedCluster(configService, packageService)
+	uiService.On("CreateSelect", "Do you want to continue?", []string{"Yes", "No"}).Return("No", nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeApply, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNotCalled(t, "UninstallPackage", mock.Anything)
+	packageService.AssertNotCalled(t, "InstallPackage", mock.Anything)
+	packageService.AssertNotCalled(t, "UpgradePackage", mock.Anything)
+}
+
+func Test_packageSyncCommand_Succeeds_AppliesWithoutAskingWhenNothingIsUninstalled(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{{Name: "removed", Version: "6.0.0"}}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{{Name: "removed", Version: "6.0.0"}}, nil)
+	packageService.On("DetectDrift", mock.Anything, mock.Anything).Return([]models.PackageDrift{
+		{Name: "removed", Kind: constants.DriftMissing, ConfigVersion: "6.0.0"},
+	}, nil)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeApply, false)
+
+	assert.Nil(t, err)
+	uiService.AssertNotCalled(t, "CreateSelect", mock.Anything, mock.Anything)
+	packageService.AssertCalled(t, "InstallPackage", models.ChartEntry{Name: "removed", Version: "6.0.0"})
+}
+
+func Test_packageSyncCommand_Fails_WhenApplyFails(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+	mockDriftedCluster(configService, packageService)
+	packageService.On("InstallPackage", mock.Anything).Return(nil)
+	packageService.On("UpgradePackage", mock.Anything).Return(errors.New("test error"))
+	packageService.On("UninstallPackage", mock.Anything).Return(nil)
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeApply, true)
+
+	assert.NotNil(t, err)
+	packageService.AssertNumberOfCalls(t, "InstallPackage", 1)
+	packageService.AssertNumberOfCalls(t, "UninstallPackage", 1)
+}
+
+func Test_packageSyncCommand_Fails_WhenDetectingDriftFails(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{}, nil)
+	packageService.On("DetectDrift", mock.Anything, mock.Anything).Return([]models.PackageDrift{}, errors.New("test error"))
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeReport, false)
+
+	assert.NotNil(t, err)
+}
+
+func Test_packageSyncCommand_Fails_WithNoCluster(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initPackageSyncCommand()
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error"))
+
+	err := packageSyncCommand(helperService, uiService, configService, packageService, helmService, syncModeReport, false)
+
+	assert.ErrorContains(t, err, "No BBE cluster found")
+	assert.Equal(t, clierror.ExitConfigInvalid, clierror.ExitCode(err))
+	packageService.AssertNumberOfCalls(t, "DetectDrift", 0)
+}
+
+func initPackageSyncCommand() (*mocks.MockHelperService, *mocks.MockUiService, *mocks.MockConfigService, *mocks.MockPackageService, *mocks.MockHelmService) {
+	helperService := &mocks.MockHelperService{}
+	uiService := &mocks.MockUiService{}
+	configService := &mocks.MockConfigService{}
+	packageService := &mocks.MockPackageService{}
+	helmService := &mocks.MockHelmService{}
+
+	return helperService, uiService, configService, packageService, helmService
+}
+
+func mockDriftedCluster(configService *mocks.MockConfigService, packageService *mocks.MockPackageService) {
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "in-sync", Version: "1.0.0"},
+		{Name: "upgraded", Version: "2.0.0", Policy: "minor"},
+		{Name: "removed", Version: "6.0.0"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "in-sync", Version: "1.0.0"},
+		{Name: "upgraded", Version: "2.2.0", LocalChart: "upgraded-2.2.0.tgz"},
+		{Name: "removed", Version: "6.0.0", LocalChart: "removed-6.0.0.tgz"},
+		{Name: "unmanaged", Version: "4.0.0", Library: "bbe"},
+	}, nil)
+	packageService.On("DetectDrift", mock.Anything, mock.Anything).Return([]models.PackageDrift{
+		{Name: "upgraded", Kind: constants.DriftVersion, ConfigVersion: "2.0.0", ClusterVersion: "2.1.0", Status: "deployed"},
+		{Name: "removed", Kind: constants.DriftMissing, ConfigVersion: "6.0.0"},
+		{Name: "unmanaged", Kind: constants.DriftUnmanaged, ClusterVersion: "4.0.0", Status: "deployed", Library: "bbe"},
+	}, nil)
+}
+
+func Test_packageListCommand_Succeeds_WithJsonOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatJson)
+	helperService, _, configService, packageService, _ := initPackageSyncCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "blocky", Version: "0.1.3"},
+		{Name: "private", Version: "1.0.0", Policy: "pinned", Library: "home"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{{Name: "blocky", Version: "0.2.0"}}, nil)
+
+	err := packageListCommand(helperService, configService, packageService)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"name": "blocky", "version": "0.1.3", "latest": "0.2.0", "policy": "major", "library": "bbe"},
+		{"name": "private", "version": "1.0.0", "policy": "pinned", "library": "home"}
+	]`, buffer.String())
+}
+
+func Test_packageListCommand_Succeeds_WhenLibraryFails(t *testing.T) {
+	buffer := captureOutput(t, output.FormatText)
+	helperService, _, configService, packageService, _ := initPackageSyncCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{{Name: "blocky", Version: "0.1.3"}}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{}, errors.New("timeout"))
+
+	err := packageListCommand(helperService, configService, packageService)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), "blocky")
+}
+
+func Test_packageListCommand_Fails_WithoutCluster(t *testing.T) {
+	helperService, _, configService, packageService, _ := initPackageSyncCommand()
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, nil)
+
+	err := packageListCommand(helperService, configService, packageService)
+
+	assert.ErrorContains(t, err, "No BBE cluster found")
+}
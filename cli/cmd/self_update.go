@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/update_service"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update bbe to the latest release",
+	Long:  fmt.Sprintf("Update bbe to the latest release, or to the release given with --version. The download is verified against the checksum published with the release before the running binary is replaced. Set %s to use a mirror of the GitHub releases.", constants.ReleasesUrlEnvVar),
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		updateService := update_service.UpdateService{}
+
+		version, _ := cmd.Flags().GetString("version")
+
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("Failed to find the bbe binary: %w", err)
+		}
+		executable, err = filepath.EvalSymlinks(executable)
+		if err != nil {
+			return fmt.Errorf("Failed to find the bbe binary: %w", err)
+		}
+
+		return selfUpdateCommand(updateService, version, executable)
+	},
+}
+
+func selfUpdateCommand(updateService interfaces.UpdateServiceInterface, version string, executable string) error {
+	current := constants.Version
+	if current == versioning.DevelopmentVersion && version == "" {
+		return clierror.New(clierror.KindUsage, errors.New("Development builds of bbe are not updated to the latest release"), "Pass --version to install a specific release")
+	}
+
+	release, err := updateService.FindRelease(version)
+	if err != nil {
+		if errors.Is(err, constants.ReleaseNotFoundError) {
+			return clierror.New(clierror.KindUsage, err, "Check the version against https://github.com/Brains-Beyond-Expectations/bbe-quest/releases")
+		}
+		return err
+	}
+
+	result := models.SelfUpdateResult{
+		Previous: current,
+		Current:  current,
+	}
+
+	if current != versioning.DevelopmentVersion {
+		comparison, err := versioning.Compare(release.Version, current)
+		if err != nil {
+			return err
+		}
+
+		if comparison == 0 || (comparison < 0 && version == "") {
+			return output.Print(result, func() string {
+				return fmt.Sprintf("bbe %s is up to date", current)
+			})
+		}
+		if comparison < 0 {
+			logger.Warning(fmt.Sprintf("Downgrading bbe from %s to %s", current, release.Version))
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Installing bbe %s to %s", release.Version, executable))
+	if err := updateService.Install(release, executable); err != nil {
+		return err
+	}
+
+	result.Current = "v" + strings.TrimPrefix(release.Version, "v")
+	result.Updated = true
+
+	return output.Print(result, func() string {
+		return fmt.Sprintf("Updated bbe from %s to %s", result.Previous, result.Current)
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateCmd.Flags().String("version", "", "Release to install instead of the latest, e.g. 1.4.0")
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setCliVersion(t *testing.T, version string) {
+	originalVersion := constants.Version
+	constants.Version = version
+	t.Cleanup(func() { constants.Version = originalVersion })
+}
+
+func Test_selfUpdateCommand_Succeeds_InstallsNewerRelease(t *testing.T) {
+	setCliVersion(t, "v1.2.0")
+	buffer := captureOutput(t, output.FormatJson)
+
+	release := &models.Release{Version: "1.4.0"}
+	updateService := &mocks.MockUpdateService{}
+	updateService.On("FindRelease", "").Return(release, nil)
+	updateService.On("Install", release, "/usr/local/bin/bbe").Return(nil)
+
+	err := selfUpdateCommand(updateService, "", "/usr/local/bin/bbe")
+
+	assert.NoError(t, err)
+	updateService.AssertExpectations(t)
+
+	var result models.SelfUpdateResult
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+	assert.Equal(t, models.SelfUpdateResult{Previous: "v1.2.0", Current: "v1.4.0", Updated: true}, result)
+}
+
+func Test_selfUpdateCommand_Succeeds_WhenAlreadyUpToDate(t *testing.T) {
+	setCliVersion(t, "v1.4.0")
+	buffer := captureOutput(t, output.FormatJson)
+
+	updateService := &mocks.MockUpdateService{}
+	updateService.On("FindRelease", "").Return(&models.Release{Version: "1.4.0"}, nil)
+
+	err := selfUpdateCommand(updateService, "", "/usr/local/bin/bbe")
+
+	assert.NoError(t, err)
+	updateService.AssertNotCalled(t, "Install")
+
+	var result models.SelfUpdateResult
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &result))
+	assert.False(t, result.Updated)
+}
+
+func Test_selfUpdateCommand_Succeeds_DowngradesToPinnedVersion(t *testing.T) {
+	setCliVersion(t, "v1.4.0")
+	captureOutput(t, output.FormatJson)
+
+	release := &models.Release{Version: "1.2.0"}
+	updateService := &mocks.MockUpdateService{}
+	updateService.On("FindRelease", "1.2.0").Return(release, nil)
+	updateService.On("Install", release, "/usr/local/bin/bbe").Return(nil)
+
+	err := selfUpdateCommand(updateService, "1.2.0", "/usr/local/bin/bbe")
+
+	assert.NoError(t, err)
+	updateService.AssertExpectations(t)
+}
+
+func Test_selfUpdateCommand_Succeeds_WithPinnedVersionOnDevelopmentBuild(t *testing.T) {
+	setCliVersion(t, "development")
+	captureOutput(t, output.FormatJson)
+
+	release := &models.Release{Version: "1.4.0"}
+	updateService := &mocks.MockUpdateService{}
+	updateService.On("FindRelease", "1.4.0").Return(release, nil)
+	updateService.On("Install", release, "/tmp/bbe").Return(nil)
+
+	err := selfUpdateCommand(updateService, "1.4.0", "/tmp/bbe")
+
+	assert.NoError(t, err)
+	updateService.AssertExpectations(t)
+}
+
+func Test_selfUpdateCommand_Fails_OnDevelopmentBuildWithoutVersion(t *testing.T) {
+	setCliVersion(t, "development")
+
+	updateService := &mocks.MockUpdateService{}
+
+	err := selfUpdateCommand(updateService, "", "/tmp/bbe")
+
+	assert.Error(t, err)
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	updateService.AssertNotCalled(t, "FindRelease")
+}
+
+func Test_selfUpdateCommand_Fails_WhenReleaseDoesNotExist(t *testing.T) {
+	setCliVersion(t, "v1.2.0")
+
+	updateService := &mocks.MockUpdateService{}
+	updateService.On("FindRelease", "9.9.9").Return((*models.Release)(nil), constants.ReleaseNotFoundError)
+
+	err := selfUpdateCommand(updateService, "9.9.9", "/usr/local/bin/bbe")
+
+	assert.ErrorIs(t, err, constants.ReleaseNotFoundError)
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+}
+
+func Test_selfUpdateCommand_Fails_WhenInstallFails(t *testing.T) {
+	setCliVersion(t, "v1.2.0")
+
+	release := &models.Release{Version: "1.4.0"}
+	updateService := &mocks.MockUpdateService{}
+	updateService.On("FindRelease", "").Return(release, nil)
+	updateService.On("Install", release, "/usr/local/bin/bbe").Return(errors.New("checksum mismatch"))
+
+	err := selfUpdateCommand(updateService, "", "/usr/local/bin/bbe")
+
+	assert.ErrorContains(t, err, "checksum mismatch")
+}
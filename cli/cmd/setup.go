@@ -1,20 +1,26 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/merge"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/dependency_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/image_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ipfinder_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/kubeconfig_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/ui_service"
 	"github.com/briandowns/spinner"
@@ -27,7 +33,7 @@ var setupCmd = &cobra.Command{
 	Aliases: []string{},
 	Short:   "Guides you through a BBE-Quest node setup",
 	Args:    cobra.ExactArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		helperService := helper_service.HelperService{}
 		dependencyService := dependency_service.DependencyService{}
 		talosService := talos_service.TalosService{}
@@ -35,97 +41,78 @@ var setupCmd = &cobra.Command{
 		uiService := ui_service.UiService{}
 		configService := config_service.ConfigService{}
 		imageService := image_service.ImageService{}
+		kubeconfigService := kubeconfig_service.KubeconfigService{}
 
-		err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
-		if err != nil {
-			logger.Error("", err)
-			os.Exit(1)
-		}
+		device, _ := cmd.Flags().GetString("device")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		return setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, device, dryRun)
 	},
 }
 
-func setupCommand(helperService interfaces.HelperServiceInterface, dependencyService interfaces.DependencyServiceInterface, talosService interfaces.TalosServiceInterface, ipFinderService interfaces.IpFinderServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, imageService interfaces.ImageServiceInterface) error {
+func setupCommand(helperService interfaces.HelperServiceInterface, dependencyService interfaces.DependencyServiceInterface, talosService interfaces.TalosServiceInterface, ipFinderService interfaces.IpFinderServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, imageService interfaces.ImageServiceInterface, kubeconfigService interfaces.KubeconfigServiceInterface, device string, dryRun bool) error {
+	nodeType, deviceGiven := image_service.NodeTypes[device]
+	if device != "" && !deviceGiven {
+		return clierror.New(clierror.KindUsage, fmt.Errorf("Unknown device type `%s`", device), fmt.Sprintf("Use one of: %s", strings.Join(image_service.DeviceTypes(), ", ")))
+	}
+
 	rng, rngError := codename.DefaultRNG()
 
-	spinner := spinner.New(spinner.CharSets[43], 100*time.Millisecond)
+	spinner := spinner.New(spinner.CharSets[43], 100*time.Millisecond, spinner.WithWriter(output.StatusWriter()))
 
 	bbeConfig, err := configService.GetBbeConfig(helperService)
-	if err != nil {
+	if errors.Is(err, constants.ConfigNotFoundError) && dryRun {
+		return clierror.New(clierror.KindUsage, errors.New("No BBE configuration found, a dry run does not create one"), "Run 'bbe config' first")
+	} else if errors.Is(err, constants.ConfigNotFoundError) {
 		bbeConfig, err = getOrGenerateConfig(helperService, uiService, configService)
 		if err != nil {
 			return fmt.Errorf("Error while generating BBE config: %w", err)
 		}
+	} else if err != nil {
+		return fmt.Errorf("Error while reading BBE config: %w", err)
 	}
 
-	if bbeConfig.Bbe.Storage.Type == "aws" {
-		err := configService.SyncConfigsWithAws(helperService, bbeConfig)
+	if isRemoteStorage(bbeConfig.Bbe.Storage.Type) && dryRun {
+		logger.Info("Skipping the sync with remote storage, the dry run uses the local config files")
+	} else if isRemoteStorage(bbeConfig.Bbe.Storage.Type) {
+		err := syncConfigs(helperService, uiService, configService, bbeConfig)
 		if err != nil {
-			return fmt.Errorf("Error while syncing config with AWS: %w", err)
+			return fmt.Errorf("Error while syncing config with remote storage: %w", err)
 		}
 	}
 
 	// Get current local path
 	workingDirectory, err := os.Getwd()
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("Failed to read the working directory: %w", err)
 	}
 
 	if !dependencyService.VerifyDependencies() {
-		return fmt.Errorf("Error while verifying dependencies")
+		return clierror.New(clierror.KindMissingDependency, errors.New("Error while verifying dependencies"), "Install the programs listed above and run 'bbe setup' again")
 	}
 
 	answer, err := uiService.CreateSelect("Is this the first node in your cluster?", []string{"Yes", "No"})
 	if err != nil {
-		panic(err)
+		return err
 	}
 	createControlPlane := answer == "Yes"
 
 	configExists := configService.CheckForTalosConfigs(helperService)
 
 	if createControlPlane && configExists {
-		return fmt.Errorf("You are trying to create a control plane node, but there are already config files present.")
+		return fmt.Errorf("You are trying to create a control plane node, but cluster `%s` already has config files, run 'bbe cluster create <name>' to set up another cluster", helperService.GetClusterName())
 	}
 
 	if !configExists && !createControlPlane {
 		return fmt.Errorf("No config files found while trying to enroll new node in existing cluster, please create your first node first")
 	}
 
-	answer, err = uiService.CreateSelect("What type of device are you setting up?", []string{"Intel NUC", "Raspberry Pi 4 (or older)"})
-	if err != nil {
-		panic(err)
-	}
-
-	var nodeType models.NodeType
-	switch answer {
-	case "Intel NUC":
-		nodeType = image_service.IntelNuc
-	case "Raspberry Pi 4 (or older)":
-		nodeType = image_service.RaspberryPi
-	default:
-		panic("Invalid node type")
-	}
-
-	err = imageCreation(helperService, uiService, imageService, workingDirectory, nodeType)
-	if err != nil {
-		return fmt.Errorf("Error while downloading image: %w", err)
-	}
-
-	firstMessage := "Please use balenaEtcher to flash the .iso to your USB device"
-	secondMessage := "Please insert the USB device into your new node and boot from it"
-
-	if nodeType.ImagerType == "rpi_generic" {
-		firstMessage = "Please use balenaEtcher to flash the .xz to your SD card"
-		secondMessage = "Please insert the SD card into your new node and boot from it"
-	}
-
-	_, err = uiService.CreateSelect(firstMessage, []string{"Done"})
-	if err != nil {
-		panic(err)
-	}
-
-	_, err = uiService.CreateSelect(secondMessage, []string{"Done"})
-	if err != nil {
-		panic(err)
+	// A dry run expects the node to be booted already, no image is downloaded
+	if !dryRun {
+		err = prepareBootMedia(helperService, uiService, imageService, workingDirectory, nodeType, deviceGiven)
+		if err != nil {
+			return err
+		}
 	}
 
 	spinner.Start()
@@ -137,7 +124,7 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 			var err error
 			result, err = uiService.CreateInput(title, "")
 			if err != nil {
-				panic(err)
+				return err
 			}
 
 			if helperService.IsValidIp(result) {
@@ -161,15 +148,28 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 	}
 
 	if len(ips) == 0 {
-		return fmt.Errorf("No node found, please make sure there is only 1 Talos node in maintenance mode.")
+		return clierror.New(clierror.KindNodeUnreachable, errors.New("No node found, please make sure there is only 1 Talos node in maintenance mode."), "Boot the node from the flashed media and make sure it is connected to the same network as this machine")
 	}
 
 	originalIp := ips[0]
 
+	// The talos configs are modified through helperService, a dry run modifies a scratch copy of them instead
+	var originalConfigs, generatedConfigs map[string][]byte
+	if dryRun {
+		scratchHelper, err := newScratchHelperService(helperService)
+		if err != nil {
+			return fmt.Errorf("Error while copying talos configs: %w", err)
+		}
+		defer os.RemoveAll(scratchHelper.configDir)
+
+		helperService = scratchHelper
+		originalConfigs = readTalosConfigs(helperService)
+	}
+
 	///////////////////////////////////////////////////////////////////////////////// QUESTIONS ///////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	chosenIp, err := uiService.CreateInput("Please choose an ip for the new node", originalIp)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	logger.Debug("Getting talos disks")
@@ -180,13 +180,13 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 
 	disk, err := uiService.CreateSelect(fmt.Sprintf("Please select the disk to install Talos on for %s", chosenIp), disks)
 	if err != nil {
-		panic(err)
+		return err
 	}
 	diskSelectionResult := strings.Fields(disk)
 
 	gatewayIp, err := uiService.CreateInput("Please choose the correct gateway ip", gatewayIpSuggestion)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	suggestedHostname := "big_brain_entropy_generator"
@@ -196,7 +196,7 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 
 	hostname, err := uiService.CreateInput("Please select the hostname", suggestedHostname)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	var clusterName string
@@ -210,7 +210,7 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 
 			clusterName, err = uiService.CreateInput("Please enter what you want to name your cluster", suggestedClusterName)
 			if err != nil {
-				panic(err)
+				return err
 			}
 
 			err = talosService.GenerateConfig(helperService, chosenIp, clusterName)
@@ -221,12 +221,16 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 
 		allowSchedulingOnControlPlanes, err = uiService.CreateSelect("Do you want to allow scheduling on the control plane? This is required if you have only one node.", []string{"Yes", "No"})
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 	}
 	///////////////////////////////////////////////////////////////////////////////// QUESTIONS END ///////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+	if dryRun {
+		generatedConfigs = readTalosConfigs(helperService)
+	}
+
 	controlPlaneIp, err := talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
 	if err != nil {
 		return fmt.Errorf("Error while getting control plane IP: %w", err)
@@ -280,6 +284,16 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 		return fmt.Errorf("Error while modifying config disk: %w", err)
 	}
 
+	if dryRun {
+		spinner.Stop()
+		plan := setupPlan(originalConfigs, generatedConfigs, readTalosConfigs(helperService))
+		plan.Node = chosenIp
+		plan.Hostname = hostname
+		plan.ConfigFile = nodeConfigFile
+		plan.Bootstrap = createControlPlane
+		return printSetupPlan(plan)
+	}
+
 	logger.Debug("Joining cluster")
 	err = talosService.JoinCluster(helperService, originalIp, nodeConfigFile)
 	if err != nil {
@@ -301,10 +315,10 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 	}
 
 	if createControlPlane {
-		logger.Debug("Downloading kube config")
-		err := talosService.DownloadKubeConfig(helperService, chosenIp, controlPlaneIp)
+		logger.Debug("Generating kube config")
+		_, err := kubeconfigService.Generate(helperService, configService, talosService, controlPlaneIp)
 		if err != nil {
-			return fmt.Errorf("Error while downloading kubeconfig: %w", err)
+			return fmt.Errorf("Error while generating kubeconfig: %w", err)
 		}
 
 		logger.Debug("Updating BBE cluster name")
@@ -313,7 +327,12 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 			return fmt.Errorf("Error while updating BBE cluster name: %w", err)
 		}
 
-		logger.Infof("Control plane node %s successfully set up", chosenIp)
+		if _, err := kubeconfigService.VerifyEndpoint(helperService); err != nil {
+			logger.Warning(err.Error())
+		}
+
+		logger.Infof("Control plane node %s successfully set up, the kubeconfig was saved to %s", chosenIp, kubeconfigService.Path(helperService))
+		logger.Info("Run 'bbe kubeconfig --merge' to add it to ~/.kube/config")
 	} else {
 		logger.Infof("Worker node %s successfully set up", chosenIp)
 	}
@@ -322,16 +341,157 @@ func setupCommand(helperService interfaces.HelperServiceInterface, dependencySer
 	return nil
 }
 
+// prepareBootMedia asks for the device type unless it was given, downloads its image and waits for it to be flashed and booted
+func prepareBootMedia(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, imageService interfaces.ImageServiceInterface, workingDirectory string, nodeType models.NodeType, deviceGiven bool) error {
+	if !deviceGiven {
+		answer, err := uiService.CreateSelect("What type of device are you setting up?", []string{"Intel NUC", "Raspberry Pi 4 (or older)"})
+		if err != nil {
+			return err
+		}
+
+		switch answer {
+		case "Intel NUC":
+			nodeType = image_service.IntelNuc
+		case "Raspberry Pi 4 (or older)":
+			nodeType = image_service.RaspberryPi
+		default:
+			return fmt.Errorf("Invalid node type `%s`", answer)
+		}
+	}
+
+	err := imageCreation(helperService, uiService, imageService, workingDirectory, nodeType)
+	if err != nil {
+		return fmt.Errorf("Error while downloading image: %w", err)
+	}
+
+	firstMessage := "Please use balenaEtcher to flash the .iso to your USB device"
+	secondMessage := "Please insert the USB device into your new node and boot from it"
+
+	if nodeType.ImagerType == "rpi_generic" {
+		firstMessage = "Please use balenaEtcher to flash the .xz to your SD card"
+		secondMessage = "Please insert the SD card into your new node and boot from it"
+	}
+
+	_, err = uiService.CreateSelect(firstMessage, []string{"Done"})
+	if err != nil {
+		return err
+	}
+
+	_, err = uiService.CreateSelect(secondMessage, []string{"Done"})
+	return err
+}
+
+// talosConfigFiles are the files setup generates and modifies in the configuration directory of the cluster
+var talosConfigFiles = []string{constants.ControlplaneConfigFile, constants.WorkerConfigFile, constants.TalosConfigFile}
+
+// scratchHelperService points the configuration directory at a scratch copy, everything else is answered by the wrapped service
+type scratchHelperService struct {
+	interfaces.HelperServiceInterface
+	configDir string
+}
+
+func newScratchHelperService(helperService interfaces.HelperServiceInterface) (scratchHelperService, error) {
+	configDir, err := os.MkdirTemp("", "bbe-setup")
+	if err != nil {
+		return scratchHelperService{}, err
+	}
+
+	for file, content := range readTalosConfigs(helperService) {
+		err := os.WriteFile(filepath.Join(configDir, file), content, 0600)
+		if err != nil {
+			os.RemoveAll(configDir)
+			return scratchHelperService{}, err
+		}
+	}
+
+	return scratchHelperService{HelperServiceInterface: helperService, configDir: configDir}, nil
+}
+
+func (helperService scratchHelperService) GetConfigDir() string {
+	return helperService.configDir
+}
+
+func (helperService scratchHelperService) GetConfigFilePath(name string) string {
+	return fmt.Sprintf("%s/%s", helperService.configDir, name)
+}
+
+// readTalosConfigs reads the talos configs that exist, missing files are left out
+func readTalosConfigs(helperService interfaces.HelperServiceInterface) map[string][]byte {
+	configs := map[string][]byte{}
+	for _, file := range talosConfigFiles {
+		content, err := os.ReadFile(helperService.GetConfigFilePath(file))
+		if err == nil {
+			configs[file] = content
+		}
+	}
+
+	return configs
+}
+
+// setupPlan diffs every talos config against the file before setup, files that did not exist are diffed against the config talosctl generated
+func setupPlan(originalConfigs map[string][]byte, generatedConfigs map[string][]byte, finalConfigs map[string][]byte) models.SetupPlan {
+	plan := models.SetupPlan{Files: []models.ConfigFileDiff{}}
+	for _, file := range talosConfigFiles {
+		final, exists := finalConfigs[file]
+		if !exists {
+			continue
+		}
+
+		baseline, existed := originalConfigs[file]
+		if !existed {
+			baseline = generatedConfigs[file]
+		}
+
+		diff := merge.Diff(baseline, final)
+		if existed && diff == "" {
+			continue
+		}
+
+		plan.Files = append(plan.Files, models.ConfigFileDiff{File: file, Generated: !existed, Diff: diff})
+	}
+
+	return plan
+}
+
+func printSetupPlan(plan models.SetupPlan) error {
+	return output.Print(plan, func() string {
+		var builder strings.Builder
+
+		fmt.Fprintf(&builder, "Node %s would be set up with hostname %s by applying %s", plan.Node, plan.Hostname, plan.ConfigFile)
+		if plan.Bootstrap {
+			builder.WriteString(", the cluster would be bootstrapped")
+		}
+		builder.WriteString("\n")
+
+		for _, file := range plan.Files {
+			if file.Generated {
+				fmt.Fprintf(&builder, "\n%s would be generated", file.File)
+				if file.Diff != "" {
+					builder.WriteString(", with these changes to the generated config")
+				}
+				builder.WriteString("\n")
+			} else {
+				fmt.Fprintf(&builder, "\n%s would change\n", file.File)
+			}
+			if file.Diff != "" {
+				fmt.Fprintln(&builder, strings.TrimRight(file.Diff, "\n"))
+			}
+		}
+
+		return strings.TrimRight(builder.String(), "\n")
+	})
+}
+
 func imageCreation(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, imageService interfaces.ImageServiceInterface, workingDirectory string, nodeType models.NodeType) error {
 	imageDirectory := fmt.Sprintf("%s/_out", workingDirectory)
 	resultFilePath := fmt.Sprintf("%s/%s", imageDirectory, nodeType.OutputFile)
-	spinner := spinner.New(spinner.CharSets[43], 100*time.Millisecond)
+	spinner := spinner.New(spinner.CharSets[43], 100*time.Millisecond, spinner.WithWriter(output.StatusWriter()))
 
 	_, imageExists := helperService.CheckIfFileExists(resultFilePath)
 	if imageExists {
 		result, err := uiService.CreateSelect("An image already exists, would you like to redownload it?", []string{"Yes", "No"})
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		if result == "No" {
@@ -355,4 +515,8 @@ func imageCreation(helperService interfaces.HelperServiceInterface, uiService in
 
 func init() {
 	rootCmd.AddCommand(setupCmd)
+
+	setupCmd.Flags().Bool("dry-run", false, "Show the changes to the talos configs without applying them to the node")
+	setupCmd.Flags().String("device", "", fmt.Sprintf("Type of the device to set up, one of: %s, asks when empty", strings.Join(image_service.DeviceTypes(), ", ")))
+	setupCmd.RegisterFlagCompletionFunc("device", completeDeviceTypes)
 }
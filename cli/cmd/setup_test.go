@@ -1,74 +1,81 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/image_service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
 func Test_setupCommand_Succeeds_WithControlPlane_RaspberryPi(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
 func Test_setupCommand_Succeeds_WithWorkerNode_RaspberryPi(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	uiService.On("CreateSelect", "Is this the first node in your cluster?", mock.Anything).Return("No", nil)
 	configService.On("CheckForTalosConfigs", helperService).Return(true)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, false)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, false)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Succeeds_WithControlPlane_IntelNUC(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	uiService.On("CreateSelect", "What type of device are you setting up?", mock.Anything).Return("Intel NUC", nil)
 	uiService.On("CreateSelect", "Please use balenaEtcher to flash the .iso to your USB device", mock.Anything).Return("Done", nil)
 	uiService.On("CreateSelect", "Please insert the USB device into your new node and boot from it", mock.Anything).Return("Done", nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
 func Test_setupCommand_Succeeds_WithWorkerNode_IntelNUC(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	uiService.On("CreateSelect", "Is this the first node in your cluster?", mock.Anything).Return("No", nil)
 	configService.On("CheckForTalosConfigs", helperService).Return(true)
@@ -77,21 +84,21 @@ func Test_setupCommand_Succeeds_WithWorkerNode_IntelNUC(t *testing.T) {
 	uiService.On("CreateSelect", "Please use balenaEtcher to flash the .iso to your USB device", mock.Anything).Return("Done", nil)
 	uiService.On("CreateSelect", "Please insert the USB device into your new node and boot from it", mock.Anything).Return("Done", nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, false)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, false)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Succeeds_WithIpNotFoundFallback(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	ipFinderService.On("GetGatewayIp", helperService).Return("", errors.New("test error"))
 	uiService.On("CreateInput", "Gateway IP not found, please enter the IP of the network you want to scan:", mock.Anything).Return("test", nil)
@@ -99,232 +106,276 @@ func Test_setupCommand_Succeeds_WithIpNotFoundFallback(t *testing.T) {
 	uiService.On("CreateInput", "Invalid Gateway IP, please enter a valid IP:", mock.Anything).Return(gatewayIp, nil)
 	helperService.On("IsValidIp", gatewayIp).Return(true)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 2)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
 func Test_setupCommand_Succeeds_WithPreexistingImage(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	now := time.Now()
 	helperService.On("CheckIfFileExists", mock.Anything).Return(&now, true)
 	uiService.On("CreateSelect", "An image already exists, would you like to redownload it?", mock.Anything).Return("No", nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
 func Test_setupCommand_Succeeds_GeneratesLocalConfig(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
-	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error")).Once()
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
 	uiService.On("CreateSelect", "No BBE configuration file found, where would you like to store your config files?", mock.Anything).Return("Local", nil)
-	configService.On("GenerateBbeConfig", helperService, "local").Return(nil)
+	configService.On("GenerateBbeConfig", helperService, models.StorageConfig{Type: "local"}).Return(nil)
 	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 1)
-	configService.AssertCalled(t, "GenerateBbeConfig", helperService, "local")
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertCalled(t, "GenerateBbeConfig", helperService, models.StorageConfig{Type: "local"})
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
 func Test_setupCommand_Succeeds_GeneratesAwsConfig(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
-	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error")).Once()
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
 	uiService.On("CreateSelect", "No BBE configuration file found, where would you like to store your config files?", mock.Anything).Return("AWS", nil)
-	configService.On("GenerateBbeConfig", helperService, "aws").Return(nil)
+	storage := mockAwsStoragePrompts(uiService)
+	configService.On("GenerateBbeConfig", helperService, storage).Return(nil)
 	bbeConfig := models.BbeConfig{}
 	bbeConfig.Bbe.Storage.Type = "aws"
 	configService.On("GetBbeConfig", mock.Anything).Return(&bbeConfig, nil)
-	configService.On("SyncConfigsWithAws", helperService, &bbeConfig).Return(nil)
+	configService.On("SyncConfigs", helperService, &bbeConfig).Return(nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 1)
-	configService.AssertCalled(t, "GenerateBbeConfig", helperService, "aws")
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 1)
+	configService.AssertCalled(t, "GenerateBbeConfig", helperService, storage)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
 func Test_setupCommand_Fails_WithNoNodeFound(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	ipFinderService.On("LocateDevice", helperService, talosService, gatewayIp).Return([]string{}, nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
+	assert.Equal(t, clierror.ExitNodeUnreachable, clierror.ExitCode(err))
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails_WithMoreThanOneNodeFound(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	ipFinderService.On("LocateDevice", helperService, talosService, gatewayIp).Return([]string{gatewayIp, nodeIp, chosenIp}, nil)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails_WhenFailingToGenerateBbeConfig(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
-	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error")).Once()
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
 	uiService.On("CreateSelect", "No BBE configuration file found, where would you like to store your config files?", mock.Anything).Return("Local", nil)
-	configService.On("GenerateBbeConfig", helperService, "local").Return(errors.New("test error"))
+	configService.On("GenerateBbeConfig", helperService, models.StorageConfig{Type: "local"}).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 1)
-	configService.AssertCalled(t, "GenerateBbeConfig", helperService, "local")
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertCalled(t, "GenerateBbeConfig", helperService, models.StorageConfig{Type: "local"})
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
-func Test_setupCommand_Fails_WhenFailingToSyncConfigsWithAws(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+func Test_setupCommand_Fails_WhenFailingToSyncConfigs(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
-	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("test error")).Once()
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, constants.ConfigNotFoundError).Once()
 	uiService.On("CreateSelect", "No BBE configuration file found, where would you like to store your config files?", mock.Anything).Return("AWS", nil)
-	configService.On("GenerateBbeConfig", helperService, "aws").Return(nil)
+	storage := mockAwsStoragePrompts(uiService)
+	configService.On("GenerateBbeConfig", helperService, storage).Return(nil)
 	bbeConfig := models.BbeConfig{}
 	bbeConfig.Bbe.Storage.Type = "aws"
 	configService.On("GetBbeConfig", mock.Anything).Return(&bbeConfig, nil)
-	configService.On("SyncConfigsWithAws", helperService, &bbeConfig).Return(errors.New("test error"))
+	configService.On("SyncConfigs", helperService, &bbeConfig).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 1)
-	configService.AssertCalled(t, "GenerateBbeConfig", helperService, "aws")
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 1)
+	configService.AssertCalled(t, "GenerateBbeConfig", helperService, storage)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 1)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails_WhenDependenciesMissing(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	dependencyService.On("VerifyDependencies").Return(false)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
+	assert.Equal(t, clierror.ExitMissingDependency, clierror.ExitCode(err))
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
+func Test_setupCommand_Fails_WhenUserAborts(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+
+	uiService.On("CreateSelect", "Is this the first node in your cluster?", []string{"Yes", "No"}).Return("", constants.UserAbortError)
+
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
+
+	assert.ErrorIs(t, err, constants.UserAbortError)
+	assert.Equal(t, clierror.ExitUserAbort, clierror.ExitCode(err))
+	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
+}
+
+func Test_setupCommand_Succeeds_WithDeviceFlag(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+
+	uiService.On("CreateSelect", "Please use balenaEtcher to flash the .iso to your USB device", mock.Anything).Return("Done", nil)
+	uiService.On("CreateSelect", "Please insert the USB device into your new node and boot from it", mock.Anything).Return("Done", nil)
+
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "intel-nuc", false)
+
+	assert.Nil(t, err)
+	imageService.AssertCalled(t, "CreateImage", image_service.IntelNuc, mock.Anything)
+	uiService.AssertNotCalled(t, "CreateSelect", "What type of device are you setting up?", mock.Anything)
+}
+
+func Test_setupCommand_Fails_WithUnknownDevice(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, _, _, _ := initSetupTests()
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "toaster", false)
+
+	assert.ErrorContains(t, err, "Unknown device type `toaster`")
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	configService.AssertNotCalled(t, "GetBbeConfig", mock.Anything)
+}
+
 func Test_setupCommand_Fails_WhenEnrollingIntoExistingClusterWithMissingConfigs(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	uiService.On("CreateSelect", "Is this the first node in your cluster?", mock.Anything).Return("No", nil)
 	configService.On("CheckForTalosConfigs", helperService).Return(false)
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails_WhenEnrollingNewFirstNodeWithPreexistingConfigs(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	uiService.On("CreateSelect", "Is this the first node in your cluster?", mock.Anything).Return("Yes", nil)
 	configService.On("CheckForTalosConfigs", helperService).Return(true)
+	helperService.On("GetClusterName").Return("default")
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToDownloadImage(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	imageService.On("CreateImage", mock.Anything, mock.Anything).Return("imagePath", errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
@@ -332,18 +383,18 @@ func Test_setupCommand_Fails__WhenFailingToDownloadImage(t *testing.T) {
 	ipFinderService.AssertNumberOfCalls(t, "LocateDevice", 0)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenNoDevicesAreFound(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	ipFinderService.On("LocateDevice", helperService, talosService, gatewayIp).Return([]string{}, errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
@@ -351,281 +402,294 @@ func Test_setupCommand_Fails__WhenNoDevicesAreFound(t *testing.T) {
 	ipFinderService.AssertNumberOfCalls(t, "LocateDevice", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 0)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenNoDisksAreFound(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("GetDisks", helperService, nodeIp).Return([]string{}, errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToGenerateTalosConfig(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("GenerateConfig", helperService, chosenIp, "talos-cluster").Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToGetControlPlaneIp(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("GetControlPlaneIp", helperService, constants.ControlplaneConfigFile).Return("", errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToModifyNetworkNodeIp(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("ModifyNetworkNodeIp", helperService, mock.Anything, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToGetNetworkInterface(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("GetNetworkInterface", helperService, nodeIp).Return("", errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToModifyNetworkInterface(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("ModifyNetworkInterface", helperService, mock.Anything, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToModifyNetworkGateway(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("ModifyNetworkGateway", helperService, mock.Anything, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToModifyNetworkHostname(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("ModifyNetworkHostname", helperService, mock.Anything, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToModifySchedulingOnControlPlane(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("ModifySchedulingOnControlPlane", helperService, mock.Anything, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToModifyConfigDisk(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("ModifyConfigDisk", helperService, mock.Anything, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToJoinCluster(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("JoinCluster", helperService, nodeIp, mock.Anything).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToBootstrapCluster(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("BootstrapCluster", helperService, chosenIp, chosenIp).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
 func Test_setupCommand_Fails__WhenFailingToVerifyNodeHealth(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	talosService.On("VerifyNodeHealth", helperService, chosenIp, chosenIp).Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
-func Test_setupCommand_Fails__WhenFailingToDownloadKubeConfig(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+func Test_setupCommand_Fails__WhenFailingToGenerateKubeConfig(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
-	talosService.On("DownloadKubeConfig", helperService, chosenIp, chosenIp).Return(errors.New("test error"))
+	kubeconfigService.On("Generate", helperService, configService, talosService, chosenIp).Return("", errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 0)
 }
 
+func Test_setupCommand_Succeeds_WhenApiServerIsNotReachableYet(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+
+	kubeconfigService.On("VerifyEndpoint", helperService).Return("", errors.New("The API server is not reachable"))
+
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
+
+	assert.Nil(t, err)
+	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
+}
+
 func Test_setupCommand_Fails__WhenFailingToUpdateBbeClusterName(t *testing.T) {
-	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
 
 	configService.On("UpdateBbeClusterName", helperService, "talos-cluster").Return(errors.New("test error"))
 
-	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, gatewayIp, nodeIp, chosenIp, true)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
 
-	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService)
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", false)
 
 	assert.NotNil(t, err)
 	helperService.AssertNumberOfCalls(t, "IsValidIp", 0)
 	imageService.AssertNumberOfCalls(t, "CreateImage", 1)
 	talosService.AssertNumberOfCalls(t, "GetDisks", 1)
 	configService.AssertNumberOfCalls(t, "GenerateBbeConfig", 0)
-	configService.AssertNumberOfCalls(t, "SyncConfigsWithAws", 0)
+	configService.AssertNumberOfCalls(t, "SyncConfigs", 0)
 	configService.AssertNumberOfCalls(t, "UpdateBbeClusterName", 1)
 }
 
-func initSetupTests() (*mocks.MockHelperService, *mocks.MockDependencyService, *mocks.MockTalosService, *mocks.MockIpFinderService, *mocks.MockUiService, *mocks.MockConfigService, *mocks.MockImageService, string, string, string) {
+func initSetupTests() (*mocks.MockHelperService, *mocks.MockDependencyService, *mocks.MockTalosService, *mocks.MockIpFinderService, *mocks.MockUiService, *mocks.MockConfigService, *mocks.MockImageService, *mocks.MockKubeconfigService, string, string, string) {
 	helperService := mocks.MockHelperService{}
 	dependencyService := mocks.MockDependencyService{}
 	talosService := mocks.MockTalosService{}
@@ -633,15 +697,16 @@ func initSetupTests() (*mocks.MockHelperService, *mocks.MockDependencyService, *
 	uiService := mocks.MockUiService{}
 	configService := mocks.MockConfigService{}
 	imageService := mocks.MockImageService{}
+	kubeconfigService := mocks.MockKubeconfigService{}
 
 	gatewayIp := "127.0.0.1"
 	nodeIp := "1.2.3.4"
 	chosenIp := "5.6.7.8"
 
-	return &helperService, &dependencyService, &talosService, &ipFinderService, &uiService, &configService, &imageService, gatewayIp, nodeIp, chosenIp
+	return &helperService, &dependencyService, &talosService, &ipFinderService, &uiService, &configService, &imageService, &kubeconfigService, gatewayIp, nodeIp, chosenIp
 }
 
-func mockSuccessfulSetupFlow(helperService *mocks.MockHelperService, dependencyService *mocks.MockDependencyService, talosService *mocks.MockTalosService, ipFinderService *mocks.MockIpFinderService, uiService *mocks.MockUiService, configService *mocks.MockConfigService, imageService *mocks.MockImageService, gatewayIp, nodeIp, chosenIp string, isControlPlane bool) {
+func mockSuccessfulSetupFlow(helperService *mocks.MockHelperService, dependencyService *mocks.MockDependencyService, talosService *mocks.MockTalosService, ipFinderService *mocks.MockIpFinderService, uiService *mocks.MockUiService, configService *mocks.MockConfigService, imageService *mocks.MockImageService, kubeconfigService *mocks.MockKubeconfigService, gatewayIp, nodeIp, chosenIp string, isControlPlane bool) {
 	nodeTypeConfigFile := constants.ControlplaneConfigFile
 	if !isControlPlane {
 		nodeTypeConfigFile = constants.WorkerConfigFile
@@ -678,6 +743,118 @@ func mockSuccessfulSetupFlow(helperService *mocks.MockHelperService, dependencyS
 	talosService.On("JoinCluster", helperService, nodeIp, nodeTypeConfigFile).Return(nil)
 	talosService.On("BootstrapCluster", helperService, chosenIp, chosenIp).Return(nil)
 	talosService.On("VerifyNodeHealth", helperService, chosenIp, chosenIp).Return(nil)
-	talosService.On("DownloadKubeConfig", helperService, chosenIp, chosenIp).Return(nil)
+	kubeconfigService.On("Generate", helperService, configService, talosService, chosenIp).Return("admin@talos-cluster", nil)
+	kubeconfigService.On("VerifyEndpoint", helperService).Return("https://"+chosenIp+":6443", nil)
+	kubeconfigService.On("Path", helperService).Return("kubeconfig")
 	configService.On("UpdateBbeClusterName", helperService, "talos-cluster").Return(nil)
 }
+
+// mockDryRunSetupFlow modifies the talos configs in the directory of the helper service the talos service is called with, a dry run calls it with a scratch copy of configDir
+func mockDryRunSetupFlow(helperService *mocks.MockHelperService, talosService *mocks.MockTalosService, configDir string, nodeTypeConfigFile string) {
+	for _, file := range talosConfigFiles {
+		helperService.On("GetConfigFilePath", file).Return(filepath.Join(configDir, file))
+	}
+
+	appendToConfig := func(args mock.Arguments, line string) {
+		path := args.Get(0).(interfaces.HelperServiceInterface).GetConfigFilePath(args.String(1))
+		content, _ := os.ReadFile(path)
+		os.WriteFile(path, append(content, []byte(line+"\n")...), 0600)
+	}
+
+	talosService.On("GenerateConfig", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		configDir := args.Get(0).(interfaces.HelperServiceInterface).GetConfigDir()
+		for _, file := range talosConfigFiles {
+			os.WriteFile(filepath.Join(configDir, file), []byte("version: v1alpha1\n"), 0600)
+		}
+	}).Return(nil)
+	talosService.On("GetDisks", mock.Anything, mock.Anything).Return([]string{"node", "namespace", "sda"}, nil)
+	talosService.On("GetControlPlaneIp", mock.Anything, constants.ControlplaneConfigFile).Return("5.6.7.8", nil)
+	talosService.On("ModifyNetworkNodeIp", mock.Anything, nodeTypeConfigFile, mock.Anything).Run(func(args mock.Arguments) {
+		appendToConfig(args, "address: "+args.String(2))
+	}).Return(nil)
+	talosService.On("GetNetworkInterface", mock.Anything, mock.Anything).Return("eth0", nil)
+	talosService.On("ModifyNetworkInterface", mock.Anything, nodeTypeConfigFile, mock.Anything).Return(nil)
+	talosService.On("ModifyNetworkGateway", mock.Anything, nodeTypeConfigFile, mock.Anything).Return(nil)
+	talosService.On("ModifyNetworkHostname", mock.Anything, nodeTypeConfigFile, mock.Anything).Run(func(args mock.Arguments) {
+		appendToConfig(args, "hostname: "+args.String(2))
+	}).Return(nil)
+	talosService.On("ModifySchedulingOnControlPlane", mock.Anything, mock.Anything).Return(nil)
+	talosService.On("ModifyConfigDisk", mock.Anything, nodeTypeConfigFile, mock.Anything).Return(nil)
+}
+
+func Test_setupCommand_Succeeds_WithDryRunForNewCluster(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	buffer := captureOutput(t, output.FormatJson)
+	configDir := t.TempDir()
+
+	mockDryRunSetupFlow(helperService, talosService, configDir, constants.ControlplaneConfigFile)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, true)
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", true)
+
+	assert.Nil(t, err)
+	imageService.AssertNotCalled(t, "CreateImage", mock.Anything, mock.Anything)
+	uiService.AssertNotCalled(t, "CreateSelect", "What type of device are you setting up?", mock.Anything)
+	talosService.AssertNotCalled(t, "JoinCluster", mock.Anything, mock.Anything, mock.Anything)
+	talosService.AssertNotCalled(t, "BootstrapCluster", mock.Anything, mock.Anything, mock.Anything)
+	kubeconfigService.AssertNotCalled(t, "Generate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	configService.AssertNotCalled(t, "UpdateBbeClusterName", mock.Anything, mock.Anything)
+
+	entries, _ := os.ReadDir(configDir)
+	assert.Empty(t, entries)
+
+	var plan models.SetupPlan
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Equal(t, chosenIp, plan.Node)
+	assert.Equal(t, "talos-node", plan.Hostname)
+	assert.Equal(t, constants.ControlplaneConfigFile, plan.ConfigFile)
+	assert.True(t, plan.Bootstrap)
+	assert.Len(t, plan.Files, 3)
+	assert.Equal(t, constants.ControlplaneConfigFile, plan.Files[0].File)
+	assert.True(t, plan.Files[0].Generated)
+	assert.Contains(t, plan.Files[0].Diff, "+ address: 5.6.7.8")
+	assert.Contains(t, plan.Files[0].Diff, "+ hostname: talos-node")
+	assert.Empty(t, plan.Files[1].Diff)
+}
+
+func Test_setupCommand_Succeeds_WithDryRunForWorker(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp := initSetupTests()
+	buffer := captureOutput(t, output.FormatJson)
+	configDir := t.TempDir()
+	workerConfig := []byte("version: v1alpha1\naddress: 1.1.1.1\n")
+	os.WriteFile(filepath.Join(configDir, constants.ControlplaneConfigFile), []byte("version: v1alpha1\n"), 0600)
+	os.WriteFile(filepath.Join(configDir, constants.WorkerConfigFile), workerConfig, 0600)
+
+	uiService.On("CreateSelect", "Is this the first node in your cluster?", mock.Anything).Return("No", nil)
+	configService.On("CheckForTalosConfigs", helperService).Return(true)
+
+	mockDryRunSetupFlow(helperService, talosService, configDir, constants.WorkerConfigFile)
+	mockSuccessfulSetupFlow(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, gatewayIp, nodeIp, chosenIp, false)
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", true)
+
+	assert.Nil(t, err)
+	talosService.AssertNotCalled(t, "JoinCluster", mock.Anything, mock.Anything, mock.Anything)
+
+	content, _ := os.ReadFile(filepath.Join(configDir, constants.WorkerConfigFile))
+	assert.Equal(t, workerConfig, content)
+
+	var plan models.SetupPlan
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.False(t, plan.Bootstrap)
+	assert.Equal(t, []models.ConfigFileDiff{
+		{File: constants.WorkerConfigFile, Diff: "  version: v1alpha1\n  address: 1.1.1.1\n+ address: 5.6.7.8\n+ hostname: talos-node"},
+	}, plan.Files)
+}
+
+func Test_setupCommand_Fails_WithDryRunWithoutBbeConfig(t *testing.T) {
+	helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, _, _, _ := initSetupTests()
+
+	configService.On("GetBbeConfig", mock.Anything).Return((*models.BbeConfig)(nil), constants.ConfigNotFoundError)
+
+	err := setupCommand(helperService, dependencyService, talosService, ipFinderService, uiService, configService, imageService, kubeconfigService, "", true)
+
+	assert.ErrorContains(t, err, "a dry run does not create one")
+	assert.Equal(t, clierror.ExitUsage, clierror.ExitCode(err))
+	configService.AssertNotCalled(t, "GenerateBbeConfig", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/kubeconfig_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the configuration and reachability of the active cluster",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		talosService := talos_service.TalosService{}
+		kubeconfigService := kubeconfig_service.KubeconfigService{}
+
+		return statusCommand(helperService, configService, talosService, kubeconfigService)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func statusCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, kubeconfigService interfaces.KubeconfigServiceInterface) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	result := models.StatusResult{
+		Cluster:   bbeConfig.Bbe.Cluster.Name,
+		Context:   bbeConfig.Bbe.Cluster.Context,
+		ConfigDir: helperService.GetConfigDir(),
+		Storage:   bbeConfig.Bbe.Storage.Type,
+		Packages:  len(bbeConfig.Bbe.Packages),
+	}
+	if result.Storage == "" {
+		result.Storage = constants.StorageLocal
+	}
+
+	if _, exists := helperService.CheckIfFileExists(helperService.GetConfigFilePath(constants.ControlplaneConfigFile)); exists {
+		controlPlaneIp, err := talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Unable to read the control plane address: %v", err))
+		}
+		result.ControlPlane = controlPlaneIp
+	}
+
+	kubeconfigPath := kubeconfigService.Path(helperService)
+	if _, exists := helperService.CheckIfFileExists(kubeconfigPath); exists {
+		result.Kubeconfig = kubeconfigPath
+
+		server, err := kubeconfigService.VerifyEndpoint(helperService)
+		if err != nil {
+			logger.Debug(err.Error())
+		}
+		result.ApiServer = server
+		result.ApiReachable = err == nil
+	}
+
+	return output.Print(result, func() string {
+		return formatStatus(result)
+	})
+}
+
+func formatStatus(status models.StatusResult) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	apiServer := valueOrDash(status.ApiServer)
+	if status.ApiServer != "" {
+		reachability := "not reachable"
+		if status.ApiReachable {
+			reachability = "reachable"
+		}
+		apiServer = fmt.Sprintf("%s (%s)", status.ApiServer, reachability)
+	}
+
+	fmt.Fprintf(writer, "Cluster:\t%s\n", status.Cluster)
+	fmt.Fprintf(writer, "Context:\t%s\n", valueOrDash(status.Context))
+	fmt.Fprintf(writer, "Config:\t%s\n", status.ConfigDir)
+	fmt.Fprintf(writer, "Control plane:\t%s\n", valueOrDash(status.ControlPlane))
+	fmt.Fprintf(writer, "Kubeconfig:\t%s\n", valueOrDash(status.Kubeconfig))
+	fmt.Fprintf(writer, "API server:\t%s\n", apiServer)
+	fmt.Fprintf(writer, "Storage:\t%s\n", status.Storage)
+	fmt.Fprintf(writer, "Packages:\t%d\n", status.Packages)
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initStatusCommandTest() (*mocks.MockHelperService, *mocks.MockConfigService, *mocks.MockTalosService, *mocks.MockKubeconfigService) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	talosService := &mocks.MockTalosService{}
+	kubeconfigService := &mocks.MockKubeconfigService{}
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "home"
+	bbeConfig.Bbe.Cluster.Context = "admin@home"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{{Name: "blocky", Version: "0.1.3"}}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+
+	helperService.On("GetConfigDir").Return("/home/user/.bbe/clusters/home")
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, true)
+	helperService.On("CheckIfFileExists", "kubeconfig").Return(nil, true)
+	talosService.On("GetControlPlaneIp", helperService, constants.ControlplaneConfigFile).Return("192.168.1.10", nil)
+	kubeconfigService.On("Path", helperService).Return("kubeconfig")
+
+	return helperService, configService, talosService, kubeconfigService
+}
+
+func Test_statusCommand_Succeeds_WithJsonOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatJson)
+	helperService, configService, talosService, kubeconfigService := initStatusCommandTest()
+	kubeconfigService.On("VerifyEndpoint", helperService).Return("https://192.168.1.10:6443", nil)
+
+	err := statusCommand(helperService, configService, talosService, kubeconfigService)
+
+	assert.Nil(t, err)
+	var result models.StatusResult
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &result))
+	assert.Equal(t, models.StatusResult{
+		Cluster:      "home",
+		Context:      "admin@home",
+		ConfigDir:    "/home/user/.bbe/clusters/home",
+		ControlPlane: "192.168.1.10",
+		Kubeconfig:   "kubeconfig",
+		ApiServer:    "https://192.168.1.10:6443",
+		ApiReachable: true,
+		Storage:      constants.StorageLocal,
+		Packages:     1,
+	}, result)
+}
+
+func Test_statusCommand_Succeeds_WhenApiServerIsNotReachable(t *testing.T) {
+	buffer := captureOutput(t, output.FormatText)
+	helperService, configService, talosService, kubeconfigService := initStatusCommandTest()
+	kubeconfigService.On("VerifyEndpoint", helperService).Return("https://192.168.1.10:6443", errors.New("connection refused"))
+
+	err := statusCommand(helperService, configService, talosService, kubeconfigService)
+
+	assert.Nil(t, err)
+	assert.Contains(t, buffer.String(), "https://192.168.1.10:6443 (not reachable)")
+}
+
+func Test_statusCommand_Fails_WithoutCluster(t *testing.T) {
+	helperService := &mocks.MockHelperService{}
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, constants.ConfigNotFoundError)
+
+	err := statusCommand(helperService, configService, &mocks.MockTalosService{}, &mocks.MockKubeconfigService{})
+
+	assert.ErrorContains(t, err, "No BBE cluster found")
+}
@@ -1,12 +1,15 @@
 package cmd
 
 import (
-	"errors"
 	"fmt"
-	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/config_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helm_service"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helper_service"
@@ -20,7 +23,7 @@ var upgradeCmd = &cobra.Command{
 	Aliases: []string{"u"},
 	Short:   "Upgrade BBE packages",
 	Args:    cobra.ExactArgs(0),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		helperService := helper_service.HelperService{}
 		uiService := ui_service.UiService{}
 		configService := config_service.ConfigService{}
@@ -28,28 +31,62 @@ var upgradeCmd = &cobra.Command{
 		helmService := helm_service.HelmService{}
 
 		uninteractive, _ := cmd.Flags().GetBool("yes")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
 
-		err := upgradeCommand(helperService, uiService, configService, packageService, helmService, uninteractive)
-		if err != nil {
-			logger.Error("", err)
-			os.Exit(1)
-		}
+		return upgradeCommand(helperService, uiService, configService, packageService, helmService, uninteractive, dryRun)
 	},
 }
 
-func upgradeCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, uninteractive bool) error {
-	bbeConfig, err := configService.GetBbeConfig(helperService)
-	if err != nil || bbeConfig.Bbe.Cluster.Name == "" {
-		logger.Info("No BBE cluster found, please run 'bbe setup' to create your cluster")
-		return errors.New("No BBE cluster found, please run 'bbe setup' to create your cluster")
+var upgradePlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show the available package upgrades without applying them",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+		packageService := package_service.PackageService{}
+
+		return upgradePlanCommand(helperService, configService, packageService)
+	},
+}
+
+var upgradePolicyCmd = &cobra.Command{
+	Use:               "policy <package> <patch|minor|major|pinned>",
+	Short:             "Set the upgrade policy of an installed BBE package",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeUpgradePolicy,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		helperService := helper_service.HelperService{}
+		configService := config_service.ConfigService{}
+
+		return upgradePolicyCommand(helperService, configService, args[0], args[1])
+	},
+}
+
+type upgradeCandidate struct {
+	index     int
+	chart     models.ChartEntry
+	installed models.LocalPackage
+	change    string
+	allowed   bool
+}
+
+func upgradeCommand(helperService interfaces.HelperServiceInterface, uiService interfaces.UiServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface, uninteractive bool, dryRun bool) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
 	}
 
 	installedPackages := bbeConfig.Bbe.Packages
-	allPackages, err := packageService.GetAll()
+	allPackages, err := packageService.GetAll(helperService, *bbeConfig)
 	if err != nil {
 		return err
 	}
 
+	if dryRun {
+		return printPackagePlan(upgradeDryRunPlan(findUpgradeCandidates(installedPackages, allPackages), *bbeConfig, packageService, helmService))
+	}
+
 	defer func() {
 		err := configService.UpdateBbePackages(helperService, installedPackages)
 		if err != nil {
@@ -57,41 +94,203 @@ func upgradeCommand(helperService interfaces.HelperServiceInterface, uiService i
 		}
 	}()
 
-	// Go through our currently installed packages and see if a newer version is available
+	candidates := findUpgradeCandidates(installedPackages, allPackages)
+	if len(candidates) == 0 {
+		logger.Info("All packages are up to date")
+		return nil
+	}
+
+	logger.Info(formatUpgradeSummary(upgradePlan(candidates)))
+
+	for _, candidate := range candidates {
+		if !candidate.allowed {
+			continue
+		}
+
+		upgrade := uninteractive
+		if !uninteractive {
+			result, err := uiService.CreateSelect(fmt.Sprintf("Package %s has a %s upgrade from %s to %s available. Do you want to upgrade?", candidate.installed.Name, candidate.change, candidate.installed.Version, candidate.chart.Version), []string{"Yes", "No"})
+			if err != nil {
+				return err
+			}
+
+			upgrade = result == "Yes"
+		}
+
+		if upgrade {
+			err := packageService.UpgradePackage(candidate.chart, *bbeConfig, helmService)
+			if err != nil {
+				return err
+			}
+			installedPackages[candidate.index].Version = candidate.chart.Version
+		}
+	}
+
+	logger.Info("All packages checked")
+
+	return nil
+}
+
+func findUpgradeCandidates(installedPackages []models.LocalPackage, allPackages []models.ChartEntry) []upgradeCandidate {
+	var candidates []upgradeCandidate
 	for i, installedPackage := range installedPackages {
-		logger.Info(fmt.Sprintf("Checking for newer version of package %s...", installedPackage.Name))
 		for _, pkg := range allPackages {
-			if installedPackage.Name == pkg.Name {
-				if installedPackage.Version != pkg.Version {
-					upgrade := uninteractive
-					if !uninteractive {
-						result, err := uiService.CreateSelect(fmt.Sprintf("Package %s has newer version %s available. Do you want to upgrade?", pkg.Name, pkg.Version), []string{"Yes", "No"})
-						if err != nil {
-							return err
-						}
-
-						upgrade = result == "Yes"
-					}
-					if upgrade {
-						err := packageService.UpgradePackage(pkg, *bbeConfig, helmService)
-						if err != nil {
-							return err
-						}
-						installedPackages[i].Version = pkg.Version
-					}
-				}
+			if !package_service.IsPackageChart(pkg, installedPackage) {
+				continue
+			}
+
+			change, err := versioning.ClassifyChange(installedPackage.Version, pkg.Version)
+			if err != nil {
+				logger.Warning(fmt.Sprintf("Unable to compare versions of package %s: %v", installedPackage.Name, err))
 				break
 			}
+
+			if change != versioning.ChangeNone {
+				candidates = append(candidates, upgradeCandidate{
+					index:     i,
+					chart:     package_service.ChartForPackage(pkg, installedPackage),
+					installed: installedPackage,
+					change:    change,
+					allowed:   versioning.IsChangeAllowed(installedPackage.Policy, change),
+				})
+			}
+			break
 		}
 	}
 
-	logger.Info("All packages checked")
+	return candidates
+}
 
-	return nil
+// upgradePlan describes the candidates the way they are shown to the user, before anything is upgraded
+func upgradePlan(candidates []upgradeCandidate) []models.UpgradePlanEntry {
+	plan := []models.UpgradePlanEntry{}
+	for _, candidate := range candidates {
+		policy := candidate.installed.Policy
+		if policy == "" {
+			policy = versioning.PolicyMajor
+		}
+
+		action := "upgrade"
+		if !candidate.allowed {
+			action = "held"
+		}
+
+		plan = append(plan, models.UpgradePlanEntry{
+			Name:      candidate.installed.Name,
+			Current:   candidate.installed.Version,
+			Available: candidate.chart.Version,
+			Change:    candidate.change,
+			Policy:    policy,
+			Action:    action,
+		})
+	}
+
+	return plan
+}
+
+// upgradeDryRunPlan describes the upgrades the policies allow, with the manifest changes helm renders for them
+func upgradeDryRunPlan(candidates []upgradeCandidate, bbeConfig models.BbeConfig, packageService interfaces.PackageServiceInterface, helmService interfaces.HelmServiceInterface) []models.PackagePlanEntry {
+	plan := []models.PackagePlanEntry{}
+	for _, candidate := range candidates {
+		if !candidate.allowed {
+			continue
+		}
+
+		entry := packagePlanEntry(candidate.chart, "upgrade")
+		entry.Current = candidate.installed.Version
+
+		diff, err := packageService.DiffUpgrade(candidate.chart, bbeConfig, helmService)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Unable to render the changes of package %s: %v", candidate.installed.Name, err))
+		}
+		entry.Diff = diff
+
+		plan = append(plan, entry)
+	}
+
+	return plan
+}
+
+func formatUpgradeSummary(plan []models.UpgradePlanEntry) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "PACKAGE\tCURRENT\t\tAVAILABLE\tCHANGE\tPOLICY\tACTION")
+	for _, entry := range plan {
+		fmt.Fprintf(writer, "%s\t%s\t→\t%s\t%s\t%s\t%s\n", entry.Name, entry.Current, entry.Available, entry.Change, entry.Policy, entry.Action)
+	}
+	writer.Flush()
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+func upgradePlanCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageService interfaces.PackageServiceInterface) error {
+	bbeConfig, err := getClusterConfig(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	allPackages, err := packageService.GetAll(helperService, *bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	plan := upgradePlan(findUpgradeCandidates(bbeConfig.Bbe.Packages, allPackages))
+
+	return output.Print(plan, func() string {
+		if len(plan) == 0 {
+			return "All packages are up to date"
+		}
+
+		return formatUpgradeSummary(plan)
+	})
+}
+
+// completeUpgradePolicy completes the installed package and then the policy
+func completeUpgradePolicy(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return completeInstalledPackages(cmd, args, toComplete)
+	case 1:
+		return versioning.Policies, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func upgradePolicyCommand(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, packageName string, policy string) error {
+	if !versioning.IsValidPolicy(policy) {
+		return fmt.Errorf("Invalid upgrade policy `%s`, expected one of: %s", policy, strings.Join(versioning.Policies, ", "))
+	}
+
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return fmt.Errorf("No BBE configuration found, please run 'bbe config' first: %w", err)
+	}
+
+	packages := bbeConfig.Bbe.Packages
+	for i, pkg := range packages {
+		if pkg.Name == packageName {
+			packages[i].Policy = policy
+
+			err := configService.UpdateBbePackages(helperService, packages)
+			if err != nil {
+				return fmt.Errorf("Failed to update BBE configuration: %w", err)
+			}
+
+			logger.Infof("Upgrade policy of package %s set to %s", packageName, policy)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Package `%s` is not installed", packageName)
 }
 
 func init() {
 	rootCmd.AddCommand(upgradeCmd)
+	upgradeCmd.AddCommand(upgradePlanCmd)
+	upgradeCmd.AddCommand(upgradePolicyCmd)
 
 	upgradeCmd.PersistentFlags().BoolP("yes", "y", false, "Automatically accept yes/no questions without input.")
+	upgradeCmd.Flags().Bool("dry-run", false, "Show the packages that would be upgraded and their manifest changes without changing anything")
 }
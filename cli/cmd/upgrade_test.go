@@ -1,9 +1,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/stretchr/testify/assert"
@@ -15,7 +17,7 @@ func Test_upgradeCommand_Succeeds_WithNothingToDo(t *testing.T) {
 
 	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false, false)
 
 	assert.Nil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
@@ -47,7 +49,7 @@ func Test_upgradeCommand_Fails_With_No_Cluster_name(t *testing.T) {
 	}
 	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "No BBE cluster found, please run 'bbe setup' to create your cluster")
@@ -72,7 +74,7 @@ func Test_upgradeCommand_Fails_With_Error_Getting_BBE_Config(t *testing.T) {
 	fakeError := errors.New("Fake GetBbeConfig error")
 	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, fakeError)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "No BBE cluster found, please run 'bbe setup' to create your cluster")
@@ -100,7 +102,7 @@ func Test_upgradeCommand_Fails_WhenCreateSelect(t *testing.T) {
 
 	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false, false)
 
 	assert.Error(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
@@ -131,7 +133,7 @@ func Test_upgradeCommand_Succeeds_WithInteractiveUpgrade(t *testing.T) {
 
 	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false, false)
 
 	assert.Nil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
@@ -169,7 +171,7 @@ func Test_upgradeCommand_Succeeds_WithNonInteractiveUpgrade(t *testing.T) {
 
 	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
 
 	assert.Nil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
@@ -210,7 +212,7 @@ func Test_upgradeCommand_Fails_Prtial_UpdatesBbeConfig(t *testing.T) {
 
 	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
 
-	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true)
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
 
 	assert.NotNil(t, err)
 	configService.AssertNumberOfCalls(t, "GetBbeConfig", 1)
@@ -263,3 +265,292 @@ func mockSuccessfulUpgradeFlow(_ *mocks.MockHelperService, uiService *mocks.Mock
 	}, nil)
 	packageService.On("UpgradePackage", mock.Anything).Return(nil)
 }
+
+func Test_upgradeCommand_Succeeds_HoldsUpgradesOutsidePolicy(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initUpgradeCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{
+			Name:    "package_one",
+			Version: "1.0.0",
+			Policy:  "minor",
+		},
+		{
+			Name:    "package_two",
+			Version: "3.0.0",
+			Policy:  "pinned",
+		},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{
+			Name:    "package_one",
+			Version: "2.0.0",
+		},
+		{
+			Name:    "package_two",
+			Version: "3.0.1",
+		},
+	}, nil)
+
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 0)
+}
+
+func Test_upgradeCommand_Succeeds_WithReleasesOfTheSameChart(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initUpgradeCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "postgres", Version: "1.0.0"},
+		{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Version: "1.0.0"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "postgres", Version: "1.1.0"},
+	}, nil)
+	packageService.On("UpgradePackage", mock.Anything).Return(nil)
+
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 2)
+	packageService.AssertCalled(t, "UpgradePackage", models.ChartEntry{Name: "postgres", Version: "1.1.0"})
+	packageService.AssertCalled(t, "UpgradePackage", models.ChartEntry{Name: "postgres", Version: "1.1.0", Release: "postgres-analytics", Namespace: "analytics"})
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "postgres", Version: "1.1.0"},
+		{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Version: "1.1.0"},
+	})
+}
+
+func Test_upgradeCommand_Succeeds_WithChartOfferedByTwoLibraries(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initUpgradeCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "blocky", Version: "1.0.0", Library: "internal"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "blocky", Version: "3.0.0", Library: "bbe"},
+		{Name: "blocky", Version: "1.1.0", Library: "internal"},
+	}, nil)
+	packageService.On("UpgradePackage", mock.Anything).Return(nil)
+
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, false)
+
+	assert.Nil(t, err)
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 1)
+	packageService.AssertCalled(t, "UpgradePackage", models.ChartEntry{Name: "blocky", Version: "1.1.0", Library: "internal"})
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{Name: "blocky", Version: "1.1.0", Library: "internal"},
+	})
+}
+
+func Test_upgradeCommand_Succeeds_IgnoresDowngrades(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initUpgradeCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{
+			Name:    "package_one",
+			Version: "0.10.0",
+		},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{
+			Name:    "package_one",
+			Version: "0.9.0",
+		},
+	}, nil)
+
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false, false)
+
+	assert.Nil(t, err)
+	uiService.AssertNumberOfCalls(t, "CreateSelect", 0)
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 0)
+}
+
+func Test_upgradePolicyCommand_Succeeds(t *testing.T) {
+	helperService, _, configService, _, _ := initUpgradeCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{
+			Name:    "package_one",
+			Version: "1.0.0",
+		},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	configService.On("UpdateBbePackages", mock.Anything, mock.Anything).Return(nil)
+
+	err := upgradePolicyCommand(helperService, configService, "package_one", "patch")
+
+	assert.Nil(t, err)
+	configService.AssertCalled(t, "UpdateBbePackages", mock.Anything, []models.LocalPackage{
+		{
+			Name:    "package_one",
+			Version: "1.0.0",
+			Policy:  "patch",
+		},
+	})
+}
+
+func Test_upgradePolicyCommand_Fails_WithInvalidPolicy(t *testing.T) {
+	helperService, _, configService, _, _ := initUpgradeCommand()
+
+	err := upgradePolicyCommand(helperService, configService, "package_one", "sometimes")
+
+	assert.Error(t, err)
+	configService.AssertNumberOfCalls(t, "GetBbeConfig", 0)
+}
+
+func Test_upgradePolicyCommand_Fails_WhenPackageNotInstalled(t *testing.T) {
+	helperService, _, configService, _, _ := initUpgradeCommand()
+
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, nil)
+
+	err := upgradePolicyCommand(helperService, configService, "package_one", "minor")
+
+	assert.Error(t, err)
+	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 0)
+}
+
+func Test_upgradePlanCommand_Succeeds_WithJsonOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatJson)
+	helperService, _, configService, packageService, _ := initUpgradeCommand()
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "package_one", Version: "1.0.0"},
+		{Name: "package_two", Version: "1.0.0", Policy: "patch"},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("GetAll").Return([]models.ChartEntry{
+		{Name: "package_one", Version: "1.1.0"},
+		{Name: "package_two", Version: "2.0.0"},
+	}, nil)
+
+	err := upgradePlanCommand(helperService, configService, packageService)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[
+		{"name": "package_one", "current": "1.0.0", "available": "1.1.0", "change": "minor", "policy": "major", "action": "upgrade"},
+		{"name": "package_two", "current": "1.0.0", "available": "2.0.0", "change": "major", "policy": "patch", "action": "held"}
+	]`, buffer.String())
+	packageService.AssertNumberOfCalls(t, "UpgradePackage", 0)
+	configService.AssertNumberOfCalls(t, "UpdateBbePackages", 0)
+}
+
+func Test_upgradePlanCommand_Succeeds_WithEmptyPlan(t *testing.T) {
+	buffer := captureOutput(t, output.FormatJson)
+	helperService, uiService, configService, packageService, _ := initUpgradeCommand()
+	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
+
+	err := upgradePlanCommand(helperService, configService, packageService)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[]`, buffer.String())
+}
+
+func Test_upgradePlanCommand_Fails_WithoutCluster(t *testing.T) {
+	helperService, _, configService, packageService, _ := initUpgradeCommand()
+	configService.On("GetBbeConfig", mock.Anything).Return(&models.BbeConfig{}, errors.New("not found"))
+
+	err := upgradePlanCommand(helperService, configService, packageService)
+
+	assert.ErrorContains(t, err, "No BBE cluster found")
+}
+
+func Test_upgradeCommand_Succeeds_WithDryRun(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initUpgradeCommand()
+	buffer := captureOutput(t, output.FormatJson)
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{
+			Name:    "package_one",
+			Version: "1.0.0",
+		},
+		{
+			Name:    "package_two",
+			Version: "1.0.0",
+			Policy:  "minor",
+		},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("DiffUpgrade", mock.Anything).Return("- image: one:1.0.0\n+ image: one:2.0.0\n", nil)
+
+	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
+
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, false, true)
+
+	assert.Nil(t, err)
+	uiService.AssertNumberOfCalls(t, "CreateSelect", 0)
+	packageService.AssertNotCalled(t, "UpgradePackage", mock.Anything)
+	configService.AssertNotCalled(t, "UpdateBbePackages", mock.Anything, mock.Anything)
+
+	var plan []models.PackagePlanEntry
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Equal(t, []models.PackagePlanEntry{
+		{Name: "package_one", Version: "2.0.0", Current: "1.0.0", Library: "bbe", Namespace: "package_one", Action: "upgrade", Diff: "- image: one:1.0.0\n+ image: one:2.0.0\n"},
+	}, plan)
+}
+
+func Test_upgradeCommand_Succeeds_WithDryRunWhenDiffFails(t *testing.T) {
+	helperService, uiService, configService, packageService, helmService := initUpgradeCommand()
+	buffer := captureOutput(t, output.FormatJson)
+
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Name = "test"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{
+			Name:    "package_one",
+			Version: "1.0.0",
+		},
+	}
+	configService.On("GetBbeConfig", mock.Anything).Return(bbeConfig, nil)
+	packageService.On("DiffUpgrade", mock.Anything).Return("", errors.New("helm not found"))
+
+	mockSuccessfulUpgradeFlow(helperService, uiService, configService, packageService)
+
+	err := upgradeCommand(helperService, uiService, configService, packageService, helmService, true, true)
+
+	assert.Nil(t, err)
+	packageService.AssertNotCalled(t, "UpgradePackage", mock.Anything)
+
+	var plan []models.PackagePlanEntry
+	assert.Nil(t, json.Unmarshal(buffer.Bytes(), &plan))
+	assert.Len(t, plan, 1)
+	assert.Empty(t, plan[0].Diff)
+}
+
+func Test_formatPackagePlan_Succeeds_WithUpgradeDiff(t *testing.T) {
+	plan := []models.PackagePlanEntry{
+		{Name: "blocky", Version: "0.1.3", Current: "0.1.2", Library: "bbe", Namespace: "blocky", Action: "upgrade", Diff: "- image: blocky:0.1.2\n+ image: blocky:0.1.3\n"},
+	}
+
+	formatted := formatPackagePlan(plan)
+
+	assert.Contains(t, formatted, "0.1.2 → 0.1.3")
+	assert.Contains(t, formatted, "NAMESPACE")
+	assert.Contains(t, formatted, "blocky:\n- image: blocky:0.1.2\n+ image: blocky:0.1.3")
+}
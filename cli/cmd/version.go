@@ -1,8 +1,13 @@
 package cmd
 
 import (
+	"fmt"
+	"runtime"
+
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/spf13/cobra"
 )
 
@@ -11,12 +16,21 @@ var versionCmd = &cobra.Command{
 	Aliases: []string{"v"},
 	Short:   "Show bbe version",
 	Args:    cobra.ExactArgs(0),
-	Run:     versionCommand,
+	RunE:    versionCommand,
 }
 
-func versionCommand(cmd *cobra.Command, args []string) {
+func versionCommand(cmd *cobra.Command, args []string) error {
 	logger.Debug("Showing version")
-	logger.Infof("Version: %s", constants.Version)
+
+	result := models.VersionResult{
+		Version: constants.Version,
+		Os:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+	}
+
+	return output.Print(result, func() string {
+		return fmt.Sprintf("Version: %s", result.Version)
+	})
 }
 
 func init() {
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"testing"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_versionCommand_Succeeds_WithoutArgs(t *testing.T) {
@@ -27,3 +32,15 @@ func Test_versionCommand_Succeeds_WithArgs(t *testing.T) {
 	}()
 	versionCommand(cmd, args)
 }
+
+func Test_versionCommand_Succeeds_WithJsonOutput(t *testing.T) {
+	buffer := captureOutput(t, output.FormatJson)
+
+	versionCommand(&cobra.Command{}, []string{})
+
+	var result models.VersionResult
+	err := json.Unmarshal(buffer.Bytes(), &result)
+	assert.Nil(t, err)
+	assert.Equal(t, constants.Version, result.Version)
+	assert.NotEmpty(t, result.Os)
+}
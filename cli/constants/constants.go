@@ -4,9 +4,102 @@ import "errors"
 
 var Version = "development"
 var ConfigExistsError = errors.New("Config already exists")
+var LibraryVerificationError = errors.New("Library signature verification failed")
+var StorageObjectNotFoundError = errors.New("Object not found in storage")
+var EncryptionKeyMissingError = errors.New("Encryption key not found")
+var ConfigNotFoundError = errors.New("Config file not found")
+var ConfigTooNewError = errors.New("Config file was written by a newer version of bbe")
+var BackupIntegrityError = errors.New("Backup integrity check failed")
+var UserAbortError = errors.New("Aborted by the user")
 
 var ControlplaneConfigFile = "controlplane.yaml"
 var WorkerConfigFile = "worker.yaml"
 var TalosConfigFile = "talosconfig"
 var BbeConfigFile = "bbe.yaml"
+
+// Schema version of bbe.yaml, files without an apiVersion are version 0
+var BbeConfigApiVersionPrefix = "bbe-quest/v"
+var BbeConfigVersion = 1
+var BbeConfigBackupFile = "bbe.yaml.v%d.bak"
+var DefaultLibraryName = "bbe"
+var LibraryCacheFile = "cache/library.yaml"
+var LibraryMirrorFile = "library.yaml"
+var LibrarySignatureExtension = ".sig"
 var BbeLibraryUrl = "https://raw.githubusercontent.com/Brains-Beyond-Expectations/bbe-charts/main/library.yaml"
+
+// Public keys trusted to sign the official library, base64 encoded ed25519 keys, an unsigned official library needs bbe.library.allow_unsigned
+var TrustedLibraryKeys = []string{
+	"DI14Dyf2MIdOpZdk/7+O7itLhJzaRcONGOY5iizbx3E=",
+}
+
+var HelmStatusDeployed = "deployed"
+
+// Kinds of drift between bbe.yaml and the releases in the cluster
+var DriftMissing = "missing"
+var DriftVersion = "version"
+var DriftStatus = "status"
+var DriftUnmanaged = "unmanaged"
+
+// Supported config storage types
+var StorageLocal = "local"
+var StorageAws = "aws"
+var StorageS3 = "s3"
+var StorageGit = "git"
+var StorageDirectory = "directory"
+
+var DefaultAwsRegion = "eu-west-1"
+var DefaultAwsBucketPrefix = "bbe-config"
+
+var GitStorageCacheDir = "cache/storage-git"
+var SyncStateFile = "sync-state.yaml"
+var DefaultGitStorageBranch = "main"
+
+// Every cluster keeps its configuration in its own directory below ClustersDir
+var ClustersDir = "clusters"
+var ClusterRegistryFile = "clusters.yaml"
+var DefaultClusterName = "default"
+var ClusterEnvVar = "BBE_CLUSTER"
+var ClusterNotFoundError = errors.New("Cluster not found")
+
+// Private age key used to encrypt Talos secrets, never synced to remote storage
+var EncryptionKeyFile = "keys/storage.key"
+
+// Every cluster keeps an admin kubeconfig in its config directory, merging it into the user's kubeconfig is optional
+var KubeConfigEnvVar = "KUBECONFIG"
+var KubeConfigConflictError = errors.New("Kubeconfig entries with the same name already exist")
+var KubeConfigMergeOverwrite = "overwrite"
+var KubeConfigMergeRename = "rename"
+
+// Backups bundle the synced config files with a kubeconfig, the node inventory and the values of every package
+var KubeConfigFile = "kubeconfig"
+var NodeInventoryFile = "nodes.yaml"
+var PackageValuesDir = "values"
+var BackupManifestFile = "manifest.yaml"
+var BackupFormatVersion = 1
+var BackupPassphraseEnvVar = "BBE_BACKUP_PASSPHRASE"
+
+// Config files containing cluster secrets, encrypted before upload when encryption is enabled
+var SecretConfigFiles = []string{TalosConfigFile, ControlplaneConfigFile, WorkerConfigFile}
+
+// etcd snapshots are stored below EtcdSnapshotDir in the configured storage, or below LocalSnapshotDir in the config directory for local storage.
+// They hold every Kubernetes secret and are encrypted like the Talos configs.
+var EtcdSnapshotDir = "etcd/"
+var LocalSnapshotDir = "snapshots"
+var EtcdSnapshotExtension = ".db"
+var DefaultEtcdSnapshotKeep = 7
+
+// Every run writes a log file with all messages and subprocess output below LogsDir in the base config directory
+var LogsDir = "logs"
+var LogFileKeep = 20
+
+// Outcomes of the checks run by bbe doctor
+var DoctorPass = "pass"
+var DoctorWarn = "warn"
+var DoctorFail = "fail"
+
+// Releases of bbe are published on GitHub with a tarball and a sha256 checksum per platform, BBE_RELEASES_URL points self-update at a mirror
+var BbeReleasesUrl = "https://api.github.com/repos/Brains-Beyond-Expectations/bbe-quest/releases"
+var ReleasesUrlEnvVar = "BBE_RELEASES_URL"
+var ReleaseChecksumExtension = ".sha256"
+var ReleaseNotFoundError = errors.New("Release not found")
+var ReleaseChecksumError = errors.New("Release checksum verification failed")
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+type BackupServiceInterface interface {
+	Create(helperService HelperServiceInterface, configService ConfigServiceInterface, talosService TalosServiceInterface, helmService HelmServiceInterface) (*models.Backup, error)
+	Write(backup *models.Backup, passphrase string, output string) error
+	Read(input string, passphrase string) (*models.Backup, error)
+	Restore(helperService HelperServiceInterface, backup *models.Backup) error
+}
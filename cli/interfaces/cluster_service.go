@@ -0,0 +1,10 @@
+package interfaces
+
+type ClusterServiceInterface interface {
+	List(helperService HelperServiceInterface) ([]string, error)
+	Exists(helperService HelperServiceInterface, name string) bool
+	Create(helperService HelperServiceInterface, name string) error
+	Use(helperService HelperServiceInterface, name string) error
+	Delete(helperService HelperServiceInterface, name string) error
+	MigrateLegacyConfig(helperService HelperServiceInterface) error
+}
@@ -6,11 +6,25 @@ import (
 
 type ConfigServiceInterface interface {
 	GetBbeConfig(helperService HelperServiceInterface) (*models.BbeConfig, error)
-	GenerateBbeConfig(helperService HelperServiceInterface, storage string) error
+	ValidateBbeConfig(helperService HelperServiceInterface) error
+	GetBbeValue(helperService HelperServiceInterface, key string) (string, error)
+	SetBbeValue(helperService HelperServiceInterface, key string, value string) error
+	MigrateStorage(helperService HelperServiceInterface, bbeConfig *models.BbeConfig, storage models.StorageConfig) error
+	GenerateBbeConfig(helperService HelperServiceInterface, storage models.StorageConfig) error
 	UpdateBbeClusterName(helperService HelperServiceInterface, clusterName string) error
+	UpdateBbeClusterContext(helperService HelperServiceInterface, context string) error
 	UpdateBbeStorageType(helperService HelperServiceInterface, storageType string) error
 	UpdateBbeAwsBucketName(helperService HelperServiceInterface, bucketName string) error
 	UpdateBbePackages(helperService HelperServiceInterface, packages []models.LocalPackage) error
+	UpdateBbeLibraries(helperService HelperServiceInterface, libraries []models.LibrarySource) error
 	CheckForTalosConfigs(helperService HelperServiceInterface) bool
-	SyncConfigsWithAws(helperService HelperServiceInterface, bbeConfig *models.BbeConfig) error
+	UpdateBbeStorage(helperService HelperServiceInterface, storage models.StorageConfig) error
+	SyncConfigs(helperService HelperServiceInterface, bbeConfig *models.BbeConfig) error
+	EnableEncryption(helperService HelperServiceInterface, bbeConfig *models.BbeConfig) error
+	RotateEncryptionKey(helperService HelperServiceInterface, bbeConfig *models.BbeConfig) error
+	ResolveConflict(helperService HelperServiceInterface, bbeConfig *models.BbeConfig, conflict models.SyncConflict, content []byte) error
+	ConfigHistory(helperService HelperServiceInterface, bbeConfig *models.BbeConfig, name string) ([]models.StorageObject, error)
+	RestoreConfig(helperService HelperServiceInterface, bbeConfig *models.BbeConfig, name string, version string) error
+	SnapshotBackend(helperService HelperServiceInterface, bbeConfig *models.BbeConfig) (StorageBackend, error)
+	CheckStorage(helperService HelperServiceInterface, bbeConfig *models.BbeConfig) error
 }
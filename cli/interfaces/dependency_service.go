@@ -1,5 +1,8 @@
 package interfaces
 
+import "github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+
 type DependencyServiceInterface interface {
 	VerifyDependencies() bool
+	GetToolVersion(name string) (models.ToolVersion, error)
 }
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+type EtcdServiceInterface interface {
+	Snapshot(helperService HelperServiceInterface, configService ConfigServiceInterface, talosService TalosServiceInterface) (*models.StorageObject, error)
+	List(helperService HelperServiceInterface, configService ConfigServiceInterface) ([]models.StorageObject, error)
+	Prune(helperService HelperServiceInterface, configService ConfigServiceInterface) ([]models.StorageObject, error)
+	Restore(helperService HelperServiceInterface, configService ConfigServiceInterface, talosService TalosServiceInterface, name string, nodeIp string) error
+}
@@ -1,9 +1,18 @@
 package interfaces
 
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
 type HelmServiceInterface interface {
 	AddRepo(repoName string, repoUrl string) error
 	InstallChart(pkgName string, chartName string, repoName string, version string, namespace string, context string) error
 	UpgradeChart(pkgName string, chartName string, repoName string, version string, namespace string, context string) error
 	UninstallChart(pkgName string, namespace string, context string) error
 	IsPackageInstalled(pkgName string, namespace string, context string) bool
+	PullChart(chartName string, repoName string, version string, destination string) (string, error)
+	ListReleases(context string) ([]models.HelmRelease, error)
+	GetValues(pkgName string, namespace string, context string) ([]byte, error)
+	GetManifest(pkgName string, namespace string, context string) ([]byte, error)
+	TemplateChart(pkgName string, chartName string, repoUrl string, version string, namespace string, values []byte) ([]byte, error)
 }
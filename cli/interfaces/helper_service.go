@@ -11,6 +11,8 @@ type HelperServiceInterface interface {
 	DeleteEmptyStrings(s []string) []string
 	IsWsl() bool
 	IsValidIp(ip string) bool
+	GetBaseDir() string
+	GetClusterName() string
 	GetConfigDir() string
 	GetConfigFilePath(name string) string
 }
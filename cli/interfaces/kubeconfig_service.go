@@ -0,0 +1,8 @@
+package interfaces
+
+type KubeconfigServiceInterface interface {
+	Generate(helperService HelperServiceInterface, configService ConfigServiceInterface, talosService TalosServiceInterface, controlPlaneIp string) (string, error)
+	Path(helperService HelperServiceInterface) string
+	VerifyEndpoint(helperService HelperServiceInterface) (string, error)
+	Merge(helperService HelperServiceInterface, target string, strategy string) (string, error)
+}
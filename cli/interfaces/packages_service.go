@@ -5,8 +5,12 @@ import (
 )
 
 type PackageServiceInterface interface {
-	GetAll() ([]models.ChartEntry, error)
+	GetAll(helperService HelperServiceInterface, bbeConfig models.BbeConfig) ([]models.ChartEntry, error)
+	MirrorLibrary(helperService HelperServiceInterface, bbeConfig models.BbeConfig, helmService HelmServiceInterface, destination string) error
 	InstallPackage(chart models.ChartEntry, bbeConfig models.BbeConfig, helmService HelmServiceInterface) error
 	UpgradePackage(chart models.ChartEntry, bbeConfig models.BbeConfig, helmService HelmServiceInterface) error
+	DiffUpgrade(chart models.ChartEntry, bbeConfig models.BbeConfig, helmService HelmServiceInterface) (string, error)
 	UninstallPackage(chart models.LocalPackage, bbeConfig models.BbeConfig, helmService HelmServiceInterface) error
+	DetectDrift(bbeConfig models.BbeConfig, allPackages []models.ChartEntry, helmService HelmServiceInterface) ([]models.PackageDrift, error)
+	CheckLibrary(bbeConfig models.BbeConfig, library models.LibrarySource) error
 }
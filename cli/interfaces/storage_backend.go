@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+type StorageBackend interface {
+	Get(name string) (*models.StorageObject, error)
+	Put(name string, content []byte) error
+	List() ([]models.StorageObject, error)
+	Version(name string) (*models.StorageObject, error)
+	Versions(name string) ([]models.StorageObject, error) // Newest first
+	GetVersion(name string, version string) (*models.StorageObject, error)
+	Delete(name string) error
+}
@@ -1,11 +1,14 @@
 package interfaces
 
+import "github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+
 type TalosServiceInterface interface {
 	Ping(nodeIp string) bool
 	GenerateConfig(helperService HelperServiceInterface, controlPlaneIp string, clusterName string) error
 	JoinCluster(helperService HelperServiceInterface, nodeIp string, nodeConfigFile string) error
 	BootstrapCluster(helperService HelperServiceInterface, nodeIp string, controlPlaneIp string) error
 	VerifyNodeHealth(helperService HelperServiceInterface, nodeIp string, controlPlaneIp string) error
+	CheckHealth(helperService HelperServiceInterface, controlPlaneIp string) error
 	GetDisks(helperService HelperServiceInterface, nodeIp string) ([]string, error)
 	GetNetworkInterface(helperService HelperServiceInterface, nodeIp string) (string, error)
 	ModifyNetworkInterface(helperService HelperServiceInterface, configFile string, networkInterfaceName string) error
@@ -15,5 +18,9 @@ type TalosServiceInterface interface {
 	ModifyConfigDisk(helperService HelperServiceInterface, configFile string, disk string) error
 	ModifySchedulingOnControlPlane(helperService HelperServiceInterface, allowScheduling bool) error
 	GetControlPlaneIp(helperService HelperServiceInterface, configFile string) (string, error)
-	DownloadKubeConfig(helperService HelperServiceInterface, nodeIp string, controlPlaneIp string) error
+	EtcdSnapshot(helperService HelperServiceInterface, controlPlaneIp string, destination string) error
+	RecoverCluster(helperService HelperServiceInterface, nodeIp string, controlPlaneIp string, snapshotPath string) error
+	ExportKubeConfig(helperService HelperServiceInterface, controlPlaneIp string, destination string) error
+	GetMembers(helperService HelperServiceInterface, controlPlaneIp string) ([]models.ClusterMember, error)
+	GetNodeInventory(helperService HelperServiceInterface) (*models.NodeInventory, error)
 }
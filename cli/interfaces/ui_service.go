@@ -1,7 +1,11 @@
 package interfaces
 
+import "github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+
 type UiServiceInterface interface {
 	CreateSelect(title string, options []string) (string, error)
 	CreateInput(title string, suggestion string) (string, error)
+	CreatePasswordInput(title string) (string, error)
 	CreateMultiChoose(title string, options []string, defaultIndex []int) ([]string, error)
+	BrowsePackages(title string, listings []models.PackageListing) ([]string, error)
 }
@@ -0,0 +1,8 @@
+package interfaces
+
+import "github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+
+type UpdateServiceInterface interface {
+	FindRelease(version string) (*models.Release, error)
+	Install(release *models.Release, executable string) error
+}
@@ -0,0 +1,116 @@
+package clierror
+
+import (
+	"errors"
+	"net"
+	"os/exec"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+)
+
+// Exit codes of bbe, documented in the README
+const (
+	ExitOk                = 0
+	ExitFailure           = 1
+	ExitUsage             = 2
+	ExitMissingDependency = 3
+	ExitNetwork           = 4
+	ExitNodeUnreachable   = 5
+	ExitConfigInvalid     = 6
+	ExitHelm              = 7
+	ExitUserAbort         = 130
+)
+
+type Kind string
+
+const (
+	KindUsage             Kind = "usage"
+	KindUserAbort         Kind = "user_abort"
+	KindMissingDependency Kind = "missing_dependency"
+	KindNetwork           Kind = "network"
+	KindNodeUnreachable   Kind = "node_unreachable"
+	KindConfigInvalid     Kind = "config_invalid"
+	KindHelm              Kind = "helm"
+)
+
+var exitCodes = map[Kind]int{
+	KindUsage:             ExitUsage,
+	KindUserAbort:         ExitUserAbort,
+	KindMissingDependency: ExitMissingDependency,
+	KindNetwork:           ExitNetwork,
+	KindNodeUnreachable:   ExitNodeUnreachable,
+	KindConfigInvalid:     ExitConfigInvalid,
+	KindHelm:              ExitHelm,
+}
+
+// Error classifies a failure and carries a suggested fix for the user, its message is the message of the wrapped error
+type Error struct {
+	Kind Kind
+	Hint string
+	Err  error
+}
+
+func New(kind Kind, err error, hint string) *Error {
+	return &Error{Kind: kind, Hint: hint, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the outermost classified error in the chain of err, or classifies well known causes such as missing
+// executables, network failures and invalid configuration. It returns nil for errors it knows nothing about.
+func Classify(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, constants.UserAbortError):
+		return New(KindUserAbort, err, "")
+	case errors.Is(err, exec.ErrNotFound):
+		return New(KindMissingDependency, err, "Install the missing program and make sure it is on your PATH")
+	case errors.Is(err, constants.ConfigTooNewError):
+		return New(KindConfigInvalid, err, "Run 'bbe self-update' to upgrade bbe to the version that wrote bbe.yaml")
+	case errors.Is(err, constants.ConfigNotFoundError):
+		return New(KindConfigInvalid, err, "Run 'bbe setup' or 'bbe config' to create the configuration")
+	case errors.As(err, &netErr):
+		return New(KindNetwork, err, "Check your network connection and try again")
+	}
+
+	return nil
+}
+
+// ExitCode maps err to one of the documented exit codes
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOk
+	}
+
+	if classified := Classify(err); classified != nil {
+		if code, found := exitCodes[classified.Kind]; found {
+			return code
+		}
+	}
+
+	return ExitFailure
+}
+
+// Hint returns the suggested fix for err, if any
+func Hint(err error) string {
+	if classified := Classify(err); classified != nil {
+		return classified.Hint
+	}
+
+	return ""
+}
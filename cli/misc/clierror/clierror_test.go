@@ -0,0 +1,57 @@
+package clierror
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExitCode_Succeeds_WithoutError(t *testing.T) {
+	assert.Equal(t, ExitOk, ExitCode(nil))
+}
+
+func Test_ExitCode_Succeeds_WithUnclassifiedError(t *testing.T) {
+	assert.Equal(t, ExitFailure, ExitCode(errors.New("Something went wrong")))
+}
+
+func Test_ExitCode_Succeeds_WithWrappedClassifiedError(t *testing.T) {
+	err := fmt.Errorf("Error while installing: %w", New(KindHelm, errors.New("Failed to install helm package `grafana`"), "Check helm"))
+
+	assert.Equal(t, ExitHelm, ExitCode(err))
+	assert.Equal(t, "Check helm", Hint(err))
+}
+
+func Test_ExitCode_Succeeds_WithKnownCauses(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{fmt.Errorf("Prompt failed: %w", constants.UserAbortError), ExitUserAbort},
+		{&exec.Error{Name: "talosctl", Err: exec.ErrNotFound}, ExitMissingDependency},
+		{fmt.Errorf("%w: bbe.yaml has version 9", constants.ConfigTooNewError), ExitConfigInvalid},
+		{constants.ConfigNotFoundError, ExitConfigInvalid},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ExitNetwork},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.code, ExitCode(test.err), test.err.Error())
+	}
+}
+
+func Test_Error_Succeeds_KeepsTheMessageOfTheCause(t *testing.T) {
+	cause := errors.New("No node found")
+	err := New(KindNodeUnreachable, cause, "Boot the node")
+
+	assert.Equal(t, "No node found", err.Error())
+	assert.ErrorIs(t, err, cause)
+}
+
+func Test_Hint_Succeeds_WithoutHint(t *testing.T) {
+	assert.Equal(t, "", Hint(errors.New("Something went wrong")))
+	assert.Equal(t, "", Hint(constants.UserAbortError))
+}
@@ -0,0 +1,132 @@
+package encryption
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+var NoMatchingKeyError = errors.New("Content was not encrypted for any of the available keys")
+var WrongPassphraseError = errors.New("Wrong passphrase")
+
+var header = []byte("age-encryption.org/v1")
+
+// Cost of deriving the key from a passphrase, age's default of 18 takes about a second
+var passphraseWorkFactor = 18
+
+// GenerateKey returns a new age identity and its public recipient
+func GenerateKey() (string, string, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", err
+	}
+
+	return identity.String(), identity.Recipient().String(), nil
+}
+
+// IsEncrypted reports whether content starts with an age header
+func IsEncrypted(content []byte) bool {
+	return bytes.HasPrefix(content, header)
+}
+
+// ValidateRecipient checks that recipient is an age public key
+func ValidateRecipient(recipient string) error {
+	_, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+	return err
+}
+
+// Encrypt encrypts content for a single age recipient
+func Encrypt(content []byte, recipient string) ([]byte, error) {
+	parsedRecipient, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encryption recipient: %w", err)
+	}
+
+	return encrypt(content, parsedRecipient)
+}
+
+// EncryptWithPassphrase encrypts content so it can be decrypted with the passphrase alone, e.g. on another machine
+func EncryptWithPassphrase(content []byte, passphrase string) ([]byte, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	recipient.SetWorkFactor(passphraseWorkFactor)
+
+	return encrypt(content, recipient)
+}
+
+// DecryptWithPassphrase decrypts content encrypted with EncryptWithPassphrase
+func DecryptWithPassphrase(content []byte, passphrase string) ([]byte, error) {
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(content), identity)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, WrongPassphraseError
+		}
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+func encrypt(content []byte, recipient age.Recipient) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := age.Encrypt(&buffer, recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decrypt decrypts content with any of the identities in an age identity file
+func Decrypt(content []byte, identities string) ([]byte, error) {
+	parsedIdentities, err := age.ParseIdentities(strings.NewReader(identities))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encryption key: %w", err)
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(content), parsedIdentities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, NoMatchingKeyError
+		}
+		return nil, err
+	}
+
+	return io.ReadAll(reader)
+}
+
+// Recipient returns the public recipient of the first identity in an age identity file
+func Recipient(identities string) (string, error) {
+	parsedIdentities, err := age.ParseIdentities(strings.NewReader(identities))
+	if err != nil {
+		return "", fmt.Errorf("Invalid encryption key: %w", err)
+	}
+
+	identity, ok := parsedIdentities[0].(*age.X25519Identity)
+	if !ok {
+		return "", errors.New("Invalid encryption key: only X25519 keys are supported")
+	}
+
+	return identity.Recipient().String(), nil
+}
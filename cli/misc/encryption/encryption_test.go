@@ -0,0 +1,95 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Encrypt_Succeeds_RoundTrip(t *testing.T) {
+	identity, recipient, err := GenerateKey()
+	assert.NoError(t, err)
+
+	encrypted, err := Encrypt([]byte("secret"), recipient)
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+	assert.NotContains(t, string(encrypted), "secret")
+
+	decrypted, err := Decrypt(encrypted, identity)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), decrypted)
+}
+
+func Test_Decrypt_Succeeds_WithAnyIdentityInFile(t *testing.T) {
+	oldIdentity, oldRecipient, err := GenerateKey()
+	assert.NoError(t, err)
+	newIdentity, _, err := GenerateKey()
+	assert.NoError(t, err)
+
+	encrypted, err := Encrypt([]byte("secret"), oldRecipient)
+	assert.NoError(t, err)
+
+	decrypted, err := Decrypt(encrypted, newIdentity+"\n"+oldIdentity+"\n")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), decrypted)
+}
+
+func Test_Decrypt_Fails_WithWrongIdentity(t *testing.T) {
+	_, recipient, err := GenerateKey()
+	assert.NoError(t, err)
+	otherIdentity, _, err := GenerateKey()
+	assert.NoError(t, err)
+
+	encrypted, err := Encrypt([]byte("secret"), recipient)
+	assert.NoError(t, err)
+
+	_, err = Decrypt(encrypted, otherIdentity)
+
+	assert.ErrorIs(t, err, NoMatchingKeyError)
+}
+
+func Test_Encrypt_Fails_WithInvalidRecipient(t *testing.T) {
+	_, err := Encrypt([]byte("secret"), "not-a-recipient")
+
+	assert.Error(t, err)
+}
+
+func Test_IsEncrypted_Succeeds_WithPlainContent(t *testing.T) {
+	assert.False(t, IsEncrypted([]byte("cluster:\n  name: test")))
+}
+
+func Test_Recipient_Succeeds(t *testing.T) {
+	identity, recipient, err := GenerateKey()
+	assert.NoError(t, err)
+
+	result, err := Recipient(identity + "\n")
+
+	assert.NoError(t, err)
+	assert.Equal(t, recipient, result)
+}
+
+func Test_EncryptWithPassphrase_Succeeds_RoundTrip(t *testing.T) {
+	passphraseWorkFactor = 10
+
+	encrypted, err := EncryptWithPassphrase([]byte("secret"), "correct horse battery staple")
+	assert.NoError(t, err)
+	assert.True(t, IsEncrypted(encrypted))
+
+	decrypted, err := DecryptWithPassphrase(encrypted, "correct horse battery staple")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), decrypted)
+}
+
+func Test_DecryptWithPassphrase_Fails_WithWrongPassphrase(t *testing.T) {
+	passphraseWorkFactor = 10
+
+	encrypted, err := EncryptWithPassphrase([]byte("secret"), "correct horse battery staple")
+	assert.NoError(t, err)
+
+	_, err = DecryptWithPassphrase(encrypted, "wrong")
+
+	assert.ErrorIs(t, err, WrongPassphraseError)
+}
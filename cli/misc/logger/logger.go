@@ -2,54 +2,238 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 )
 
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+var Formats = []string{FormatText, FormatJson}
+
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+var Levels = []string{"debug", "info", "warn", "error"}
+
 type plainTextHandler struct {
 	writer io.Writer
-	level  slog.Level
+	level  slog.Leveler
 }
 
 func (h *plainTextHandler) Enabled(_ context.Context, level slog.Level) bool {
-	return level >= h.level
+	return level >= h.level.Level()
 }
 
 func (h *plainTextHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
 func (h *plainTextHandler) WithGroup(string) slog.Handler      { return h }
 
+// Handle prints the message followed by its attributes, one per line and indented below it
 func (h *plainTextHandler) Handle(ctx context.Context, r slog.Record) error {
 	if !h.Enabled(ctx, r.Level) {
 		return nil
 	}
-	msg := strings.TrimPrefix(r.Message, "msg=")
-	fmt.Fprintln(h.writer, msg)
+
+	var builder strings.Builder
+	if r.Level == slog.LevelDebug {
+		builder.WriteString("DEBUG: ")
+	}
+	builder.WriteString(strings.TrimPrefix(r.Message, "msg="))
+
+	details := []string{}
+	r.Attrs(func(attr slog.Attr) bool {
+		value := attr.Value.Resolve()
+
+		if list, ok := value.Any().([]string); ok {
+			for _, item := range list {
+				details = append(details, fmt.Sprintf("  %s: %s", attr.Key, item))
+			}
+			return true
+		}
+
+		text := value.String()
+		if strings.Contains(text, "\n") {
+			details = append(details, fmt.Sprintf("  %s:", attr.Key))
+			for _, line := range strings.Split(text, "\n") {
+				details = append(details, fmt.Sprintf("    %s", line))
+			}
+			return true
+		}
+
+		details = append(details, fmt.Sprintf("  %s: %s", attr.Key, text))
+		return true
+	})
+
+	for _, detail := range details {
+		builder.WriteString("\n")
+		builder.WriteString(detail)
+	}
+
+	fmt.Fprintln(h.writer, builder.String())
 	return nil
 }
 
-var defaultHandler *plainTextHandler
+// fanoutHandler passes every record to each handler that accepts its level
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
 
-func Initialize() {
-	defaultHandler = &plainTextHandler{
-		writer: os.Stdout,
-		level:  slog.LevelInfo,
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *fanoutHandler) WithGroup(string) slog.Handler      { return h }
+
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
 	}
+	return errors.Join(errs...)
+}
+
+var consoleWriter io.Writer = os.Stdout
+var consoleFormat = FormatText
+var consoleLevel = &slog.LevelVar{}
+var logFile *os.File
+
+func Initialize() {
+	consoleLevel.Set(DefaultLevel())
+	apply()
+}
 
+// DefaultLevel is used when neither --verbose nor --log-level is given, development builds log debug messages
+func DefaultLevel() slog.Level {
 	if constants.Version == "development" {
-		defaultHandler.level = slog.LevelDebug
+		return slog.LevelDebug
+	}
+
+	return slog.LevelInfo
+}
+
+func ParseLevel(value string) (slog.Level, error) {
+	level, found := levels[strings.ToLower(value)]
+	if !found {
+		return slog.LevelInfo, fmt.Errorf("Unknown log level `%s`, expected one of: %s", value, strings.Join(Levels, ", "))
+	}
+
+	return level, nil
+}
+
+// Configure sets the level and format of the messages shown on the console, the log file always gets every message
+func Configure(level slog.Level, format string) error {
+	if format != FormatText && format != FormatJson {
+		return fmt.Errorf("Unknown log format `%s`, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+
+	consoleLevel.Set(level)
+	consoleFormat = format
+	apply()
+
+	return nil
+}
+
+// SetWriter redirects log messages, e.g. to stderr when stdout is reserved for a structured result
+func SetWriter(writer io.Writer) {
+	consoleWriter = writer
+	apply()
+}
+
+// OpenLogFile writes every message of this run to a new file in dir and deletes all but the newest keep files
+func OpenLogFile(dir string, keep int) (string, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("bbe-%s-%d.log", time.Now().Format("20060102-150405"), os.Getpid()))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	CloseLogFile()
+	logFile = file
+	apply()
+
+	err = pruneLogFiles(dir, keep)
+	if err != nil {
+		Warning(fmt.Sprintf("Unable to delete old log files: %v", err))
 	}
 
-	logger := slog.New(defaultHandler)
-	slog.SetDefault(logger)
+	return path, nil
+}
+
+func CloseLogFile() {
+	if logFile == nil {
+		return
+	}
+
+	logFile.Close()
+	logFile = nil
+	apply()
+}
+
+func pruneLogFiles(dir string, keep int) error {
+	files, err := filepath.Glob(filepath.Join(dir, "bbe-*.log"))
+	if err != nil {
+		return err
+	}
+
+	// The timestamp in the name sorts the files from oldest to newest
+	sort.Strings(files)
+	for len(files) > keep {
+		err := os.Remove(files[0])
+		if err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+
+	return nil
+}
+
+func apply() {
+	var console slog.Handler = &plainTextHandler{writer: consoleWriter, level: consoleLevel}
+	if consoleFormat == FormatJson {
+		console = slog.NewJSONHandler(consoleWriter, &slog.HandlerOptions{Level: consoleLevel})
+	}
+
+	handlers := []slog.Handler{console}
+	if logFile != nil {
+		handlers = append(handlers, slog.NewTextHandler(logFile, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	slog.SetDefault(slog.New(&fanoutHandler{handlers: handlers}))
 }
 
 func Debug(msg string) {
-	slog.Debug(fmt.Sprintf("DEBUG: %s", msg))
+	slog.Debug(msg)
 }
 
 func Info(msg string) {
@@ -64,9 +248,97 @@ func Warning(msg string) {
 	slog.Warn(msg)
 }
 
+// Error logs msg followed by every cause wrapped in err, the outermost cause is the message when msg is empty
 func Error(msg string, err error) {
+	ErrorWithHint(msg, err, "")
+}
+
+// ErrorWithHint logs an error like Error, followed by a suggested fix
+func ErrorWithHint(msg string, err error, hint string) {
+	attrs := []any{}
+
 	if err != nil {
-		slog.Debug(err.Error())
+		causes := causeChain(err)
+		if msg == "" {
+			msg, causes = causes[0], causes[1:]
+		}
+
+		if len(causes) > 0 {
+			attrs = append(attrs, "cause", causes)
+		}
 	}
-	slog.Error(msg)
+
+	if hint != "" {
+		attrs = append(attrs, "hint", hint)
+	}
+
+	slog.Error(msg, attrs...)
+}
+
+// Command records a subprocess and its output at debug level, so every command of a run ends up in the log file
+func Command(cmd *exec.Cmd, output []byte, err error) {
+	attrs := []any{}
+
+	if trimmed := strings.TrimRight(string(output), "\n"); trimmed != "" {
+		attrs = append(attrs, "output", trimmed)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		attrs = append(attrs, "stderr", strings.TrimRight(string(exitErr.Stderr), "\n"))
+	}
+
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+
+	slog.Debug(fmt.Sprintf("Ran %s", commandLine(cmd)), attrs...)
+}
+
+// commandLine describes cmd like cmd.String, with credentials embedded in URL arguments replaced
+func commandLine(cmd *exec.Cmd) string {
+	if len(cmd.Args) == 0 {
+		return cmd.String()
+	}
+
+	args := []string{cmd.Path}
+	for _, arg := range cmd.Args[1:] {
+		parsed, err := url.Parse(arg)
+		if err == nil && parsed.Scheme != "" && parsed.User != nil {
+			parsed.User = url.User("redacted")
+			arg = parsed.String()
+		}
+		args = append(args, arg)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// causeChain splits an error into the message added by each wrapping level, outermost first
+func causeChain(err error) []string {
+	chain := []string{}
+
+	for err != nil {
+		message := err.Error()
+		next := errors.Unwrap(err)
+
+		if next != nil && message == next.Error() {
+			// The wrapper only adds type information, e.g. a classified error
+			err = next
+			continue
+		}
+
+		if next == nil || !strings.HasSuffix(message, ": "+next.Error()) {
+			// Not wrapped, or wrapped in a way the message cannot be split
+			chain = append(chain, message)
+			break
+		}
+
+		if trimmed := strings.TrimSuffix(message, ": "+next.Error()); trimmed != "" {
+			chain = append(chain, trimmed)
+		}
+		err = next
+	}
+
+	return chain
 }
@@ -0,0 +1,89 @@
+package merge
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContext = 2
+
+// Diff returns the changed lines between from and to, prefixed with "-" and "+" and surrounded by a few unchanged lines
+func Diff(from []byte, to []byte) string {
+	fromLines := splitLines(from)
+	toLines := splitLines(to)
+
+	// Longest common subsequence table, lengths[i][j] covers fromLines[i:] and toLines[j:]
+	lengths := make([][]int, len(fromLines)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(toLines)+1)
+	}
+	for i := len(fromLines) - 1; i >= 0; i-- {
+		for j := len(toLines) - 1; j >= 0; j-- {
+			if fromLines[i] == toLines[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(fromLines) || j < len(toLines) {
+		switch {
+		case i < len(fromLines) && j < len(toLines) && fromLines[i] == toLines[j]:
+			lines = append(lines, "  "+fromLines[i])
+			i++
+			j++
+		case i < len(fromLines) && (j == len(toLines) || lengths[i+1][j] >= lengths[i][j+1]):
+			lines = append(lines, "- "+fromLines[i])
+			i++
+		default:
+			lines = append(lines, "+ "+toLines[j])
+			j++
+		}
+	}
+
+	return strings.Join(collapseUnchanged(lines), "\n")
+}
+
+// collapseUnchanged replaces long runs of unchanged lines with a marker
+func collapseUnchanged(lines []string) []string {
+	changed := make([]bool, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		for j := max(0, i-diffContext); j <= min(len(lines)-1, i+diffContext); j++ {
+			changed[j] = true
+		}
+	}
+
+	collapsed := []string{}
+	skipped := 0
+	for i, line := range lines {
+		if changed[i] {
+			if skipped > 0 {
+				collapsed = append(collapsed, fmt.Sprintf("  ... %d unchanged lines", skipped))
+				skipped = 0
+			}
+			collapsed = append(collapsed, line)
+			continue
+		}
+		skipped++
+	}
+	if skipped > 0 && len(collapsed) > 0 {
+		collapsed = append(collapsed, fmt.Sprintf("  ... %d unchanged lines", skipped))
+	}
+
+	return collapsed
+}
+
+func splitLines(content []byte) []string {
+	text := strings.TrimSuffix(string(content), "\n")
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(text, "\n")
+}
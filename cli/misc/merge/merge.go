@@ -0,0 +1,209 @@
+package merge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var ConflictError = errors.New("Both sides changed the same value")
+
+// missing marks a key that does not exist on one side of the merge
+type missing struct{}
+
+// Yaml merges the local and remote changes to base key by key, it fails when both sides changed the same key differently.
+// Comments and formatting are not preserved.
+func Yaml(base []byte, local []byte, remote []byte) ([]byte, error) {
+	baseDocuments, err := decode(base)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the last synced version: %w", err)
+	}
+	localDocuments, err := decode(local)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the local version: %w", err)
+	}
+	remoteDocuments, err := decode(remote)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the remote version: %w", err)
+	}
+
+	if len(localDocuments) != len(remoteDocuments) {
+		return nil, fmt.Errorf("%w: the number of YAML documents differs", ConflictError)
+	}
+
+	conflicts := []string{}
+	merged := [][]byte{}
+	for i := range localDocuments {
+		var baseDocument interface{} = missing{}
+		if i < len(baseDocuments) {
+			baseDocument = baseDocuments[i]
+		}
+
+		value, documentConflicts := mergeValue("", baseDocument, localDocuments[i], remoteDocuments[i])
+		conflicts = append(conflicts, documentConflicts...)
+
+		output, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, output)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ConflictError, strings.Join(conflicts, ", "))
+	}
+
+	return bytes.Join(merged, []byte("---\n")), nil
+}
+
+// ChangedPaths lists the keys whose values differ between from and to, e.g. ".machine.token", without revealing the values
+func ChangedPaths(from []byte, to []byte) ([]string, error) {
+	fromDocuments, err := decode(from)
+	if err != nil {
+		return nil, err
+	}
+	toDocuments, err := decode(to)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for i := range max(len(fromDocuments), len(toDocuments)) {
+		var fromDocument, toDocument interface{} = missing{}, missing{}
+		if i < len(fromDocuments) {
+			fromDocument = fromDocuments[i]
+		}
+		if i < len(toDocuments) {
+			toDocument = toDocuments[i]
+		}
+
+		for _, path := range changedPaths("", fromDocument, toDocument) {
+			if !slices.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+func changedPaths(path string, from interface{}, to interface{}) []string {
+	if reflect.DeepEqual(from, to) {
+		return nil
+	}
+
+	fromMap, fromIsMap := from.(yaml.MapSlice)
+	toMap, toIsMap := to.(yaml.MapSlice)
+	if !fromIsMap || !toIsMap {
+		if path == "" {
+			path = "."
+		}
+		return []string{path}
+	}
+
+	paths := []string{}
+	for _, item := range fromMap {
+		paths = append(paths, changedPaths(fmt.Sprintf("%s.%v", path, item.Key), item.Value, valueOrMissing(toMap, item.Key))...)
+	}
+	for _, item := range toMap {
+		if _, found := lookup(fromMap, item.Key); !found {
+			paths = append(paths, fmt.Sprintf("%s.%v", path, item.Key))
+		}
+	}
+
+	return paths
+}
+
+func decode(content []byte) ([]yaml.MapSlice, error) {
+	documents := []yaml.MapSlice{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.MapSlice
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, document)
+	}
+}
+
+func mergeValue(path string, base interface{}, local interface{}, remote interface{}) (interface{}, []string) {
+	if reflect.DeepEqual(local, remote) {
+		return local, nil
+	}
+	if reflect.DeepEqual(base, local) {
+		return remote, nil
+	}
+	if reflect.DeepEqual(base, remote) {
+		return local, nil
+	}
+
+	localMap, localIsMap := local.(yaml.MapSlice)
+	remoteMap, remoteIsMap := remote.(yaml.MapSlice)
+	if localIsMap && remoteIsMap {
+		baseMap, _ := base.(yaml.MapSlice)
+		return mergeMaps(path, baseMap, localMap, remoteMap)
+	}
+
+	if path == "" {
+		path = "."
+	}
+	return local, []string{path}
+}
+
+// mergeMaps keeps the local key order and appends keys that were only added remotely
+func mergeMaps(path string, base yaml.MapSlice, local yaml.MapSlice, remote yaml.MapSlice) (yaml.MapSlice, []string) {
+	merged := yaml.MapSlice{}
+	conflicts := []string{}
+
+	keys := []interface{}{}
+	for _, item := range local {
+		keys = append(keys, item.Key)
+	}
+	for _, item := range remote {
+		if _, found := lookup(local, item.Key); !found {
+			keys = append(keys, item.Key)
+		}
+	}
+
+	for _, key := range keys {
+		value, keyConflicts := mergeValue(fmt.Sprintf("%s.%v", path, key), valueOrMissing(base, key), valueOrMissing(local, key), valueOrMissing(remote, key))
+		conflicts = append(conflicts, keyConflicts...)
+
+		if _, deleted := value.(missing); !deleted {
+			merged = append(merged, yaml.MapItem{Key: key, Value: value})
+		}
+	}
+
+	return merged, conflicts
+}
+
+func lookup(values yaml.MapSlice, key interface{}) (interface{}, bool) {
+	for _, item := range values {
+		if reflect.DeepEqual(item.Key, key) {
+			return item.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+func valueOrMissing(values yaml.MapSlice, key interface{}) interface{} {
+	value, found := lookup(values, key)
+	if !found {
+		return missing{}
+	}
+
+	return value
+}
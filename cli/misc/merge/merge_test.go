@@ -0,0 +1,81 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Yaml_Succeeds_WithChangesToDifferentKeys(t *testing.T) {
+	base := []byte("bbe:\n  cluster:\n    name: home\n  packages: []\n")
+	local := []byte("bbe:\n  cluster:\n    name: lab\n  packages: []\n")
+	remote := []byte("bbe:\n  cluster:\n    name: home\n  packages:\n  - name: blocky\n")
+
+	merged, err := Yaml(base, local, remote)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bbe:\n  cluster:\n    name: lab\n  packages:\n  - name: blocky\n", string(merged))
+}
+
+func Test_Yaml_Succeeds_WithAddedAndDeletedKeys(t *testing.T) {
+	base := []byte("a: 1\nb: 2\n")
+	local := []byte("a: 1\n")
+	remote := []byte("a: 1\nb: 2\nc: 3\n")
+
+	merged, err := Yaml(base, local, remote)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\nc: 3\n", string(merged))
+}
+
+func Test_Yaml_Succeeds_WithMultipleDocuments(t *testing.T) {
+	base := []byte("a: 1\n---\nb: 1\n")
+	local := []byte("a: 2\n---\nb: 1\n")
+	remote := []byte("a: 1\n---\nb: 2\n")
+
+	merged, err := Yaml(base, local, remote)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 2\n---\nb: 2\n", string(merged))
+}
+
+func Test_Yaml_Fails_WhenBothSidesChangeTheSameKey(t *testing.T) {
+	base := []byte("machine:\n  token: abc\n")
+	local := []byte("machine:\n  token: def\n")
+	remote := []byte("machine:\n  token: ghi\n")
+
+	_, err := Yaml(base, local, remote)
+
+	assert.ErrorIs(t, err, ConflictError)
+	assert.ErrorContains(t, err, ".machine.token")
+}
+
+func Test_Yaml_Fails_WithInvalidYaml(t *testing.T) {
+	_, err := Yaml([]byte("a: 1\n"), []byte("a: [\n"), []byte("a: 2\n"))
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ConflictError)
+}
+
+func Test_ChangedPaths_Succeeds_WithoutValues(t *testing.T) {
+	from := []byte("machine:\n  token: old-token\n  type: controlplane\n  ca:\n    crt: old-crt\n")
+	to := []byte("machine:\n  token: new-token\n  type: controlplane\n  ca:\n    crt: old-crt\n    key: new-key\n")
+
+	paths, err := ChangedPaths(from, to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{".machine.token", ".machine.ca.key"}, paths)
+}
+
+func Test_Diff_Succeeds_ShowsChangedLines(t *testing.T) {
+	from := []byte("a\nb\nc\nd\ne\nf\ng\n")
+	to := []byte("a\nb\nc\nd\nE\nf\ng\n")
+
+	diff := Diff(from, to)
+
+	assert.Equal(t, "  ... 2 unchanged lines\n  c\n  d\n- e\n+ E\n  f\n  g", diff)
+}
+
+func Test_Diff_Succeeds_WithIdenticalContent(t *testing.T) {
+	assert.Equal(t, "", Diff([]byte("a\n"), []byte("a\n")))
+}
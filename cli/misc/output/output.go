@@ -0,0 +1,84 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+	FormatYaml = "yaml"
+)
+
+var Formats = []string{FormatText, FormatJson, FormatYaml}
+
+var format = FormatText
+var stdout io.Writer = os.Stdout
+var stderr io.Writer = os.Stderr
+
+func SetFormat(value string) error {
+	for _, known := range Formats {
+		if value == known {
+			format = value
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown output format `%s`, expected one of: %s", value, strings.Join(Formats, ", "))
+}
+
+func Format() string {
+	return format
+}
+
+// SetWriter replaces stdout as the destination of results and returns the previous destination, e.g. to capture results in tests
+func SetWriter(writer io.Writer) io.Writer {
+	previous := stdout
+	stdout = writer
+	return previous
+}
+
+// IsStructured reports whether stdout is reserved for a json or yaml result
+func IsStructured() bool {
+	return format != FormatText
+}
+
+// StatusWriter is where spinners and prompts are drawn, stderr when stdout is reserved for a structured result
+func StatusWriter() io.Writer {
+	if IsStructured() {
+		return stderr
+	}
+
+	return stdout
+}
+
+// Print writes result to stdout as json or yaml, or the text returned by text for the text format
+func Print(result interface{}, text func() string) error {
+	switch format {
+	case FormatJson:
+		content, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Failed to encode the result as json: %w", err)
+		}
+
+		_, err = fmt.Fprintln(stdout, string(content))
+		return err
+	case FormatYaml:
+		content, err := yaml.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("Failed to encode the result as yaml: %w", err)
+		}
+
+		_, err = stdout.Write(content)
+		return err
+	default:
+		_, err := fmt.Fprintln(stdout, text())
+		return err
+	}
+}
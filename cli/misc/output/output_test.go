@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+}
+
+func initOutputTest(t *testing.T, value string) (*bytes.Buffer, *bytes.Buffer) {
+	var out, errOut bytes.Buffer
+	stdout, stderr = &out, &errOut
+
+	err := SetFormat(value)
+	assert.Nil(t, err)
+
+	t.Cleanup(func() {
+		format = FormatText
+	})
+
+	return &out, &errOut
+}
+
+func Test_Print_Succeeds_WithText(t *testing.T) {
+	out, _ := initOutputTest(t, FormatText)
+
+	err := Print(testResult{Name: "blocky", Version: "0.1.3"}, func() string { return "blocky 0.1.3" })
+
+	assert.Nil(t, err)
+	assert.Equal(t, "blocky 0.1.3\n", out.String())
+	assert.False(t, IsStructured())
+}
+
+func Test_Print_Succeeds_WithJson(t *testing.T) {
+	out, _ := initOutputTest(t, FormatJson)
+
+	err := Print(testResult{Name: "blocky", Version: "0.1.3"}, func() string { return "blocky 0.1.3" })
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name": "blocky", "version": "0.1.3"}`, out.String())
+	assert.True(t, IsStructured())
+}
+
+func Test_Print_Succeeds_WithYaml(t *testing.T) {
+	out, _ := initOutputTest(t, FormatYaml)
+
+	err := Print([]testResult{{Name: "blocky", Version: "0.1.3"}}, func() string { return "blocky 0.1.3" })
+
+	assert.Nil(t, err)
+	assert.Equal(t, "- name: blocky\n  version: 0.1.3\n", out.String())
+}
+
+func Test_StatusWriter_Succeeds_UsesStderrForStructuredFormats(t *testing.T) {
+	out, errOut := initOutputTest(t, FormatJson)
+
+	assert.Equal(t, errOut, StatusWriter())
+
+	format = FormatText
+	assert.Equal(t, out, StatusWriter())
+}
+
+func Test_SetFormat_Fails_WithUnknownFormat(t *testing.T) {
+	err := SetFormat("xml")
+
+	assert.ErrorContains(t, err, "Unknown output format `xml`")
+	assert.Equal(t, FormatText, Format())
+}
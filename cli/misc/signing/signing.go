@@ -0,0 +1,75 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var MissingSignatureError = errors.New("Signature is missing")
+var InvalidSignatureError = errors.New("Signature does not match any trusted key")
+
+// GenerateKey returns a base64 encoded ed25519 key pair
+func GenerateKey() (string, string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(publicKey), base64.StdEncoding.EncodeToString(privateKey), nil
+}
+
+// Sign returns a base64 encoded detached signature of content
+func Sign(content []byte, privateKey string) ([]byte, error) {
+	decodedKey, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace([]byte(privateKey))))
+	if err != nil || len(decodedKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("Invalid private key")
+	}
+
+	signature := ed25519.Sign(ed25519.PrivateKey(decodedKey), content)
+
+	return []byte(base64.StdEncoding.EncodeToString(signature)), nil
+}
+
+// Verify checks a base64 encoded detached signature against a list of base64 encoded public keys
+func Verify(content []byte, signature []byte, publicKeys []string) error {
+	if len(bytes.TrimSpace(signature)) == 0 {
+		return MissingSignatureError
+	}
+
+	decodedSignature, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil || len(decodedSignature) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: malformed signature", InvalidSignatureError)
+	}
+
+	for _, publicKey := range publicKeys {
+		decodedKey, err := decodePublicKey(publicKey)
+		if err != nil {
+			return err
+		}
+
+		if ed25519.Verify(ed25519.PublicKey(decodedKey), content, decodedSignature) {
+			return nil
+		}
+	}
+
+	return InvalidSignatureError
+}
+
+// ValidatePublicKey checks that publicKey is a base64 encoded ed25519 public key
+func ValidatePublicKey(publicKey string) error {
+	_, err := decodePublicKey(publicKey)
+	return err
+}
+
+func decodePublicKey(publicKey string) ([]byte, error) {
+	decodedKey, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(decodedKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("Invalid public key `%s`", publicKey)
+	}
+
+	return decodedKey, nil
+}
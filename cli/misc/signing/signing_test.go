@@ -0,0 +1,71 @@
+package signing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Verify_Succeeds_WithTrustedKey(t *testing.T) {
+	otherPublicKey, _, err := GenerateKey()
+	assert.NoError(t, err)
+	publicKey, privateKey, err := GenerateKey()
+	assert.NoError(t, err)
+
+	signature, err := Sign([]byte("content"), privateKey)
+	assert.NoError(t, err)
+
+	err = Verify([]byte("content"), signature, []string{otherPublicKey, publicKey})
+
+	assert.NoError(t, err)
+}
+
+func Test_Verify_Fails_WithTamperedContent(t *testing.T) {
+	publicKey, privateKey, err := GenerateKey()
+	assert.NoError(t, err)
+
+	signature, err := Sign([]byte("content"), privateKey)
+	assert.NoError(t, err)
+
+	err = Verify([]byte("tampered content"), signature, []string{publicKey})
+
+	assert.ErrorIs(t, err, InvalidSignatureError)
+}
+
+func Test_Verify_Fails_WithUntrustedKey(t *testing.T) {
+	otherPublicKey, _, err := GenerateKey()
+	assert.NoError(t, err)
+	_, privateKey, err := GenerateKey()
+	assert.NoError(t, err)
+
+	signature, err := Sign([]byte("content"), privateKey)
+	assert.NoError(t, err)
+
+	err = Verify([]byte("content"), signature, []string{otherPublicKey})
+
+	assert.ErrorIs(t, err, InvalidSignatureError)
+}
+
+func Test_Verify_Fails_WithMissingSignature(t *testing.T) {
+	publicKey, _, err := GenerateKey()
+	assert.NoError(t, err)
+
+	err = Verify([]byte("content"), nil, []string{publicKey})
+
+	assert.ErrorIs(t, err, MissingSignatureError)
+}
+
+func Test_Verify_Fails_WithMalformedSignature(t *testing.T) {
+	publicKey, _, err := GenerateKey()
+	assert.NoError(t, err)
+
+	err = Verify([]byte("content"), []byte("not a signature"), []string{publicKey})
+
+	assert.ErrorIs(t, err, InvalidSignatureError)
+}
+
+func Test_Sign_Fails_WithInvalidKey(t *testing.T) {
+	_, err := Sign([]byte("content"), "not a key")
+
+	assert.Error(t, err)
+}
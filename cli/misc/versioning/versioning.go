@@ -0,0 +1,117 @@
+package versioning
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const DevelopmentVersion = "development"
+
+const (
+	ChangeNone      = "none"
+	ChangePatch     = "patch"
+	ChangeMinor     = "minor"
+	ChangeMajor     = "major"
+	ChangeDowngrade = "downgrade"
+)
+
+const (
+	PolicyPatch  = "patch"
+	PolicyMinor  = "minor"
+	PolicyMajor  = "major"
+	PolicyPinned = "pinned"
+)
+
+var Policies = []string{PolicyPatch, PolicyMinor, PolicyMajor, PolicyPinned}
+
+func Parse(version string) (*semver.Version, error) {
+	parsed, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid semantic version `%s`: %w", version, err)
+	}
+
+	return parsed, nil
+}
+
+func Compare(a string, b string) (int, error) {
+	parsedA, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+
+	parsedB, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return parsedA.Compare(parsedB), nil
+}
+
+// ClassifyChange describes the step from current to available as one of the Change* constants
+func ClassifyChange(current string, available string) (string, error) {
+	parsedCurrent, err := Parse(current)
+	if err != nil {
+		return "", err
+	}
+
+	parsedAvailable, err := Parse(available)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case parsedAvailable.Equal(parsedCurrent):
+		return ChangeNone, nil
+	case parsedAvailable.LessThan(parsedCurrent):
+		return ChangeDowngrade, nil
+	case parsedAvailable.Major() != parsedCurrent.Major():
+		return ChangeMajor, nil
+	case parsedAvailable.Minor() != parsedCurrent.Minor():
+		return ChangeMinor, nil
+	default:
+		return ChangePatch, nil
+	}
+}
+
+func IsValidPolicy(policy string) bool {
+	for _, valid := range Policies {
+		if policy == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsChangeAllowed reports whether a change class may be applied under the given policy, an empty policy behaves like PolicyMajor
+func IsChangeAllowed(policy string, change string) bool {
+	if change == ChangeNone || change == ChangeDowngrade {
+		return false
+	}
+
+	switch policy {
+	case PolicyPinned:
+		return false
+	case PolicyPatch:
+		return change == ChangePatch
+	case PolicyMinor:
+		return change == ChangePatch || change == ChangeMinor
+	default:
+		return true
+	}
+}
+
+// IsCliCompatible reports whether cliVersion satisfies the minimum version required by a library revision
+func IsCliCompatible(minCliVersion string, cliVersion string) bool {
+	if cliVersion == DevelopmentVersion || minCliVersion == "" {
+		return true
+	}
+
+	comparison, err := Compare(minCliVersion, cliVersion)
+	if err != nil {
+		return false
+	}
+
+	return comparison <= 0
+}
@@ -0,0 +1,70 @@
+package versioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compare_Succeeds_WithMultiDigitComponents(t *testing.T) {
+	result, err := Compare("0.10.0", "0.9.0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result)
+}
+
+func Test_Compare_Fails_WithInvalidVersion(t *testing.T) {
+	_, err := Compare("not-a-version", "0.9.0")
+
+	assert.Error(t, err)
+}
+
+func Test_ClassifyChange_Succeeds(t *testing.T) {
+	testCases := map[string]struct {
+		current   string
+		available string
+		expected  string
+	}{
+		"none":      {current: "1.2.3", available: "1.2.3", expected: ChangeNone},
+		"patch":     {current: "1.2.3", available: "1.2.4", expected: ChangePatch},
+		"minor":     {current: "1.2.3", available: "1.10.0", expected: ChangeMinor},
+		"major":     {current: "1.2.3", available: "2.0.0", expected: ChangeMajor},
+		"downgrade": {current: "1.10.0", available: "1.9.0", expected: ChangeDowngrade},
+		"prefixed":  {current: "v1.2.3", available: "1.2.4", expected: ChangePatch},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := ClassifyChange(testCase.current, testCase.available)
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, result)
+		})
+	}
+}
+
+func Test_ClassifyChange_Fails_WithInvalidVersion(t *testing.T) {
+	_, err := ClassifyChange("1.0.0", "latest")
+
+	assert.Error(t, err)
+}
+
+func Test_IsChangeAllowed_Succeeds(t *testing.T) {
+	assert.True(t, IsChangeAllowed("", ChangeMajor))
+	assert.True(t, IsChangeAllowed(PolicyMajor, ChangeMinor))
+	assert.True(t, IsChangeAllowed(PolicyMinor, ChangeMinor))
+	assert.False(t, IsChangeAllowed(PolicyMinor, ChangeMajor))
+	assert.True(t, IsChangeAllowed(PolicyPatch, ChangePatch))
+	assert.False(t, IsChangeAllowed(PolicyPatch, ChangeMinor))
+	assert.False(t, IsChangeAllowed(PolicyPinned, ChangePatch))
+	assert.False(t, IsChangeAllowed(PolicyMajor, ChangeDowngrade))
+	assert.False(t, IsChangeAllowed(PolicyMajor, ChangeNone))
+}
+
+func Test_IsCliCompatible_Succeeds(t *testing.T) {
+	assert.True(t, IsCliCompatible("0.9.0", "0.10.0"))
+	assert.False(t, IsCliCompatible("0.10.0", "0.9.0"))
+	assert.True(t, IsCliCompatible("0.10.0", DevelopmentVersion))
+	assert.True(t, IsCliCompatible("", "0.1.0"))
+	assert.False(t, IsCliCompatible("garbage", "0.1.0"))
+}
@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBackupService struct {
+	mock.Mock
+}
+
+func (mock *MockBackupService) Create(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, helmService interfaces.HelmServiceInterface) (*models.Backup, error) {
+	args := mock.Called(helperService, configService, talosService, helmService)
+	return args.Get(0).(*models.Backup), args.Error(1)
+}
+
+func (mock *MockBackupService) Write(backup *models.Backup, passphrase string, output string) error {
+	args := mock.Called(backup, passphrase, output)
+	return args.Error(0)
+}
+
+func (mock *MockBackupService) Read(input string, passphrase string) (*models.Backup, error) {
+	args := mock.Called(input, passphrase)
+	return args.Get(0).(*models.Backup), args.Error(1)
+}
+
+func (mock *MockBackupService) Restore(helperService interfaces.HelperServiceInterface, backup *models.Backup) error {
+	args := mock.Called(helperService, backup)
+	return args.Error(0)
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockClusterService struct {
+	mock.Mock
+}
+
+func (mock *MockClusterService) List(helperService interfaces.HelperServiceInterface) ([]string, error) {
+	args := mock.Called(helperService)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (mock *MockClusterService) Exists(helperService interfaces.HelperServiceInterface, name string) bool {
+	args := mock.Called(helperService, name)
+	return args.Bool(0)
+}
+
+func (mock *MockClusterService) Create(helperService interfaces.HelperServiceInterface, name string) error {
+	args := mock.Called(helperService, name)
+	return args.Error(0)
+}
+
+func (mock *MockClusterService) Use(helperService interfaces.HelperServiceInterface, name string) error {
+	args := mock.Called(helperService, name)
+	return args.Error(0)
+}
+
+func (mock *MockClusterService) Delete(helperService interfaces.HelperServiceInterface, name string) error {
+	args := mock.Called(helperService, name)
+	return args.Error(0)
+}
+
+func (mock *MockClusterService) MigrateLegacyConfig(helperService interfaces.HelperServiceInterface) error {
+	args := mock.Called(helperService)
+	return args.Error(0)
+}
@@ -15,7 +15,27 @@ func (m *MockConfigService) GetBbeConfig(helperService interfaces.HelperServiceI
 	return args.Get(0).(*models.BbeConfig), args.Error(1)
 }
 
-func (m *MockConfigService) GenerateBbeConfig(helperService interfaces.HelperServiceInterface, storage string) error {
+func (m *MockConfigService) ValidateBbeConfig(helperService interfaces.HelperServiceInterface) error {
+	args := m.Called(helperService)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) GetBbeValue(helperService interfaces.HelperServiceInterface, key string) (string, error) {
+	args := m.Called(helperService, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockConfigService) SetBbeValue(helperService interfaces.HelperServiceInterface, key string, value string) error {
+	args := m.Called(helperService, key, value)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) MigrateStorage(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, storage models.StorageConfig) error {
+	args := m.Called(helperService, bbeConfig, storage)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) GenerateBbeConfig(helperService interfaces.HelperServiceInterface, storage models.StorageConfig) error {
 	args := m.Called(helperService, storage)
 	return args.Error(0)
 }
@@ -25,6 +45,11 @@ func (m *MockConfigService) UpdateBbeClusterName(helperService interfaces.Helper
 	return args.Error(0)
 }
 
+func (m *MockConfigService) UpdateBbeClusterContext(helperService interfaces.HelperServiceInterface, context string) error {
+	args := m.Called(helperService, context)
+	return args.Error(0)
+}
+
 func (m *MockConfigService) UpdateBbeStorageType(helperService interfaces.HelperServiceInterface, storageType string) error {
 	args := m.Called(helperService, storageType)
 	return args.Error(0)
@@ -40,12 +65,57 @@ func (m *MockConfigService) UpdateBbePackages(helperService interfaces.HelperSer
 	return args.Error(0)
 }
 
+func (m *MockConfigService) UpdateBbeLibraries(helperService interfaces.HelperServiceInterface, libraries []models.LibrarySource) error {
+	args := m.Called(helperService, libraries)
+	return args.Error(0)
+}
+
 func (m *MockConfigService) CheckForTalosConfigs(helperService interfaces.HelperServiceInterface) bool {
 	args := m.Called(helperService)
 	return args.Bool(0)
 }
 
-func (m *MockConfigService) SyncConfigsWithAws(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+func (m *MockConfigService) UpdateBbeStorage(helperService interfaces.HelperServiceInterface, storage models.StorageConfig) error {
+	args := m.Called(helperService, storage)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) SyncConfigs(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	args := m.Called(helperService, bbeConfig)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) EnableEncryption(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	args := m.Called(helperService, bbeConfig)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) RotateEncryptionKey(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	args := m.Called(helperService, bbeConfig)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) ResolveConflict(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, conflict models.SyncConflict, content []byte) error {
+	args := m.Called(helperService, bbeConfig, conflict, content)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) ConfigHistory(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, name string) ([]models.StorageObject, error) {
+	args := m.Called(helperService, bbeConfig, name)
+	return args.Get(0).([]models.StorageObject), args.Error(1)
+}
+
+func (m *MockConfigService) RestoreConfig(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, name string, version string) error {
+	args := m.Called(helperService, bbeConfig, name, version)
+	return args.Error(0)
+}
+
+func (m *MockConfigService) SnapshotBackend(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) (interfaces.StorageBackend, error) {
+	args := m.Called(helperService, bbeConfig)
+	return args.Get(0).(interfaces.StorageBackend), args.Error(1)
+}
+
+func (m *MockConfigService) CheckStorage(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
 	args := m.Called(helperService, bbeConfig)
 	return args.Error(0)
 }
@@ -1,6 +1,9 @@
 package mocks
 
-import "github.com/stretchr/testify/mock"
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/mock"
+)
 
 type MockDependencyService struct {
 	mock.Mock
@@ -18,3 +21,8 @@ func (_m *MockDependencyService) VerifyDependencies() bool {
 
 	return r0
 }
+
+func (_m *MockDependencyService) GetToolVersion(name string) (models.ToolVersion, error) {
+	ret := _m.Called(name)
+	return ret.Get(0).(models.ToolVersion), ret.Error(1)
+}
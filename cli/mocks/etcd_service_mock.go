@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEtcdService struct {
+	mock.Mock
+}
+
+func (m *MockEtcdService) Snapshot(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface) (*models.StorageObject, error) {
+	args := m.Called(helperService, configService, talosService)
+	return args.Get(0).(*models.StorageObject), args.Error(1)
+}
+
+func (m *MockEtcdService) List(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) ([]models.StorageObject, error) {
+	args := m.Called(helperService, configService)
+	return args.Get(0).([]models.StorageObject), args.Error(1)
+}
+
+func (m *MockEtcdService) Prune(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) ([]models.StorageObject, error) {
+	args := m.Called(helperService, configService)
+	return args.Get(0).([]models.StorageObject), args.Error(1)
+}
+
+func (m *MockEtcdService) Restore(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, name string, nodeIp string) error {
+	args := m.Called(helperService, configService, talosService, name, nodeIp)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -37,3 +38,28 @@ func (m *MockHelmService) IsPackageInstalled(pkgName string, namespace string, c
 	args := m.Called(pkgName, namespace, context)
 	return args.Bool(0)
 }
+
+func (m *MockHelmService) PullChart(chartName string, repoName string, version string, destination string) (string, error) {
+	args := m.Called(chartName, repoName, version, destination)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockHelmService) ListReleases(context string) ([]models.HelmRelease, error) {
+	args := m.Called(context)
+	return args.Get(0).([]models.HelmRelease), args.Error(1)
+}
+
+func (m *MockHelmService) GetValues(pkgName string, namespace string, context string) ([]byte, error) {
+	args := m.Called(pkgName, namespace, context)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockHelmService) GetManifest(pkgName string, namespace string, context string) ([]byte, error) {
+	args := m.Called(pkgName, namespace, context)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockHelmService) TemplateChart(pkgName string, chartName string, repoUrl string, version string, namespace string, values []byte) ([]byte, error) {
+	args := m.Called(pkgName, chartName, repoUrl, version, namespace, values)
+	return args.Get(0).([]byte), args.Error(1)
+}
@@ -34,6 +34,18 @@ func (mock *MockHelperService) DeleteEmptyStrings(s []string) []string {
 	return args.Get(0).([]string)
 }
 
+func (mock *MockHelperService) GetBaseDir() string {
+	args := mock.Mock.Called()
+
+	return args.String(0)
+}
+
+func (mock *MockHelperService) GetClusterName() string {
+	args := mock.Mock.Called()
+
+	return args.String(0)
+}
+
 func (mock *MockHelperService) GetConfigDir() string {
 	args := mock.Mock.Called()
 
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockKubeconfigService struct {
+	mock.Mock
+}
+
+func (m *MockKubeconfigService) Generate(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, controlPlaneIp string) (string, error) {
+	args := m.Called(helperService, configService, talosService, controlPlaneIp)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockKubeconfigService) Path(helperService interfaces.HelperServiceInterface) string {
+	args := m.Called(helperService)
+	return args.String(0)
+}
+
+func (m *MockKubeconfigService) VerifyEndpoint(helperService interfaces.HelperServiceInterface) (string, error) {
+	args := m.Called(helperService)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockKubeconfigService) Merge(helperService interfaces.HelperServiceInterface, target string, strategy string) (string, error) {
+	args := m.Called(helperService, target, strategy)
+	return args.String(0), args.Error(1)
+}
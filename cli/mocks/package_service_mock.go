@@ -10,11 +10,16 @@ type MockPackageService struct {
 	mock.Mock
 }
 
-func (m *MockPackageService) GetAll() ([]models.ChartEntry, error) {
+func (m *MockPackageService) GetAll(helperService interfaces.HelperServiceInterface, bbeConfig models.BbeConfig) ([]models.ChartEntry, error) {
 	args := m.Called()
 	return args.Get(0).([]models.ChartEntry), args.Error(1)
 }
 
+func (m *MockPackageService) MirrorLibrary(helperService interfaces.HelperServiceInterface, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface, destination string) error {
+	args := m.Called(destination)
+	return args.Error(0)
+}
+
 func (m *MockPackageService) InstallPackage(pkg models.ChartEntry, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) error {
 	args := m.Called(pkg)
 	return args.Error(0)
@@ -25,7 +30,22 @@ func (m *MockPackageService) UpgradePackage(pkg models.ChartEntry, bbeConfig mod
 	return args.Error(0)
 }
 
+func (m *MockPackageService) DiffUpgrade(pkg models.ChartEntry, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) (string, error) {
+	args := m.Called(pkg)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockPackageService) DetectDrift(bbeConfig models.BbeConfig, allPackages []models.ChartEntry, helmService interfaces.HelmServiceInterface) ([]models.PackageDrift, error) {
+	args := m.Called(bbeConfig.Bbe.Packages, allPackages)
+	return args.Get(0).([]models.PackageDrift), args.Error(1)
+}
+
 func (m *MockPackageService) UninstallPackage(pkg models.LocalPackage, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) error {
 	args := m.Called(pkg)
 	return args.Error(0)
 }
+
+func (m *MockPackageService) CheckLibrary(bbeConfig models.BbeConfig, library models.LibrarySource) error {
+	args := m.Called(library)
+	return args.Error(0)
+}
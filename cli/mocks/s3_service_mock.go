@@ -17,24 +17,48 @@ func (mock *MockS3Service) CreateBucket(ctx context.Context, params *s3.CreateBu
 	return args.Get(0).(*s3.CreateBucketOutput), args.Error(1)
 }
 
+func (mock *MockS3Service) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	args := mock.Called(ctx, params, optFns)
+
+	return args.Get(0).(*s3.DeleteObjectOutput), args.Error(1)
+}
+
 func (mock *MockS3Service) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
 	args := mock.Called(ctx, params, optFns)
 
 	return args.Get(0).(*s3.GetObjectOutput), args.Error(1)
 }
 
+func (mock *MockS3Service) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	args := mock.Called(ctx, params, optFns)
+
+	return args.Get(0).(*s3.HeadObjectOutput), args.Error(1)
+}
+
 func (mock *MockS3Service) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
 	args := mock.Called(ctx, params, optFns)
 
 	return args.Get(0).(*s3.ListBucketsOutput), args.Error(1)
 }
 
+func (mock *MockS3Service) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	args := mock.Called(ctx, params, optFns)
+
+	return args.Get(0).(*s3.ListObjectVersionsOutput), args.Error(1)
+}
+
 func (mock *MockS3Service) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error) {
 	args := mock.Called(ctx, params, optFns)
 
 	return args.Get(0).(*s3.PutBucketEncryptionOutput), args.Error(1)
 }
 
+func (mock *MockS3Service) PutBucketTagging(ctx context.Context, params *s3.PutBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketTaggingOutput, error) {
+	args := mock.Called(ctx, params, optFns)
+
+	return args.Get(0).(*s3.PutBucketTaggingOutput), args.Error(1)
+}
+
 func (mock *MockS3Service) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
 	args := mock.Called(ctx, params, optFns)
 
@@ -46,3 +70,9 @@ func (mock *MockS3Service) PutObject(ctx context.Context, params *s3.PutObjectIn
 
 	return args.Get(0).(*s3.PutObjectOutput), args.Error(1)
 }
+
+func (mock *MockS3Service) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error) {
+	args := mock.Called(ctx, params, optFns)
+
+	return args.Get(0).(*s3.PutPublicAccessBlockOutput), args.Error(1)
+}
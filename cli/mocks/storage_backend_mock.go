@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockStorageBackend struct {
+	mock.Mock
+}
+
+func (m *MockStorageBackend) Get(name string) (*models.StorageObject, error) {
+	args := m.Called(name)
+	return args.Get(0).(*models.StorageObject), args.Error(1)
+}
+
+func (m *MockStorageBackend) Put(name string, content []byte) error {
+	args := m.Called(name, content)
+	return args.Error(0)
+}
+
+func (m *MockStorageBackend) List() ([]models.StorageObject, error) {
+	args := m.Called()
+	return args.Get(0).([]models.StorageObject), args.Error(1)
+}
+
+func (m *MockStorageBackend) Version(name string) (*models.StorageObject, error) {
+	args := m.Called(name)
+	return args.Get(0).(*models.StorageObject), args.Error(1)
+}
+
+func (m *MockStorageBackend) Versions(name string) ([]models.StorageObject, error) {
+	args := m.Called(name)
+	return args.Get(0).([]models.StorageObject), args.Error(1)
+}
+
+func (m *MockStorageBackend) GetVersion(name string, version string) (*models.StorageObject, error) {
+	args := m.Called(name, version)
+	return args.Get(0).(*models.StorageObject), args.Error(1)
+}
+
+func (m *MockStorageBackend) Delete(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
@@ -4,6 +4,7 @@ import (
 	"os/exec"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -84,7 +85,32 @@ func (m *MockTalosService) GetControlPlaneIp(helperService interfaces.HelperServ
 	return args.Get(0).(string), args.Error(1)
 }
 
-func (m *MockTalosService) DownloadKubeConfig(helperService interfaces.HelperServiceInterface, nodeIp string, controlPlaneIp string) error {
-	args := m.Called(helperService, nodeIp, controlPlaneIp)
+func (m *MockTalosService) ExportKubeConfig(helperService interfaces.HelperServiceInterface, controlPlaneIp string, destination string) error {
+	args := m.Called(helperService, controlPlaneIp, destination)
+	return args.Error(0)
+}
+
+func (m *MockTalosService) GetMembers(helperService interfaces.HelperServiceInterface, controlPlaneIp string) ([]models.ClusterMember, error) {
+	args := m.Called(helperService, controlPlaneIp)
+	return args.Get(0).([]models.ClusterMember), args.Error(1)
+}
+
+func (m *MockTalosService) EtcdSnapshot(helperService interfaces.HelperServiceInterface, controlPlaneIp string, destination string) error {
+	args := m.Called(helperService, controlPlaneIp, destination)
+	return args.Error(0)
+}
+
+func (m *MockTalosService) RecoverCluster(helperService interfaces.HelperServiceInterface, nodeIp string, controlPlaneIp string, snapshotPath string) error {
+	args := m.Called(helperService, nodeIp, controlPlaneIp, snapshotPath)
 	return args.Error(0)
 }
+
+func (m *MockTalosService) CheckHealth(helperService interfaces.HelperServiceInterface, controlPlaneIp string) error {
+	args := m.Called(helperService, controlPlaneIp)
+	return args.Error(0)
+}
+
+func (m *MockTalosService) GetNodeInventory(helperService interfaces.HelperServiceInterface) (*models.NodeInventory, error) {
+	args := m.Called(helperService)
+	return args.Get(0).(*models.NodeInventory), args.Error(1)
+}
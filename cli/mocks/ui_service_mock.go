@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -18,7 +19,17 @@ func (mock *MockUiService) CreateInput(title string, suggestion string) (string,
 	return args.Get(0).(string), args.Error(1)
 }
 
+func (mock *MockUiService) CreatePasswordInput(title string) (string, error) {
+	args := mock.Mock.Called(title)
+	return args.Get(0).(string), args.Error(1)
+}
+
 func (mock *MockUiService) CreateMultiChoose(title string, options []string, defaultIndex []int) ([]string, error) {
 	args := mock.Mock.Called(title, options, defaultIndex)
 	return args.Get(0).([]string), args.Error(1)
 }
+
+func (mock *MockUiService) BrowsePackages(title string, listings []models.PackageListing) ([]string, error) {
+	args := mock.Mock.Called(title, listings)
+	return args.Get(0).([]string), args.Error(1)
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUpdateService struct {
+	mock.Mock
+}
+
+func (m *MockUpdateService) FindRelease(version string) (*models.Release, error) {
+	args := m.Called(version)
+	return args.Get(0).(*models.Release), args.Error(1)
+}
+
+func (m *MockUpdateService) Install(release *models.Release, executable string) error {
+	args := m.Called(release, executable)
+	return args.Error(0)
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Backup holds every file needed to manage a cluster from another workstation, keyed by their path relative to the config directory
+type Backup struct {
+	Manifest BackupManifest
+	Files    map[string][]byte
+}
+
+type BackupManifest struct {
+	Version    int          `yaml:"version"` // Format of the backup, not of bbe.yaml
+	Cluster    string       `yaml:"cluster"`
+	CreatedAt  time.Time    `yaml:"created_at"`
+	CliVersion string       `yaml:"cli_version"`
+	Files      []BackupFile `yaml:"files"`
+}
+
+type BackupFile struct {
+	Path   string `yaml:"path"`
+	Size   int64  `yaml:"size"`
+	Sha256 string `yaml:"sha256"`
+}
+
+// NodeInventory lists the nodes of a cluster as recorded in its talosconfig
+type NodeInventory struct {
+	ControlPlaneEndpoint string   `yaml:"control_plane_endpoint,omitempty"`
+	Endpoints            []string `yaml:"endpoints"`
+	Nodes                []string `yaml:"nodes"`
+}
@@ -1,17 +1,25 @@
 package models
 
 type BbeConfig struct {
-	Bbe struct {
+	ApiVersion string `yaml:"apiVersion,omitempty"` // e.g. "bbe-quest/v1", set on every write
+	Bbe        struct {
 		Cluster struct {
 			Name    string `yaml:"name,omitempty"`
 			Context string `yaml:"context,omitempty"`
 		} `yaml:"cluster,omitempty"`
-		Storage struct {
-			Type string `yaml:"type,omitempty"` // "local" or "aws"
-			Aws  struct {
-				BucketName string `yaml:"bucket_name,omitempty"`
-			} `yaml:"aws,omitempty"`
-		} `yaml:"storage,omitempty"`
+		Storage StorageConfig `yaml:"storage,omitempty"`
+		Library struct {
+			Source        string `yaml:"source,omitempty"`         // URL, local file or oci:// reference, defaults to the official BBE library
+			Timeout       int    `yaml:"timeout,omitempty"`        // Seconds to wait for a remote library, defaults to 10
+			AllowUnsigned bool   `yaml:"allow_unsigned,omitempty"` // Accept the library without a signature, its contents are then not verified
+		} `yaml:"library,omitempty"`
+		Libraries []LibrarySource `yaml:"libraries,omitempty"` // Additional libraries, in order of precedence after the official one
+		Etcd      struct {
+			Snapshots struct {
+				Keep       int `yaml:"keep,omitempty"`         // Number of snapshots to keep, defaults to 7
+				MaxAgeDays int `yaml:"max_age_days,omitempty"` // Snapshots older than this are deleted, the newest snapshot is always kept
+			} `yaml:"snapshots,omitempty"`
+		} `yaml:"etcd,omitempty"`
 		Packages []LocalPackage `yaml:"packages"`
 	} `yaml:"bbe,omitempty"`
 }
@@ -1,7 +1,7 @@
 package models
 
 type Library struct {
-	Library []LibraryEntry `mapstructure:"library"`
+	Library []LibraryEntry `mapstructure:"library" yaml:"library"`
 }
 
 type LibraryEntry struct {
@@ -11,8 +11,17 @@ type LibraryEntry struct {
 }
 
 type ChartEntry struct {
-	Name           string `mapstructure:"name" yaml:"name"`
-	Version        string `mapstructure:"version" yaml:"version"`
-	RepositoryUrl  string `mapstructure:"repositoryUrl" yaml:"repositoryUrl"`
-	RepositoryName string `mapstructure:"repositoryName" yaml:"repositoryName"`
+	Name           string   `mapstructure:"name" yaml:"name"`
+	Version        string   `mapstructure:"version" yaml:"version"`
+	RepositoryUrl  string   `mapstructure:"repositoryUrl" yaml:"repositoryUrl"`
+	RepositoryName string   `mapstructure:"repositoryName" yaml:"repositoryName"`
+	LocalChart     string   `mapstructure:"localChart" yaml:"localChart,omitempty"` // Chart archive, relative to the library file
+	Description    string   `mapstructure:"description" yaml:"description,omitempty"`
+	Category       string   `mapstructure:"category" yaml:"category,omitempty"`
+	Homepage       string   `mapstructure:"homepage" yaml:"homepage,omitempty"`
+	Icon           string   `mapstructure:"icon" yaml:"icon,omitempty"`                 // URL of the icon of the package
+	Release        string   `mapstructure:"release" yaml:"release,omitempty"`           // Name of the helm release, defaults to the chart name
+	Namespace      string   `mapstructure:"namespace" yaml:"namespace,omitempty"`       // Namespace of the release, defaults to the release name
+	Dependencies   []string `mapstructure:"dependencies" yaml:"dependencies,omitempty"` // Names of the packages of the same library the package needs
+	Library        string   `mapstructure:"-" yaml:"-"`                                 // Name of the library the chart was loaded from
 }
@@ -0,0 +1,9 @@
+package models
+
+// ClusterMember is a node of the cluster as reported by Talos cluster discovery
+type ClusterMember struct {
+	Hostname        string   `json:"hostname" yaml:"hostname"`
+	MachineType     string   `json:"machine_type" yaml:"machine_type"` // "controlplane" or "worker"
+	Addresses       []string `json:"addresses" yaml:"addresses"`
+	OperatingSystem string   `json:"operating_system" yaml:"operating_system"`
+}
@@ -0,0 +1,6 @@
+package models
+
+// ClusterRegistry records which of the locally configured clusters bbe commands act on
+type ClusterRegistry struct {
+	Current string `yaml:"current"`
+}
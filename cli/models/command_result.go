@@ -0,0 +1,85 @@
+package models
+
+// Results printed by commands supporting --output json|yaml, their field names are relied upon by automation
+
+type VersionResult struct {
+	Version string `json:"version" yaml:"version"`
+	Os      string `json:"os" yaml:"os"`
+	Arch    string `json:"arch" yaml:"arch"`
+}
+
+type ClusterResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Current bool   `json:"current" yaml:"current"`
+}
+
+type StatusResult struct {
+	Cluster      string `json:"cluster" yaml:"cluster"`
+	Context      string `json:"context,omitempty" yaml:"context,omitempty"`
+	ConfigDir    string `json:"config_dir" yaml:"config_dir"`
+	ControlPlane string `json:"control_plane,omitempty" yaml:"control_plane,omitempty"`
+	Kubeconfig   string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	ApiServer    string `json:"api_server,omitempty" yaml:"api_server,omitempty"`
+	ApiReachable bool   `json:"api_reachable" yaml:"api_reachable"`
+	Storage      string `json:"storage" yaml:"storage"`
+	Packages     int    `json:"packages" yaml:"packages"`
+}
+
+type PackageResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Version string `json:"version" yaml:"version"`
+	Latest  string `json:"latest,omitempty" yaml:"latest,omitempty"` // Version offered by the libraries, empty when they could not be loaded
+	Policy  string `json:"policy" yaml:"policy"`
+	Library string `json:"library" yaml:"library"`
+}
+
+type UpgradePlanEntry struct {
+	Name      string `json:"name" yaml:"name"`
+	Current   string `json:"current" yaml:"current"`
+	Available string `json:"available" yaml:"available"`
+	Change    string `json:"change" yaml:"change"` // "patch", "minor", "major" or "downgrade"
+	Policy    string `json:"policy" yaml:"policy"`
+	Action    string `json:"action" yaml:"action"` // "upgrade", or "held" when the policy does not allow the change
+}
+
+type DoctorCheck struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"` // "pass", "warn" or "fail"
+	Message string `json:"message" yaml:"message"`
+	Hint    string `json:"hint,omitempty" yaml:"hint,omitempty"` // How to fix a warning or failure
+}
+
+type DoctorResult struct {
+	Checks []DoctorCheck `json:"checks" yaml:"checks"`
+}
+
+type SelfUpdateResult struct {
+	Previous string `json:"previous" yaml:"previous"`
+	Current  string `json:"current" yaml:"current"`
+	Updated  bool   `json:"updated" yaml:"updated"`
+}
+
+type PackagePlanEntry struct {
+	Name      string `json:"name" yaml:"name"`                       // Name of the release
+	Chart     string `json:"chart,omitempty" yaml:"chart,omitempty"` // Library chart, empty when it matches the name
+	Version   string `json:"version" yaml:"version"`
+	Current   string `json:"current,omitempty" yaml:"current,omitempty"` // Installed version of an upgrade
+	Library   string `json:"library" yaml:"library"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Action    string `json:"action" yaml:"action"`                 // "install", "upgrade" or "uninstall"
+	Diff      string `json:"diff,omitempty" yaml:"diff,omitempty"` // Manifest changes of an upgrade, when helm could render them
+}
+
+type SetupPlan struct {
+	Node       string           `json:"node" yaml:"node"`
+	Hostname   string           `json:"hostname" yaml:"hostname"`
+	ConfigFile string           `json:"config_file" yaml:"config_file"` // Talos config applied to the node
+	Bootstrap  bool             `json:"bootstrap" yaml:"bootstrap"`
+	Files      []ConfigFileDiff `json:"files" yaml:"files"`
+}
+
+type ConfigFileDiff struct {
+	File      string `json:"file" yaml:"file"`
+	Generated bool   `json:"generated" yaml:"generated"` // The file does not exist yet, the diff is against the config talosctl would generate
+	Diff      string `json:"diff" yaml:"diff"`
+}
@@ -0,0 +1,10 @@
+package models
+
+type HelmRelease struct {
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	Revision   string `json:"revision"`
+	Status     string `json:"status"`
+	Chart      string `json:"chart"` // Chart name and version, e.g. "blocky-0.1.3"
+	AppVersion string `json:"app_version"`
+}
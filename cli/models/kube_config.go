@@ -0,0 +1,21 @@
+package models
+
+// KubeConfig covers the parts of a kubeconfig bbe reads and merges, every other key is kept as is
+type KubeConfig struct {
+	ApiVersion     string                 `yaml:"apiVersion,omitempty"`
+	Kind           string                 `yaml:"kind,omitempty"`
+	Clusters       []KubeConfigEntry      `yaml:"clusters"`
+	Contexts       []KubeConfigEntry      `yaml:"contexts"`
+	Users          []KubeConfigEntry      `yaml:"users"`
+	CurrentContext string                 `yaml:"current-context"`
+	Extra          map[string]interface{} `yaml:",inline"`
+}
+
+// KubeConfigEntry is a named cluster, context or user, only the field matching its list is set
+type KubeConfigEntry struct {
+	Name    string                 `yaml:"name"`
+	Cluster map[string]interface{} `yaml:"cluster,omitempty"`
+	Context map[string]interface{} `yaml:"context,omitempty"`
+	User    map[string]interface{} `yaml:"user,omitempty"`
+	Extra   map[string]interface{} `yaml:",inline"`
+}
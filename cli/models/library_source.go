@@ -0,0 +1,8 @@
+package models
+
+type LibrarySource struct {
+	Name          string `yaml:"name"`
+	Source        string `yaml:"source"`                   // URL, local file or oci:// reference
+	PublicKey     string `yaml:"public_key,omitempty"`     // Base64 encoded ed25519 key the library must be signed with
+	AllowUnsigned bool   `yaml:"allow_unsigned,omitempty"` // Accept the library without a public key or signature, its contents are then not verified
+}
@@ -1,6 +1,10 @@
 package models
 
 type LocalPackage struct {
-	Name    string `yaml:"name,omitempty"`
-	Version string `yaml:"version,omitempty"`
+	Name      string `yaml:"name,omitempty"` // Name of the helm release
+	Version   string `yaml:"version,omitempty"`
+	Policy    string `yaml:"policy,omitempty"`    // "patch", "minor", "major" or "pinned", defaults to "major"
+	Library   string `yaml:"library,omitempty"`   // Library the package was installed from, defaults to the official library
+	Chart     string `yaml:"chart,omitempty"`     // Library chart of the package, defaults to the name, lets a chart be installed more than once
+	Namespace string `yaml:"namespace,omitempty"` // Namespace of the release, defaults to the name
 }
@@ -0,0 +1,12 @@
+package models
+
+type PackageDrift struct {
+	Name           string
+	Chart          string // Library chart of the release, empty when it matches the name
+	Namespace      string // Namespace of the release
+	Kind           string // "missing", "version", "status" or "unmanaged"
+	ConfigVersion  string // Version recorded in bbe.yaml, empty for unmanaged releases
+	ClusterVersion string // Version of the live release, empty for missing releases
+	Status         string // Helm status of the live release
+	Library        string // Library providing the chart, if known
+}
@@ -0,0 +1,8 @@
+package models
+
+// PackageListing is a library package as offered for selection
+type PackageListing struct {
+	Name      string // Name shown to the user, prefixed with the library outside the default library
+	Chart     ChartEntry
+	Installed string // Version recorded in bbe.yaml, empty when the package is not installed
+}
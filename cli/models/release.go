@@ -0,0 +1,7 @@
+package models
+
+// Release is a published version of bbe, Assets maps the file names of the release to their download URLs
+type Release struct {
+	Version string
+	Assets  map[string]string
+}
@@ -0,0 +1,28 @@
+package models
+
+type StorageConfig struct {
+	Type string `yaml:"type,omitempty"` // "local", "aws", "s3", "git" or "directory"
+	Aws  struct {
+		Region       string `yaml:"region,omitempty"`        // Defaults to "eu-west-1"
+		Profile      string `yaml:"profile,omitempty"`       // Named profile from ~/.aws/config, the default credential chain is used when empty
+		Endpoint     string `yaml:"endpoint,omitempty"`      // e.g. a VPC endpoint or LocalStack, empty for the public AWS endpoint
+		BucketName   string `yaml:"bucket_name,omitempty"`   // Created when it does not exist yet
+		BucketPrefix string `yaml:"bucket_prefix,omitempty"` // Used to find or create a bucket when no bucket name is set, defaults to "bbe-config"
+	} `yaml:"aws,omitempty"`
+	S3 struct {
+		Endpoint   string `yaml:"endpoint,omitempty"` // e.g. https://minio.local:9000 or https://<account>.r2.cloudflarestorage.com
+		Region     string `yaml:"region,omitempty"`
+		BucketName string `yaml:"bucket_name,omitempty"`
+		PathStyle  bool   `yaml:"path_style,omitempty"` // Required by most self-hosted endpoints such as MinIO
+	} `yaml:"s3,omitempty"`
+	Git struct {
+		Repository string `yaml:"repository,omitempty"`
+		Branch     string `yaml:"branch,omitempty"` // Defaults to "main"
+	} `yaml:"git,omitempty"`
+	Directory struct {
+		Path string `yaml:"path,omitempty"` // e.g. a mounted NFS share or USB drive
+	} `yaml:"directory,omitempty"`
+	Encryption struct {
+		Recipient string `yaml:"recipient,omitempty"` // age public key, the private key never leaves the machine
+	} `yaml:"encryption,omitempty"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type StorageObject struct {
+	Name    string
+	Version string // Backend specific revision, e.g. an S3 version id or a git commit
+	ModTime time.Time
+	Content []byte // Only set when the object was fetched with Get
+}
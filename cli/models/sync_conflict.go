@@ -0,0 +1,10 @@
+package models
+
+// SyncConflict describes a config file that changed both locally and in remote storage since the last sync
+type SyncConflict struct {
+	Name          string
+	Base          []byte // Content at the last sync, nil when the file was never synced from this machine
+	Local         []byte
+	Remote        []byte
+	RemoteVersion string
+}
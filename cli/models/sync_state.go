@@ -0,0 +1,13 @@
+package models
+
+// SyncState records what every config file looked like the last time it was synced with remote storage
+type SyncState struct {
+	Files map[string]SyncedFile `yaml:"files"`
+}
+
+type SyncedFile struct {
+	Version   string `yaml:"version"`             // Remote version the local copy was last synced with
+	Hash      string `yaml:"hash"`                // sha256 of the content at the last sync, used to detect changes
+	Content   string `yaml:"content,omitempty"`   // Content at the last sync, used as the base of three-way merges, never kept for Talos secrets
+	Encrypted string `yaml:"encrypted,omitempty"` // Base64 encoded content of a Talos secret at the last sync, encrypted when encryption is enabled
+}
@@ -0,0 +1,8 @@
+package models
+
+// ToolVersion is an external program run by bbe, Minimum is the oldest version known to work
+type ToolVersion struct {
+	Name    string
+	Version string
+	Minimum string
+}
@@ -0,0 +1,331 @@
+package backup_service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/package_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/talos_service"
+	"gopkg.in/yaml.v2"
+)
+
+// Largest file accepted from a backup, config files are a few kilobytes
+const maxBackupFileSize = 16 << 20
+
+var backupConfigFiles = []string{
+	constants.BbeConfigFile,
+	constants.TalosConfigFile,
+	constants.ControlplaneConfigFile,
+	constants.WorkerConfigFile,
+	// Without the key the stored config cannot be decrypted on another workstation, the backup itself is encrypted with the passphrase
+	constants.EncryptionKeyFile,
+}
+
+type BackupService struct{}
+
+// Create collects the files of the current cluster, the kubeconfig and package values are skipped with a warning when the cluster is unreachable
+func (backupService BackupService) Create(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, helmService interfaces.HelmServiceInterface) (*models.Backup, error) {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load the BBE configuration: %w", err)
+	}
+
+	backup := &models.Backup{
+		Manifest: models.BackupManifest{
+			Version:    constants.BackupFormatVersion,
+			Cluster:    helperService.GetClusterName(),
+			CreatedAt:  time.Now().UTC().Truncate(time.Second),
+			CliVersion: constants.Version,
+		},
+		Files: map[string][]byte{},
+	}
+
+	for _, name := range backupConfigFiles {
+		filePath := helperService.GetConfigFilePath(name)
+		if _, exists := helperService.CheckIfFileExists(filePath); !exists {
+			continue
+		}
+
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+		backup.Files[name] = content
+	}
+
+	if talosconfig, found := backup.Files[constants.TalosConfigFile]; found {
+		inventory, err := talos_service.ParseNodeInventory(talosconfig)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the nodes from %s: %w", constants.TalosConfigFile, err)
+		}
+
+		if _, found := backup.Files[constants.ControlplaneConfigFile]; found {
+			inventory.ControlPlaneEndpoint, err = talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		content, err := yaml.Marshal(inventory)
+		if err != nil {
+			return nil, err
+		}
+		backup.Files[constants.NodeInventoryFile] = content
+
+		if inventory.ControlPlaneEndpoint != "" {
+			kubeconfig, err := backupService.exportKubeConfig(helperService, talosService, inventory.ControlPlaneEndpoint)
+			if err != nil {
+				logger.Warning(fmt.Sprintf("Unable to export a kubeconfig, it can be recreated from the talosconfig later: %v", err))
+			} else {
+				backup.Files[constants.KubeConfigFile] = kubeconfig
+			}
+		}
+	}
+
+	for _, pkg := range bbeConfig.Bbe.Packages {
+		values, err := helmService.GetValues(pkg.Name, package_service.PackageNamespace(pkg), bbeConfig.Bbe.Cluster.Context)
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Skipping the values of %s: %v", pkg.Name, err))
+			continue
+		}
+
+		if trimmed := strings.TrimSpace(string(values)); trimmed == "" || trimmed == "null" || trimmed == "{}" {
+			continue
+		}
+		backup.Files[path.Join(constants.PackageValuesDir, pkg.Name+".yaml")] = values
+	}
+
+	backup.Manifest.Files = manifestFiles(backup.Files)
+	return backup, nil
+}
+
+// Write stores the backup as a gzipped tar archive encrypted with passphrase
+func (backupService BackupService) Write(backup *models.Backup, passphrase string, output string) error {
+	if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("The file `%s` already exists", output)
+	}
+
+	manifest, err := yaml.Marshal(backup.Manifest)
+	if err != nil {
+		return err
+	}
+
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = writeTarFile(tarWriter, constants.BackupManifestFile, manifest, backup.Manifest.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, file := range backup.Manifest.Files {
+		err = writeTarFile(tarWriter, file.Path, backup.Files[file.Path], backup.Manifest.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	encrypted, err := encryption.EncryptWithPassphrase(buffer.Bytes(), passphrase)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(output, encrypted, 0600)
+}
+
+// Read decrypts a backup and verifies every file against the checksums in its manifest
+func (backupService BackupService) Read(input string, passphrase string) (*models.Backup, error) {
+	content, err := os.ReadFile(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if !encryption.IsEncrypted(content) {
+		return nil, fmt.Errorf("`%s` is not a BBE backup", input)
+	}
+
+	archive, err := encryption.DecryptWithPassphrase(content, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := readTar(archive)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", constants.BackupIntegrityError, err)
+	}
+
+	manifestContent, found := files[constants.BackupManifestFile]
+	if !found {
+		return nil, fmt.Errorf("%w: the backup has no %s", constants.BackupIntegrityError, constants.BackupManifestFile)
+	}
+	delete(files, constants.BackupManifestFile)
+
+	var manifest models.BackupManifest
+	err = yaml.UnmarshalStrict(manifestContent, &manifest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s: %v", constants.BackupIntegrityError, constants.BackupManifestFile, err)
+	}
+
+	if manifest.Version > constants.BackupFormatVersion {
+		return nil, fmt.Errorf("The backup was created by a newer version of bbe (format %d), please upgrade bbe", manifest.Version)
+	}
+
+	err = verify(manifest, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Backup{Manifest: manifest, Files: files}, nil
+}
+
+// Restore writes the files of a backup into the config directory of the current cluster
+func (backupService BackupService) Restore(helperService interfaces.HelperServiceInterface, backup *models.Backup) error {
+	configDir := helperService.GetConfigDir()
+
+	for _, file := range backup.Manifest.Files {
+		filePath := filepath.Join(configDir, filepath.FromSlash(file.Path))
+
+		err := os.MkdirAll(filepath.Dir(filePath), 0700)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filePath, backup.Files[file.Path], 0600)
+		if err != nil {
+			return fmt.Errorf("Failed to restore %s: %w", file.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func (backupService BackupService) exportKubeConfig(helperService interfaces.HelperServiceInterface, talosService interfaces.TalosServiceInterface, controlPlaneIp string) ([]byte, error) {
+	tempDir, err := os.MkdirTemp("", "bbe-backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	destination := filepath.Join(tempDir, constants.KubeConfigFile)
+	err = talosService.ExportKubeConfig(helperService, controlPlaneIp, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(destination)
+}
+
+func manifestFiles(files map[string][]byte) []models.BackupFile {
+	manifest := []models.BackupFile{}
+	for name, content := range files {
+		manifest = append(manifest, models.BackupFile{Path: name, Size: int64(len(content)), Sha256: checksum(content)})
+	}
+
+	slices.SortFunc(manifest, func(a models.BackupFile, b models.BackupFile) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return manifest
+}
+
+func verify(manifest models.BackupManifest, files map[string][]byte) error {
+	listed := []string{}
+	for _, file := range manifest.Files {
+		listed = append(listed, file.Path)
+
+		content, found := files[file.Path]
+		if !found {
+			return fmt.Errorf("%w: %s is missing", constants.BackupIntegrityError, file.Path)
+		}
+		if checksum(content) != file.Sha256 {
+			return fmt.Errorf("%w: %s does not match its checksum", constants.BackupIntegrityError, file.Path)
+		}
+	}
+
+	for name := range files {
+		if !slices.Contains(listed, name) {
+			return fmt.Errorf("%w: %s is not listed in the manifest", constants.BackupIntegrityError, name)
+		}
+	}
+
+	return nil
+}
+
+func writeTarFile(writer *tar.Writer, name string, content []byte, modTime time.Time) error {
+	err := writer.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(content)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(content)
+	return err
+}
+
+func readTar(archive []byte) (map[string][]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	reader := tar.NewReader(gzipReader)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %s", header.Name)
+		}
+		// Only plain relative paths, a backup must not write outside the config directory
+		if !filepath.IsLocal(header.Name) || path.Clean(header.Name) != header.Name {
+			return nil, fmt.Errorf("invalid path %s", header.Name)
+		}
+		if header.Size > maxBackupFileSize {
+			return nil, fmt.Errorf("%s is too large", header.Name)
+		}
+
+		content, err := io.ReadAll(io.LimitReader(reader, maxBackupFileSize))
+		if err != nil {
+			return nil, err
+		}
+		files[header.Name] = content
+	}
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
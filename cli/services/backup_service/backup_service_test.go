@@ -0,0 +1,233 @@
+package backup_service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const talosconfig = "context: home\ncontexts:\n  home:\n    endpoints:\n    - 192.168.1.10\n    nodes:\n    - 192.168.1.10\n    - 192.168.1.11\n"
+
+func initBackupTest(t *testing.T, files map[string]string) (*mocks.MockHelperService, string) {
+	configDir := t.TempDir()
+
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetClusterName").Return("home")
+	helperService.On("GetConfigDir").Return(configDir)
+	for _, name := range backupConfigFiles {
+		filePath := filepath.Join(configDir, name)
+		helperService.On("GetConfigFilePath", name).Return(filePath)
+
+		content, found := files[name]
+		if !found {
+			helperService.On("CheckIfFileExists", filePath).Return(nil, false)
+			continue
+		}
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		assert.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+		helperService.On("CheckIfFileExists", filePath).Return(nil, true)
+	}
+
+	return helperService, configDir
+}
+
+func testBackup() *models.Backup {
+	files := map[string][]byte{
+		constants.BbeConfigFile:   []byte("bbe:\n  cluster:\n    name: home\n"),
+		"values/blocky.yaml":      []byte("replicas: 2\n"),
+		constants.TalosConfigFile: []byte(talosconfig),
+	}
+
+	return &models.Backup{
+		Manifest: models.BackupManifest{Version: constants.BackupFormatVersion, Cluster: "home", Files: manifestFiles(files)},
+		Files:    files,
+	}
+}
+
+func Test_Create_Succeeds_WithConfigFilesNodesAndValues(t *testing.T) {
+	backupService := BackupService{}
+	helperService, _ := initBackupTest(t, map[string]string{
+		constants.BbeConfigFile:          "bbe: {}\n",
+		constants.TalosConfigFile:        talosconfig,
+		constants.ControlplaneConfigFile: "machine: {}\n",
+	})
+
+	configService := &mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "admin@home"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{{Name: "blocky"}, {Name: "empty"}}
+	configService.On("GetBbeConfig", helperService).Return(bbeConfig, nil)
+
+	talosService := &mocks.MockTalosService{}
+	talosService.On("GetControlPlaneIp", helperService, constants.ControlplaneConfigFile).Return("192.168.1.10", nil)
+	talosService.On("ExportKubeConfig", helperService, "192.168.1.10", mock.Anything).Return(errors.New("unreachable"))
+
+	helmService := &mocks.MockHelmService{}
+	helmService.On("GetValues", "blocky", "blocky", "admin@home").Return([]byte("replicas: 2\n"), nil)
+	helmService.On("GetValues", "empty", "empty", "admin@home").Return([]byte("null\n"), nil)
+
+	backup, err := backupService.Create(helperService, configService, talosService, helmService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "home", backup.Manifest.Cluster)
+	assert.Equal(t, "replicas: 2\n", string(backup.Files["values/blocky.yaml"]))
+	assert.NotContains(t, backup.Files, "values/empty.yaml")
+	assert.NotContains(t, backup.Files, constants.WorkerConfigFile)
+	assert.NotContains(t, backup.Files, constants.KubeConfigFile)
+	assert.Equal(t, "control_plane_endpoint: 192.168.1.10\nendpoints:\n- 192.168.1.10\nnodes:\n- 192.168.1.10\n- 192.168.1.11\n", string(backup.Files[constants.NodeInventoryFile]))
+
+	paths := []string{}
+	for _, file := range backup.Manifest.Files {
+		paths = append(paths, file.Path)
+	}
+	assert.Equal(t, []string{"bbe.yaml", "controlplane.yaml", "nodes.yaml", "talosconfig", "values/blocky.yaml"}, paths)
+}
+
+func Test_Create_Fails_WithoutBbeConfig(t *testing.T) {
+	backupService := BackupService{}
+	helperService, _ := initBackupTest(t, map[string]string{})
+
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", helperService).Return((*models.BbeConfig)(nil), constants.ConfigNotFoundError)
+
+	_, err := backupService.Create(helperService, configService, &mocks.MockTalosService{}, &mocks.MockHelmService{})
+
+	assert.ErrorIs(t, err, constants.ConfigNotFoundError)
+}
+
+func Test_WriteAndRead_Succeeds_RoundTrip(t *testing.T) {
+	backupService := BackupService{}
+	output := filepath.Join(t.TempDir(), "backup.tar.gz.age")
+	backup := testBackup()
+
+	err := backupService.Write(backup, "correct horse", output)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(output)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	restored, err := backupService.Read(output, "correct horse")
+
+	assert.NoError(t, err)
+	assert.Equal(t, backup.Manifest, restored.Manifest)
+	assert.Equal(t, backup.Files, restored.Files)
+}
+
+func Test_Write_Fails_WhenOutputExists(t *testing.T) {
+	backupService := BackupService{}
+	output := filepath.Join(t.TempDir(), "backup.tar.gz.age")
+	assert.NoError(t, os.WriteFile(output, []byte("keep"), 0600))
+
+	err := backupService.Write(testBackup(), "correct horse", output)
+
+	assert.ErrorContains(t, err, "already exists")
+	content, _ := os.ReadFile(output)
+	assert.Equal(t, "keep", string(content))
+}
+
+func Test_Read_Fails_WithWrongPassphrase(t *testing.T) {
+	backupService := BackupService{}
+	output := filepath.Join(t.TempDir(), "backup.tar.gz.age")
+	assert.NoError(t, backupService.Write(testBackup(), "correct horse", output))
+
+	_, err := backupService.Read(output, "battery staple")
+
+	assert.ErrorIs(t, err, encryption.WrongPassphraseError)
+}
+
+func Test_Read_Fails_WithUnencryptedFile(t *testing.T) {
+	backupService := BackupService{}
+	input := filepath.Join(t.TempDir(), "backup.tar.gz")
+	assert.NoError(t, os.WriteFile(input, []byte("plain"), 0600))
+
+	_, err := backupService.Read(input, "correct horse")
+
+	assert.ErrorContains(t, err, "is not a BBE backup")
+}
+
+func Test_Verify_Fails_WithTamperedFile(t *testing.T) {
+	backup := testBackup()
+	backup.Files[constants.BbeConfigFile] = []byte("bbe: {}\n")
+
+	err := verify(backup.Manifest, backup.Files)
+
+	assert.ErrorIs(t, err, constants.BackupIntegrityError)
+	assert.ErrorContains(t, err, "bbe.yaml does not match its checksum")
+}
+
+func Test_Verify_Fails_WithUnlistedFile(t *testing.T) {
+	backup := testBackup()
+	backup.Files["extra.yaml"] = []byte("a: 1\n")
+
+	err := verify(backup.Manifest, backup.Files)
+
+	assert.ErrorIs(t, err, constants.BackupIntegrityError)
+	assert.ErrorContains(t, err, "extra.yaml is not listed")
+}
+
+func Test_Restore_Succeeds_WritesFilesIntoConfigDir(t *testing.T) {
+	backupService := BackupService{}
+	helperService, configDir := initBackupTest(t, map[string]string{})
+
+	err := backupService.Restore(helperService, testBackup())
+
+	assert.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(configDir, "values", "blocky.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "replicas: 2\n", string(content))
+	info, err := os.Stat(filepath.Join(configDir, constants.TalosConfigFile))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_CreateWriteReadRestore_Succeeds_WithEncryptionKey(t *testing.T) {
+	identity, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	backupService := BackupService{}
+	helperService, _ := initBackupTest(t, map[string]string{
+		constants.BbeConfigFile:     "bbe:\n  storage:\n    encryption:\n      recipient: " + recipient + "\n",
+		constants.EncryptionKeyFile: identity,
+	})
+
+	configService := &mocks.MockConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Encryption.Recipient = recipient
+	configService.On("GetBbeConfig", helperService).Return(bbeConfig, nil)
+
+	backup, err := backupService.Create(helperService, configService, &mocks.MockTalosService{}, &mocks.MockHelmService{})
+	assert.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "backup.tar.gz.age")
+	assert.NoError(t, backupService.Write(backup, "correct horse", output))
+
+	restored, err := backupService.Read(output, "correct horse")
+	assert.NoError(t, err)
+
+	newHelperService, newConfigDir := initBackupTest(t, map[string]string{})
+	assert.NoError(t, backupService.Restore(newHelperService, restored))
+
+	keyFile := filepath.Join(newConfigDir, constants.EncryptionKeyFile)
+	content, err := os.ReadFile(keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, identity, string(content))
+	info, err := os.Stat(keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// The restored key still decrypts what was stored for the recipient
+	encrypted, err := encryption.Encrypt([]byte("secret"), recipient)
+	assert.NoError(t, err)
+	decrypted, err := encryption.Decrypt(encrypted, string(content))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(decrypted))
+}
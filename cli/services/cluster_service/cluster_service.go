@@ -0,0 +1,166 @@
+package cluster_service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"gopkg.in/yaml.v2"
+)
+
+var clusterNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type ClusterService struct{}
+
+func (clusterService ClusterService) List(helperService interfaces.HelperServiceInterface) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(helperService.GetBaseDir(), constants.ClustersDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	clusters := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			clusters = append(clusters, entry.Name())
+		}
+	}
+
+	return clusters, nil
+}
+
+func (clusterService ClusterService) Exists(helperService interfaces.HelperServiceInterface, name string) bool {
+	clusters, err := clusterService.List(helperService)
+	return err == nil && slices.Contains(clusters, name)
+}
+
+// Create adds an empty cluster directory and makes it the active cluster
+func (clusterService ClusterService) Create(helperService interfaces.HelperServiceInterface, name string) error {
+	err := ValidateName(name)
+	if err != nil {
+		return err
+	}
+
+	if clusterService.Exists(helperService, name) {
+		return fmt.Errorf("Cluster `%s` already exists", name)
+	}
+
+	err = os.MkdirAll(clusterService.clusterDir(helperService, name), 0700)
+	if err != nil {
+		return fmt.Errorf("Failed to create the directory of cluster `%s`: %w", name, err)
+	}
+
+	return clusterService.Use(helperService, name)
+}
+
+func (clusterService ClusterService) Use(helperService interfaces.HelperServiceInterface, name string) error {
+	if !clusterService.Exists(helperService, name) {
+		return fmt.Errorf("%w: %s", constants.ClusterNotFoundError, name)
+	}
+
+	return clusterService.writeRegistry(helperService, models.ClusterRegistry{Current: name})
+}
+
+// Delete removes the local configuration of a cluster, the nodes and remote storage are left untouched
+func (clusterService ClusterService) Delete(helperService interfaces.HelperServiceInterface, name string) error {
+	if !clusterService.Exists(helperService, name) {
+		return fmt.Errorf("%w: %s", constants.ClusterNotFoundError, name)
+	}
+
+	if helperService.GetClusterName() == name {
+		return fmt.Errorf("Cluster `%s` is the active cluster, switch to another cluster with 'bbe cluster use' first", name)
+	}
+
+	return os.RemoveAll(clusterService.clusterDir(helperService, name))
+}
+
+// MigrateLegacyConfig moves a configuration written before multiple clusters were supported into its own cluster directory
+func (clusterService ClusterService) MigrateLegacyConfig(helperService interfaces.HelperServiceInterface) error {
+	baseDir := helperService.GetBaseDir()
+	if _, exists := helperService.CheckIfFileExists(filepath.Join(baseDir, constants.ClustersDir)); exists {
+		return nil
+	}
+
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	legacyEntries := []string{}
+	for _, entry := range entries {
+		// Log files belong to every cluster and stay in the base directory
+		if entry.Name() != constants.ClusterRegistryFile && entry.Name() != constants.LogsDir {
+			legacyEntries = append(legacyEntries, entry.Name())
+		}
+	}
+	if len(legacyEntries) == 0 {
+		return nil
+	}
+
+	name := legacyClusterName(baseDir)
+	clusterDir := clusterService.clusterDir(helperService, name)
+	err = os.MkdirAll(clusterDir, 0700)
+	if err != nil {
+		return fmt.Errorf("Failed to create the directory of cluster `%s`: %w", name, err)
+	}
+
+	for _, entry := range legacyEntries {
+		err := os.Rename(filepath.Join(baseDir, entry), filepath.Join(clusterDir, entry))
+		if err != nil {
+			return fmt.Errorf("Failed to move `%s` to cluster `%s`: %w", entry, name, err)
+		}
+	}
+
+	logger.Infof("Moved the existing configuration to cluster `%s`", name)
+	return clusterService.writeRegistry(helperService, models.ClusterRegistry{Current: name})
+}
+
+func (clusterService ClusterService) clusterDir(helperService interfaces.HelperServiceInterface, name string) string {
+	return filepath.Join(helperService.GetBaseDir(), constants.ClustersDir, name)
+}
+
+func (clusterService ClusterService) writeRegistry(helperService interfaces.HelperServiceInterface, registry models.ClusterRegistry) error {
+	file, err := yaml.Marshal(registry)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(helperService.GetBaseDir(), constants.ClusterRegistryFile), file, 0600)
+}
+
+// ValidateName only allows names that are safe to use as a directory name and as a Talos cluster name
+func ValidateName(name string) error {
+	if !clusterNamePattern.MatchString(name) {
+		return fmt.Errorf("Invalid cluster name `%s`, use lowercase letters, digits and dashes", name)
+	}
+
+	return nil
+}
+
+// legacyClusterName names the migrated cluster after the cluster in bbe.yaml when that is a valid name
+func legacyClusterName(baseDir string) string {
+	file, err := os.ReadFile(filepath.Join(baseDir, constants.BbeConfigFile))
+	if err != nil {
+		return constants.DefaultClusterName
+	}
+
+	var bbeConfig models.BbeConfig
+	if err := yaml.Unmarshal(file, &bbeConfig); err != nil || ValidateName(bbeConfig.Bbe.Cluster.Name) != nil {
+		return constants.DefaultClusterName
+	}
+
+	return bbeConfig.Bbe.Cluster.Name
+}
@@ -0,0 +1,154 @@
+package cluster_service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func initClusterTest(t *testing.T, current string) (*mocks.MockHelperService, string) {
+	baseDir := t.TempDir()
+
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetBaseDir").Return(baseDir)
+	helperService.On("GetClusterName").Return(current)
+
+	return helperService, baseDir
+}
+
+func Test_Create_Succeeds_AndSwitchesToTheNewCluster(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "default")
+
+	err := clusterService.Create(helperService, "lab")
+
+	assert.NoError(t, err)
+	assert.DirExists(t, filepath.Join(baseDir, "clusters", "lab"))
+	registry, err := os.ReadFile(filepath.Join(baseDir, "clusters.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "current: lab\n", string(registry))
+}
+
+func Test_Create_Fails_WithInvalidName(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "default")
+
+	err := clusterService.Create(helperService, "../home")
+
+	assert.ErrorContains(t, err, "Invalid cluster name")
+	assert.NoDirExists(t, filepath.Join(baseDir, "home"))
+}
+
+func Test_Create_Fails_WhenClusterExists(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, _ := initClusterTest(t, "default")
+
+	assert.NoError(t, clusterService.Create(helperService, "lab"))
+	err := clusterService.Create(helperService, "lab")
+
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func Test_List_Succeeds_WithoutClusters(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, _ := initClusterTest(t, "default")
+
+	clusters, err := clusterService.List(helperService)
+
+	assert.NoError(t, err)
+	assert.Empty(t, clusters)
+}
+
+func Test_Use_Fails_WithUnknownCluster(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, _ := initClusterTest(t, "default")
+
+	err := clusterService.Use(helperService, "lab")
+
+	assert.ErrorIs(t, err, constants.ClusterNotFoundError)
+}
+
+func Test_Delete_Succeeds_WithInactiveCluster(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "home")
+
+	assert.NoError(t, clusterService.Create(helperService, "lab"))
+	err := clusterService.Delete(helperService, "lab")
+
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(baseDir, "clusters", "lab"))
+}
+
+func Test_Delete_Fails_WithActiveCluster(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "lab")
+
+	assert.NoError(t, clusterService.Create(helperService, "lab"))
+	err := clusterService.Delete(helperService, "lab")
+
+	assert.ErrorContains(t, err, "is the active cluster")
+	assert.DirExists(t, filepath.Join(baseDir, "clusters", "lab"))
+}
+
+func Test_MigrateLegacyConfig_Succeeds_MovesExistingConfig(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "default")
+	helperService.On("CheckIfFileExists", filepath.Join(baseDir, "clusters")).Return(nil, false)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "bbe.yaml"), []byte("bbe:\n  cluster:\n    name: home\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "talosconfig"), []byte("context: home\n"), 0600))
+	assert.NoError(t, os.MkdirAll(filepath.Join(baseDir, "cache"), 0700))
+
+	err := clusterService.MigrateLegacyConfig(helperService)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(baseDir, "clusters", "home", "bbe.yaml"))
+	assert.FileExists(t, filepath.Join(baseDir, "clusters", "home", "talosconfig"))
+	assert.DirExists(t, filepath.Join(baseDir, "clusters", "home", "cache"))
+	assert.NoFileExists(t, filepath.Join(baseDir, "bbe.yaml"))
+	registry, err := os.ReadFile(filepath.Join(baseDir, "clusters.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "current: home\n", string(registry))
+}
+
+func Test_MigrateLegacyConfig_Succeeds_WithoutExistingConfig(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "default")
+	helperService.On("CheckIfFileExists", filepath.Join(baseDir, "clusters")).Return(nil, false)
+
+	err := clusterService.MigrateLegacyConfig(helperService)
+
+	assert.NoError(t, err)
+	assert.NoDirExists(t, filepath.Join(baseDir, "clusters"))
+}
+
+func Test_MigrateLegacyConfig_Succeeds_LeavesLogsInBaseDir(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "default")
+	helperService.On("CheckIfFileExists", filepath.Join(baseDir, "clusters")).Return(nil, false)
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(baseDir, "logs"), 0700))
+
+	err := clusterService.MigrateLegacyConfig(helperService)
+
+	assert.NoError(t, err)
+	assert.DirExists(t, filepath.Join(baseDir, "logs"))
+	assert.NoDirExists(t, filepath.Join(baseDir, "clusters"))
+}
+
+func Test_MigrateLegacyConfig_Succeeds_UsesDefaultNameForInvalidClusterName(t *testing.T) {
+	clusterService := ClusterService{}
+	helperService, baseDir := initClusterTest(t, "default")
+	helperService.On("CheckIfFileExists", filepath.Join(baseDir, "clusters")).Return(nil, false)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(baseDir, "bbe.yaml"), []byte("bbe:\n  cluster:\n    name: My Cluster\n"), 0644))
+
+	err := clusterService.MigrateLegacyConfig(helperService)
+
+	assert.NoError(t, err)
+	assert.FileExists(t, filepath.Join(baseDir, "clusters", "default", "bbe.yaml"))
+}
@@ -3,18 +3,26 @@ package config_service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/s3_service"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/storage_service"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -25,8 +33,16 @@ var osReadFile = os.ReadFile
 var osMkdirAll = os.MkdirAll
 var osWriteFile = os.WriteFile
 var initS3Client = initS3Service
+var initS3CompatibleClient = initS3CompatibleService
 var yamlMarshal = yaml.Marshal
 
+var syncedConfigFiles = []string{
+	constants.BbeConfigFile,
+	constants.TalosConfigFile,
+	constants.ControlplaneConfigFile,
+	constants.WorkerConfigFile,
+}
+
 type ConfigService struct{}
 
 func (config ConfigService) GetBbeConfig(helperService interfaces.HelperServiceInterface) (*models.BbeConfig, error) {
@@ -36,13 +52,207 @@ func (config ConfigService) GetBbeConfig(helperService interfaces.HelperServiceI
 
 	_, exists := helperService.CheckIfFileExists(filePath)
 	if !exists {
-		return nil, errors.New("Config file not found")
+		return nil, constants.ConfigNotFoundError
+	}
+
+	return config.readBbeConfig(helperService)
+}
+
+// ValidateBbeConfig checks bbe.yaml and returns a ValidationError listing every problem found
+func (config ConfigService) ValidateBbeConfig(helperService interfaces.HelperServiceInterface) error {
+	filePath := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.BbeConfigFile)
+	if _, exists := helperService.CheckIfFileExists(filePath); !exists {
+		return constants.ConfigNotFoundError
+	}
+
+	content, err := osReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	// Older files are checked as bbe migrates them, the file itself is only rewritten when bbe loads it
+	content, _, err = config.migrateBbeConfigContent(helperService, content)
+	if err != nil {
+		return err
+	}
+
+	problems := validateBbeYaml(content)
+
+	// Values can only be checked when every value has the expected type
+	var bbeConfig models.BbeConfig
+	if err := yaml.Unmarshal(content, &bbeConfig); err == nil {
+		problems = append(problems, checkBbeConfig(&bbeConfig)...)
+	}
+
+	if len(problems) > 0 {
+		return invalidBbeConfigError(helperService.GetConfigDir(), &ValidationError{Problems: problems})
+	}
+
+	return nil
+}
+
+// GetBbeValue returns a single value from bbe.yaml, or the YAML of everything below key
+func (config ConfigService) GetBbeValue(helperService interfaces.HelperServiceInterface, key string) (string, error) {
+	bbeConfig, err := config.GetBbeConfig(helperService)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := lookupKey(bbeConfig, key)
+	if err != nil {
+		return "", err
+	}
+
+	switch value.Kind() {
+	case reflect.Struct, reflect.Slice:
+		output, err := yamlMarshal(value.Interface())
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(string(output), "\n"), nil
+	default:
+		return fmt.Sprint(value.Interface()), nil
+	}
+}
+
+// SetBbeValue changes a single value in bbe.yaml, the change is only written when the result is valid
+func (config ConfigService) SetBbeValue(helperService interfaces.HelperServiceInterface, key string, input string) error {
+	normalizedKey := strings.TrimPrefix(key, "bbe.")
+	if slices.Contains(storageLocationKeys, normalizedKey) {
+		return fmt.Errorf("`%s` decides where the config files are stored, use 'bbe config migrate-storage' to change it", key)
+	}
+	if normalizedKey == "storage.encryption.recipient" {
+		return fmt.Errorf("`%s` is managed by 'bbe config encryption'", key)
+	}
+
+	bbeConfig, err := config.GetBbeConfig(helperService)
+	if err != nil {
+		return err
 	}
 
-	return config.readBbeConfig(helperService), nil
+	value, err := lookupKey(bbeConfig, key)
+	if err != nil {
+		return err
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(input)
+	case reflect.Int:
+		number, err := strconv.Atoi(input)
+		if err != nil {
+			return fmt.Errorf("`%s` must be a whole number", key)
+		}
+		value.SetInt(int64(number))
+	case reflect.Bool:
+		flag, err := strconv.ParseBool(input)
+		if err != nil {
+			return fmt.Errorf("`%s` must be true or false", key)
+		}
+		value.SetBool(flag)
+	default:
+		return fmt.Errorf("`%s` is not a single value, edit %s to change it", key, constants.BbeConfigFile)
+	}
+
+	if problems := checkBbeConfig(bbeConfig); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return config.writeBbeConfig(helperService, bbeConfig)
 }
 
-func (config ConfigService) GenerateBbeConfig(helperService interfaces.HelperServiceInterface, storage string) error {
+// MigrateStorage switches bbe.yaml to another storage and moves the local config files there, the previous storage is left untouched
+func (config ConfigService) MigrateStorage(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, storage models.StorageConfig) error {
+	migrated := *bbeConfig
+	migrated.Bbe.Storage = storage
+	if storage.Type != constants.StorageLocal {
+		migrated.Bbe.Storage.Encryption = bbeConfig.Bbe.Storage.Encryption
+	}
+
+	if problems := checkBbeConfig(&migrated); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	if storage.Type == constants.StorageLocal {
+		err := config.writeBbeConfig(helperService, &migrated)
+		if err != nil {
+			return err
+		}
+
+		*bbeConfig = migrated
+		return config.writeSyncState(helperService, &migrated, &models.SyncState{Files: map[string]models.SyncedFile{}})
+	}
+
+	// Resolve the bucket up front, the backend would otherwise write bbe.yaml before the new storage was checked
+	if storage.Type == constants.StorageAws && storage.Aws.BucketName == "" {
+		client, err := initS3Client(storage)
+		if err != nil {
+			return err
+		}
+
+		migrated.Bbe.Storage.Aws.BucketName, err = config.findOrCreateBucket(client, storage)
+		if err != nil {
+			return err
+		}
+	}
+
+	backend, err := config.storageBackend(helperService, &migrated)
+	if err != nil {
+		return err
+	}
+
+	err = config.checkMigrationTarget(helperService, backend)
+	if err != nil {
+		return err
+	}
+
+	err = config.writeBbeConfig(helperService, &migrated)
+	if err != nil {
+		return err
+	}
+	*bbeConfig = migrated
+
+	// The recorded versions belong to the previous storage
+	err = config.writeSyncState(helperService, &migrated, &models.SyncState{Files: map[string]models.SyncedFile{}})
+	if err != nil {
+		return err
+	}
+
+	return config.uploadConfigs(helperService, bbeConfig)
+}
+
+// checkMigrationTarget refuses to overwrite config files in the new storage that differ from the local copies
+func (config ConfigService) checkMigrationTarget(helperService interfaces.HelperServiceInterface, backend interfaces.StorageBackend) error {
+	different := []string{}
+
+	for _, name := range syncedConfigFiles {
+		if name == constants.BbeConfigFile {
+			// bbe.yaml always differs as it now points at the new storage
+			continue
+		}
+
+		remote, err := backend.Get(name)
+		if errors.Is(err, constants.StorageObjectNotFoundError) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		local, err := osReadFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), name))
+		if err != nil || !bytes.Equal(local, remote.Content) {
+			different = append(different, name)
+		}
+	}
+
+	if len(different) > 0 {
+		return fmt.Errorf("The new storage already contains different config files (%s), choose an empty location", strings.Join(different, ", "))
+	}
+
+	return nil
+}
+
+func (config ConfigService) GenerateBbeConfig(helperService interfaces.HelperServiceInterface, storage models.StorageConfig) error {
 	fileLocation := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.BbeConfigFile)
 	_, exists := helperService.CheckIfFileExists(fileLocation)
 	if exists {
@@ -50,16 +260,15 @@ func (config ConfigService) GenerateBbeConfig(helperService interfaces.HelperSer
 	}
 
 	bbeConfig := &models.BbeConfig{}
-	if storage == "local" {
-		bbeConfig.Bbe.Storage.Type = "local"
-	} else if storage == "aws" {
-		client, err := initS3Client()
+	bbeConfig.Bbe.Storage = storage
+
+	if storage.Type == constants.StorageAws {
+		client, err := initS3Client(storage)
 		if err != nil {
 			return err
 		}
 
-		bbeConfig.Bbe.Storage.Type = "aws"
-		bucketName, err := config.findOrCreateBucket(client)
+		bucketName, err := config.findOrCreateBucket(client, storage)
 		if err != nil {
 			return err
 		}
@@ -76,7 +285,18 @@ func (config ConfigService) UpdateBbeClusterName(helperService interfaces.Helper
 	}
 
 	bbeConfig.Bbe.Cluster.Name = clusterName
-	bbeConfig.Bbe.Cluster.Context = fmt.Sprintf("admin@%s", clusterName)
+
+	return config.writeBbeConfig(helperService, bbeConfig)
+}
+
+// UpdateBbeClusterContext records the kube context of the cluster as read from its kubeconfig
+func (config ConfigService) UpdateBbeClusterContext(helperService interfaces.HelperServiceInterface, context string) error {
+	bbeConfig, err := config.GetBbeConfig(helperService)
+	if err != nil {
+		return err
+	}
+
+	bbeConfig.Bbe.Cluster.Context = context
 
 	return config.writeBbeConfig(helperService, bbeConfig)
 }
@@ -103,6 +323,17 @@ func (config ConfigService) UpdateBbeAwsBucketName(helperService interfaces.Help
 	return config.writeBbeConfig(helperService, bbeConfig)
 }
 
+func (config ConfigService) UpdateBbeStorage(helperService interfaces.HelperServiceInterface, storage models.StorageConfig) error {
+	bbeConfig, err := config.GetBbeConfig(helperService)
+	if err != nil {
+		return err
+	}
+
+	bbeConfig.Bbe.Storage = storage
+
+	return config.writeBbeConfig(helperService, bbeConfig)
+}
+
 func (config ConfigService) UpdateBbePackages(helperService interfaces.HelperServiceInterface, packages []models.LocalPackage) error {
 	bbeConfig, err := config.GetBbeConfig(helperService)
 	if err != nil {
@@ -114,6 +345,17 @@ func (config ConfigService) UpdateBbePackages(helperService interfaces.HelperSer
 	return config.writeBbeConfig(helperService, bbeConfig)
 }
 
+func (config ConfigService) UpdateBbeLibraries(helperService interfaces.HelperServiceInterface, libraries []models.LibrarySource) error {
+	bbeConfig, err := config.GetBbeConfig(helperService)
+	if err != nil {
+		return err
+	}
+
+	bbeConfig.Bbe.Libraries = libraries
+
+	return config.writeBbeConfig(helperService, bbeConfig)
+}
+
 func (config ConfigService) writeBbeConfig(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
 	fileLocation := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.BbeConfigFile)
 
@@ -125,6 +367,7 @@ func (config ConfigService) writeBbeConfig(helperService interfaces.HelperServic
 		}
 	}
 	bbeConfig.Bbe.Packages = filteredPackages
+	bbeConfig.ApiVersion = bbeConfigApiVersion()
 
 	yamlFile, err := yamlMarshal(bbeConfig)
 	if err != nil {
@@ -163,60 +406,440 @@ func (config ConfigService) CheckForTalosConfigs(helperService interfaces.Helper
 	return true
 }
 
-func (config ConfigService) SyncConfigsWithAws(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
-	client, err := initS3Client()
+// SyncConflictError is returned by SyncConfigs when files changed both locally and in remote storage, every other file has been synced
+type SyncConflictError struct {
+	Conflicts []models.SyncConflict
+}
+
+func (err *SyncConflictError) Error() string {
+	names := []string{}
+	for _, conflict := range err.Conflicts {
+		names = append(names, conflict.Name)
+	}
+
+	return fmt.Sprintf("Config files changed both locally and in remote storage: %s", strings.Join(names, ", "))
+}
+
+func (config ConfigService) SyncConfigs(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	backend, err := config.storageBackend(helperService, bbeConfig)
 	if err != nil {
 		return err
 	}
 
-	if bbeConfig == nil || bbeConfig.Bbe.Storage.Aws.BucketName == "" {
-		bucketName, err := config.findOrCreateBucket(client)
+	state := config.readSyncState(helperService)
+	conflicts := []models.SyncConflict{}
+
+	for _, file := range syncedConfigFiles {
+		conflict, err := config.syncConfigFile(helperService, backend, state, file)
 		if err != nil {
 			return err
 		}
 
-		bbeConfig.Bbe.Storage.Aws.BucketName = bucketName
+		if conflict != nil {
+			conflicts = append(conflicts, *conflict)
+		}
 	}
 
-	configFiles := []string{
-		constants.BbeConfigFile,
-		constants.TalosConfigFile,
-		constants.ControlplaneConfigFile,
-		constants.WorkerConfigFile,
+	err = config.writeSyncState(helperService, bbeConfig, state)
+	if err != nil {
+		return err
 	}
 
-	for _, file := range configFiles {
-		err := config.syncConfigFileWithAws(helperService, client, bbeConfig, file)
+	if len(conflicts) > 0 {
+		return &SyncConflictError{Conflicts: conflicts}
+	}
+
+	return nil
+}
+
+// ResolveConflict stores content both locally and in remote storage, it fails when the remote file changed again since the conflict was detected
+func (config ConfigService) ResolveConflict(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, conflict models.SyncConflict, content []byte) error {
+	backend, err := config.storageBackend(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	current, err := backend.Version(conflict.Name)
+	if err != nil && !errors.Is(err, constants.StorageObjectNotFoundError) {
+		return err
+	}
+	if err == nil && current.Version != conflict.RemoteVersion {
+		return fmt.Errorf("%s changed in remote storage while the conflict was being resolved, run 'bbe config' again", conflict.Name)
+	}
+
+	err = osWriteFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), conflict.Name), content, 0600)
+	if err != nil {
+		return err
+	}
+
+	state := config.readSyncState(helperService)
+	if bytes.Equal(content, conflict.Remote) {
+		config.recordSync(state, conflict.Name, conflict.RemoteVersion, content)
+	} else {
+		err = config.upload(backend, state, conflict.Name, content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return config.writeSyncState(helperService, bbeConfig, state)
+}
+
+// ConfigHistory lists the versions of a config file kept by the remote storage, newest first
+func (config ConfigService) ConfigHistory(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, name string) ([]models.StorageObject, error) {
+	err := config.checkSyncedConfigFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := config.storageBackend(helperService, bbeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.Versions(name)
+}
+
+// RestoreConfig replaces the local copy with a previous version and uploads it as the newest version
+func (config ConfigService) RestoreConfig(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, name string, version string) error {
+	err := config.checkSyncedConfigFile(name)
+	if err != nil {
+		return err
+	}
+
+	backend, err := config.storageBackend(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	object, err := backend.GetVersion(name, version)
+	if err != nil {
+		return err
+	}
+
+	err = osWriteFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), name), object.Content, 0600)
+	if err != nil {
+		return err
+	}
+
+	state := config.readSyncState(helperService)
+	err = config.upload(backend, state, name, object.Content)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("Restored %s to version %s", name, version)
+	return config.writeSyncState(helperService, bbeConfig, state)
+}
+
+func (config ConfigService) checkSyncedConfigFile(name string) error {
+	if !slices.Contains(syncedConfigFiles, name) {
+		return fmt.Errorf("Unknown config file `%s`, expected one of %s", name, strings.Join(syncedConfigFiles, ", "))
+	}
+
+	return nil
+}
+
+// EnableEncryption creates or reuses the local encryption key and uploads the Talos secrets encrypted
+func (config ConfigService) EnableEncryption(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	if bbeConfig.Bbe.Storage.Encryption.Recipient != "" {
+		return errors.New("Encryption is already enabled, use 'bbe config encryption rotate' to replace the key")
+	}
+
+	keyFile := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.EncryptionKeyFile)
+
+	var recipient string
+	identities, err := osReadFile(keyFile)
+	if err == nil {
+		logger.Infof("Reusing existing encryption key %s", keyFile)
+		recipient, err = encryption.Recipient(string(identities))
+		if err != nil {
+			return err
+		}
+	} else {
+		var identity string
+		identity, recipient, err = encryption.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		err = config.writeEncryptionKey(keyFile, identity)
 		if err != nil {
 			return err
 		}
 	}
 
+	bbeConfig.Bbe.Storage.Encryption.Recipient = recipient
+	err = config.writeBbeConfig(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	// Versions uploaded before encryption was enabled are not rewritten by the upload
+	state := config.readSyncState(helperService)
+	if slices.ContainsFunc(constants.SecretConfigFiles, func(name string) bool { _, synced := state.Files[name]; return synced }) {
+		logger.Warning("Earlier unencrypted versions of the Talos secrets remain in the remote storage history, delete them there or regenerate the secrets")
+	}
+
+	err = config.uploadConfigs(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	logger.Warning(fmt.Sprintf("Talos secrets are now encrypted, back up %s: without it they cannot be recovered", keyFile))
 	return nil
 }
 
-func (config ConfigService) findOrCreateBucket(client interfaces.S3ServiceInterface) (string, error) {
+// RotateEncryptionKey re-encrypts the Talos secrets with a new key, the old key is kept until every file has been uploaded again
+func (config ConfigService) RotateEncryptionKey(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	if bbeConfig.Bbe.Storage.Encryption.Recipient == "" {
+		return errors.New("Encryption is not enabled, use 'bbe config encryption enable' first")
+	}
+
+	// Make sure the local copies are current before they are re-encrypted
+	err := config.SyncConfigs(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	keyFile := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.EncryptionKeyFile)
+	oldIdentities, err := osReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("%w: %s", constants.EncryptionKeyMissingError, keyFile)
+	}
+
+	identity, recipient, err := encryption.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	err = config.writeEncryptionKey(keyFile, identity+"\n"+string(oldIdentities))
+	if err != nil {
+		return err
+	}
+
+	bbeConfig.Bbe.Storage.Encryption.Recipient = recipient
+	err = config.writeBbeConfig(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	err = config.uploadConfigs(helperService, bbeConfig)
+	if err != nil {
+		return fmt.Errorf("Failed to re-encrypt config files, both keys are kept in %s: %w", keyFile, err)
+	}
+
+	err = config.writeEncryptionKey(keyFile, identity)
+	if err != nil {
+		return err
+	}
+
+	logger.Warning(fmt.Sprintf("Encryption key rotated, copy %s to your other machines", keyFile))
+	return nil
+}
+
+func (config ConfigService) writeEncryptionKey(keyFile string, identities string) error {
+	err := osMkdirAll(filepath.Dir(keyFile), 0700)
+	if err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(identities, "\n") {
+		identities += "\n"
+	}
+
+	return osWriteFile(keyFile, []byte(identities), 0600)
+}
+
+// uploadConfigs uploads every local config file regardless of the remote copy
+func (config ConfigService) uploadConfigs(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	backend, err := config.storageBackend(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	state := config.readSyncState(helperService)
+
+	for _, name := range syncedConfigFiles {
+		filePath := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), name)
+		if _, exists := helperService.CheckIfFileExists(filePath); !exists {
+			continue
+		}
+
+		content, err := osReadFile(filePath)
+		if err != nil {
+			return err
+		}
+
+		err = config.upload(backend, state, name, content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return config.writeSyncState(helperService, bbeConfig, state)
+}
+
+// storageBackend returns the configured backend, wrapped so Talos secrets are encrypted when encryption is enabled
+func (config ConfigService) storageBackend(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) (interfaces.StorageBackend, error) {
+	backend, err := config.remoteStorageBackend(helperService, bbeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFile := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.EncryptionKeyFile)
+	secrets := slices.Concat(constants.SecretConfigFiles, []string{constants.EtcdSnapshotDir})
+	return storage_service.NewEncryptedBackend(backend, bbeConfig.Bbe.Storage.Encryption.Recipient, keyFile, secrets), nil
+}
+
+// SnapshotBackend returns the backend etcd snapshots are stored in, a directory next to the configs when storage is local
+func (config ConfigService) SnapshotBackend(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) (interfaces.StorageBackend, error) {
+	if bbeConfig.Bbe.Storage.Type != constants.StorageLocal {
+		return config.storageBackend(helperService, bbeConfig)
+	}
+
+	snapshotDir := helperService.GetConfigFilePath(constants.LocalSnapshotDir)
+	err := osMkdirAll(snapshotDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage_service.NewDirectoryBackend(snapshotDir), nil
+}
+
+// CheckStorage lists the configured remote storage with the current credentials, unlike syncing it never creates a bucket
+func (config ConfigService) CheckStorage(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) error {
+	storage := bbeConfig.Bbe.Storage
+	if storage.Type == constants.StorageAws && storage.Aws.BucketName == "" {
+		client, err := initS3Client(storage)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.ListBuckets(context.Background(), &s3.ListBucketsInput{BucketRegion: aws.String(awsRegion(storage))})
+		return err
+	}
+
+	backend, err := config.remoteStorageBackend(helperService, bbeConfig)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.List()
+	return err
+}
+
+// remoteStorageBackend returns the backend for the configured storage type, an AWS bucket is found or created when none is configured yet
+func (config ConfigService) remoteStorageBackend(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig) (interfaces.StorageBackend, error) {
+	storage := bbeConfig.Bbe.Storage
+
+	switch storage.Type {
+	case constants.StorageAws:
+		client, err := initS3Client(storage)
+		if err != nil {
+			return nil, err
+		}
+
+		if storage.Aws.BucketName == "" {
+			bucketName, err := config.findOrCreateBucket(client, storage)
+			if err != nil {
+				return nil, err
+			}
+
+			bbeConfig.Bbe.Storage.Aws.BucketName = bucketName
+			err = config.writeBbeConfig(helperService, bbeConfig)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return storage_service.NewS3Backend(client, bbeConfig.Bbe.Storage.Aws.BucketName), nil
+	case constants.StorageS3:
+		if storage.S3.Endpoint == "" || storage.S3.BucketName == "" {
+			return nil, errors.New("S3 storage requires an endpoint and a bucket name")
+		}
+
+		client, err := initS3CompatibleClient(storage)
+		if err != nil {
+			return nil, err
+		}
+
+		return storage_service.NewS3Backend(client, storage.S3.BucketName), nil
+	case constants.StorageGit:
+		if storage.Git.Repository == "" {
+			return nil, errors.New("Git storage requires a repository")
+		}
+
+		workdir := helperService.GetConfigFilePath(constants.GitStorageCacheDir)
+		return storage_service.NewGitBackend(workdir, storage.Git.Repository, storage.Git.Branch), nil
+	case constants.StorageDirectory:
+		if storage.Directory.Path == "" {
+			return nil, errors.New("Directory storage requires a path")
+		}
+
+		return storage_service.NewDirectoryBackend(storage.Directory.Path), nil
+	default:
+		return nil, fmt.Errorf("Storage type `%s` does not support syncing", storage.Type)
+	}
+}
+
+// findOrCreateBucket uses the configured bucket, or the only bucket in the region starting with the configured prefix, and creates it when it does not exist
+func (config ConfigService) findOrCreateBucket(client interfaces.S3ServiceInterface, storage models.StorageConfig) (string, error) {
 	ctx := context.Background()
-	output, err := client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	region := awsRegion(storage)
+
+	prefix := storage.Aws.BucketPrefix
+	if storage.Aws.BucketName != "" {
+		prefix = storage.Aws.BucketName
+	} else if prefix == "" {
+		prefix = constants.DefaultAwsBucketPrefix
+	}
+
+	output, err := client.ListBuckets(ctx, &s3.ListBucketsInput{
+		Prefix:       aws.String(prefix),
+		BucketRegion: aws.String(region),
+	})
 	if err != nil {
 		return "", err
 	}
 
+	matches := []string{}
 	for _, bucket := range output.Buckets {
 		bucketName := aws.ToString(bucket.Name)
-		if len(bucketName) >= 10 && bucketName[:10] == "bbe-config" {
-			logger.Infof("Found existing configuration on AWS: %s", *bucket.Name)
-			return *bucket.Name, nil
+		if storage.Aws.BucketName != "" && bucketName != storage.Aws.BucketName {
+			continue
+		}
+		// Not every S3 compatible endpoint filters by prefix
+		if strings.HasPrefix(bucketName, prefix) {
+			matches = append(matches, bucketName)
 		}
 	}
 
+	if len(matches) == 1 {
+		logger.Infof("Found existing configuration on AWS: %s", matches[0])
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("Found multiple buckets starting with `%s` in %s (%s), set bbe.storage.aws.bucket_name in bbe.yaml to choose one", prefix, region, strings.Join(matches, ", "))
+	}
+
+	if storage.Aws.BucketName != "" {
+		logger.Infof("Creating configuration bucket %s in %s", storage.Aws.BucketName, region)
+		err = config.createS3Bucket(ctx, client, storage.Aws.BucketName, region)
+		if err != nil {
+			return "", err
+		}
+
+		return storage.Aws.BucketName, nil
+	}
+
 	logger.Info("No existing configuration found on AWS, creating a new one")
 	attempts := 0
 	for attempts < 3 {
 		timestamp := fmt.Sprintf("%d", time.Now().Unix())
-		bucketName := "bbe-config-" + timestamp
+		bucketName := prefix + "-" + timestamp
 
-		err = config.createS3Bucket(ctx, client, bucketName)
+		err = config.createS3Bucket(ctx, client, bucketName, region)
 		if err != nil {
 			logger.Error("Failed to create configuration bucket", err)
 		}
@@ -232,135 +855,326 @@ func (config ConfigService) findOrCreateBucket(client interfaces.S3ServiceInterf
 	return "", errors.New("failed to create AWS configuration after 3 attempts")
 }
 
-func (config ConfigService) readBbeConfig(helperService interfaces.HelperServiceInterface) *models.BbeConfig {
+func (config ConfigService) readBbeConfig(helperService interfaces.HelperServiceInterface) (*models.BbeConfig, error) {
 	configDir := helperService.GetConfigDir()
 
 	file, err := osReadFile(fmt.Sprintf("%s/%s", configDir, constants.BbeConfigFile))
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("Failed to read %s: %w", constants.BbeConfigFile, err)
 	}
 
 	var bbeConfig models.BbeConfig
-	err = yaml.Unmarshal(file, &bbeConfig)
+	if len(bytes.TrimSpace(file)) == 0 {
+		return &bbeConfig, nil
+	}
+
+	file, err = config.migrateBbeConfigFile(helperService, file)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return &bbeConfig
+	err = yaml.UnmarshalStrict(file, &bbeConfig)
+	if err != nil {
+		if problems := validateBbeYaml(file); len(problems) > 0 {
+			return nil, invalidBbeConfigError(configDir, &ValidationError{Problems: problems})
+		}
+		return nil, invalidBbeConfigError(configDir, fmt.Errorf("Failed to parse %s: %w", constants.BbeConfigFile, err))
+	}
+
+	return &bbeConfig, nil
 }
 
-func (config ConfigService) syncConfigFileWithAws(helperService interfaces.HelperServiceInterface, client interfaces.S3ServiceInterface, bbeConfig *models.BbeConfig, name string) error {
-	ctx := context.Background()
+// migrateBbeConfigFile upgrades bbe.yaml in place when it was written with an older schema, the original is kept as a backup
+func (config ConfigService) migrateBbeConfigFile(helperService interfaces.HelperServiceInterface, file []byte) ([]byte, error) {
+	migrated, version, err := config.migrateBbeConfigContent(helperService, file)
+	if err != nil {
+		return nil, err
+	}
+	if version == constants.BbeConfigVersion {
+		return file, nil
+	}
 
-	filePath := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), name)
+	backupFile := fmt.Sprintf(constants.BbeConfigBackupFile, version)
+	err = osWriteFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), backupFile), file, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to back up %s before migrating it: %w", constants.BbeConfigFile, err)
+	}
 
-	localModTime, exists := helperService.CheckIfFileExists(filePath)
+	err = osWriteFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.BbeConfigFile), migrated, 0644)
+	if err != nil {
+		return nil, err
+	}
 
-	output, s3Err := client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bbeConfig.Bbe.Storage.Aws.BucketName),
-		Key:    aws.String(name),
-	})
-	if s3Err != nil {
-		var noSuchKey *types.NoSuchKey
-		if !errors.As(s3Err, &noSuchKey) {
-			return s3Err
+	logger.Infof("Migrated %s from version %d to version %d, the previous file was saved as %s", constants.BbeConfigFile, version, constants.BbeConfigVersion, backupFile)
+	return migrated, nil
+}
+
+// migrateBbeConfigContent returns bbe.yaml upgraded to the current schema without touching the file, next to the version it was written with
+func (config ConfigService) migrateBbeConfigContent(helperService interfaces.HelperServiceInterface, file []byte) ([]byte, int, error) {
+	version, err := bbeConfigVersion(file)
+	if err != nil {
+		if problems := validateBbeYaml(file); len(problems) > 0 {
+			return nil, 0, invalidBbeConfigError(helperService.GetConfigDir(), &ValidationError{Problems: problems})
 		}
-	} else {
-		defer output.Body.Close()
+		return nil, 0, err
 	}
 
-	// File exists neither locally nor in S3
-	if !exists && s3Err != nil {
-		logger.Infof("No local config file found and no config file found in AWS")
-		return nil
+	if version > constants.BbeConfigVersion {
+		return nil, version, fmt.Errorf("%w: %s has version %d but this version of bbe supports up to version %d, please upgrade bbe", constants.ConfigTooNewError, constants.BbeConfigFile, version, constants.BbeConfigVersion)
+	}
+	if version == constants.BbeConfigVersion {
+		return file, version, nil
+	}
+
+	migrated, err := migrateBbeConfig(file, version)
+	if err != nil {
+		return nil, version, err
 	}
 
-	// File exists in S3 but not locally
-	if !exists && s3Err == nil {
-		s3FileContents, err := io.ReadAll(output.Body)
+	return migrated, version, nil
+}
+
+// syncConfigFile compares both copies against the last synced state, a conflict is returned when both sides changed
+func (config ConfigService) syncConfigFile(helperService interfaces.HelperServiceInterface, backend interfaces.StorageBackend, state *models.SyncState, name string) (*models.SyncConflict, error) {
+	filePath := fmt.Sprintf("%s/%s", helperService.GetConfigDir(), name)
+
+	_, exists := helperService.CheckIfFileExists(filePath)
+
+	remote, remoteErr := backend.Get(name)
+	if remoteErr != nil && !errors.Is(remoteErr, constants.StorageObjectNotFoundError) {
+		return nil, remoteErr
+	}
+
+	// File exists neither locally nor remotely
+	if !exists && remoteErr != nil {
+		logger.Infof("No local config file found and no config file found in remote storage")
+		return nil, nil
+	}
+
+	// File exists remotely but not locally
+	if !exists && remoteErr == nil {
+		err := osWriteFile(filePath, remote.Content, 0600)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		err = osWriteFile(filePath, s3FileContents, 0644)
+		config.recordSync(state, name, remote.Version, remote.Content)
+		logger.Infof("Config file %s synced from remote storage", name)
+		return nil, nil
+	}
+
+	content, err := osReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// File exists locally but not remotely
+	if remoteErr != nil {
+		err = config.upload(backend, state, name, content)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		logger.Infof("Config file %s synced from AWS", name)
-		return nil
+		logger.Infof("Config file %s synced to remote storage", name)
+		return nil, nil
 	}
 
-	// File exists locally but not in S3
-	if exists && s3Err != nil {
-		content, err := osReadFile(filePath)
+	// File exists both locally and remotely
+
+	if bytes.Equal(remote.Content, content) {
+		config.recordSync(state, name, remote.Version, content)
+		logger.Infof("Config file %s is already in sync", name)
+		return nil, nil
+	}
+
+	synced, known := state.Files[name]
+	localChanged := !known || synced.Hash != contentHash(content)
+	remoteChanged := !known || (synced.Version != remote.Version && synced.Hash != contentHash(remote.Content))
+
+	if !remoteChanged {
+		err = config.upload(backend, state, name, content)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		_, err = client.PutObject(ctx, &s3.PutObjectInput{
-			Bucket: aws.String(bbeConfig.Bbe.Storage.Aws.BucketName),
-			Key:    aws.String(name),
-			Body:   bytes.NewReader(content),
-		})
+
+		logger.Infof("Local changes to config file %s have been synced to remote storage", name)
+		return nil, nil
+	}
+
+	if !localChanged {
+		err = osWriteFile(filePath, remote.Content, 0600)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		logger.Infof("Config file %s synced to AWS", name)
-		return nil
+		config.recordSync(state, name, remote.Version, remote.Content)
+		logger.Infof("Config file %s was changed in remote storage and has been synced", name)
+		return nil, nil
+	}
+
+	conflict := &models.SyncConflict{
+		Name:          name,
+		Local:         content,
+		Remote:        remote.Content,
+		RemoteVersion: remote.Version,
 	}
+	if known {
+		conflict.Base = config.syncBase(helperService, name, synced)
+	}
+
+	return conflict, nil
+}
 
-	// File exists both locally and in S3
+// syncBase returns the content of a file at the last sync, nil when it was not kept or cannot be decrypted
+func (config ConfigService) syncBase(helperService interfaces.HelperServiceInterface, name string, synced models.SyncedFile) []byte {
+	if synced.Content != "" {
+		return []byte(synced.Content)
+	}
+	if synced.Encrypted == "" {
+		return nil
+	}
 
-	s3FileContents, err := io.ReadAll(output.Body)
+	encrypted, err := base64.StdEncoding.DecodeString(synced.Encrypted)
 	if err != nil {
-		return err
+		logger.Debug(fmt.Sprintf("Ignoring unreadable merge base of %s: %v", name, err))
+		return nil
 	}
 
-	content, s3Err := osReadFile(filePath)
-	if s3Err != nil {
-		return s3Err
+	identities, err := osReadFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.EncryptionKeyFile))
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Unable to read the encryption key for the merge base of %s: %v", name, err))
+		return nil
 	}
 
-	if bytes.Equal(s3FileContents, content) {
-		logger.Infof("Config file %s is already in sync", name)
+	content, err := encryption.Decrypt(encrypted, string(identities))
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Unable to decrypt the merge base of %s: %v", name, err))
 		return nil
 	}
 
-	s3ModTime := output.LastModified
+	return content
+}
+
+// upload stores content in remote storage and records the resulting version as synced
+func (config ConfigService) upload(backend interfaces.StorageBackend, state *models.SyncState, name string, content []byte) error {
+	err := backend.Put(name, content)
+	if err != nil {
+		return err
+	}
+
+	object, err := backend.Version(name)
+	if err != nil {
+		return err
+	}
+
+	config.recordSync(state, name, object.Version, content)
+	return nil
+}
+
+func (config ConfigService) recordSync(state *models.SyncState, name string, version string, content []byte) {
+	state.Files[name] = models.SyncedFile{Version: version, Hash: contentHash(content), Content: string(content)}
+}
+
+func contentHash(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// readSyncState returns an empty state when nothing was synced yet, every file that differs is then treated as a conflict
+func (config ConfigService) readSyncState(helperService interfaces.HelperServiceInterface) *models.SyncState {
+	state := &models.SyncState{}
 
-	if s3ModTime.After(*localModTime) {
-		err = osWriteFile(filePath, s3FileContents, 0644)
+	file, err := osReadFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.SyncStateFile))
+	if err == nil {
+		err = yaml.Unmarshal(file, state)
 		if err != nil {
-			return err
+			logger.Warning(fmt.Sprintf("Ignoring unreadable sync state: %v", err))
+			state = &models.SyncState{}
 		}
+	}
 
-		logger.Infof("Config file %s on AWS was newer than the local copy and has been synced", name)
-		return nil
+	if state.Files == nil {
+		state.Files = map[string]models.SyncedFile{}
 	}
 
-	_, s3Err = client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(bbeConfig.Bbe.Storage.Aws.BucketName),
-		Key:    aws.String(name),
-		Body:   bytes.NewReader(content),
-	})
-	if s3Err != nil {
-		return s3Err
+	// States written by earlier versions only kept the content
+	for name, synced := range state.Files {
+		if synced.Hash == "" && synced.Content != "" {
+			synced.Hash = contentHash([]byte(synced.Content))
+			state.Files[name] = synced
+		}
 	}
 
-	logger.Infof("Local config file %s was newer than the copy on AWS and has been synced", name)
-	return nil
+	return state
 }
 
-func (config ConfigService) createS3Bucket(ctx context.Context, client interfaces.S3ServiceInterface, bucketName string) error {
-	_, err := client.CreateBucket(ctx, &s3.CreateBucketInput{
+// writeSyncState keeps the merge base of the Talos secrets only when encryption is enabled and then only encrypted,
+// the file is still only readable by the user as the merge base of bbe.yaml is kept as is
+func (config ConfigService) writeSyncState(helperService interfaces.HelperServiceInterface, bbeConfig *models.BbeConfig, state *models.SyncState) error {
+	stored := &models.SyncState{Files: map[string]models.SyncedFile{}}
+	for name, synced := range state.Files {
+		if slices.Contains(constants.SecretConfigFiles, name) && synced.Content != "" {
+			base := []byte(synced.Content)
+			synced.Content = ""
+
+			recipient := bbeConfig.Bbe.Storage.Encryption.Recipient
+			if recipient != "" {
+				encrypted, err := encryption.Encrypt(base, recipient)
+				if err != nil {
+					return fmt.Errorf("Failed to encrypt the merge base of %s: %w", name, err)
+				}
+				synced.Encrypted = base64.StdEncoding.EncodeToString(encrypted)
+			}
+		}
+		stored.Files[name] = synced
+	}
+
+	file, err := yamlMarshal(stored)
+	if err != nil {
+		return err
+	}
+
+	return osWriteFile(fmt.Sprintf("%s/%s", helperService.GetConfigDir(), constants.SyncStateFile), file, 0600)
+}
+
+func (config ConfigService) createS3Bucket(ctx context.Context, client interfaces.S3ServiceInterface, bucketName string, region string) error {
+	input := &s3.CreateBucketInput{Bucket: aws.String(bucketName)}
+	// us-east-1 is the default location and is rejected as an explicit constraint
+	if region != "us-east-1" {
+		input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+			LocationConstraint: types.BucketLocationConstraint(region),
+		}
+	}
+
+	_, err := client.CreateBucket(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %w", err)
+	}
+
+	_, err = client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
 		Bucket: aws.String(bucketName),
-		CreateBucketConfiguration: &types.CreateBucketConfiguration{
-			LocationConstraint: types.BucketLocationConstraint("eu-west-1"),
+		PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       aws.Bool(true),
+			BlockPublicPolicy:     aws.Bool(true),
+			IgnorePublicAcls:      aws.Bool(true),
+			RestrictPublicBuckets: aws.Bool(true),
 		},
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create bucket: %w", err)
+		return fmt.Errorf("failed to block public access: %w", err)
+	}
+
+	_, err = client.PutBucketTagging(ctx, &s3.PutBucketTaggingInput{
+		Bucket: aws.String(bucketName),
+		Tagging: &types.Tagging{
+			TagSet: []types.Tag{
+				{Key: aws.String("managed-by"), Value: aws.String("bbe-quest")},
+				{Key: aws.String("purpose"), Value: aws.String("bbe-config")},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag bucket: %w", err)
 	}
 
 	_, err = client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
@@ -393,6 +1207,32 @@ func (config ConfigService) createS3Bucket(ctx context.Context, client interface
 	return nil
 }
 
-func initS3Service() (interfaces.S3ServiceInterface, error) {
-	return s3_service.Initialize(context.Background())
+func initS3Service(storage models.StorageConfig) (interfaces.S3ServiceInterface, error) {
+	return s3_service.Initialize(context.Background(), s3_service.Options{
+		Region:   awsRegion(storage),
+		Profile:  storage.Aws.Profile,
+		Endpoint: storage.Aws.Endpoint,
+	})
+}
+
+func awsRegion(storage models.StorageConfig) string {
+	if storage.Aws.Region == "" {
+		return constants.DefaultAwsRegion
+	}
+
+	return storage.Aws.Region
+}
+
+func initS3CompatibleService(storage models.StorageConfig) (interfaces.S3ServiceInterface, error) {
+	region := storage.S3.Region
+	if region == "" {
+		// Most S3 compatible services ignore the region but the SDK requires one
+		region = "us-east-1"
+	}
+
+	return s3_service.Initialize(context.Background(), s3_service.Options{
+		Region:    region,
+		Endpoint:  storage.S3.Endpoint,
+		PathStyle: storage.S3.PathStyle,
+	})
 }
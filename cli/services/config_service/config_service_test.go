@@ -5,11 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -64,7 +69,7 @@ func Test_GenerateBbeConfig_Succeeds_ReturnsOnExistingConfig(t *testing.T) {
 	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(nil, true)
 	mockHelperService.On("GetConfigDir").Return("")
 
-	err := configService.GenerateBbeConfig(mockHelperService, "local")
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "local"})
 
 	assert.NoError(t, err)
 
@@ -85,7 +90,7 @@ func Test_GenerateBbeConfig_Succeeds_CreatesLocalConfig(t *testing.T) {
 	osMkdirAll = mockOs.MkdirAll
 	osWriteFile = mockOs.WriteFile
 
-	err := configService.GenerateBbeConfig(mockHelperService, "local")
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "local"})
 
 	assert.NoError(t, err)
 
@@ -103,10 +108,12 @@ func Test_GenerateBbeConfig_Succeeds_CreatesAwsConfig_WithNoExistingBucket(t *te
 	mockS3Service := &mocks.MockS3Service{}
 	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{}, nil)
 	mockS3Service.On("CreateBucket", mock.Anything, mock.Anything, mock.Anything).Return(&s3.CreateBucketOutput{}, nil)
+	mockS3Service.On("PutPublicAccessBlock", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutPublicAccessBlockOutput{}, nil)
+	mockS3Service.On("PutBucketTagging", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketTaggingOutput{}, nil)
 	mockS3Service.On("PutBucketEncryption", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketEncryptionOutput{}, nil)
 	mockS3Service.On("PutBucketVersioning", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketVersioningOutput{}, nil)
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return mockS3Service, nil
 	}
 
@@ -116,7 +123,7 @@ func Test_GenerateBbeConfig_Succeeds_CreatesAwsConfig_WithNoExistingBucket(t *te
 	osMkdirAll = mockOs.MkdirAll
 	osWriteFile = mockOs.WriteFile
 
-	err := configService.GenerateBbeConfig(mockHelperService, "aws")
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "aws"})
 
 	assert.NoError(t, err)
 
@@ -141,7 +148,7 @@ func Test_GenerateBbeConfig_Succeeds_CreatesAwsConfig_WithExistingBucket(t *test
 		Buckets: []types.Bucket{{Name: aws.String("bbe-config-1738850879")}},
 	}, nil)
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return mockS3Service, nil
 	}
 
@@ -151,7 +158,7 @@ func Test_GenerateBbeConfig_Succeeds_CreatesAwsConfig_WithExistingBucket(t *test
 	osMkdirAll = mockOs.MkdirAll
 	osWriteFile = mockOs.WriteFile
 
-	err := configService.GenerateBbeConfig(mockHelperService, "aws")
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "aws"})
 
 	assert.NoError(t, err)
 
@@ -164,6 +171,78 @@ func Test_GenerateBbeConfig_Succeeds_CreatesAwsConfig_WithExistingBucket(t *test
 	mockS3Service.AssertNumberOfCalls(t, "PutBucketVersioning", 0)
 }
 
+func Test_GenerateBbeConfig_Succeeds_CreatesConfiguredAwsBucket(t *testing.T) {
+	configService := ConfigService{}
+
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(nil, false)
+	mockHelperService.On("GetConfigDir").Return("")
+
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{
+		Buckets: []types.Bucket{{Name: aws.String("my-config-backup")}},
+	}, nil)
+	mockS3Service.On("CreateBucket", mock.Anything, mock.Anything, mock.Anything).Return(&s3.CreateBucketOutput{}, nil)
+	mockS3Service.On("PutPublicAccessBlock", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutPublicAccessBlockOutput{}, nil)
+	mockS3Service.On("PutBucketTagging", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketTaggingOutput{}, nil)
+	mockS3Service.On("PutBucketEncryption", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketEncryptionOutput{}, nil)
+	mockS3Service.On("PutBucketVersioning", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketVersioningOutput{}, nil)
+
+	var usedStorage models.StorageConfig
+	initS3Client = func(storage models.StorageConfig) (interfaces.S3ServiceInterface, error) {
+		usedStorage = storage
+		return mockS3Service, nil
+	}
+
+	mockOs := &mocks.MockOs{}
+	mockOs.On("MkdirAll", mock.Anything, mock.Anything).Return(nil)
+	mockOs.On("WriteFile", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	osMkdirAll = mockOs.MkdirAll
+	osWriteFile = mockOs.WriteFile
+	yamlMarshal = yaml.Marshal
+
+	storage := models.StorageConfig{Type: "aws"}
+	storage.Aws.Region = "us-east-1"
+	storage.Aws.Profile = "homelab"
+	storage.Aws.BucketName = "my-config"
+
+	err := configService.GenerateBbeConfig(mockHelperService, storage)
+
+	assert.NoError(t, err)
+	assert.Equal(t, storage, usedStorage)
+
+	// us-east-1 does not accept a location constraint
+	mockS3Service.AssertCalled(t, "CreateBucket", mock.Anything, &s3.CreateBucketInput{Bucket: aws.String("my-config")}, mock.Anything)
+	mockS3Service.AssertNumberOfCalls(t, "PutPublicAccessBlock", 1)
+	mockS3Service.AssertNumberOfCalls(t, "PutBucketTagging", 1)
+
+	written := mockOs.Calls[len(mockOs.Calls)-1].Arguments.Get(1).([]byte)
+	assert.Contains(t, string(written), "bucket_name: my-config")
+	assert.Contains(t, string(written), "profile: homelab")
+}
+
+func Test_GenerateBbeConfig_Fails_WithMultipleMatchingAwsBuckets(t *testing.T) {
+	configService := ConfigService{}
+
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(nil, false)
+	mockHelperService.On("GetConfigDir").Return("")
+
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{
+		Buckets: []types.Bucket{{Name: aws.String("bbe-config-1")}, {Name: aws.String("bbe-config-2")}},
+	}, nil)
+
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
+		return mockS3Service, nil
+	}
+
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "aws"})
+
+	assert.ErrorContains(t, err, "bbe-config-1, bbe-config-2")
+	mockS3Service.AssertNumberOfCalls(t, "CreateBucket", 0)
+}
+
 func Test_GenerateBbeConfig_Fails_WithAwsConfigWithNoAwsCredentials(t *testing.T) {
 	configService := ConfigService{}
 
@@ -174,11 +253,11 @@ func Test_GenerateBbeConfig_Fails_WithAwsConfigWithNoAwsCredentials(t *testing.T
 	mockS3Service := &mocks.MockS3Service{}
 	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{}, nil)
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return nil, errors.New("test error")
 	}
 
-	err := configService.GenerateBbeConfig(mockHelperService, "aws")
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "aws"})
 
 	assert.Error(t, err)
 
@@ -196,11 +275,11 @@ func Test_GenerateBbeConfig_Fails_WhenFailingToQueryS3(t *testing.T) {
 	mockS3Service := &mocks.MockS3Service{}
 	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{}, errors.New("test error"))
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return mockS3Service, nil
 	}
 
-	err := configService.GenerateBbeConfig(mockHelperService, "aws")
+	err := configService.GenerateBbeConfig(mockHelperService, models.StorageConfig{Type: "aws"})
 
 	assert.Error(t, err)
 
@@ -218,6 +297,7 @@ func Test_UpdateBbeClusterName_Succeeds(t *testing.T) {
 
 	mockOs := &mocks.MockOs{}
 	config := models.BbeConfig{}
+	config.ApiVersion = bbeConfigApiVersion()
 	yamlFile, err := yaml.Marshal(config)
 	if err != nil {
 		panic(err)
@@ -262,6 +342,7 @@ func Test_UpdateBbeStorageType_Succeeds(t *testing.T) {
 	mockOs := &mocks.MockOs{}
 	config := models.BbeConfig{}
 	config.Bbe.Storage.Type = "testStorage"
+	config.ApiVersion = bbeConfigApiVersion()
 	yamlFile, err := yaml.Marshal(config)
 	if err != nil {
 		panic(err)
@@ -305,6 +386,7 @@ func Test_UpdateBbeAwsBucketName_Succeeds(t *testing.T) {
 	mockOs := &mocks.MockOs{}
 	config := models.BbeConfig{}
 	config.Bbe.Storage.Aws.BucketName = "testBucket"
+	config.ApiVersion = bbeConfigApiVersion()
 	yamlFile, err := yaml.Marshal(config)
 	if err != nil {
 		panic(err)
@@ -349,6 +431,7 @@ func Test_UpdateBbePackages_Succeeds(t *testing.T) {
 	config := models.BbeConfig{}
 	config.Bbe.Packages = []models.LocalPackage{{Name: "package1"}, {Name: "package2"}}
 	config.Bbe.Storage.Aws.BucketName = "testBucket"
+	config.ApiVersion = bbeConfigApiVersion()
 	yamlFile, err := yaml.Marshal(config)
 	if err != nil {
 		panic(err)
@@ -381,6 +464,93 @@ func Test_UpdateBbePackages_Fails_WhenUnableTo_GetBbeConfig(t *testing.T) {
 	mockHelperService.AssertNumberOfCalls(t, "GetConfigDir", 1)
 }
 
+func Test_UpdateBbeLibraries_Succeeds(t *testing.T) {
+	configService := ConfigService{}
+
+	mockHelperService := &mocks.MockHelperService{}
+	now := time.Now()
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(&now, true)
+	mockHelperService.On("GetConfigDir").Return("")
+
+	mockOs := &mocks.MockOs{}
+	yamlFile, err := yaml.Marshal(models.BbeConfig{})
+	if err != nil {
+		panic(err)
+	}
+	mockOs.On("MkdirAll", mock.Anything, mock.Anything).Return(nil)
+	mockOs.On("WriteFile", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockOs.On("ReadFile", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(yamlFile, nil)
+	osMkdirAll = mockOs.MkdirAll
+	osWriteFile = mockOs.WriteFile
+	osReadFile = mockOs.ReadFile
+	yamlMarshal = yaml.Marshal
+
+	err = configService.UpdateBbeLibraries(mockHelperService, []models.LibrarySource{{Name: "internal", Source: "https://example.com/library.yaml"}})
+
+	assert.NoError(t, err)
+	mockOs.AssertCalled(t, "WriteFile", "/bbe.yaml", mock.MatchedBy(func(data []byte) bool {
+		return bytes.Contains(data, []byte("name: internal"))
+	}), mock.Anything)
+}
+
+func Test_UpdateBbeLibraries_Fails_WhenUnableTo_GetBbeConfig(t *testing.T) {
+	configService := ConfigService{}
+
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(nil, false)
+	mockHelperService.On("GetConfigDir").Return("")
+
+	err := configService.UpdateBbeLibraries(mockHelperService, []models.LibrarySource{})
+
+	assert.Error(t, err)
+}
+
+func Test_UpdateBbeStorage_Succeeds(t *testing.T) {
+	configService := ConfigService{}
+
+	mockHelperService := &mocks.MockHelperService{}
+	now := time.Now()
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(&now, true)
+	mockHelperService.On("GetConfigDir").Return("")
+
+	mockOs := &mocks.MockOs{}
+	yamlFile, err := yaml.Marshal(models.BbeConfig{})
+	if err != nil {
+		panic(err)
+	}
+	mockOs.On("MkdirAll", mock.Anything, mock.Anything).Return(nil)
+	mockOs.On("WriteFile", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockOs.On("ReadFile", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(yamlFile, nil)
+	osMkdirAll = mockOs.MkdirAll
+	osWriteFile = mockOs.WriteFile
+	osReadFile = mockOs.ReadFile
+	yamlMarshal = yaml.Marshal
+
+	storage := models.StorageConfig{Type: "s3"}
+	storage.S3.Endpoint = "http://minio.local:9000"
+	storage.S3.BucketName = "bbe"
+	storage.S3.PathStyle = true
+
+	err = configService.UpdateBbeStorage(mockHelperService, storage)
+
+	assert.NoError(t, err)
+	mockOs.AssertCalled(t, "WriteFile", "/bbe.yaml", mock.MatchedBy(func(data []byte) bool {
+		return bytes.Contains(data, []byte("endpoint: http://minio.local:9000")) && bytes.Contains(data, []byte("path_style: true"))
+	}), mock.Anything)
+}
+
+func Test_UpdateBbeStorage_Fails_WhenUnableTo_GetBbeConfig(t *testing.T) {
+	configService := ConfigService{}
+
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("/%s", constants.BbeConfigFile)).Return(nil, false)
+	mockHelperService.On("GetConfigDir").Return("")
+
+	err := configService.UpdateBbeStorage(mockHelperService, models.StorageConfig{})
+
+	assert.Error(t, err)
+}
+
 func Test_CheckForTalosConfigs_Succeeds_WithAllFilesExisting(t *testing.T) {
 	configService := ConfigService{}
 
@@ -435,7 +605,7 @@ func Test_CheckForTalosConfigs_Succeeds_WithOneFileMissing(t *testing.T) {
 	mockHelperService.AssertNumberOfCalls(t, "GetConfigDir", 1)
 }
 
-func Test_SyncConfigsWithAws_Succeeds_WithConfigsOnlyLocally(t *testing.T) {
+func Test_SyncConfigs_Succeeds_WithAwsConfigsOnlyLocally(t *testing.T) {
 	configService := ConfigService{}
 
 	mockHelperService := &mocks.MockHelperService{}
@@ -446,6 +616,8 @@ func Test_SyncConfigsWithAws_Succeeds_WithConfigsOnlyLocally(t *testing.T) {
 	mockS3Service := &mocks.MockS3Service{}
 	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{}, nil)
 	mockS3Service.On("CreateBucket", mock.Anything, mock.Anything, mock.Anything).Return(&s3.CreateBucketOutput{}, nil)
+	mockS3Service.On("PutPublicAccessBlock", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutPublicAccessBlockOutput{}, nil)
+	mockS3Service.On("PutBucketTagging", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketTaggingOutput{}, nil)
 	mockS3Service.On("PutBucketEncryption", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketEncryptionOutput{}, nil)
 	mockS3Service.On("PutBucketVersioning", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutBucketVersioningOutput{}, nil)
 
@@ -453,22 +625,30 @@ func Test_SyncConfigsWithAws_Succeeds_WithConfigsOnlyLocally(t *testing.T) {
 	mockS3Service.On(("GetObject"), mock.Anything, mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{}, noSuchKeyError)
 
 	mockS3Service.On("PutObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{}, nil)
+	mockS3Service.On("HeadObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{VersionId: aws.String("v1")}, nil)
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return mockS3Service, nil
 	}
 
 	mockOs := &mocks.MockOs{}
 	config := models.BbeConfig{}
+	config.Bbe.Storage.Type = "aws"
+	config.ApiVersion = bbeConfigApiVersion()
 	yamlFile, err := yaml.Marshal(config)
 	if err != nil {
 		panic(err)
 	}
+	mockOs.On("ReadFile", fmt.Sprintf("/%s", constants.SyncStateFile)).Return([]byte{}, os.ErrNotExist)
 	mockOs.On("ReadFile", mock.Anything).Return(yamlFile, nil)
+	mockOs.On("WriteFile", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockOs.On("MkdirAll", mock.Anything, mock.Anything).Return(nil)
 	osWriteFile = mockOs.WriteFile
+	osMkdirAll = mockOs.MkdirAll
+	yamlMarshal = yaml.Marshal
 	osReadFile = mockOs.ReadFile
 
-	err = configService.SyncConfigsWithAws(mockHelperService, &config)
+	err = configService.SyncConfigs(mockHelperService, &config)
 
 	assert.NoError(t, err)
 
@@ -479,12 +659,16 @@ func Test_SyncConfigsWithAws_Succeeds_WithConfigsOnlyLocally(t *testing.T) {
 
 	mockS3Service.AssertNumberOfCalls(t, "GetObject", 4)
 	mockS3Service.AssertNumberOfCalls(t, "PutObject", 4)
+	mockS3Service.AssertNumberOfCalls(t, "HeadObject", 4)
 
-	mockOs.AssertNumberOfCalls(t, "ReadFile", 4)
-	mockOs.AssertNumberOfCalls(t, "WriteFile", 0)
+	// The four config files and the sync state are read, bbe.yaml is updated with the new bucket before the sync state is written
+	mockOs.AssertNumberOfCalls(t, "ReadFile", 5)
+	mockOs.AssertNumberOfCalls(t, "WriteFile", 2)
+	mockOs.AssertCalled(t, "WriteFile", fmt.Sprintf("/%s", constants.BbeConfigFile), mock.Anything, mock.Anything)
+	assert.True(t, strings.HasPrefix(config.Bbe.Storage.Aws.BucketName, "bbe-config-"))
 }
 
-func Test_SyncConfigsWithAws_Succeeds_WithConfigsRemotely(t *testing.T) {
+func Test_SyncConfigs_Succeeds_WithAwsConfigsRemotely(t *testing.T) {
 	configService := ConfigService{}
 
 	mockHelperService := &mocks.MockHelperService{}
@@ -502,16 +686,19 @@ func Test_SyncConfigsWithAws_Succeeds_WithConfigsRemotely(t *testing.T) {
 	mockS3Service.On(("GetObject"), mock.Anything, mock.Anything, mock.Anything).Return(s3ObjectOutput, nil)
 	mockS3Service.On("PutObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{}, nil)
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return mockS3Service, nil
 	}
 
 	mockOs := &mocks.MockOs{}
+	mockOs.On("ReadFile", mock.Anything).Return([]byte{}, os.ErrNotExist)
 	mockOs.On("WriteFile", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	osReadFile = mockOs.ReadFile
 	osWriteFile = mockOs.WriteFile
 
 	config := models.BbeConfig{}
-	err := configService.SyncConfigsWithAws(mockHelperService, &config)
+	config.Bbe.Storage.Type = "aws"
+	err := configService.SyncConfigs(mockHelperService, &config)
 
 	assert.NoError(t, err)
 
@@ -523,10 +710,11 @@ func Test_SyncConfigsWithAws_Succeeds_WithConfigsRemotely(t *testing.T) {
 	mockS3Service.AssertNumberOfCalls(t, "GetObject", 4)
 	mockS3Service.AssertNumberOfCalls(t, "PutObject", 0)
 
-	mockOs.AssertNumberOfCalls(t, "ReadFile", 0)
-	mockOs.AssertNumberOfCalls(t, "WriteFile", 4)
+	// Only the sync state is read, bbe.yaml is updated with the adopted bucket before the four config files and the sync state are written
+	mockOs.AssertNumberOfCalls(t, "ReadFile", 1)
+	mockOs.AssertNumberOfCalls(t, "WriteFile", 6)
 }
-func Test_SyncConfigsWithAws_Succeeds_WithMismatchedTimestamps(t *testing.T) {
+func Test_SyncConfigs_Fails_WithAwsConflictsRegardlessOfTimestamps(t *testing.T) {
 	configService := ConfigService{}
 
 	mockHelperService := &mocks.MockHelperService{}
@@ -555,7 +743,7 @@ func Test_SyncConfigsWithAws_Succeeds_WithMismatchedTimestamps(t *testing.T) {
 	mockS3Service.On(("GetObject"), mock.Anything, mock.Anything, mock.Anything).Return(s3ObjectOutput, nil).Once()
 	mockS3Service.On("PutObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{}, nil)
 
-	initS3Client = func() (interfaces.S3ServiceInterface, error) {
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
 		return mockS3Service, nil
 	}
 
@@ -570,9 +758,13 @@ func Test_SyncConfigsWithAws_Succeeds_WithMismatchedTimestamps(t *testing.T) {
 	osReadFile = mockOs.ReadFile
 
 	config := models.BbeConfig{}
-	err = configService.SyncConfigsWithAws(mockHelperService, &config)
+	config.Bbe.Storage.Type = "aws"
+	err = configService.SyncConfigs(mockHelperService, &config)
 
-	assert.NoError(t, err)
+	// Without a recorded sync neither side can be trusted, modification times are ignored
+	var conflictErr *SyncConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Len(t, conflictErr.Conflicts, 4)
 
 	mockS3Service.AssertNumberOfCalls(t, "ListBuckets", 1)
 	mockS3Service.AssertNumberOfCalls(t, "CreateBucket", 0)
@@ -580,9 +772,10 @@ func Test_SyncConfigsWithAws_Succeeds_WithMismatchedTimestamps(t *testing.T) {
 	mockS3Service.AssertNumberOfCalls(t, "PutBucketVersioning", 0)
 
 	mockS3Service.AssertNumberOfCalls(t, "GetObject", 4)
-	mockS3Service.AssertNumberOfCalls(t, "PutObject", 2)
+	mockS3Service.AssertNumberOfCalls(t, "PutObject", 0)
 
-	mockOs.AssertNumberOfCalls(t, "ReadFile", 4)
+	// The config files and the sync state are read, only bbe.yaml with the adopted bucket and the sync state are written
+	mockOs.AssertNumberOfCalls(t, "ReadFile", 5)
 	mockOs.AssertNumberOfCalls(t, "WriteFile", 2)
 }
 
@@ -702,3 +895,654 @@ func Test_WriteBbeConfig_Fails_On_Yaml_Marshal(t *testing.T) {
 	mockOs.AssertNumberOfCalls(t, "MkdirAll", 0)
 	mockOs.AssertNumberOfCalls(t, "WriteFile", 0)
 }
+
+func Test_SyncConfigs_Succeeds_WithDirectoryStorage(t *testing.T) {
+	configService := ConfigService{}
+	osReadFile = os.ReadFile
+	osWriteFile = os.WriteFile
+	yamlMarshal = yaml.Marshal
+
+	configDir := t.TempDir()
+	storageDir := t.TempDir()
+
+	// bbe.yaml only exists locally, talosconfig only exists in storage
+	err := os.WriteFile(filepath.Join(configDir, constants.BbeConfigFile), []byte("bbe: {}"), 0644)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(storageDir, constants.TalosConfigFile), []byte("talos"), 0644)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("GetConfigDir").Return(configDir)
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("%s/%s", configDir, constants.BbeConfigFile)).Return(&now, true)
+	mockHelperService.On("CheckIfFileExists", mock.Anything).Return(nil, false)
+
+	config := models.BbeConfig{}
+	config.Bbe.Storage.Type = "directory"
+	config.Bbe.Storage.Directory.Path = storageDir
+
+	err = configService.SyncConfigs(mockHelperService, &config)
+
+	assert.NoError(t, err)
+
+	uploaded, err := os.ReadFile(filepath.Join(storageDir, constants.BbeConfigFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "bbe: {}", string(uploaded))
+
+	downloaded, err := os.ReadFile(filepath.Join(configDir, constants.TalosConfigFile))
+	assert.NoError(t, err)
+	assert.Equal(t, "talos", string(downloaded))
+}
+
+func Test_SyncConfigs_Fails_WithIncompleteS3Storage(t *testing.T) {
+	configService := ConfigService{}
+
+	config := models.BbeConfig{}
+	config.Bbe.Storage.Type = "s3"
+	config.Bbe.Storage.S3.BucketName = "bbe"
+
+	err := configService.SyncConfigs(&mocks.MockHelperService{}, &config)
+
+	assert.ErrorContains(t, err, "S3 storage requires an endpoint and a bucket name")
+}
+
+func Test_SyncConfigs_Fails_WithLocalStorage(t *testing.T) {
+	configService := ConfigService{}
+
+	config := models.BbeConfig{}
+	config.Bbe.Storage.Type = "local"
+
+	err := configService.SyncConfigs(&mocks.MockHelperService{}, &config)
+
+	assert.Error(t, err)
+}
+
+func Test_EnableEncryption_Succeeds_UploadsEncryptedSecrets(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initEncryptionTest(t)
+
+	err := configService.EnableEncryption(mockHelperService, config)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, config.Bbe.Storage.Encryption.Recipient)
+
+	identity, err := os.ReadFile(filepath.Join(configDir, constants.EncryptionKeyFile))
+	assert.NoError(t, err)
+
+	uploaded, err := os.ReadFile(filepath.Join(storageDir, constants.TalosConfigFile))
+	assert.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(uploaded))
+
+	decrypted, err := encryption.Decrypt(uploaded, string(identity))
+	assert.NoError(t, err)
+	assert.Equal(t, "talos", string(decrypted))
+
+	uploadedConfig, err := os.ReadFile(filepath.Join(storageDir, constants.BbeConfigFile))
+	assert.NoError(t, err)
+	assert.Contains(t, string(uploadedConfig), config.Bbe.Storage.Encryption.Recipient)
+}
+
+func Test_EnableEncryption_Fails_WhenAlreadyEnabled(t *testing.T) {
+	configService := ConfigService{}
+
+	config := &models.BbeConfig{}
+	config.Bbe.Storage.Encryption.Recipient = "age1existing"
+
+	err := configService.EnableEncryption(&mocks.MockHelperService{}, config)
+
+	assert.Error(t, err)
+}
+
+func Test_RotateEncryptionKey_Succeeds(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initEncryptionTest(t)
+
+	err := configService.EnableEncryption(mockHelperService, config)
+	assert.NoError(t, err)
+	oldRecipient := config.Bbe.Storage.Encryption.Recipient
+	oldIdentity, err := os.ReadFile(filepath.Join(configDir, constants.EncryptionKeyFile))
+	assert.NoError(t, err)
+
+	err = configService.RotateEncryptionKey(mockHelperService, config)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldRecipient, config.Bbe.Storage.Encryption.Recipient)
+
+	newIdentity, err := os.ReadFile(filepath.Join(configDir, constants.EncryptionKeyFile))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(newIdentity), strings.TrimSpace(string(oldIdentity)))
+
+	uploaded, err := os.ReadFile(filepath.Join(storageDir, constants.TalosConfigFile))
+	assert.NoError(t, err)
+
+	_, err = encryption.Decrypt(uploaded, string(oldIdentity))
+	assert.ErrorIs(t, err, encryption.NoMatchingKeyError)
+
+	decrypted, err := encryption.Decrypt(uploaded, string(newIdentity))
+	assert.NoError(t, err)
+	assert.Equal(t, "talos", string(decrypted))
+}
+
+func Test_RotateEncryptionKey_Fails_WhenNotEnabled(t *testing.T) {
+	configService := ConfigService{}
+
+	err := configService.RotateEncryptionKey(&mocks.MockHelperService{}, &models.BbeConfig{})
+
+	assert.Error(t, err)
+}
+
+func initEncryptionTest(t *testing.T) (string, string, *mocks.MockHelperService, *models.BbeConfig) {
+	osReadFile = os.ReadFile
+	osWriteFile = os.WriteFile
+	osMkdirAll = os.MkdirAll
+	yamlMarshal = yaml.Marshal
+
+	configDir := t.TempDir()
+	storageDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(configDir, constants.TalosConfigFile), []byte("talos"), 0644)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("GetConfigDir").Return(configDir)
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("%s/%s", configDir, constants.BbeConfigFile)).Return(&now, true)
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("%s/%s", configDir, constants.TalosConfigFile)).Return(&now, true)
+	mockHelperService.On("CheckIfFileExists", mock.Anything).Return(nil, false)
+
+	config := &models.BbeConfig{}
+	config.Bbe.Storage.Type = "directory"
+	config.Bbe.Storage.Directory.Path = storageDir
+
+	return configDir, storageDir, mockHelperService, config
+}
+
+func Test_SyncConfigs_Succeeds_UploadsLocalChangesSinceLastSync(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initSyncTest(t, "one", "one")
+	assert.NoError(t, configService.SyncConfigs(mockHelperService, config))
+
+	err := os.WriteFile(filepath.Join(configDir, constants.TalosConfigFile), []byte("local"), 0644)
+	assert.NoError(t, err)
+
+	err = configService.SyncConfigs(mockHelperService, config)
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(storageDir, constants.TalosConfigFile), "local")
+}
+
+func Test_SyncConfigs_Succeeds_DownloadsRemoteChangesSinceLastSync(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initSyncTest(t, "one", "one")
+	assert.NoError(t, configService.SyncConfigs(mockHelperService, config))
+
+	err := os.WriteFile(filepath.Join(storageDir, constants.TalosConfigFile), []byte("remote"), 0644)
+	assert.NoError(t, err)
+	// The local copy is newer, which must not matter as it did not change since the last sync
+	assert.NoError(t, os.Chtimes(filepath.Join(storageDir, constants.TalosConfigFile), time.Now(), time.Now().Add(-time.Hour)))
+
+	err = configService.SyncConfigs(mockHelperService, config)
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.TalosConfigFile), "remote")
+}
+
+func Test_SyncConfigs_Fails_WhenBothSidesChangedSinceLastSync(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initSyncTest(t, "one", "one")
+	assert.NoError(t, configService.SyncConfigs(mockHelperService, config))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(configDir, constants.TalosConfigFile), []byte("local"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(storageDir, constants.TalosConfigFile), []byte("remote"), 0644))
+
+	err := configService.SyncConfigs(mockHelperService, config)
+
+	// Without encryption no copy of the Talos secrets is kept to merge from
+	var conflictErr *SyncConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, []models.SyncConflict{{
+		Name:          constants.TalosConfigFile,
+		Local:         []byte("local"),
+		Remote:        []byte("remote"),
+		RemoteVersion: conflictErr.Conflicts[0].RemoteVersion,
+	}}, conflictErr.Conflicts)
+	assertFileContent(t, filepath.Join(configDir, constants.TalosConfigFile), "local")
+	assertFileContent(t, filepath.Join(storageDir, constants.TalosConfigFile), "remote")
+}
+
+func Test_SyncConfigs_Succeeds_KeepsSecretsOutOfSyncState(t *testing.T) {
+	configService := ConfigService{}
+	configDir, _, mockHelperService, config := initSyncTest(t, "secret-talosconfig", "")
+
+	err := configService.SyncConfigs(mockHelperService, config)
+
+	assert.NoError(t, err)
+	state, err := os.ReadFile(filepath.Join(configDir, constants.SyncStateFile))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(state), "secret-talosconfig")
+	assert.Contains(t, string(state), contentHash([]byte("secret-talosconfig")))
+}
+
+func Test_SyncConfigs_Fails_WhenBothSidesChangedSinceLastSync_WithEncryptedBase(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initSyncTest(t, "one", "one")
+	identity, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(configDir, constants.EncryptionKeyFile)), 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(configDir, constants.EncryptionKeyFile), []byte(identity), 0600))
+	config.Bbe.Storage.Encryption.Recipient = recipient
+	assert.NoError(t, configService.SyncConfigs(mockHelperService, config))
+
+	state, err := os.ReadFile(filepath.Join(configDir, constants.SyncStateFile))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(state), "content: one")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(configDir, constants.TalosConfigFile), []byte("local"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(storageDir, constants.TalosConfigFile), []byte("remote"), 0644))
+
+	err = configService.SyncConfigs(mockHelperService, config)
+
+	var conflictErr *SyncConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, []byte("one"), conflictErr.Conflicts[0].Base)
+}
+
+func Test_ResolveConflict_Succeeds_StoresContentOnBothSides(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initSyncTest(t, "local", "remote")
+
+	err := configService.SyncConfigs(mockHelperService, config)
+	var conflictErr *SyncConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+
+	err = configService.ResolveConflict(mockHelperService, config, conflictErr.Conflicts[0], []byte("merged"))
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.TalosConfigFile), "merged")
+	assertFileContent(t, filepath.Join(storageDir, constants.TalosConfigFile), "merged")
+	assert.NoError(t, configService.SyncConfigs(mockHelperService, config))
+}
+
+func Test_ResolveConflict_Fails_WhenRemoteChangedAgain(t *testing.T) {
+	configService := ConfigService{}
+	configDir, storageDir, mockHelperService, config := initSyncTest(t, "local", "remote")
+
+	err := configService.SyncConfigs(mockHelperService, config)
+	var conflictErr *SyncConflictError
+	assert.ErrorAs(t, err, &conflictErr)
+	assert.NoError(t, os.WriteFile(filepath.Join(storageDir, constants.TalosConfigFile), []byte("newer"), 0644))
+
+	err = configService.ResolveConflict(mockHelperService, config, conflictErr.Conflicts[0], []byte("local"))
+
+	assert.Error(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.TalosConfigFile), "local")
+	assertFileContent(t, filepath.Join(storageDir, constants.TalosConfigFile), "newer")
+}
+
+func Test_RestoreConfig_Succeeds_WithCurrentVersion(t *testing.T) {
+	configService := ConfigService{}
+	configDir, _, mockHelperService, config := initSyncTest(t, "", "remote")
+
+	versions, err := configService.ConfigHistory(mockHelperService, config, constants.TalosConfigFile)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+
+	err = configService.RestoreConfig(mockHelperService, config, constants.TalosConfigFile, versions[0].Version)
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.TalosConfigFile), "remote")
+}
+
+func Test_RestoreConfig_Fails_WithUnknownFile(t *testing.T) {
+	configService := ConfigService{}
+
+	err := configService.RestoreConfig(&mocks.MockHelperService{}, &models.BbeConfig{}, "secrets.yaml", "v1")
+
+	assert.ErrorContains(t, err, "Unknown config file")
+}
+
+// initSyncTest syncs talosconfig between a config directory and directory storage, an empty content means the file does not exist
+func initSyncTest(t *testing.T, local string, remote string) (string, string, *mocks.MockHelperService, *models.BbeConfig) {
+	osReadFile = os.ReadFile
+	osWriteFile = os.WriteFile
+	osMkdirAll = os.MkdirAll
+	yamlMarshal = yaml.Marshal
+
+	configDir := t.TempDir()
+	storageDir := t.TempDir()
+
+	if local != "" {
+		assert.NoError(t, os.WriteFile(filepath.Join(configDir, constants.TalosConfigFile), []byte(local), 0644))
+	}
+	if remote != "" {
+		assert.NoError(t, os.WriteFile(filepath.Join(storageDir, constants.TalosConfigFile), []byte(remote), 0644))
+	}
+
+	now := time.Now()
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("GetConfigDir").Return(configDir)
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("%s/%s", configDir, constants.TalosConfigFile)).Return(&now, local != "")
+	mockHelperService.On("CheckIfFileExists", mock.Anything).Return(nil, false)
+
+	config := &models.BbeConfig{}
+	config.Bbe.Storage.Type = "directory"
+	config.Bbe.Storage.Directory.Path = storageDir
+
+	return configDir, storageDir, mockHelperService, config
+}
+
+func assertFileContent(t *testing.T, path string, expected string) {
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(content))
+}
+
+func Test_GetBbeConfig_Fails_WithMalformedYaml(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  storage:\n    type: [local\n")
+
+	config, err := configService.GetBbeConfig(mockHelperService)
+
+	assert.Nil(t, config)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.ErrorContains(t, err, "Invalid YAML")
+}
+
+func Test_GetBbeConfig_Fails_WithWrongType(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "apiVersion: bbe-quest/v1\nbbe:\n  packages:\n    name: blocky\n")
+
+	_, err := configService.GetBbeConfig(mockHelperService)
+
+	assert.ErrorContains(t, err, "line 4: bbe.packages must be a list")
+	assert.Equal(t, clierror.ExitConfigInvalid, clierror.ExitCode(err))
+	assert.Contains(t, clierror.Hint(err), "bbe config validate")
+}
+
+func Test_ValidateBbeConfig_Succeeds(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  storage:\n    type: git\n    git:\n      repository: git@example.com:bbe.git\n  packages:\n  - name: blocky\n    version: 1.0.0\n    policy: minor\n")
+
+	err := configService.ValidateBbeConfig(mockHelperService)
+
+	assert.NoError(t, err)
+}
+
+func Test_ValidateBbeConfig_Succeeds_WithoutMigratingFile(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "bbe:\n  cluster:\n    name: home\n")
+
+	err := configService.ValidateBbeConfig(mockHelperService)
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), "bbe:\n  cluster:\n    name: home\n")
+	assert.NoFileExists(t, filepath.Join(configDir, "bbe.yaml.v0.bak"))
+}
+
+func Test_ValidateBbeConfig_Fails_WithUnknownKeysAndInvalidValues(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  storage:\n    type: s3\n    s3:\n      bucket: bbe\n  packages:\n  - name: blocky\n    policy: sometimes\n")
+
+	err := configService.ValidateBbeConfig(mockHelperService)
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"line 6: unknown key `bbe.storage.s3.bucket`, expected one of endpoint, region, bucket_name, path_style",
+		"bbe.storage.s3.endpoint must be an http or https URL for storage type `s3`",
+		"bbe.storage.s3.bucket_name is required for storage type `s3`",
+		"bbe.packages[0] requires a name and a version",
+	}, validationErr.Problems)
+}
+
+func Test_ValidateBbeConfig_Succeeds_WithChartInstalledTwice(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  packages:\n  - name: postgres\n    version: 1.0.0\n  - name: postgres-analytics\n    chart: postgres\n    namespace: analytics\n    version: 1.0.0\n")
+
+	err := configService.ValidateBbeConfig(mockHelperService)
+
+	assert.NoError(t, err)
+}
+
+func Test_ValidateBbeConfig_Fails_WithInvalidNamespace(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  packages:\n  - name: postgres\n    namespace: Analytics_DB\n    version: 1.0.0\n")
+
+	err := configService.ValidateBbeConfig(mockHelperService)
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"bbe.packages[0].namespace `Analytics_DB` is not a valid namespace, it must be lowercase alphanumeric characters or '-' and at most 63 characters",
+	}, validationErr.Problems)
+}
+
+func Test_ValidateBbeConfig_Fails_WithLibraryWithoutPublicKey(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  library:\n    allow_unsigned: true\n  libraries:\n  - name: internal\n    source: https://example.com/library.yaml\n  - name: staging\n    source: https://example.com/staging.yaml\n    allow_unsigned: true\n")
+
+	err := configService.ValidateBbeConfig(mockHelperService)
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{
+		"bbe.libraries[0] requires a public_key, or allow_unsigned to accept it without verification",
+	}, validationErr.Problems)
+}
+
+func Test_GetBbeValue_Succeeds(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe:\n  storage:\n    type: aws\n    aws:\n      region: eu-north-1\n")
+
+	value, err := configService.GetBbeValue(mockHelperService, "storage.aws.region")
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-north-1", value)
+
+	value, err = configService.GetBbeValue(mockHelperService, "bbe.storage.aws")
+	assert.NoError(t, err)
+	assert.Equal(t, "region: eu-north-1", value)
+}
+
+func Test_GetBbeValue_Fails_WithUnknownKey(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe: {}\n")
+
+	_, err := configService.GetBbeValue(mockHelperService, "storage.aws.zone")
+
+	assert.ErrorContains(t, err, "Unknown key `storage.aws.zone`")
+}
+
+func Test_SetBbeValue_Succeeds(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "apiVersion: bbe-quest/v1\nbbe:\n  library:\n    timeout: 10\n")
+
+	err := configService.SetBbeValue(mockHelperService, "library.timeout", "30")
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), "apiVersion: bbe-quest/v1\nbbe:\n  library:\n    timeout: 30\n  packages: []\n")
+}
+
+func Test_SetBbeValue_Fails_WithStorageLocation(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "bbe: {}\n")
+
+	err := configService.SetBbeValue(mockHelperService, "storage.type", "aws")
+
+	assert.ErrorContains(t, err, "bbe config migrate-storage")
+}
+
+func Test_SetBbeValue_Fails_WithInvalidValue(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "apiVersion: bbe-quest/v1\nbbe:\n  library:\n    timeout: 10\n")
+
+	err := configService.SetBbeValue(mockHelperService, "library.timeout", "-1")
+
+	assert.ErrorContains(t, err, "bbe.library.timeout must not be negative")
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), "apiVersion: bbe-quest/v1\nbbe:\n  library:\n    timeout: 10\n")
+}
+
+func Test_SetBbeValue_Succeeds_WithSnapshotRetention(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "apiVersion: bbe-quest/v1\nbbe: {}\n")
+
+	err := configService.SetBbeValue(mockHelperService, "etcd.snapshots.keep", "14")
+
+	assert.NoError(t, err)
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), "apiVersion: bbe-quest/v1\nbbe:\n  etcd:\n    snapshots:\n      keep: 14\n  packages: []\n")
+}
+
+func Test_SnapshotBackend_Succeeds_WithLocalStorage(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "bbe: {}\n")
+	snapshotDir := filepath.Join(configDir, constants.LocalSnapshotDir)
+	mockHelperService.On("GetConfigFilePath", constants.LocalSnapshotDir).Return(snapshotDir)
+
+	config := &models.BbeConfig{}
+	config.Bbe.Storage.Type = constants.StorageLocal
+	backend, err := configService.SnapshotBackend(mockHelperService, config)
+
+	assert.NoError(t, err)
+	assert.NoError(t, backend.Put("etcd/home.db", []byte("etcd")))
+	assert.FileExists(t, filepath.Join(snapshotDir, "etcd", "home.db"))
+}
+
+func Test_MigrateStorage_Succeeds_FromLocalToDirectory(t *testing.T) {
+	configService := ConfigService{}
+	configDir, _, mockHelperService, config := initSyncTest(t, "talos", "")
+	storageDir := config.Bbe.Storage.Directory.Path
+	config.Bbe.Storage = models.StorageConfig{Type: "local"}
+
+	storage := models.StorageConfig{Type: "directory"}
+	storage.Directory.Path = storageDir
+
+	err := configService.MigrateStorage(mockHelperService, config, storage)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "directory", config.Bbe.Storage.Type)
+	assertFileContent(t, filepath.Join(storageDir, constants.TalosConfigFile), "talos")
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), fmt.Sprintf("apiVersion: bbe-quest/v1\nbbe:\n  storage:\n    type: directory\n    directory:\n      path: %s\n  packages: []\n", storageDir))
+}
+
+func Test_MigrateStorage_Fails_WhenTargetContainsDifferentConfigs(t *testing.T) {
+	configService := ConfigService{}
+	configDir, _, mockHelperService, config := initSyncTest(t, "talos", "other talos")
+	storage := config.Bbe.Storage
+	config.Bbe.Storage = models.StorageConfig{Type: "local"}
+
+	err := configService.MigrateStorage(mockHelperService, config, storage)
+
+	assert.ErrorContains(t, err, "already contains different config files (talosconfig)")
+	assert.Equal(t, "local", config.Bbe.Storage.Type)
+	assert.NoFileExists(t, filepath.Join(configDir, constants.BbeConfigFile))
+}
+
+func Test_MigrateStorage_Fails_WithIncompleteStorage(t *testing.T) {
+	configService := ConfigService{}
+	config := &models.BbeConfig{}
+
+	err := configService.MigrateStorage(&mocks.MockHelperService{}, config, models.StorageConfig{Type: "git"})
+
+	assert.ErrorContains(t, err, "bbe.storage.git.repository is required")
+}
+
+func initBbeYamlTest(t *testing.T, content string) (string, *mocks.MockHelperService) {
+	osReadFile = os.ReadFile
+	osWriteFile = os.WriteFile
+	osMkdirAll = os.MkdirAll
+	yamlMarshal = yaml.Marshal
+
+	configDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(configDir, constants.BbeConfigFile), []byte(content), 0644))
+
+	now := time.Now()
+	mockHelperService := &mocks.MockHelperService{}
+	mockHelperService.On("GetConfigDir").Return(configDir)
+	mockHelperService.On("CheckIfFileExists", fmt.Sprintf("%s/%s", configDir, constants.BbeConfigFile)).Return(&now, true)
+
+	return configDir, mockHelperService
+}
+
+func Test_GetBbeConfig_Succeeds_MigratesUnversionedConfig(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "bbe:\n  cluster:\n    name: home\n")
+
+	config, err := configService.GetBbeConfig(mockHelperService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "home", config.Bbe.Cluster.Name)
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), "apiVersion: bbe-quest/v1\nbbe:\n  cluster:\n    name: home\n")
+	assertFileContent(t, filepath.Join(configDir, "bbe.yaml.v0.bak"), "bbe:\n  cluster:\n    name: home\n")
+}
+
+func Test_GetBbeConfig_Fails_WithNewerConfigVersion(t *testing.T) {
+	configService := ConfigService{}
+	configDir, mockHelperService := initBbeYamlTest(t, "apiVersion: bbe-quest/v99\nbbe: {}\n")
+
+	_, err := configService.GetBbeConfig(mockHelperService)
+
+	assert.ErrorIs(t, err, constants.ConfigTooNewError)
+	assertFileContent(t, filepath.Join(configDir, constants.BbeConfigFile), "apiVersion: bbe-quest/v99\nbbe: {}\n")
+}
+
+func Test_GetBbeConfig_Fails_WithUnknownApiVersion(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "apiVersion: example.com/v1\nbbe: {}\n")
+
+	_, err := configService.GetBbeConfig(mockHelperService)
+
+	assert.ErrorContains(t, err, "Unknown apiVersion `example.com/v1`")
+}
+
+func Test_GetBbeConfig_Fails_WithUnknownField(t *testing.T) {
+	configService := ConfigService{}
+	_, mockHelperService := initBbeYamlTest(t, "apiVersion: bbe-quest/v1\nbbe:\n  clusters: {}\n")
+
+	_, err := configService.GetBbeConfig(mockHelperService)
+
+	assert.ErrorContains(t, err, "line 3: unknown key `bbe.clusters`")
+}
+
+func Test_bbeConfigMigrations_Succeeds_CoverEveryVersion(t *testing.T) {
+	assert.Len(t, bbeConfigMigrations, constants.BbeConfigVersion)
+}
+
+func Test_CheckStorage_Succeeds_WithDirectoryStorage(t *testing.T) {
+	configService := ConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = constants.StorageDirectory
+	bbeConfig.Bbe.Storage.Directory.Path = t.TempDir()
+
+	err := configService.CheckStorage(&mocks.MockHelperService{}, bbeConfig)
+
+	assert.NoError(t, err)
+}
+
+func Test_CheckStorage_Fails_WithMissingDirectory(t *testing.T) {
+	configService := ConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage.Type = constants.StorageDirectory
+	bbeConfig.Bbe.Storage.Directory.Path = filepath.Join(t.TempDir(), "missing")
+
+	err := configService.CheckStorage(&mocks.MockHelperService{}, bbeConfig)
+
+	assert.ErrorContains(t, err, "Failed to list storage directory")
+}
+
+func Test_CheckStorage_Succeeds_WithoutAwsBucket_DoesNotCreateOne(t *testing.T) {
+	configService := ConfigService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Storage = models.StorageConfig{Type: constants.StorageAws}
+
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListBuckets", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListBucketsOutput{}, nil)
+	initS3Client = func(models.StorageConfig) (interfaces.S3ServiceInterface, error) {
+		return mockS3Service, nil
+	}
+
+	err := configService.CheckStorage(&mocks.MockHelperService{}, bbeConfig)
+
+	assert.NoError(t, err)
+	mockS3Service.AssertNumberOfCalls(t, "ListBuckets", 1)
+	mockS3Service.AssertNotCalled(t, "CreateBucket", mock.Anything, mock.Anything, mock.Anything)
+}
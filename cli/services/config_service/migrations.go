@@ -0,0 +1,73 @@
+package config_service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"gopkg.in/yaml.v2"
+)
+
+// bbeConfigMigration upgrades a bbe.yaml document by one schema version
+type bbeConfigMigration func(document yaml.MapSlice) (yaml.MapSlice, error)
+
+// bbeConfigMigrations[i] upgrades a document from version i to version i+1, add an entry whenever constants.BbeConfigVersion is raised
+var bbeConfigMigrations = []bbeConfigMigration{
+	// Version 0 files predate the apiVersion field, their content is already valid version 1
+	func(document yaml.MapSlice) (yaml.MapSlice, error) {
+		return document, nil
+	},
+}
+
+// bbeConfigVersion reads the schema version from the apiVersion field of bbe.yaml
+func bbeConfigVersion(content []byte) (int, error) {
+	var header struct {
+		ApiVersion string `yaml:"apiVersion"`
+	}
+	err := yaml.Unmarshal(content, &header)
+	if err != nil {
+		return 0, err
+	}
+
+	if header.ApiVersion == "" {
+		return 0, nil
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(header.ApiVersion, constants.BbeConfigApiVersionPrefix))
+	if !strings.HasPrefix(header.ApiVersion, constants.BbeConfigApiVersionPrefix) || err != nil || version < 0 {
+		return 0, fmt.Errorf("Unknown apiVersion `%s` in %s", header.ApiVersion, constants.BbeConfigFile)
+	}
+
+	return version, nil
+}
+
+// migrateBbeConfig upgrades content from version to the current schema version
+func migrateBbeConfig(content []byte, version int) ([]byte, error) {
+	var document yaml.MapSlice
+	err := yaml.Unmarshal(content, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	for ; version < constants.BbeConfigVersion; version++ {
+		document, err = bbeConfigMigrations[version](document)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to migrate %s from version %d: %w", constants.BbeConfigFile, version, err)
+		}
+	}
+
+	// The apiVersion always comes first
+	migrated := yaml.MapSlice{{Key: "apiVersion", Value: bbeConfigApiVersion()}}
+	for _, item := range document {
+		if item.Key != "apiVersion" {
+			migrated = append(migrated, item)
+		}
+	}
+
+	return yaml.Marshal(migrated)
+}
+
+func bbeConfigApiVersion() string {
+	return fmt.Sprintf("%s%d", constants.BbeConfigApiVersionPrefix, constants.BbeConfigVersion)
+}
@@ -0,0 +1,276 @@
+package config_service
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var storageTypes = []string{constants.StorageLocal, constants.StorageAws, constants.StorageS3, constants.StorageGit, constants.StorageDirectory}
+var packagePolicies = []string{"patch", "minor", "major", "pinned"}
+
+// Kubernetes namespaces are RFC 1123 labels
+var namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Keys that change where the config files are stored, they are only changed by migrating the storage
+var storageLocationKeys = []string{
+	"storage.type",
+	"storage.aws.bucket_name",
+	"storage.aws.bucket_prefix",
+	"storage.s3.endpoint",
+	"storage.s3.bucket_name",
+	"storage.git.repository",
+	"storage.git.branch",
+	"storage.directory.path",
+}
+
+// ValidationError lists everything wrong with bbe.yaml
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("%s is invalid:\n  - %s", constants.BbeConfigFile, strings.Join(err.Problems, "\n  - "))
+}
+
+// invalidBbeConfigError marks err as a problem with the content of bbe.yaml
+func invalidBbeConfigError(configDir string, err error) error {
+	return clierror.New(clierror.KindConfigInvalid, err, fmt.Sprintf("Edit %s/%s and run 'bbe config validate' to check it again", configDir, constants.BbeConfigFile))
+}
+
+// validateBbeYaml checks the structure of bbe.yaml against the BbeConfig model, problems point at the offending line
+func validateBbeYaml(content []byte) []string {
+	var document yamlv3.Node
+	err := yamlv3.Unmarshal(content, &document)
+	if err != nil {
+		return []string{fmt.Sprintf("Invalid YAML: %v", err)}
+	}
+
+	if len(document.Content) == 0 {
+		return []string{}
+	}
+
+	return validateNode(document.Content[0], reflect.TypeOf(models.BbeConfig{}), "")
+}
+
+func validateNode(node *yamlv3.Node, valueType reflect.Type, path string) []string {
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return []string{}
+	}
+
+	location := path
+	if location == "" {
+		location = "document"
+	}
+
+	switch valueType.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			return []string{fmt.Sprintf("line %d: %s must be a map", node.Line, location)}
+		}
+
+		problems := []string{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, found := fieldByYamlName(valueType, key.Value)
+			if !found {
+				problems = append(problems, fmt.Sprintf("line %d: unknown key `%s`, expected one of %s", key.Line, joinPath(path, key.Value), strings.Join(yamlNames(valueType), ", ")))
+				continue
+			}
+			problems = append(problems, validateNode(node.Content[i+1], field.Type, joinPath(path, key.Value))...)
+		}
+		return problems
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			return []string{fmt.Sprintf("line %d: %s must be a list", node.Line, location)}
+		}
+
+		problems := []string{}
+		for i, item := range node.Content {
+			problems = append(problems, validateNode(item, valueType.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return problems
+	case reflect.Bool:
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!bool" {
+			return []string{fmt.Sprintf("line %d: %s must be true or false", node.Line, location)}
+		}
+	case reflect.Int:
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!int" {
+			return []string{fmt.Sprintf("line %d: %s must be a whole number", node.Line, location)}
+		}
+	case reflect.String:
+		if node.Kind != yamlv3.ScalarNode {
+			return []string{fmt.Sprintf("line %d: %s must be a single value", node.Line, location)}
+		}
+	}
+
+	return []string{}
+}
+
+// checkBbeConfig checks the values in bbe.yaml that the structure alone cannot catch
+func checkBbeConfig(bbeConfig *models.BbeConfig) []string {
+	problems := []string{}
+	storage := bbeConfig.Bbe.Storage
+
+	if storage.Type != "" && !slices.Contains(storageTypes, storage.Type) {
+		problems = append(problems, fmt.Sprintf("bbe.storage.type `%s` is not supported, expected one of %s", storage.Type, strings.Join(storageTypes, ", ")))
+	}
+
+	if storage.Aws.Endpoint != "" && !isHttpUrl(storage.Aws.Endpoint) {
+		problems = append(problems, fmt.Sprintf("bbe.storage.aws.endpoint `%s` must be an http or https URL", storage.Aws.Endpoint))
+	}
+
+	switch storage.Type {
+	case constants.StorageS3:
+		if !isHttpUrl(storage.S3.Endpoint) {
+			problems = append(problems, "bbe.storage.s3.endpoint must be an http or https URL for storage type `s3`")
+		}
+		if storage.S3.BucketName == "" {
+			problems = append(problems, "bbe.storage.s3.bucket_name is required for storage type `s3`")
+		}
+	case constants.StorageGit:
+		if storage.Git.Repository == "" {
+			problems = append(problems, "bbe.storage.git.repository is required for storage type `git`")
+		}
+	case constants.StorageDirectory:
+		if !filepath.IsAbs(storage.Directory.Path) {
+			problems = append(problems, "bbe.storage.directory.path must be an absolute path for storage type `directory`")
+		}
+	}
+
+	if storage.Encryption.Recipient != "" && encryption.ValidateRecipient(storage.Encryption.Recipient) != nil {
+		problems = append(problems, "bbe.storage.encryption.recipient is not an age public key, it should start with `age1`")
+	}
+
+	if bbeConfig.Bbe.Library.Timeout < 0 {
+		problems = append(problems, "bbe.library.timeout must not be negative")
+	}
+
+	if bbeConfig.Bbe.Etcd.Snapshots.Keep < 0 {
+		problems = append(problems, "bbe.etcd.snapshots.keep must not be negative")
+	}
+	if bbeConfig.Bbe.Etcd.Snapshots.MaxAgeDays < 0 {
+		problems = append(problems, "bbe.etcd.snapshots.max_age_days must not be negative")
+	}
+
+	libraryNames := []string{constants.DefaultLibraryName}
+	for i, library := range bbeConfig.Bbe.Libraries {
+		if library.Name == "" || library.Source == "" {
+			problems = append(problems, fmt.Sprintf("bbe.libraries[%d] requires a name and a source", i))
+			continue
+		}
+		if slices.Contains(libraryNames, library.Name) {
+			problems = append(problems, fmt.Sprintf("bbe.libraries[%d]: the library name `%s` is used more than once", i, library.Name))
+		}
+		if library.PublicKey == "" && !library.AllowUnsigned {
+			problems = append(problems, fmt.Sprintf("bbe.libraries[%d] requires a public_key, or allow_unsigned to accept it without verification", i))
+		}
+		libraryNames = append(libraryNames, library.Name)
+	}
+
+	packageNames := []string{}
+	for i, pkg := range bbeConfig.Bbe.Packages {
+		if pkg.Name == "" || pkg.Version == "" {
+			problems = append(problems, fmt.Sprintf("bbe.packages[%d] requires a name and a version", i))
+			continue
+		}
+		if slices.Contains(packageNames, pkg.Name) {
+			problems = append(problems, fmt.Sprintf("bbe.packages[%d]: package `%s` is listed more than once", i, pkg.Name))
+		}
+		packageNames = append(packageNames, pkg.Name)
+
+		if pkg.Policy != "" && !slices.Contains(packagePolicies, pkg.Policy) {
+			problems = append(problems, fmt.Sprintf("bbe.packages[%d].policy `%s` is not supported, expected one of %s", i, pkg.Policy, strings.Join(packagePolicies, ", ")))
+		}
+		if pkg.Namespace != "" && (len(pkg.Namespace) > 63 || !namespacePattern.MatchString(pkg.Namespace)) {
+			problems = append(problems, fmt.Sprintf("bbe.packages[%d].namespace `%s` is not a valid namespace, it must be lowercase alphanumeric characters or '-' and at most 63 characters", i, pkg.Namespace))
+		}
+		if pkg.Library != "" && !slices.Contains(libraryNames, pkg.Library) {
+			problems = append(problems, fmt.Sprintf("bbe.packages[%d].library `%s` is not a configured library", i, pkg.Library))
+		}
+	}
+
+	return problems
+}
+
+// lookupKey finds the value of a dotted key such as storage.aws.region, the leading bbe. is optional
+func lookupKey(bbeConfig *models.BbeConfig, key string) (reflect.Value, error) {
+	value := reflect.ValueOf(bbeConfig).Elem().FieldByName("Bbe")
+
+	for _, part := range strings.Split(strings.TrimPrefix(key, "bbe."), ".") {
+		switch value.Kind() {
+		case reflect.Struct:
+			field, found := fieldByYamlName(value.Type(), part)
+			if !found {
+				return reflect.Value{}, fmt.Errorf("Unknown key `%s`, `%s` is not one of %s", key, part, strings.Join(yamlNames(value.Type()), ", "))
+			}
+			value = value.FieldByIndex(field.Index)
+		case reflect.Slice:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= value.Len() {
+				return reflect.Value{}, fmt.Errorf("Unknown key `%s`, `%s` is not an index between 0 and %d", key, part, value.Len()-1)
+			}
+			value = value.Index(index)
+		default:
+			return reflect.Value{}, fmt.Errorf("Unknown key `%s`", key)
+		}
+	}
+
+	return value, nil
+}
+
+func fieldByYamlName(valueType reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if yamlName(field) == name {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func yamlNames(valueType reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < valueType.NumField(); i++ {
+		names = append(names, yamlName(valueType.Field(i)))
+	}
+
+	return names
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+
+	return name
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func isHttpUrl(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -3,17 +3,35 @@ package dependency_service
 import (
 	"fmt"
 	"os/exec"
+	"regexp"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 )
 
+var execCommand = exec.Command
+
+// Programs checked by bbe doctor, with the arguments printing their version and the oldest version known to work
+var tools = map[string]struct {
+	args    []string
+	minimum string
+}{
+	"talosctl": {args: []string{"version", "--client", "--short"}, minimum: "1.8.0"},
+	"nmap":     {args: []string{"--version"}, minimum: "7.80"},
+	"helm":     {args: []string{"version", "--short"}, minimum: "3.12.0"},
+	"git":      {args: []string{"--version"}, minimum: "2.20.0"},
+	"oras":     {args: []string{"version"}, minimum: "1.0.0"},
+}
+
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
 type DependencyService struct{}
 
 func (dependencyService DependencyService) VerifyDependencies() bool {
 	dependencyChecks := map[string]struct {
 		args []string
 	}{
-		"talosctl": {args: []string{""}},
+		"talosctl": {args: []string{"version", "--client"}},
 		"nmap":     {args: []string{"--version"}},
 		"grep":     {args: []string{"--version"}},
 		"bash":     {args: []string{"--version"}},
@@ -23,7 +41,8 @@ func (dependencyService DependencyService) VerifyDependencies() bool {
 	errors := 0
 	for dependency, check := range dependencyChecks {
 		cmd := exec.Command("bash", "-c", buildCommand(dependency, check.args))
-		err := cmd.Run()
+		output, err := cmd.CombinedOutput()
+		logger.Command(cmd, output, err)
 		if err != nil {
 			errors++
 			logger.Error(fmt.Sprintf("Dependency %s is not installed, please install it.", dependency), nil)
@@ -33,6 +52,30 @@ func (dependencyService DependencyService) VerifyDependencies() bool {
 	return errors == 0
 }
 
+// GetToolVersion returns the installed version of a program checked by bbe doctor next to the oldest version bbe supports
+func (dependencyService DependencyService) GetToolVersion(name string) (models.ToolVersion, error) {
+	tool, found := tools[name]
+	if !found {
+		return models.ToolVersion{Name: name}, fmt.Errorf("Unknown program `%s`", name)
+	}
+
+	result := models.ToolVersion{Name: name, Minimum: tool.minimum}
+
+	cmd := execCommand(name, tool.args...)
+	output, err := cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
+	if err != nil {
+		return result, err
+	}
+
+	result.Version = versionPattern.FindString(string(output))
+	if result.Version == "" {
+		return result, fmt.Errorf("Unable to read the version of %s", name)
+	}
+
+	return result, nil
+}
+
 var buildCommand = func(dependency string, args []string) string {
 	command := dependency
 	for _, arg := range args {
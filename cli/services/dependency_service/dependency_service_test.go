@@ -1,6 +1,7 @@
 package dependency_service
 
 import (
+	"os/exec"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,6 +35,52 @@ func Test_VerifyDependencies_Fails_WithNoDependencies(t *testing.T) {
 	buildCommand = originalCommand
 }
 
+func Test_GetToolVersion_Succeeds(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", "Client:\n\tTalos v1.8.2")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	dependencyService := DependencyService{}
+	tool, err := dependencyService.GetToolVersion("talosctl")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.8.2", tool.Version)
+	assert.Equal(t, "1.8.0", tool.Minimum)
+}
+
+func Test_GetToolVersion_Fails_WhenNotInstalled(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("bbe-missing-nmap")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	dependencyService := DependencyService{}
+	tool, err := dependencyService.GetToolVersion("nmap")
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Equal(t, "7.80", tool.Minimum)
+}
+
+func Test_GetToolVersion_Fails_WithoutVersionInOutput(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", "helm")
+	}
+	defer func() { execCommand = exec.Command }()
+
+	dependencyService := DependencyService{}
+	_, err := dependencyService.GetToolVersion("helm")
+
+	assert.ErrorContains(t, err, "Unable to read the version of helm")
+}
+
+func Test_GetToolVersion_Fails_WithUnknownProgram(t *testing.T) {
+	dependencyService := DependencyService{}
+	_, err := dependencyService.GetToolVersion("kubectl")
+
+	assert.ErrorContains(t, err, "Unknown program `kubectl`")
+}
+
 func Test_BuildCommand(t *testing.T) {
 	dependency := "test"
 	args := []string{"--version"}
@@ -0,0 +1,209 @@
+package etcd_service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+var timeNow = time.Now
+
+type EtcdService struct{}
+
+// Snapshot takes an etcd snapshot from the control plane and stores it in the snapshot backend
+func (etcdService EtcdService) Snapshot(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface) (*models.StorageObject, error) {
+	controlPlaneIp, err := etcdService.controlPlaneIp(helperService, talosService)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := etcdService.backend(helperService, configService)
+	if err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "bbe-etcd")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	snapshotPath := filepath.Join(tempDir, "snapshot"+constants.EtcdSnapshotExtension)
+	err = talosService.EtcdSnapshot(helperService, controlPlaneIp, snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to take an etcd snapshot from %s: %w", controlPlaneIp, err)
+	}
+
+	content, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeNow().UTC()
+	name := fmt.Sprintf("%s%s-%s%s", constants.EtcdSnapshotDir, helperService.GetClusterName(), now.Format("20060102-150405"), constants.EtcdSnapshotExtension)
+	err = backend.Put(name, content)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to store the etcd snapshot: %w", err)
+	}
+
+	return &models.StorageObject{Name: name, ModTime: now, Content: content}, nil
+}
+
+// List returns the stored snapshots, newest first
+func (etcdService EtcdService) List(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) ([]models.StorageObject, error) {
+	backend, err := etcdService.backend(helperService, configService)
+	if err != nil {
+		return nil, err
+	}
+
+	return etcdService.list(backend)
+}
+
+// Prune deletes the snapshots outside the retention configured in bbe.yaml, the newest snapshot is never deleted
+func (etcdService EtcdService) Prune(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) ([]models.StorageObject, error) {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := configService.SnapshotBackend(helperService, bbeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := etcdService.list(backend)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := bbeConfig.Bbe.Etcd.Snapshots.Keep
+	if keep == 0 {
+		keep = constants.DefaultEtcdSnapshotKeep
+	}
+	maxAge := time.Duration(bbeConfig.Bbe.Etcd.Snapshots.MaxAgeDays) * 24 * time.Hour
+
+	deleted := []models.StorageObject{}
+	for i, snapshot := range snapshots {
+		expired := maxAge > 0 && timeNow().Sub(snapshot.ModTime) > maxAge
+		if i == 0 || (i < keep && !expired) {
+			continue
+		}
+
+		err := backend.Delete(snapshot.Name)
+		if err != nil {
+			return deleted, fmt.Errorf("Failed to delete snapshot %s: %w", snapshot.Name, err)
+		}
+		deleted = append(deleted, snapshot)
+	}
+
+	return deleted, nil
+}
+
+// Restore recovers the cluster from a snapshot on a freshly installed control plane at nodeIp, the configured control plane by default.
+// A node still in maintenance mode is configured with controlplane.yaml first.
+func (etcdService EtcdService) Restore(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, name string, nodeIp string) error {
+	controlPlaneIp, err := etcdService.controlPlaneIp(helperService, talosService)
+	if err != nil {
+		return err
+	}
+
+	backend, err := etcdService.backend(helperService, configService)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := backend.Get(SnapshotName(name))
+	if err != nil {
+		return fmt.Errorf("Failed to download snapshot %s: %w", name, err)
+	}
+
+	tempDir, err := os.MkdirTemp("", "bbe-etcd")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	snapshotPath := filepath.Join(tempDir, "snapshot"+constants.EtcdSnapshotExtension)
+	err = os.WriteFile(snapshotPath, snapshot.Content, 0600)
+	if err != nil {
+		return err
+	}
+
+	if nodeIp == "" {
+		nodeIp = controlPlaneIp
+	}
+
+	if talosService.Ping(nodeIp) {
+		err = talosService.JoinCluster(helperService, nodeIp, constants.ControlplaneConfigFile)
+		if err != nil {
+			return fmt.Errorf("Failed to apply %s to %s: %w", constants.ControlplaneConfigFile, nodeIp, err)
+		}
+	} else {
+		logger.Info(fmt.Sprintf("%s is not in maintenance mode, assuming it is already configured", nodeIp))
+	}
+
+	err = talosService.RecoverCluster(helperService, nodeIp, nodeIp, snapshotPath)
+	if err != nil {
+		return err
+	}
+
+	return talosService.VerifyNodeHealth(helperService, nodeIp, nodeIp)
+}
+
+// SnapshotName accepts a snapshot name with or without the storage directory
+func SnapshotName(name string) string {
+	if strings.HasPrefix(name, constants.EtcdSnapshotDir) {
+		return name
+	}
+
+	return constants.EtcdSnapshotDir + name
+}
+
+func (etcdService EtcdService) backend(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface) (interfaces.StorageBackend, error) {
+	bbeConfig, err := configService.GetBbeConfig(helperService)
+	if err != nil {
+		return nil, err
+	}
+
+	return configService.SnapshotBackend(helperService, bbeConfig)
+}
+
+func (etcdService EtcdService) list(backend interfaces.StorageBackend) ([]models.StorageObject, error) {
+	objects, err := backend.List()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := []models.StorageObject{}
+	for _, object := range objects {
+		if strings.HasPrefix(object.Name, constants.EtcdSnapshotDir) && strings.HasSuffix(object.Name, constants.EtcdSnapshotExtension) {
+			snapshots = append(snapshots, object)
+		}
+	}
+
+	slices.SortFunc(snapshots, func(a models.StorageObject, b models.StorageObject) int {
+		if !a.ModTime.Equal(b.ModTime) {
+			return b.ModTime.Compare(a.ModTime)
+		}
+		return strings.Compare(b.Name, a.Name)
+	})
+
+	return snapshots, nil
+}
+
+func (etcdService EtcdService) controlPlaneIp(helperService interfaces.HelperServiceInterface, talosService interfaces.TalosServiceInterface) (string, error) {
+	if _, exists := helperService.CheckIfFileExists(helperService.GetConfigFilePath(constants.ControlplaneConfigFile)); !exists {
+		return "", errors.New("No control plane config found, please run 'bbe setup' or 'bbe config' first")
+	}
+
+	return talosService.GetControlPlaneIp(helperService, constants.ControlplaneConfigFile)
+}
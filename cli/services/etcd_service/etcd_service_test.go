@@ -0,0 +1,182 @@
+package etcd_service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/storage_service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func initEtcdTest(t *testing.T, bbeConfig *models.BbeConfig) (*mocks.MockHelperService, *mocks.MockConfigService, *mocks.MockTalosService, string) {
+	timeNow = func() time.Time { return time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	storageDir := t.TempDir()
+
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetClusterName").Return("home")
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, true)
+
+	configService := &mocks.MockConfigService{}
+	configService.On("GetBbeConfig", helperService).Return(bbeConfig, nil)
+	configService.On("SnapshotBackend", helperService, bbeConfig).Return(storage_service.NewDirectoryBackend(storageDir), nil)
+
+	talosService := &mocks.MockTalosService{}
+	talosService.On("GetControlPlaneIp", helperService, constants.ControlplaneConfigFile).Return("192.168.1.10", nil)
+
+	return helperService, configService, talosService, storageDir
+}
+
+func writeSnapshot(t *testing.T, storageDir string, name string, modTime time.Time) {
+	filePath := filepath.Join(storageDir, "etcd", name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+	assert.NoError(t, os.WriteFile(filePath, []byte(name), 0600))
+	assert.NoError(t, os.Chtimes(filePath, modTime, modTime))
+}
+
+func Test_Snapshot_Succeeds_StoresSnapshotInBackend(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService, configService, talosService, storageDir := initEtcdTest(t, &models.BbeConfig{})
+	talosService.On("EtcdSnapshot", helperService, "192.168.1.10", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		assert.NoError(t, os.WriteFile(args.String(2), []byte("etcd"), 0600))
+	})
+
+	snapshot, err := etcdService.Snapshot(helperService, configService, talosService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "etcd/home-20261019-120000.db", snapshot.Name)
+	content, err := os.ReadFile(filepath.Join(storageDir, "etcd", "home-20261019-120000.db"))
+	assert.NoError(t, err)
+	assert.Equal(t, "etcd", string(content))
+}
+
+func Test_Snapshot_Fails_WhenTalosCtlFails(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService, configService, talosService, storageDir := initEtcdTest(t, &models.BbeConfig{})
+	talosService.On("EtcdSnapshot", helperService, "192.168.1.10", mock.Anything).Return(errors.New("connection refused"))
+
+	_, err := etcdService.Snapshot(helperService, configService, talosService)
+
+	assert.ErrorContains(t, err, "connection refused")
+	assert.NoDirExists(t, filepath.Join(storageDir, "etcd"))
+}
+
+func Test_Snapshot_Fails_WithoutControlPlaneConfig(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.ControlplaneConfigFile).Return("controlplane.yaml")
+	helperService.On("CheckIfFileExists", "controlplane.yaml").Return(nil, false)
+
+	_, err := etcdService.Snapshot(helperService, &mocks.MockConfigService{}, &mocks.MockTalosService{})
+
+	assert.ErrorContains(t, err, "No control plane config found")
+}
+
+func Test_List_Succeeds_NewestFirstAndOnlySnapshots(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService, configService, _, storageDir := initEtcdTest(t, &models.BbeConfig{})
+	now := timeNow()
+	writeSnapshot(t, storageDir, "home-1.db", now.Add(-2*time.Hour))
+	writeSnapshot(t, storageDir, "home-2.db", now.Add(-time.Hour))
+	assert.NoError(t, os.WriteFile(filepath.Join(storageDir, constants.BbeConfigFile), []byte("bbe:"), 0600))
+
+	snapshots, err := etcdService.List(helperService, configService)
+
+	assert.NoError(t, err)
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, "etcd/home-2.db", snapshots[0].Name)
+	assert.Equal(t, "etcd/home-1.db", snapshots[1].Name)
+}
+
+func Test_Prune_Succeeds_KeepsConfiguredNumberOfSnapshots(t *testing.T) {
+	etcdService := EtcdService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Etcd.Snapshots.Keep = 2
+	helperService, configService, _, storageDir := initEtcdTest(t, bbeConfig)
+	now := timeNow()
+	for i, name := range []string{"home-3.db", "home-2.db", "home-1.db"} {
+		writeSnapshot(t, storageDir, name, now.Add(-time.Duration(i)*time.Hour))
+	}
+
+	deleted, err := etcdService.Prune(helperService, configService)
+
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "etcd/home-1.db", deleted[0].Name)
+	assert.NoFileExists(t, filepath.Join(storageDir, "etcd", "home-1.db"))
+	assert.FileExists(t, filepath.Join(storageDir, "etcd", "home-2.db"))
+}
+
+func Test_Prune_Succeeds_AlwaysKeepsNewestSnapshot(t *testing.T) {
+	etcdService := EtcdService{}
+	bbeConfig := &models.BbeConfig{}
+	bbeConfig.Bbe.Etcd.Snapshots.MaxAgeDays = 7
+	helperService, configService, _, storageDir := initEtcdTest(t, bbeConfig)
+	now := timeNow()
+	writeSnapshot(t, storageDir, "home-2.db", now.Add(-10*24*time.Hour))
+	writeSnapshot(t, storageDir, "home-1.db", now.Add(-20*24*time.Hour))
+
+	deleted, err := etcdService.Prune(helperService, configService)
+
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, "etcd/home-1.db", deleted[0].Name)
+	assert.FileExists(t, filepath.Join(storageDir, "etcd", "home-2.db"))
+}
+
+func Test_Restore_Succeeds_AppliesConfigToNodeInMaintenanceMode(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService, configService, talosService, storageDir := initEtcdTest(t, &models.BbeConfig{})
+	writeSnapshot(t, storageDir, "home-1.db", timeNow())
+
+	talosService.On("Ping", mock.Anything, "192.168.1.50").Return(true)
+	talosService.On("JoinCluster", helperService, "192.168.1.50", constants.ControlplaneConfigFile).Return(nil)
+	talosService.On("RecoverCluster", helperService, "192.168.1.50", "192.168.1.50", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		content, err := os.ReadFile(args.String(3))
+		assert.NoError(t, err)
+		assert.Equal(t, "home-1.db", string(content))
+	})
+	talosService.On("VerifyNodeHealth", helperService, "192.168.1.50", "192.168.1.50").Return(nil)
+
+	err := etcdService.Restore(helperService, configService, talosService, "home-1.db", "192.168.1.50")
+
+	assert.NoError(t, err)
+	talosService.AssertCalled(t, "JoinCluster", helperService, "192.168.1.50", constants.ControlplaneConfigFile)
+	talosService.AssertNotCalled(t, "RecoverCluster", helperService, "192.168.1.10", mock.Anything, mock.Anything)
+	talosService.AssertCalled(t, "VerifyNodeHealth", helperService, "192.168.1.50", "192.168.1.50")
+}
+
+func Test_Restore_Succeeds_OnConfiguredControlPlaneByDefault(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService, configService, talosService, storageDir := initEtcdTest(t, &models.BbeConfig{})
+	writeSnapshot(t, storageDir, "home-1.db", timeNow())
+
+	talosService.On("Ping", mock.Anything, "192.168.1.10").Return(false)
+	talosService.On("RecoverCluster", helperService, "192.168.1.10", "192.168.1.10", mock.Anything).Return(nil)
+	talosService.On("VerifyNodeHealth", helperService, "192.168.1.10", "192.168.1.10").Return(nil)
+
+	err := etcdService.Restore(helperService, configService, talosService, "home-1.db", "")
+
+	assert.NoError(t, err)
+	talosService.AssertNotCalled(t, "JoinCluster", mock.Anything, mock.Anything, mock.Anything)
+	talosService.AssertCalled(t, "RecoverCluster", helperService, "192.168.1.10", "192.168.1.10", mock.Anything)
+}
+
+func Test_Restore_Fails_WithUnknownSnapshot(t *testing.T) {
+	etcdService := EtcdService{}
+	helperService, configService, talosService, _ := initEtcdTest(t, &models.BbeConfig{})
+
+	err := etcdService.Restore(helperService, configService, talosService, "missing.db", "")
+
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+	talosService.AssertNotCalled(t, "RecoverCluster", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -1,10 +1,16 @@
 package helm_service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 )
 
 var execCommand = exec.Command
@@ -15,10 +21,10 @@ func (HelmService HelmService) AddRepo(repoName string, repoUrl string) error {
 	cmd := execCommand("helm", "repo", "add", repoName, repoUrl)
 	logger.Debug(fmt.Sprintf("Adding helm repository `%s` with url `%s`", repoName, repoUrl))
 	response, err := cmd.CombinedOutput()
-	logger.Debug(fmt.Sprintf("Response: %s", string(response)))
+	logger.Command(cmd, response, err)
 
 	if err != nil {
-		return fmt.Errorf("Failed to add helm repository `%s`: %w", repoName, err)
+		return helmError(fmt.Errorf("Failed to add helm repository `%s`: %w", repoName, err))
 	}
 
 	updateRepoErr := HelmService.updateRepo(repoName)
@@ -30,32 +36,34 @@ func (HelmService HelmService) AddRepo(repoName string, repoUrl string) error {
 }
 
 func (HelmService HelmService) InstallChart(pkgName string, chartName string, repoName string, version string, namespace string, context string) error {
-	cmd := execCommand("helm", "install", pkgName, fmt.Sprintf("%s/%s", repoName, chartName),
-		"--version", version,
+	args := append([]string{"install", pkgName}, chartReference(chartName, repoName, version)...)
+	cmd := execCommand("helm", append(args,
 		"--namespace", namespace,
 		"--create-namespace",
-		"--kube-context", context)
+		"--kube-context", context)...)
 	logger.Debug(fmt.Sprintf("Installing helm chart `%s` from repo `%s` with version `%s` in namespace `%s`", pkgName, repoName, version, namespace))
-	logger.Debug(fmt.Sprintf("Command: %s", cmd.String()))
 
 	response, err := cmd.CombinedOutput()
-	logger.Debug(fmt.Sprintf("Response: %s", string(response)))
+	logger.Command(cmd, response, err)
 
 	if err != nil {
-		return fmt.Errorf("Failed to install helm package `%s`: %w", pkgName, err)
+		return helmError(fmt.Errorf("Failed to install helm package `%s`: %w", pkgName, err))
 	}
 	return nil
 }
 
 func (HelmService HelmService) UpgradeChart(pkgName string, chartName string, repoName string, version string, namespace string, context string) error {
-	cmd := execCommand("helm", "upgrade", pkgName, fmt.Sprintf("%s/%s", repoName, chartName),
-		"--version", version,
+	args := append([]string{"upgrade", pkgName}, chartReference(chartName, repoName, version)...)
+	cmd := execCommand("helm", append(args,
 		"--namespace", namespace,
 		"--create-namespace",
-		"--kube-context", context)
+		"--kube-context", context)...)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("Failed to upgrade helm package `%s`: %w", pkgName, err)
+	response, err := cmd.CombinedOutput()
+	logger.Command(cmd, response, err)
+
+	if err != nil {
+		return helmError(fmt.Errorf("Failed to upgrade helm package `%s`: %w", pkgName, err))
 	}
 	return nil
 }
@@ -65,8 +73,11 @@ func (HelmService HelmService) UninstallChart(pkgName string, namespace string,
 		"--namespace", namespace,
 		"--kube-context", context)
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("Failed to uninstall helm package `%s`: %w", pkgName, err)
+	response, err := cmd.CombinedOutput()
+	logger.Command(cmd, response, err)
+
+	if err != nil {
+		return helmError(fmt.Errorf("Failed to uninstall helm package `%s`: %w", pkgName, err))
 	}
 	return nil
 }
@@ -76,22 +87,166 @@ func (HelmService HelmService) IsPackageInstalled(pkgName string, namespace stri
 		"--namespace", namespace,
 		"--kube-context", context,
 	)
-	if err := cmd.Run(); err != nil {
+	response, err := cmd.CombinedOutput()
+	logger.Command(cmd, response, err)
+
+	if err != nil {
 		return false
 	}
 
 	return true
 }
 
+func (HelmService HelmService) PullChart(chartName string, repoName string, version string, destination string) (string, error) {
+	cmd := execCommand("helm", "pull", fmt.Sprintf("%s/%s", repoName, chartName),
+		"--version", version,
+		"--destination", destination)
+	logger.Debug(fmt.Sprintf("Pulling helm chart `%s` from repo `%s` with version `%s` into `%s`", chartName, repoName, version, destination))
+	response, err := cmd.CombinedOutput()
+	logger.Command(cmd, response, err)
+
+	if err != nil {
+		return "", helmError(fmt.Errorf("Failed to pull helm chart `%s`: %w", chartName, err))
+	}
+
+	return fmt.Sprintf("%s-%s.tgz", chartName, version), nil
+}
+
+func (HelmService HelmService) ListReleases(context string) ([]models.HelmRelease, error) {
+	cmd := execCommand("helm", "list",
+		"--all-namespaces",
+		"--all",
+		"--output", "json",
+		"--kube-context", context)
+	logger.Debug(fmt.Sprintf("Listing helm releases in context `%s`", context))
+
+	response, err := cmd.Output()
+	logger.Command(cmd, response, err)
+
+	if err != nil {
+		return nil, helmError(fmt.Errorf("Failed to list helm releases: %w", err))
+	}
+
+	var releases []models.HelmRelease
+	err = json.Unmarshal(response, &releases)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse helm releases: %w", err)
+	}
+
+	return releases, nil
+}
+
+// GetValues returns the user supplied values of a release as YAML
+func (HelmService HelmService) GetValues(pkgName string, namespace string, context string) ([]byte, error) {
+	cmd := execCommand("helm", "get", "values", pkgName,
+		"--namespace", namespace,
+		"--kube-context", context,
+		"--output", "yaml")
+	logger.Debug(fmt.Sprintf("Reading values of helm release `%s` in namespace `%s`", pkgName, namespace))
+
+	// The values are left out of the log, they may hold credentials
+	response, err := cmd.Output()
+	logger.Command(cmd, nil, err)
+
+	if err != nil {
+		return nil, helmError(fmt.Errorf("Failed to read values of helm package `%s`: %w", pkgName, err))
+	}
+
+	return response, nil
+}
+
+// GetManifest returns the rendered manifest of a deployed release
+func (HelmService HelmService) GetManifest(pkgName string, namespace string, context string) ([]byte, error) {
+	cmd := execCommand("helm", "get", "manifest", pkgName,
+		"--namespace", namespace,
+		"--kube-context", context)
+	logger.Debug(fmt.Sprintf("Reading manifest of helm release `%s` in namespace `%s`", pkgName, namespace))
+
+	response, err := cmd.Output()
+	logger.Command(cmd, nil, err)
+
+	if err != nil {
+		return nil, helmError(fmt.Errorf("Failed to read manifest of helm package `%s`: %w", pkgName, err))
+	}
+
+	return response, nil
+}
+
+// TemplateChart renders a chart locally without adding its repository, an empty repoUrl renders a chart archive on disk.
+// values are passed to helm as a values file, e.g. the user supplied values of a release.
+func (HelmService HelmService) TemplateChart(pkgName string, chartName string, repoUrl string, version string, namespace string, values []byte) ([]byte, error) {
+	args := []string{"template", pkgName, chartName}
+	if repoUrl != "" {
+		args = append(args, "--repo", repoUrl, "--version", version)
+	}
+	args = append(args, "--namespace", namespace)
+
+	if len(values) > 0 {
+		// The values may hold credentials, the file is only readable by the user and removed after rendering
+		valuesFile, err := os.CreateTemp("", "bbe-values-*.yaml")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(valuesFile.Name())
+
+		_, err = valuesFile.Write(values)
+		valuesFile.Close()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "--values", valuesFile.Name())
+	}
+
+	cmd := execCommand("helm", args...)
+	logger.Debug(fmt.Sprintf("Rendering helm chart `%s` with version `%s`", chartName, version))
+
+	response, err := cmd.Output()
+	logger.Command(cmd, nil, err)
+
+	if err != nil {
+		return nil, helmError(fmt.Errorf("Failed to render helm chart `%s`: %w", chartName, err))
+	}
+
+	return response, nil
+}
+
+// ChartVersion extracts the chart version from a release of the named chart, such as "ingress-nginx-4.12.0" or "cert-manager-v1.14.4"
+func ChartVersion(release models.HelmRelease, chart string) string {
+	version, found := strings.CutPrefix(release.Chart, chart+"-")
+	if !found {
+		return ""
+	}
+
+	return version
+}
+
+// chartReference points helm at a repository chart, or at a chart archive on disk when no repository is given
+func chartReference(chartName string, repoName string, version string) []string {
+	if repoName == "" {
+		return []string{chartName}
+	}
+
+	return []string{fmt.Sprintf("%s/%s", repoName, chartName), "--version", version}
+}
+
 func (HelmService HelmService) updateRepo(repoName string) error {
 	cmd := execCommand("helm", "repo", "update", repoName)
 	logger.Debug(fmt.Sprintf("Updating helm repository `%s`", repoName))
 	response, err := cmd.CombinedOutput()
-	logger.Debug(fmt.Sprintf("Response: %s", string(response)))
+	logger.Command(cmd, response, err)
 
 	if err != nil {
-		return fmt.Errorf("Failed to update helm repository `%s`: %w", repoName, err)
+		return helmError(fmt.Errorf("Failed to update helm repository `%s`: %w", repoName, err))
 	}
 
 	return nil
 }
+
+// helmError marks a failed helm invocation, a missing helm binary is left to be reported as a missing dependency
+func helmError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return err
+	}
+
+	return clierror.New(clierror.KindHelm, err, "Run again with --verbose to see the output of helm, it is also kept in the log files in ~/.bbe/logs")
+}
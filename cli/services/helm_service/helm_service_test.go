@@ -1,9 +1,12 @@
 package helm_service
 
 import (
+	"os"
 	"os/exec"
 	"testing"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,6 +48,19 @@ func Test_Helm_Service_Fails_Install_Repo(t *testing.T) {
 	// Assert an error occurred
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Failed to install helm package `packageName`: exit status 1")
+	assert.Equal(t, clierror.ExitHelm, clierror.ExitCode(err))
+}
+
+func Test_Helm_Service_Fails_Install_Repo_WithoutHelm(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("bbe-missing-helm")
+	}
+
+	helmService := HelmService{}
+	err := helmService.InstallChart("packageName", "chartName", "repoName", "version", "namespace", "context")
+
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+	assert.Equal(t, clierror.ExitMissingDependency, clierror.ExitCode(err))
 }
 
 func Test_Helm_Service_Succeeds_Install_Repo(t *testing.T) {
@@ -139,3 +155,173 @@ func Test_Helm_Service_Succeeds_IsPackageInstalled(t *testing.T) {
 	// Assert an error occurred
 	assert.True(t, res)
 }
+
+func Test_Helm_Service_Fails_PullChart(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helmService := HelmService{}
+	_, err := helmService.PullChart("chartName", "repoName", "1.0.0", "destination")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Failed to pull helm chart `chartName`: exit status 1")
+}
+
+func Test_Helm_Service_Succeeds_PullChart(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("true")
+	}
+
+	helmService := HelmService{}
+	archive, err := helmService.PullChart("chartName", "repoName", "1.0.0", "destination")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "chartName-1.0.0.tgz", archive)
+}
+
+func Test_chartReference_Succeeds_WithRepository(t *testing.T) {
+	assert.Equal(t, []string{"repoName/chartName", "--version", "1.0.0"}, chartReference("chartName", "repoName", "1.0.0"))
+}
+
+func Test_chartReference_Succeeds_WithLocalArchive(t *testing.T) {
+	assert.Equal(t, []string{"/mirror/chartName-1.0.0.tgz"}, chartReference("/mirror/chartName-1.0.0.tgz", "", "1.0.0"))
+}
+
+func Test_Helm_Service_Fails_ListReleases(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helmService := HelmService{}
+	releases, err := helmService.ListReleases("context")
+
+	assert.Nil(t, releases)
+	assert.Contains(t, err.Error(), "Failed to list helm releases: exit status 1")
+}
+
+func Test_Helm_Service_Succeeds_ListReleases(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", `[{"name":"blocky","namespace":"blocky","revision":"2","status":"deployed","chart":"blocky-0.1.3","app_version":"v0.24"}]`)
+	}
+
+	helmService := HelmService{}
+	releases, err := helmService.ListReleases("context")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.HelmRelease{
+		{Name: "blocky", Namespace: "blocky", Revision: "2", Status: "deployed", Chart: "blocky-0.1.3", AppVersion: "v0.24"},
+	}, releases)
+}
+
+func Test_ChartVersion_Succeeds(t *testing.T) {
+	assert.Equal(t, "4.12.0", ChartVersion(models.HelmRelease{Chart: "ingress-nginx-4.12.0"}, "ingress-nginx"))
+	assert.Equal(t, "v1.14.4", ChartVersion(models.HelmRelease{Chart: "cert-manager-v1.14.4"}, "cert-manager"))
+	assert.Equal(t, "1.0.0-rc.1", ChartVersion(models.HelmRelease{Chart: "kube-prometheus-stack-1.0.0-rc.1"}, "kube-prometheus-stack"))
+	assert.Equal(t, "", ChartVersion(models.HelmRelease{Chart: "unversioned"}, "unversioned"))
+	assert.Equal(t, "", ChartVersion(models.HelmRelease{Chart: "other-chart-1.0.0"}, "ingress-nginx"))
+}
+
+func Test_Helm_Service_Succeeds_GetValues(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", "replicaCount: 2")
+	}
+
+	helmService := HelmService{}
+	values, err := helmService.GetValues("blocky", "blocky", "context")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "replicaCount: 2\n", string(values))
+}
+
+func Test_Helm_Service_Fails_GetValues(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helmService := HelmService{}
+	_, err := helmService.GetValues("blocky", "blocky", "context")
+
+	assert.ErrorContains(t, err, "Failed to read values of helm package `blocky`")
+}
+
+func Test_Helm_Service_Succeeds_GetManifest(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", "kind: Deployment")
+	}
+
+	helmService := HelmService{}
+	manifest, err := helmService.GetManifest("blocky", "blocky", "context")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "kind: Deployment\n", string(manifest))
+}
+
+func Test_Helm_Service_Fails_GetManifest(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helmService := HelmService{}
+	_, err := helmService.GetManifest("blocky", "blocky", "context")
+
+	assert.ErrorContains(t, err, "Failed to read manifest of helm package `blocky`")
+}
+
+func Test_Helm_Service_Succeeds_TemplateChart(t *testing.T) {
+	var calledArgs []string
+	execCommand = func(_ string, args ...string) *exec.Cmd {
+		calledArgs = args
+		return exec.Command("echo", "kind: Deployment")
+	}
+
+	helmService := HelmService{}
+	manifest, err := helmService.TemplateChart("blocky", "blocky", "https://charts.example.com", "0.2.0", "blocky", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "kind: Deployment\n", string(manifest))
+	assert.Equal(t, []string{"template", "blocky", "blocky", "--repo", "https://charts.example.com", "--version", "0.2.0", "--namespace", "blocky"}, calledArgs)
+}
+
+func Test_Helm_Service_Succeeds_TemplateChartFromArchive(t *testing.T) {
+	var calledArgs []string
+	execCommand = func(_ string, args ...string) *exec.Cmd {
+		calledArgs = args
+		return exec.Command("echo", "kind: Deployment")
+	}
+
+	helmService := HelmService{}
+	_, err := helmService.TemplateChart("blocky", "/mirror/blocky-0.2.0.tgz", "", "0.2.0", "blocky", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"template", "blocky", "/mirror/blocky-0.2.0.tgz", "--namespace", "blocky"}, calledArgs)
+}
+
+func Test_Helm_Service_Succeeds_TemplateChartWithValues(t *testing.T) {
+	var calledArgs []string
+	var valuesContent []byte
+	execCommand = func(_ string, args ...string) *exec.Cmd {
+		calledArgs = args
+		valuesContent, _ = os.ReadFile(args[len(args)-1])
+		return exec.Command("echo", "kind: Deployment")
+	}
+
+	helmService := HelmService{}
+	_, err := helmService.TemplateChart("blocky", "blocky", "https://charts.example.com", "0.2.0", "blocky", []byte("replicas: 3\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "--values", calledArgs[len(calledArgs)-2])
+	assert.Equal(t, "replicas: 3\n", string(valuesContent))
+	assert.NoFileExists(t, calledArgs[len(calledArgs)-1])
+}
+
+func Test_Helm_Service_Fails_TemplateChart(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helmService := HelmService{}
+	_, err := helmService.TemplateChart("blocky", "blocky", "https://charts.example.com", "0.2.0", "blocky", nil)
+
+	assert.ErrorContains(t, err, "Failed to render helm chart `blocky`")
+}
@@ -7,13 +7,19 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/cluster_service"
+	"gopkg.in/yaml.v2"
 )
 
 var configDir string = ".bbe"
 var execCommand = exec.Command
+var invalidClusterWarning sync.Once
 
 type HelperService struct{}
 
@@ -34,7 +40,9 @@ func (helperService HelperService) PipeCommands(commands ...*exec.Cmd) ([]byte,
 	}
 
 	if len(commands) == 1 {
-		return commands[0].CombinedOutput()
+		output, err := commands[0].CombinedOutput()
+		logger.Command(commands[0], output, err)
+		return output, err
 	}
 
 	for i := 0; i < len(commands)-1; i++ {
@@ -54,8 +62,8 @@ func (helperService HelperService) PipeCommands(commands ...*exec.Cmd) ([]byte,
 	}
 
 	output, err := commands[len(commands)-1].CombinedOutput()
+	logger.Command(commands[len(commands)-1], output, err)
 	if err != nil {
-		logger.Debug(fmt.Sprintf("Last command failed: %v\nOutput: %s", err, output))
 		return output, err
 	}
 
@@ -82,8 +90,8 @@ func (helperService HelperService) DeleteEmptyStrings(s []string) []string {
 func (helperService HelperService) IsWsl() bool {
 	cmd := execCommand("uname", "-a")
 	output, err := cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
 	if err != nil {
-		logger.Debug(string(output))
 		return false
 	}
 
@@ -96,7 +104,8 @@ func (helperService HelperService) IsValidIp(ip string) bool {
 	return err == nil && match
 }
 
-func (helperService HelperService) GetConfigDir() string {
+// GetBaseDir returns the directory holding the configuration of every cluster
+func (helperService HelperService) GetBaseDir() string {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		panic(err)
@@ -104,6 +113,35 @@ func (helperService HelperService) GetConfigDir() string {
 	return filepath.Join(homeDir, configDir)
 }
 
+// GetClusterName returns the cluster selected with --cluster, or the active cluster when none was selected
+// The name becomes part of the config directory, an invalid name is ignored so it cannot point outside the clusters directory
+func (helperService HelperService) GetClusterName() string {
+	if name := os.Getenv(constants.ClusterEnvVar); name != "" {
+		err := cluster_service.ValidateName(name)
+		if err == nil {
+			return name
+		}
+		invalidClusterWarning.Do(func() {
+			logger.Warning(fmt.Sprintf("Ignoring %s: %v", constants.ClusterEnvVar, err))
+		})
+	}
+
+	content, err := os.ReadFile(filepath.Join(helperService.GetBaseDir(), constants.ClusterRegistryFile))
+	if err == nil {
+		var registry models.ClusterRegistry
+		if err := yaml.Unmarshal(content, &registry); err == nil && cluster_service.ValidateName(registry.Current) == nil {
+			return registry.Current
+		}
+	}
+
+	return constants.DefaultClusterName
+}
+
+// GetConfigDir returns the configuration directory of the current cluster
+func (helperService HelperService) GetConfigDir() string {
+	return filepath.Join(helperService.GetBaseDir(), constants.ClustersDir, helperService.GetClusterName())
+}
+
 func (helperService HelperService) GetConfigFilePath(name string) string {
 	configDir := helperService.GetConfigDir()
 	return fmt.Sprintf("%s/%s", configDir, name)
@@ -3,6 +3,7 @@ package helper_service
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -142,9 +143,60 @@ func TestGetConfigDir(t *testing.T) {
 
 func TestGetConfigFilePath(t *testing.T) {
 	helperService := HelperService{}
+	t.Setenv("BBE_CLUSTER", "")
+	t.Setenv("HOME", t.TempDir())
 
 	configFilePath := helperService.GetConfigFilePath("config.json")
-	if !strings.HasSuffix(configFilePath, ".bbe/config.json") {
-		t.Errorf("Expected config file path to end with .bbe/config.json, got %s", configFilePath)
+	if !strings.HasSuffix(configFilePath, ".bbe/clusters/default/config.json") {
+		t.Errorf("Expected config file path to end with .bbe/clusters/default/config.json, got %s", configFilePath)
 	}
 }
+
+func Test_GetClusterName_Succeeds_WithActiveCluster(t *testing.T) {
+	helperService := HelperService{}
+	t.Setenv("BBE_CLUSTER", "")
+	t.Setenv("HOME", t.TempDir())
+
+	err := os.MkdirAll(helperService.GetBaseDir(), 0700)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(helperService.GetBaseDir(), "clusters.yaml"), []byte("current: lab\n"), 0600)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "lab", helperService.GetClusterName())
+	assert.True(t, strings.HasSuffix(helperService.GetConfigDir(), filepath.Join(".bbe", "clusters", "lab")))
+}
+
+func Test_GetClusterName_Succeeds_WithClusterFromEnvironment(t *testing.T) {
+	helperService := HelperService{}
+	t.Setenv("BBE_CLUSTER", "home")
+	t.Setenv("HOME", t.TempDir())
+
+	assert.Equal(t, "home", helperService.GetClusterName())
+}
+
+func Test_GetClusterName_Succeeds_IgnoresInvalidClusterFromEnvironment(t *testing.T) {
+	helperService := HelperService{}
+	t.Setenv("BBE_CLUSTER", "../../.ssh")
+	t.Setenv("HOME", t.TempDir())
+
+	err := os.MkdirAll(helperService.GetBaseDir(), 0700)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(helperService.GetBaseDir(), "clusters.yaml"), []byte("current: lab\n"), 0600)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "lab", helperService.GetClusterName())
+	assert.True(t, strings.HasSuffix(helperService.GetConfigDir(), filepath.Join(".bbe", "clusters", "lab")))
+}
+
+func Test_GetClusterName_Succeeds_IgnoresInvalidActiveCluster(t *testing.T) {
+	helperService := HelperService{}
+	t.Setenv("BBE_CLUSTER", "")
+	t.Setenv("HOME", t.TempDir())
+
+	err := os.MkdirAll(helperService.GetBaseDir(), 0700)
+	assert.NoError(t, err)
+	err = os.WriteFile(filepath.Join(helperService.GetBaseDir(), "clusters.yaml"), []byte("current: ../lab\n"), 0600)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "default", helperService.GetClusterName())
+}
@@ -3,8 +3,10 @@ package image_service
 import (
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"os"
+	"slices"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
@@ -30,6 +32,17 @@ var RaspberryPi = models.NodeType{
 	Extensions: []string{"iscsi-tools"},
 }
 
+// NodeTypes maps the device names accepted by bbe setup --device to their images
+var NodeTypes = map[string]models.NodeType{
+	"intel-nuc":    IntelNuc,
+	"raspberry-pi": RaspberryPi,
+}
+
+// DeviceTypes returns the names of NodeTypes in alphabetical order
+func DeviceTypes() []string {
+	return slices.Sorted(maps.Keys(NodeTypes))
+}
+
 func (imageService ImageService) CreateImage(nodeType models.NodeType, outputDir string) (string, error) {
 	return imageService.downloadImage(nodeType, outputDir)
 }
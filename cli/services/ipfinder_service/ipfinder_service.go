@@ -18,8 +18,8 @@ func (ipFinderService IpFinderService) LocateDevice(helperService interfaces.Hel
 
 	cmd := execCommand("bash", "-c", fmt.Sprintf(`nmap -sn %s/24 -oG - | awk '/Up$/{print $2}'`, ip))
 	output, err := cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
 	if err != nil {
-		logger.Debug(string(output))
 		return nil, err
 	}
 
@@ -50,8 +50,8 @@ func (ipFinderService IpFinderService) GetGatewayIp(helperService interfaces.Hel
 	logger.Info("Trying to determine the Gateway IP address...")
 	cmd := execCommand("bash", "-c", command)
 	output, err = cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
 	if err != nil {
-		logger.Debug(string(output))
 		logger.Error("Error while determining IP address", nil)
 		return "", nil
 	}
@@ -0,0 +1,299 @@
+package kubeconfig_service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"gopkg.in/yaml.v2"
+)
+
+var netDialTimeout = net.DialTimeout
+var endpointTimeout = 5 * time.Second
+
+type KubeconfigService struct{}
+
+// Generate writes a fresh admin kubeconfig to the config directory of the cluster and records its context in bbe.yaml.
+// Every call issues a new client certificate, so it also rotates the credentials.
+func (kubeconfigService KubeconfigService) Generate(helperService interfaces.HelperServiceInterface, configService interfaces.ConfigServiceInterface, talosService interfaces.TalosServiceInterface, controlPlaneIp string) (string, error) {
+	path := kubeconfigService.Path(helperService)
+
+	err := talosService.ExportKubeConfig(helperService, controlPlaneIp, path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to generate a kubeconfig: %w", err)
+	}
+
+	err = os.Chmod(path, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	kubeConfig, err := readKubeConfig(path)
+	if err != nil {
+		return "", err
+	}
+	if kubeConfig.CurrentContext == "" {
+		return "", fmt.Errorf("The kubeconfig generated for %s has no current context", controlPlaneIp)
+	}
+
+	err = configService.UpdateBbeClusterContext(helperService, kubeConfig.CurrentContext)
+	if err != nil {
+		return "", fmt.Errorf("Failed to record the kube context in bbe.yaml: %w", err)
+	}
+
+	return kubeConfig.CurrentContext, nil
+}
+
+// Path returns where the kubeconfig of the active cluster is kept
+func (kubeconfigService KubeconfigService) Path(helperService interfaces.HelperServiceInterface) string {
+	return helperService.GetConfigFilePath(constants.KubeConfigFile)
+}
+
+// VerifyEndpoint checks that the API server of the current context accepts connections
+func (kubeconfigService KubeconfigService) VerifyEndpoint(helperService interfaces.HelperServiceInterface) (string, error) {
+	kubeConfig, err := readKubeConfig(kubeconfigService.Path(helperService))
+	if err != nil {
+		return "", err
+	}
+
+	server, err := currentServer(kubeConfig)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.Parse(server)
+	if err != nil || endpoint.Host == "" {
+		return server, fmt.Errorf("Invalid API server address `%s`", server)
+	}
+
+	address := endpoint.Host
+	if endpoint.Port() == "" {
+		address = net.JoinHostPort(endpoint.Hostname(), "443")
+	}
+
+	connection, err := netDialTimeout("tcp", address, endpointTimeout)
+	if err != nil {
+		return server, fmt.Errorf("The API server %s is not reachable: %w", server, err)
+	}
+	connection.Close()
+
+	return server, nil
+}
+
+// Merge adds the clusters, contexts and users of the cluster kubeconfig to target and returns the name of the merged context.
+// Entries that exist in target with different content fail with KubeConfigConflictError unless strategy is KubeConfigMergeOverwrite or KubeConfigMergeRename.
+func (kubeconfigService KubeconfigService) Merge(helperService interfaces.HelperServiceInterface, target string, strategy string) (string, error) {
+	source, err := readKubeConfig(kubeconfigService.Path(helperService))
+	if err != nil {
+		return "", err
+	}
+
+	merged := &models.KubeConfig{ApiVersion: "v1", Kind: "Config"}
+	original, err := os.ReadFile(target)
+	if err == nil {
+		merged, err = parseKubeConfig(original)
+		if err != nil {
+			return "", fmt.Errorf("Failed to parse %s: %w", target, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	conflicts := conflictingNames(source, merged)
+	if len(conflicts) > 0 {
+		switch strategy {
+		case constants.KubeConfigMergeOverwrite:
+		case constants.KubeConfigMergeRename:
+			renameConflicts(source, merged)
+		default:
+			return "", fmt.Errorf("%w in %s: %s", constants.KubeConfigConflictError, target, strings.Join(conflicts, ", "))
+		}
+	}
+
+	merged.Clusters = upsertEntries(merged.Clusters, source.Clusters)
+	merged.Contexts = upsertEntries(merged.Contexts, source.Contexts)
+	merged.Users = upsertEntries(merged.Users, source.Users)
+	if merged.CurrentContext == "" {
+		merged.CurrentContext = source.CurrentContext
+	}
+
+	content, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0700)
+	if err != nil {
+		return "", err
+	}
+
+	if original != nil {
+		err = os.WriteFile(target+".bbe.bak", original, 0600)
+		if err != nil {
+			return "", fmt.Errorf("Failed to back up %s: %w", target, err)
+		}
+	}
+
+	err = os.WriteFile(target, content, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	return source.CurrentContext, nil
+}
+
+// DefaultKubeConfigPath returns the kubeconfig kubectl reads when KUBECONFIG is not set
+func DefaultKubeConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".kube", "config"), nil
+}
+
+func readKubeConfig(path string) (*models.KubeConfig, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("No kubeconfig found at %s, run 'bbe kubeconfig' to generate one", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKubeConfig(content)
+}
+
+func parseKubeConfig(content []byte) (*models.KubeConfig, error) {
+	var kubeConfig models.KubeConfig
+	err := yaml.Unmarshal(content, &kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kubeConfig, nil
+}
+
+func currentServer(kubeConfig *models.KubeConfig) (string, error) {
+	context, found := findEntry(kubeConfig.Contexts, kubeConfig.CurrentContext)
+	if !found {
+		return "", fmt.Errorf("The current context `%s` is not defined in the kubeconfig", kubeConfig.CurrentContext)
+	}
+
+	clusterName, _ := context.Context["cluster"].(string)
+	cluster, found := findEntry(kubeConfig.Clusters, clusterName)
+	if !found {
+		return "", fmt.Errorf("The cluster `%s` is not defined in the kubeconfig", clusterName)
+	}
+
+	server, _ := cluster.Cluster["server"].(string)
+	if server == "" {
+		return "", fmt.Errorf("The cluster `%s` has no API server address", clusterName)
+	}
+
+	return server, nil
+}
+
+// conflictingNames lists the entries of source that exist in target with different content
+func conflictingNames(source *models.KubeConfig, target *models.KubeConfig) []string {
+	conflicts := []string{}
+	for _, list := range []struct {
+		kind   string
+		source []models.KubeConfigEntry
+		target []models.KubeConfigEntry
+	}{
+		{"cluster", source.Clusters, target.Clusters},
+		{"context", source.Contexts, target.Contexts},
+		{"user", source.Users, target.Users},
+	} {
+		for _, entry := range list.source {
+			existing, found := findEntry(list.target, entry.Name)
+			if found && !reflect.DeepEqual(existing, entry) {
+				conflicts = append(conflicts, fmt.Sprintf("%s `%s`", list.kind, entry.Name))
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// renameConflicts gives every conflicting entry of source a free name and updates the references of its contexts
+func renameConflicts(source *models.KubeConfig, target *models.KubeConfig) {
+	clusters := renameEntries(source.Clusters, target.Clusters)
+	users := renameEntries(source.Users, target.Users)
+
+	for _, context := range source.Contexts {
+		if name, found := clusters[fmt.Sprint(context.Context["cluster"])]; found {
+			context.Context["cluster"] = name
+		}
+		if name, found := users[fmt.Sprint(context.Context["user"])]; found {
+			context.Context["user"] = name
+		}
+	}
+
+	// Contexts are compared after their references changed, so a context pointing at a renamed cluster is renamed as well
+	contexts := renameEntries(source.Contexts, target.Contexts)
+	if name, found := contexts[source.CurrentContext]; found {
+		source.CurrentContext = name
+	}
+}
+
+func renameEntries(source []models.KubeConfigEntry, target []models.KubeConfigEntry) map[string]string {
+	renamed := map[string]string{}
+	for i, entry := range source {
+		existing, found := findEntry(target, entry.Name)
+		if !found || reflect.DeepEqual(existing, entry) {
+			continue
+		}
+
+		name := entry.Name
+		for suffix := 2; ; suffix++ {
+			name = fmt.Sprintf("%s-%d", entry.Name, suffix)
+			if _, taken := findEntry(target, name); !taken {
+				break
+			}
+		}
+
+		renamed[entry.Name] = name
+		source[i].Name = name
+	}
+
+	return renamed
+}
+
+func upsertEntries(target []models.KubeConfigEntry, source []models.KubeConfigEntry) []models.KubeConfigEntry {
+	for _, entry := range source {
+		replaced := false
+		for i := range target {
+			if target[i].Name == entry.Name {
+				target[i] = entry
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			target = append(target, entry)
+		}
+	}
+
+	return target
+}
+
+func findEntry(entries []models.KubeConfigEntry, name string) (models.KubeConfigEntry, bool) {
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+
+	return models.KubeConfigEntry{}, false
+}
@@ -0,0 +1,214 @@
+package kubeconfig_service
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const homeKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: home
+  cluster:
+    server: https://192.168.1.10:6443
+contexts:
+- name: admin@home
+  context:
+    cluster: home
+    namespace: default
+    user: admin@home
+users:
+- name: admin@home
+  user:
+    client-key-data: bmV3
+current-context: admin@home
+`
+
+func initKubeconfigTest(t *testing.T, content string) (*mocks.MockHelperService, string) {
+	configDir := t.TempDir()
+	path := filepath.Join(configDir, constants.KubeConfigFile)
+	if content != "" {
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.KubeConfigFile).Return(path)
+
+	return helperService, path
+}
+
+func Test_Generate_Succeeds_RecordsContextFromKubeconfig(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, path := initKubeconfigTest(t, "")
+
+	talosService := &mocks.MockTalosService{}
+	talosService.On("ExportKubeConfig", helperService, "192.168.1.10", path).Return(nil).Run(func(args mock.Arguments) {
+		assert.NoError(t, os.WriteFile(args.String(2), []byte(homeKubeConfig), 0644))
+	})
+	configService := &mocks.MockConfigService{}
+	configService.On("UpdateBbeClusterContext", helperService, "admin@home").Return(nil)
+
+	context, err := kubeconfigService.Generate(helperService, configService, talosService, "192.168.1.10")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@home", context)
+	configService.AssertCalled(t, "UpdateBbeClusterContext", helperService, "admin@home")
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_Generate_Fails_WhenTalosCtlFails(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, path := initKubeconfigTest(t, "")
+
+	talosService := &mocks.MockTalosService{}
+	talosService.On("ExportKubeConfig", helperService, "192.168.1.10", path).Return(errors.New("connection refused"))
+	configService := &mocks.MockConfigService{}
+
+	_, err := kubeconfigService.Generate(helperService, configService, talosService, "192.168.1.10")
+
+	assert.ErrorContains(t, err, "connection refused")
+	configService.AssertNotCalled(t, "UpdateBbeClusterContext", mock.Anything, mock.Anything)
+}
+
+func Test_VerifyEndpoint_Succeeds_WhenServerAcceptsConnections(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	dialed := ""
+	netDialTimeout = func(_ string, address string, _ time.Duration) (net.Conn, error) {
+		dialed = address
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	t.Cleanup(func() { netDialTimeout = net.DialTimeout })
+
+	server, err := kubeconfigService.VerifyEndpoint(helperService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://192.168.1.10:6443", server)
+	assert.Equal(t, "192.168.1.10:6443", dialed)
+}
+
+func Test_VerifyEndpoint_Fails_WhenServerIsUnreachable(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	netDialTimeout = func(_ string, _ string, _ time.Duration) (net.Conn, error) {
+		return nil, errors.New("i/o timeout")
+	}
+	t.Cleanup(func() { netDialTimeout = net.DialTimeout })
+
+	_, err := kubeconfigService.VerifyEndpoint(helperService)
+
+	assert.ErrorContains(t, err, "is not reachable")
+}
+
+func Test_VerifyEndpoint_Fails_WithoutKubeconfig(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, "")
+
+	_, err := kubeconfigService.VerifyEndpoint(helperService)
+
+	assert.ErrorContains(t, err, "run 'bbe kubeconfig'")
+}
+
+func Test_Merge_Succeeds_IntoMissingFile(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	target := filepath.Join(t.TempDir(), ".kube", "config")
+
+	context, err := kubeconfigService.Merge(helperService, target, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@home", context)
+	merged, err := readKubeConfig(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@home", merged.CurrentContext)
+	assert.Len(t, merged.Clusters, 1)
+}
+
+func Test_Merge_Succeeds_KeepsOtherEntriesAndCurrentContext(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	target := filepath.Join(t.TempDir(), "config")
+	existing := "apiVersion: v1\nkind: Config\nclusters:\n- name: work\n  cluster:\n    server: https://work:6443\ncontexts:\n- name: work\n  context:\n    cluster: work\n    user: work\nusers:\n- name: work\n  user:\n    token: abc\ncurrent-context: work\npreferences: {}\n"
+	assert.NoError(t, os.WriteFile(target, []byte(existing), 0600))
+
+	_, err := kubeconfigService.Merge(helperService, target, "")
+
+	assert.NoError(t, err)
+	merged, err := readKubeConfig(target)
+	assert.NoError(t, err)
+	assert.Equal(t, "work", merged.CurrentContext)
+	assert.Len(t, merged.Contexts, 2)
+	assert.Contains(t, merged.Extra, "preferences")
+	backup, err := os.ReadFile(target + ".bbe.bak")
+	assert.NoError(t, err)
+	assert.Equal(t, existing, string(backup))
+}
+
+func Test_Merge_Fails_WithConflictingEntries(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	target := filepath.Join(t.TempDir(), "config")
+	existing := "clusters:\n- name: home\n  cluster:\n    server: https://10.0.0.1:6443\ncontexts: []\nusers: []\ncurrent-context: \"\"\n"
+	assert.NoError(t, os.WriteFile(target, []byte(existing), 0600))
+
+	_, err := kubeconfigService.Merge(helperService, target, "")
+
+	assert.ErrorIs(t, err, constants.KubeConfigConflictError)
+	assert.ErrorContains(t, err, "cluster `home`")
+	content, _ := os.ReadFile(target)
+	assert.Equal(t, existing, string(content))
+}
+
+func Test_Merge_Succeeds_RenamesConflictingEntries(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	target := filepath.Join(t.TempDir(), "config")
+	existing := "clusters:\n- name: home\n  cluster:\n    server: https://10.0.0.1:6443\ncontexts:\n- name: admin@home\n  context:\n    cluster: home\n    namespace: default\n    user: admin@home\nusers:\n- name: admin@home\n  user:\n    client-key-data: b2xk\ncurrent-context: admin@home\n"
+	assert.NoError(t, os.WriteFile(target, []byte(existing), 0600))
+
+	context, err := kubeconfigService.Merge(helperService, target, constants.KubeConfigMergeRename)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin@home-2", context)
+	merged, err := readKubeConfig(target)
+	assert.NoError(t, err)
+	assert.Len(t, merged.Clusters, 2)
+	renamed, found := findEntry(merged.Contexts, "admin@home-2")
+	assert.True(t, found)
+	assert.Equal(t, "home-2", renamed.Context["cluster"])
+	assert.Equal(t, "admin@home-2", renamed.Context["user"])
+	server, err := currentServer(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://10.0.0.1:6443", server)
+}
+
+func Test_Merge_Succeeds_OverwritesConflictingEntries(t *testing.T) {
+	kubeconfigService := KubeconfigService{}
+	helperService, _ := initKubeconfigTest(t, homeKubeConfig)
+	target := filepath.Join(t.TempDir(), "config")
+	existing := "clusters:\n- name: home\n  cluster:\n    server: https://10.0.0.1:6443\ncontexts: []\nusers: []\ncurrent-context: \"\"\n"
+	assert.NoError(t, os.WriteFile(target, []byte(existing), 0600))
+
+	_, err := kubeconfigService.Merge(helperService, target, constants.KubeConfigMergeOverwrite)
+
+	assert.NoError(t, err)
+	merged, err := readKubeConfig(target)
+	assert.NoError(t, err)
+	assert.Len(t, merged.Clusters, 1)
+	server, err := currentServer(merged)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://192.168.1.10:6443", server)
+}
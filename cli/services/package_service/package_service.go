@@ -1,69 +1,486 @@
 package package_service
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/merge"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/signing"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/versioning"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/services/helm_service"
 	"gopkg.in/yaml.v3"
 )
 
 type PackageService struct{}
 
+type libraryDocument struct {
+	revision  *models.LibraryEntry
+	body      []byte
+	signature []byte
+}
+
 var ioReadAll = io.ReadAll
+var execCommand = exec.Command
+var osReadFile = os.ReadFile
+var osWriteFile = os.WriteFile
+var osMkdirAll = os.MkdirAll
+
+var defaultLibraryTimeout = 10 * time.Second
+
+func getRemoteLibrary(helperService interfaces.HelperServiceInterface, bbeConfig models.BbeConfig) (*models.LibraryEntry, error) {
+	document, err := loadLibrary(helperService, officialLibrary(bbeConfig), libraryTimeout(bbeConfig))
+	if err != nil {
+		return nil, err
+	}
+
+	return document.revision, nil
+}
+
+func officialLibrary(bbeConfig models.BbeConfig) models.LibrarySource {
+	return models.LibrarySource{
+		Name:          constants.DefaultLibraryName,
+		Source:        bbeConfig.Bbe.Library.Source,
+		AllowUnsigned: bbeConfig.Bbe.Library.AllowUnsigned,
+	}
+}
+
+func loadLibrary(helperService interfaces.HelperServiceInterface, library models.LibrarySource, timeout time.Duration) (*libraryDocument, error) {
+	cacheFile := libraryCacheFile(library.Name)
+
+	body, signature, err := fetchLibrary(library.Source, timeout)
+	if err == nil {
+		err = verifyLibrary(library, body, signature)
+		if err != nil {
+			return nil, err
+		}
+
+		var revision *models.LibraryEntry
+		revision, err = parseLibrary(body)
+		if err == nil {
+			cacheLibrary(helperService, cacheFile, body, signature)
+			resolveLocalCharts(revision, library.Source)
+			return &libraryDocument{revision: revision, body: body, signature: signature}, nil
+		}
+	}
+
+	cached, cacheErr := readCachedLibrary(helperService, library, cacheFile)
+	if cacheErr != nil {
+		logger.Debug(fmt.Sprintf("No usable cached library: %v", cacheErr))
+		return nil, err
+	}
+
+	logger.Warning(fmt.Sprintf("Unable to load the package library (%v), using cached library revision %d", err, cached.revision.ListRevision))
+	resolveLocalCharts(cached.revision, library.Source)
+
+	return cached, nil
+}
+
+// verifyLibrary refuses libraries that are not signed by a trusted key, the official library is verified against the embedded keys,
+// a library without a public key or signature is only accepted when allow_unsigned is set for it
+func verifyLibrary(library models.LibrarySource, body []byte, signature []byte) error {
+	publicKeys := constants.TrustedLibraryKeys
+	if library.Name != constants.DefaultLibraryName {
+		publicKeys = nil
+		if library.PublicKey != "" {
+			publicKeys = []string{library.PublicKey}
+		}
+	}
+
+	if len(publicKeys) == 0 {
+		if library.AllowUnsigned {
+			logger.Warning(fmt.Sprintf("Library %s has no public key configured, its contents are not verified", library.Name))
+			return nil
+		}
+		return fmt.Errorf("%w for library %s: no public key configured", constants.LibraryVerificationError, library.Name)
+	}
 
-func getRemoteLibrary() (*models.LibraryEntry, error) {
+	err := signing.Verify(body, signature, publicKeys)
+	if errors.Is(err, signing.MissingSignatureError) && library.AllowUnsigned {
+		// A signature that is present still has to match
+		logger.Warning(fmt.Sprintf("Library %s is not signed, its contents are not verified", library.Name))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w for library %s: %v", constants.LibraryVerificationError, library.Name, err)
+	}
+
+	return nil
+}
+
+func fetchLibrary(source string, timeout time.Duration) ([]byte, []byte, error) {
+	switch {
+	case source == "":
+		return fetchHttpLibrary(constants.BbeLibraryUrl, timeout)
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return fetchHttpLibrary(source, timeout)
+	case strings.HasPrefix(source, "oci://"):
+		return fetchOciLibrary(source)
+	default:
+		return fetchLocalLibrary(source)
+	}
+}
+
+func fetchHttpLibrary(libraryUrl string, timeout time.Duration) ([]byte, []byte, error) {
 	client := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout: timeout,
 	}
 
 	// Fetch the library.yaml file from remote
-	resp, err := client.Get(constants.BbeLibraryUrl)
+	resp, err := client.Get(libraryUrl)
 	if err != nil {
 		logger.Debug(fmt.Sprintf("Error fetching library.yaml: %v", err))
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("Unexpected status fetching library: %s", resp.Status)
+	}
+
 	body, err := ioReadAll(resp.Body)
 	if err != nil {
 		logger.Debug(fmt.Sprintf("Error reading response body: %v", err))
+		return nil, nil, err
+	}
+
+	signature, err := fetchHttpSignature(client, libraryUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, signature, nil
+}
+
+// fetchHttpSignature returns no signature when the server reports none exists, any other failure is an error so a library is never treated as unsigned by accident
+func fetchHttpSignature(client *http.Client, libraryUrl string) ([]byte, error) {
+	signatureUrl, err := url.Parse(libraryUrl)
+	if err != nil {
+		return nil, err
+	}
+	signatureUrl.Path += constants.LibrarySignatureExtension
+
+	resp, err := client.Get(signatureUrl.String())
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Error fetching library signature: %v", err))
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status fetching library signature: %s", resp.Status)
+	}
+
+	signature, err := ioReadAll(resp.Body)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Error reading library signature: %v", err))
 		return nil, err
 	}
 
+	return signature, nil
+}
+
+func fetchOciLibrary(reference string) ([]byte, []byte, error) {
+	outputDir, err := os.MkdirTemp("", "bbe-library-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(outputDir)
+
+	cmd := execCommand("oras", "pull", strings.TrimPrefix(reference, "oci://"), "--output", outputDir)
+	output, err := cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to pull library artifact `%s` with oras: %w", reference, err)
+	}
+
+	return fetchLocalLibrary(filepath.Join(outputDir, constants.LibraryMirrorFile))
+}
+
+func fetchLocalLibrary(path string) ([]byte, []byte, error) {
+	path = strings.TrimPrefix(path, "file://")
+
+	body, err := osReadFile(path)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Error reading library file: %v", err))
+		return nil, nil, err
+	}
+
+	signature, err := osReadFile(path + constants.LibrarySignatureExtension)
+	if err != nil {
+		signature = nil
+	}
+
+	return body, signature, nil
+}
+
+func parseLibrary(body []byte) (*models.LibraryEntry, error) {
 	var library models.Library
 	if err := yaml.Unmarshal(body, &library); err != nil {
 		logger.Debug(fmt.Sprintf("Error parsing YAML: %v", err))
 		return nil, err
 	}
 
+	required := ""
 	for _, revision := range library.Library {
-		if revision.MinBbeCli <= constants.Version {
+		if versioning.IsCliCompatible(revision.MinBbeCli, constants.Version) {
+			if required != "" {
+				logger.Info(fmt.Sprintf("A newer version of the package library requires bbe %s, run 'bbe self-update' to upgrade from %s", required, constants.Version))
+			}
 			return &revision, nil
 		}
+		if required == "" {
+			required = revision.MinBbeCli
+		}
+	}
+
+	if required != "" {
+		return nil, fmt.Errorf("No revision found for bbe-cli version %s, the library requires bbe %s, run 'bbe self-update' to upgrade", constants.Version, required)
 	}
 
 	return nil, fmt.Errorf("No revision found for current bbe-cli version")
 }
 
-func (packageService PackageService) GetAll() ([]models.ChartEntry, error) {
-	library, err := getRemoteLibrary()
+func cacheLibrary(helperService interfaces.HelperServiceInterface, cacheFile string, body []byte, signature []byte) {
+	cacheFile = helperService.GetConfigFilePath(cacheFile)
+
+	err := osMkdirAll(filepath.Dir(cacheFile), os.ModePerm)
+	if err == nil {
+		err = osWriteFile(cacheFile, body, 0644)
+	}
+	if err == nil && signature != nil {
+		err = osWriteFile(cacheFile+constants.LibrarySignatureExtension, signature, 0644)
+	}
+
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Failed to cache library: %v", err))
+	}
+}
+
+func readCachedLibrary(helperService interfaces.HelperServiceInterface, library models.LibrarySource, cacheFile string) (*libraryDocument, error) {
+	body, signature, err := fetchLocalLibrary(helperService.GetConfigFilePath(cacheFile))
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyLibrary(library, body, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	revision, err := parseLibrary(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &libraryDocument{revision: revision, body: body, signature: signature}, nil
+}
+
+// resolveLocalCharts points charts of a library on disk at their archives, either as listed or as mirrored next to the library file
+func resolveLocalCharts(revision *models.LibraryEntry, source string) {
+	if source == "" || (strings.Contains(source, "://") && !strings.HasPrefix(source, "file://")) {
+		return
+	}
+
+	libraryDir := filepath.Dir(strings.TrimPrefix(source, "file://"))
+	for i, chart := range revision.Charts {
+		listed := chart.LocalChart
+		if listed != "" && !filepath.IsAbs(listed) {
+			listed = filepath.Join(libraryDir, listed)
+		}
+
+		mirrored := filepath.Join(libraryDir, chartArchiveName(chart))
+		switch {
+		case listed != "" && fileExists(listed):
+			revision.Charts[i].LocalChart = listed
+		case fileExists(mirrored):
+			revision.Charts[i].LocalChart = mirrored
+		default:
+			revision.Charts[i].LocalChart = listed
+		}
+	}
+}
+
+func chartArchiveName(chart models.ChartEntry) string {
+	return fmt.Sprintf("%s-%s.tgz", chart.Name, chart.Version)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func libraryTimeout(bbeConfig models.BbeConfig) time.Duration {
+	if bbeConfig.Bbe.Library.Timeout > 0 {
+		return time.Duration(bbeConfig.Bbe.Library.Timeout) * time.Second
+	}
+
+	return defaultLibraryTimeout
+}
+
+func libraryCacheFile(libraryName string) string {
+	if libraryName == constants.DefaultLibraryName {
+		return constants.LibraryCacheFile
+	}
+
+	return strings.Replace(constants.LibraryCacheFile, "library", fmt.Sprintf("library-%s", libraryName), 1)
+}
+
+// CheckLibrary fetches and verifies library without falling back to the cached copy
+func (packageService PackageService) CheckLibrary(bbeConfig models.BbeConfig, library models.LibrarySource) error {
+	body, signature, err := fetchLibrary(library.Source, libraryTimeout(bbeConfig))
+	if err != nil {
+		return err
+	}
+
+	err = verifyLibrary(library, body, signature)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseLibrary(body)
+	return err
+}
+
+// GetAll lists the charts of the official library followed by those of the additional libraries in order, libraries may offer charts of the same name
+func (packageService PackageService) GetAll(helperService interfaces.HelperServiceInterface, bbeConfig models.BbeConfig) ([]models.ChartEntry, error) {
+	library, err := getRemoteLibrary(helperService, bbeConfig)
 	if err != nil {
 		logger.Debug(fmt.Sprintf("Error fetching library: %v", err))
 		return nil, err
 	}
 
-	return library.Charts, nil
+	var charts []models.ChartEntry
+	addCharts := func(libraryName string, entries []models.ChartEntry) {
+		for _, chart := range entries {
+			chart.Library = libraryName
+			charts = append(charts, chart)
+		}
+	}
+
+	addCharts(constants.DefaultLibraryName, library.Charts)
+
+	for _, source := range bbeConfig.Bbe.Libraries {
+		additional, err := loadLibrary(helperService, source, libraryTimeout(bbeConfig))
+		if err != nil {
+			logger.Warning(fmt.Sprintf("Skipping library %s: %v", source.Name, err))
+			continue
+		}
+
+		addCharts(source.Name, additional.revision.Charts)
+	}
+
+	return charts, nil
+}
+
+// MirrorLibrary copies every library verbatim, including its signature, and stores the chart archives next to it
+func (packageService PackageService) MirrorLibrary(helperService interfaces.HelperServiceInterface, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface, destination string) error {
+	err := osMkdirAll(destination, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	libraries := append([]models.LibrarySource{officialLibrary(bbeConfig)}, bbeConfig.Bbe.Libraries...)
+	for _, library := range libraries {
+		document, err := loadLibrary(helperService, library, libraryTimeout(bbeConfig))
+		if err != nil {
+			return fmt.Errorf("Failed to load library %s: %w", library.Name, err)
+		}
+
+		libraryFile := filepath.Join(destination, MirroredLibraryFile(library.Name))
+		err = osWriteFile(libraryFile, document.body, 0644)
+		if err != nil {
+			return err
+		}
+
+		if document.signature != nil {
+			err = osWriteFile(libraryFile+constants.LibrarySignatureExtension, document.signature, 0644)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, chart := range document.revision.Charts {
+			logger.Infof("Mirroring chart %s %s from library %s", chart.Name, chart.Version, library.Name)
+
+			err := mirrorChart(chart, helmService, destination)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MirroredLibraryFile is the file name a library is stored under by MirrorLibrary
+func MirroredLibraryFile(libraryName string) string {
+	if libraryName == constants.DefaultLibraryName {
+		return constants.LibraryMirrorFile
+	}
+
+	return fmt.Sprintf("%s.yaml", libraryName)
+}
+
+func mirrorChart(chart models.ChartEntry, helmService interfaces.HelmServiceInterface, destination string) error {
+	if chart.LocalChart != "" {
+		content, err := osReadFile(chart.LocalChart)
+		if err != nil {
+			return err
+		}
+
+		return osWriteFile(filepath.Join(destination, chartArchiveName(chart)), content, 0644)
+	}
+
+	err := helmService.AddRepo(chart.RepositoryName, chart.RepositoryUrl)
+	if err != nil {
+		return err
+	}
+
+	_, err = helmService.PullChart(chart.Name, chart.RepositoryName, chart.Version, destination)
+	return err
+}
+
+// AlreadyInstalledError is returned when a package already has a release in the cluster, Version holds the live chart version if it could be determined
+type AlreadyInstalledError struct {
+	Name    string
+	Version string
+}
+
+func (err *AlreadyInstalledError) Error() string {
+	if err.Version == "" {
+		return fmt.Sprintf("Package `%s` already installed", err.Name)
+	}
+
+	return fmt.Sprintf("Package `%s` already installed with version %s", err.Name, err.Version)
 }
 
 func (packageService PackageService) InstallPackage(chart models.ChartEntry, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) error {
-	if !helmService.IsPackageInstalled(chart.Name, chart.Name, bbeConfig.Bbe.Cluster.Context) {
-		logger.Debug(fmt.Sprintf("Package `%s` not installed, adding helm repo...", chart.Name))
+	release := ChartRelease(chart)
+	namespace := ChartNamespace(chart)
+
+	if !helmService.IsPackageInstalled(release, namespace, bbeConfig.Bbe.Cluster.Context) {
+		if chart.LocalChart != "" {
+			logger.Debug(fmt.Sprintf("Package `%s` not installed, installing from `%s`...", release, chart.LocalChart))
+			return helmService.InstallChart(release, chart.LocalChart, "", chart.Version, namespace, bbeConfig.Bbe.Cluster.Context)
+		}
+
+		logger.Debug(fmt.Sprintf("Package `%s` not installed, adding helm repo...", release))
 		response := helmService.AddRepo(chart.RepositoryName, chart.RepositoryUrl)
 		logger.Debug(fmt.Sprintf("Helm repo added: %v", response))
 
@@ -71,17 +488,35 @@ func (packageService PackageService) InstallPackage(chart models.ChartEntry, bbe
 			return response
 		}
 
-		return helmService.InstallChart(chart.Name, chart.Name, chart.RepositoryName, chart.Version, chart.Name, bbeConfig.Bbe.Cluster.Context)
+		return helmService.InstallChart(release, chart.Name, chart.RepositoryName, chart.Version, namespace, bbeConfig.Bbe.Cluster.Context)
 	}
-	logger.Debug(fmt.Sprintf("Package `%s` already installed", chart.Name))
+	logger.Debug(fmt.Sprintf("Package `%s` already installed", release))
 
-	return nil
+	installedError := &AlreadyInstalledError{Name: release}
+	releases, err := helmService.ListReleases(bbeConfig.Bbe.Cluster.Context)
+	if err != nil {
+		logger.Debug(fmt.Sprintf("Unable to determine installed version of `%s`: %v", release, err))
+		return installedError
+	}
+
+	if installed, found := findRelease(releases, release, namespace); found {
+		installedError.Version = helm_service.ChartVersion(installed, chart.Name)
+	}
+
+	return installedError
 }
 
 func (packageService PackageService) UpgradePackage(chart models.ChartEntry, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) error {
-	if !helmService.IsPackageInstalled(chart.Name, chart.Name, bbeConfig.Bbe.Cluster.Context) {
-		logger.Debug(fmt.Sprintf("Package `%s` not installed", chart.Name))
-		return fmt.Errorf("Package `%s` not installed", chart.Name)
+	release := ChartRelease(chart)
+	namespace := ChartNamespace(chart)
+
+	if !helmService.IsPackageInstalled(release, namespace, bbeConfig.Bbe.Cluster.Context) {
+		logger.Debug(fmt.Sprintf("Package `%s` not installed", release))
+		return fmt.Errorf("Package `%s` not installed", release)
+	}
+
+	if chart.LocalChart != "" {
+		return helmService.UpgradeChart(release, chart.LocalChart, "", chart.Version, namespace, bbeConfig.Bbe.Cluster.Context)
 	}
 
 	response := helmService.AddRepo(chart.RepositoryName, chart.RepositoryUrl)
@@ -90,14 +525,146 @@ func (packageService PackageService) UpgradePackage(chart models.ChartEntry, bbe
 		return response
 	}
 
-	return helmService.UpgradeChart(chart.Name, chart.Name, chart.RepositoryName, chart.Version, chart.Name, bbeConfig.Bbe.Cluster.Context)
+	return helmService.UpgradeChart(release, chart.Name, chart.RepositoryName, chart.Version, namespace, bbeConfig.Bbe.Cluster.Context)
+}
+
+// DiffUpgrade compares the manifest of the deployed release with the manifest the upgrade would deploy, nothing is changed in the cluster
+func (packageService PackageService) DiffUpgrade(chart models.ChartEntry, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) (string, error) {
+	release := ChartRelease(chart)
+	namespace := ChartNamespace(chart)
+
+	deployed, err := helmService.GetManifest(release, namespace, bbeConfig.Bbe.Cluster.Context)
+	if err != nil {
+		return "", err
+	}
+
+	// helm upgrade keeps the values of the release, so the new version is rendered with them as well
+	values, err := helmService.GetValues(release, namespace, bbeConfig.Bbe.Cluster.Context)
+	if err != nil {
+		return "", err
+	}
+
+	var upgraded []byte
+	if chart.LocalChart != "" {
+		upgraded, err = helmService.TemplateChart(release, chart.LocalChart, "", chart.Version, namespace, values)
+	} else {
+		upgraded, err = helmService.TemplateChart(release, chart.Name, chart.RepositoryUrl, chart.Version, namespace, values)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return merge.Diff(deployed, upgraded), nil
+}
+
+func (packageService PackageService) UninstallPackage(pkg models.LocalPackage, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) error {
+	namespace := PackageNamespace(pkg)
+
+	if !helmService.IsPackageInstalled(pkg.Name, namespace, bbeConfig.Bbe.Cluster.Context) {
+		logger.Debug(fmt.Sprintf("Package `%s` not installed", pkg.Name))
+		return fmt.Errorf("Package `%s` not installed", pkg.Name)
+	}
+
+	return helmService.UninstallChart(pkg.Name, namespace, bbeConfig.Bbe.Cluster.Context)
+}
+
+// DetectDrift compares the packages recorded in bbe.yaml with the releases in the cluster, releases of library charts missing from bbe.yaml are reported as unmanaged
+func (packageService PackageService) DetectDrift(bbeConfig models.BbeConfig, allPackages []models.ChartEntry, helmService interfaces.HelmServiceInterface) ([]models.PackageDrift, error) {
+	releases, err := helmService.ListReleases(bbeConfig.Bbe.Cluster.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := []models.PackageDrift{}
+	recorded := map[string]bool{}
+
+	for _, pkg := range bbeConfig.Bbe.Packages {
+		recorded[pkg.Name] = true
+
+		drift := models.PackageDrift{
+			Name:          pkg.Name,
+			Chart:         pkg.Chart,
+			Namespace:     PackageNamespace(pkg),
+			ConfigVersion: pkg.Version,
+			Library:       pkg.Library,
+		}
+
+		release, found := findRelease(releases, pkg.Name, drift.Namespace)
+		if !found {
+			drift.Kind = constants.DriftMissing
+			drifts = append(drifts, drift)
+			continue
+		}
+
+		drift.ClusterVersion = helm_service.ChartVersion(release, PackageChart(pkg))
+		drift.Status = release.Status
+
+		switch {
+		case drift.ClusterVersion != pkg.Version:
+			drift.Kind = constants.DriftVersion
+		case release.Status != constants.HelmStatusDeployed:
+			drift.Kind = constants.DriftStatus
+		default:
+			continue
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	// Charts of the same name are offered in library order, a release is reported once for the library it was installed from
+	for _, chart := range allPackages {
+		name := ChartRelease(chart)
+		if recorded[name] {
+			continue
+		}
+
+		release, found := findRelease(releases, name, ChartNamespace(chart))
+		if !found {
+			continue
+		}
+
+		// Further releases of the chart cannot be told apart from releases of other charts, only the library default is adopted
+		recorded[name] = true
+		chart = releaseChart(allPackages, chart, release)
+		adopted := NewLocalPackage(chart, "")
+		drifts = append(drifts, models.PackageDrift{
+			Name:           name,
+			Chart:          adopted.Chart,
+			Namespace:      release.Namespace,
+			Kind:           constants.DriftUnmanaged,
+			ClusterVersion: helm_service.ChartVersion(release, chart.Name),
+			Status:         release.Status,
+			Library:        chart.Library,
+		})
+	}
+
+	return drifts, nil
 }
 
-func (packageService PackageService) UninstallPackage(chart models.LocalPackage, bbeConfig models.BbeConfig, helmService interfaces.HelmServiceInterface) error {
-	if !helmService.IsPackageInstalled(chart.Name, chart.Name, bbeConfig.Bbe.Cluster.Context) {
-		logger.Debug(fmt.Sprintf("Package `%s` not installed", chart.Name))
-		return fmt.Errorf("Package `%s` not installed", chart.Name)
+// findRelease looks up the release of a package by its name and namespace
+// releaseChart picks the chart of the library whose version is deployed, the release name alone does not tell which library installed it
+// and the first library in order of precedence is assumed when no or several libraries offer the deployed version
+func releaseChart(allPackages []models.ChartEntry, chart models.ChartEntry, release models.HelmRelease) models.ChartEntry {
+	if helm_service.ChartVersion(release, chart.Name) == chart.Version {
+		return chart
+	}
+
+	for _, candidate := range allPackages {
+		if candidate.Name == chart.Name && ChartRelease(candidate) == ChartRelease(chart) && ChartNamespace(candidate) == ChartNamespace(chart) &&
+			helm_service.ChartVersion(release, candidate.Name) == candidate.Version {
+			return candidate
+		}
+	}
+
+	return chart
+}
+
+func findRelease(releases []models.HelmRelease, name string, namespace string) (models.HelmRelease, bool) {
+	for _, release := range releases {
+		if release.Name == name && release.Namespace == namespace {
+			return release, true
+		}
 	}
 
-	return helmService.UninstallChart(chart.Name, chart.Name, bbeConfig.Bbe.Cluster.Context)
+	return models.HelmRelease{}, false
 }
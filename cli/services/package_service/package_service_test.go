@@ -5,9 +5,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/signing"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/stretchr/testify/assert"
@@ -15,9 +19,7 @@ import (
 )
 
 func Test_GetAll_Succeeds(t *testing.T) {
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/yaml")
-		w.Write([]byte(`library:
+	ts := newLibraryServer(t, `library:
   - minBbeCli: "0.0.1"
     charts:
       - name: "blocky"
@@ -27,8 +29,7 @@ func Test_GetAll_Succeeds(t *testing.T) {
       - name: "ingress-nginx"
         version: "4.12.0"
         repositoryName: "ingress-nginx"
-        repositoryUrl: "https://kubernetes.github.io/ingress-nginx"`))
-	}))
+        repositoryUrl: "https://kubernetes.github.io/ingress-nginx"`)
 	defer ts.Close()
 
 	// Override the BbeLibraryUrl constant to point to our test server
@@ -39,7 +40,7 @@ func Test_GetAll_Succeeds(t *testing.T) {
 	packagesService := PackageService{}
 
 	// Get all packages directly from the service
-	result, err := packagesService.GetAll()
+	result, err := packagesService.GetAll(initLibraryHelperService(t), models.BbeConfig{})
 	if err != nil {
 		t.Fatalf("Failed to get all packages: %v", err)
 	}
@@ -52,13 +53,39 @@ func Test_GetAll_Succeeds(t *testing.T) {
 	assert.Equal(t, "4.12.0", result[1].Version)
 }
 
+func Test_GetAll_Succeeds_WithPackageDetails(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - minBbeCli: "0.0.1"
+    charts:
+      - name: "immich"
+        version: "0.9.0"
+        repositoryName: "immich"
+        repositoryUrl: "https://immich-app.github.io/immich-charts"
+        description: "Self-hosted photo and video backup"
+        category: "media"
+        homepage: "https://immich.app"
+        icon: "https://immich.app/favicon.png"
+        dependencies: ["postgres", "redis"]`)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	result, err := PackageService{}.GetAll(initLibraryHelperService(t), models.BbeConfig{})
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Self-hosted photo and video backup", result[0].Description)
+	assert.Equal(t, "media", result[0].Category)
+	assert.Equal(t, "https://immich.app", result[0].Homepage)
+	assert.Equal(t, "https://immich.app/favicon.png", result[0].Icon)
+	assert.Equal(t, []string{"postgres", "redis"}, result[0].Dependencies)
+}
+
 func Test_GetAll_Fails(t *testing.T) {
 	// Create a test server that returns our mock library.yaml
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Return our mock library.yaml content
-		w.Header().Set("Content-Type", "application/yaml")
-		w.Write([]byte(`Not today, not today`))
-	}))
+	ts := newLibraryServer(t, `Not today, not today`)
 	defer ts.Close()
 
 	// Override the BbeLibraryUrl constant to point to our test server
@@ -69,7 +96,7 @@ func Test_GetAll_Fails(t *testing.T) {
 	packagesService := PackageService{}
 
 	// Get all packages directly from the service
-	result, err := packagesService.GetAll()
+	result, err := packagesService.GetAll(initLibraryHelperService(t), models.BbeConfig{})
 
 	// Assert that the result contains the correct package data
 	assert.Empty(t, result)
@@ -127,9 +154,28 @@ func Test_InstallPackage_Succeeds(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func Test_InstallPackage_Skips_Already_Installed_And_Succeeds(t *testing.T) {
+func Test_InstallPackage_Succeeds_WithReleaseAndNamespace(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("IsPackageInstalled", "postgres-analytics", "analytics", "test-context").Return(false)
+	mockHelmService.On("AddRepo", "bitnami", "https://charts.bitnami.com/bitnami").Return(nil)
+	mockHelmService.On("InstallChart", "postgres-analytics", "postgres", "bitnami", "16.0.0", "analytics", "test-context").Return(nil)
+
+	packagesService := PackageService{}
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	err := packagesService.InstallPackage(models.ChartEntry{Name: "postgres", Version: "16.0.0", RepositoryName: "bitnami", RepositoryUrl: "https://charts.bitnami.com/bitnami", Release: "postgres-analytics", Namespace: "analytics"}, bbeConfig, mockHelmService)
+
+	assert.NoError(t, err)
+	mockHelmService.AssertExpectations(t)
+}
+
+func Test_InstallPackage_Fails_WhenAlreadyInstalled(t *testing.T) {
 	mockHelmService := &mocks.MockHelmService{}
 	mockHelmService.On("IsPackageInstalled", mock.Anything, mock.Anything, mock.Anything).Return(true)
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{
+		{Name: "ingress-nginx", Namespace: "ingress-nginx", Status: "deployed", Chart: "ingress-nginx-4.11.0"},
+	}, nil)
 	packagesService := PackageService{}
 
 	bbeConfig := models.BbeConfig{}
@@ -137,8 +183,26 @@ func Test_InstallPackage_Skips_Already_Installed_And_Succeeds(t *testing.T) {
 
 	err := packagesService.InstallPackage(models.ChartEntry{Name: "ingress-nginx", Version: "4.12.0"}, bbeConfig, mockHelmService)
 
-	// Assert an error occurred
-	assert.NoError(t, err)
+	var alreadyInstalled *AlreadyInstalledError
+	assert.ErrorAs(t, err, &alreadyInstalled)
+	assert.Equal(t, "4.11.0", alreadyInstalled.Version)
+	mockHelmService.AssertNumberOfCalls(t, "InstallChart", 0)
+}
+
+func Test_InstallPackage_Fails_WhenAlreadyInstalledWithUnknownVersion(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("IsPackageInstalled", mock.Anything, mock.Anything, mock.Anything).Return(true)
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{}, errors.New("helm failed"))
+	packagesService := PackageService{}
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+
+	err := packagesService.InstallPackage(models.ChartEntry{Name: "ingress-nginx", Version: "4.12.0"}, bbeConfig, mockHelmService)
+
+	var alreadyInstalled *AlreadyInstalledError
+	assert.ErrorAs(t, err, &alreadyInstalled)
+	assert.Equal(t, "", alreadyInstalled.Version)
 }
 
 func Test_UpgradePackage_Fails_WhenHelmRepositoryNotFound(t *testing.T) {
@@ -238,6 +302,21 @@ func Test_UninstallPackage_Succeeds(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_UninstallPackage_Succeeds_WithNamespace(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("IsPackageInstalled", "postgres-analytics", "analytics", "test-context").Return(true)
+	mockHelmService.On("UninstallChart", "postgres-analytics", "analytics", "test-context").Return(nil)
+
+	packagesService := PackageService{}
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	err := packagesService.UninstallPackage(models.LocalPackage{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Version: "16.0.0"}, bbeConfig, mockHelmService)
+
+	assert.NoError(t, err)
+	mockHelmService.AssertExpectations(t)
+}
+
 func Test_UninstallPackage_Fails_WhenPackageNotInstalled(t *testing.T) {
 	mockHelmService := &mocks.MockHelmService{}
 	mockHelmService.On("IsPackageInstalled", mock.Anything, mock.Anything, mock.Anything).Return(false)
@@ -261,7 +340,7 @@ func Test_getRemoteLibrary_Fails_WhenInvalidProtocol(t *testing.T) {
 
 	mockErrorMessage := "unsupported protocol scheme"
 
-	result, err := getRemoteLibrary()
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -269,10 +348,7 @@ func Test_getRemoteLibrary_Fails_WhenInvalidProtocol(t *testing.T) {
 }
 
 func Test_getRemoteLibrary_Fails_WhenIOReadFails(t *testing.T) {
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/yaml")
-		w.Write([]byte(`test`))
-	}))
+	ts := newLibraryServer(t, `test`)
 	defer ts.Close()
 
 	// Override the BbeLibraryUrl constant to point to our test server
@@ -286,17 +362,14 @@ func Test_getRemoteLibrary_Fails_WhenIOReadFails(t *testing.T) {
 
 	defer func() { ioReadAll = io.ReadAll }()
 
-	result, err := getRemoteLibrary()
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
 
 func Test_getRemoteLibrary_Fails_WhenNoFileContent(t *testing.T) {
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/yaml")
-		w.Write([]byte(``))
-	}))
+	ts := newLibraryServer(t, ``)
 	defer ts.Close()
 
 	// Override the BbeLibraryUrl constant to point to our test server
@@ -304,8 +377,640 @@ func Test_getRemoteLibrary_Fails_WhenNoFileContent(t *testing.T) {
 	constants.BbeLibraryUrl = ts.URL
 	defer func() { constants.BbeLibraryUrl = originalUrl }()
 
-	result, err := getRemoteLibrary()
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+func Test_getRemoteLibrary_Succeeds_ComparesCliVersionsSemantically(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "0.10.0"
+    list-revision: 2
+    charts: []
+  - min-bbe-cli: "0.2.0"
+    list-revision: 1
+    charts: []`)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	originalVersion := constants.Version
+	constants.Version = "0.9.0"
+	defer func() { constants.Version = originalVersion }()
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.ListRevision)
+}
+
+func Test_getRemoteLibrary_Fails_SuggestsSelfUpdateWhenCliIsTooOld(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "1.2.0"
+    list-revision: 2
+    charts: []
+  - min-bbe-cli: "1.0.0"
+    list-revision: 1
+    charts: []`)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	originalVersion := constants.Version
+	constants.Version = "0.9.0"
+	defer func() { constants.Version = originalVersion }()
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
+
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "requires bbe 1.2.0")
+	assert.ErrorContains(t, err, "bbe self-update")
+}
+
+func Test_getRemoteLibrary_Succeeds_FromLocalFileWithMirroredCharts(t *testing.T) {
+	libraryDir := t.TempDir()
+	libraryFile := filepath.Join(libraryDir, "library.yaml")
+	writeSignedLibrary(t, libraryFile, `library:
+  - min-bbe-cli: "0.0.1"
+    list-revision: 3
+    charts:
+      - name: "blocky"
+        version: "0.1.3"
+        localChart: "blocky-0.1.3.tgz"`)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Library.Source = libraryFile
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), bbeConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.ListRevision)
+	assert.Equal(t, filepath.Join(libraryDir, "blocky-0.1.3.tgz"), result.Charts[0].LocalChart)
+}
+
+func Test_getRemoteLibrary_Succeeds_FallsBackToCache(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "0.0.1"
+    list-revision: 7
+    charts:
+      - name: "blocky"
+        version: "0.1.3"`)
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	helperService := initLibraryHelperService(t)
+
+	_, err := getRemoteLibrary(helperService, models.BbeConfig{})
+	assert.NoError(t, err)
+
+	ts.Close()
+
+	result, err := getRemoteLibrary(helperService, models.BbeConfig{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, result.ListRevision)
+	assert.Equal(t, "blocky", result.Charts[0].Name)
+}
+
+func Test_CheckLibrary_Succeeds(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "0.0.1"
+    charts: []`)
+	defer ts.Close()
+
+	packageService := PackageService{}
+	err := packageService.CheckLibrary(models.BbeConfig{}, models.LibrarySource{Name: constants.DefaultLibraryName, Source: ts.URL})
+
+	assert.NoError(t, err)
+}
+
+func Test_CheckLibrary_Fails_WhenUnreachable(t *testing.T) {
+	ts := newLibraryServer(t, "library: []")
+	ts.Close()
+
+	packageService := PackageService{}
+	err := packageService.CheckLibrary(models.BbeConfig{}, models.LibrarySource{Name: constants.DefaultLibraryName, Source: ts.URL})
+
+	assert.Error(t, err)
+}
+
+func Test_CheckLibrary_Fails_WhenSignatureDoesNotMatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.yaml")
+	writeSignedLibrary(t, path, "library: []")
+	assert.NoError(t, os.WriteFile(path, []byte("library:\n  - charts: []"), 0644))
+
+	packageService := PackageService{}
+	err := packageService.CheckLibrary(models.BbeConfig{}, models.LibrarySource{Name: constants.DefaultLibraryName, Source: path})
+
+	assert.ErrorIs(t, err, constants.LibraryVerificationError)
+}
+
+func Test_InstallPackage_Succeeds_FromLocalChart(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("IsPackageInstalled", mock.Anything, mock.Anything, mock.Anything).Return(false)
+	mockHelmService.On("InstallChart", "blocky", "/mirror/blocky-0.1.3.tgz", "", "0.1.3", "blocky", "test-context").Return(nil)
+
+	packagesService := PackageService{}
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	err := packagesService.InstallPackage(models.ChartEntry{Name: "blocky", Version: "0.1.3", LocalChart: "/mirror/blocky-0.1.3.tgz"}, bbeConfig, mockHelmService)
+
+	assert.NoError(t, err)
+	mockHelmService.AssertNumberOfCalls(t, "AddRepo", 0)
+	mockHelmService.AssertNumberOfCalls(t, "InstallChart", 1)
+}
+
+func Test_MirrorLibrary_Succeeds(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "0.0.1"
+    list-revision: 4
+    charts:
+      - name: "blocky"
+        version: "0.1.3"
+        repositoryName: "blocky"
+        repositoryUrl: "https://k8s-at-home.com/charts/"`)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	destination := t.TempDir()
+
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("AddRepo", "blocky", "https://k8s-at-home.com/charts/").Return(nil)
+	mockHelmService.On("PullChart", "blocky", "blocky", "0.1.3", destination).Return("blocky-0.1.3.tgz", nil).Run(func(args mock.Arguments) {
+		err := os.WriteFile(filepath.Join(destination, "blocky-0.1.3.tgz"), []byte("archive"), 0644)
+		assert.NoError(t, err)
+	})
+
+	packagesService := PackageService{}
+
+	err := packagesService.MirrorLibrary(initLibraryHelperService(t), models.BbeConfig{}, mockHelmService, destination)
+	assert.NoError(t, err)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Library.Source = filepath.Join(destination, constants.LibraryMirrorFile)
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), bbeConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.ListRevision)
+	assert.Equal(t, filepath.Join(destination, "blocky-0.1.3.tgz"), result.Charts[0].LocalChart)
+}
+
+func Test_MirrorLibrary_Fails_WhenPullFails(t *testing.T) {
+	libraryFile := filepath.Join(t.TempDir(), "library.yaml")
+	writeSignedLibrary(t, libraryFile, `library:
+  - min-bbe-cli: "0.0.1"
+    charts:
+      - name: "blocky"
+        version: "0.1.3"
+        repositoryName: "blocky"`)
+
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("AddRepo", mock.Anything, mock.Anything).Return(nil)
+	mockHelmService.On("PullChart", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("", errors.New("Mock failed to pull"))
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Library.Source = libraryFile
+
+	packagesService := PackageService{}
+	err := packagesService.MirrorLibrary(initLibraryHelperService(t), bbeConfig, mockHelmService, t.TempDir())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Mock failed to pull")
+}
+
+func initLibraryHelperService(t *testing.T) *mocks.MockHelperService {
+	cacheDir := t.TempDir()
+
+	helperService := &mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.LibraryCacheFile).Return(filepath.Join(cacheDir, constants.LibraryCacheFile))
+	for _, name := range []string{"internal", "unreachable"} {
+		helperService.On("GetConfigFilePath", libraryCacheFile(name)).Return(filepath.Join(cacheDir, libraryCacheFile(name)))
+	}
+	helperService.On("GetConfigFilePath", mock.Anything).Return(filepath.Join(cacheDir, "cache", "library-additional.yaml"))
+
+	return helperService
+}
+
+// newLibraryServer serves a library signed with a freshly trusted key, the key is untrusted again when the test ends
+func newLibraryServer(t *testing.T, body string) *httptest.Server {
+	signature := signLibrary(t, body)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		if strings.HasSuffix(r.URL.Path, constants.LibrarySignatureExtension) {
+			w.Write(signature)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func writeSignedLibrary(t *testing.T, path string, body string) {
+	err := os.WriteFile(path, []byte(body), 0644)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(path+constants.LibrarySignatureExtension, signLibrary(t, body), 0644)
+	assert.NoError(t, err)
+}
+
+func signLibrary(t *testing.T, body string) []byte {
+	publicKey, privateKey, err := signing.GenerateKey()
+	assert.NoError(t, err)
+
+	originalKeys := constants.TrustedLibraryKeys
+	constants.TrustedLibraryKeys = append([]string{publicKey}, originalKeys...)
+	t.Cleanup(func() { constants.TrustedLibraryKeys = originalKeys })
+
+	signature, err := signing.Sign([]byte(body), privateKey)
+	assert.NoError(t, err)
+
+	return signature
+}
+
+func Test_GetAll_Succeeds_MergesAdditionalLibraries(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "0.0.1"
+    charts:
+      - name: "blocky"
+        version: "0.1.3"`)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	internalLibrary := filepath.Join(t.TempDir(), "library.yaml")
+	err := os.WriteFile(internalLibrary, []byte(`library:
+  - min-bbe-cli: "0.0.1"
+    charts:
+      - name: "blocky"
+        version: "9.9.9"
+      - name: "internal-chart"
+        version: "1.0.0"`), 0644)
+	assert.NoError(t, err)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Libraries = []models.LibrarySource{
+		{Name: "internal", Source: internalLibrary, AllowUnsigned: true},
+		{Name: "unreachable", Source: filepath.Join(t.TempDir(), "missing.yaml"), AllowUnsigned: true},
+	}
+
+	packagesService := PackageService{}
+	result, err := packagesService.GetAll(initLibraryHelperService(t), bbeConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ChartEntry{
+		{Name: "blocky", Version: "0.1.3", Library: "bbe"},
+		{Name: "blocky", Version: "9.9.9", Library: "internal"},
+		{Name: "internal-chart", Version: "1.0.0", Library: "internal"},
+	}, result)
+}
+
+func Test_getRemoteLibrary_Fails_WhenSignatureDoesNotMatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, constants.LibrarySignatureExtension) {
+			w.Write(signLibrary(t, "some other library"))
+			return
+		}
+		w.Write([]byte(`library:
+  - min-bbe-cli: "0.0.1"
+    charts: []`))
+	}))
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, constants.LibraryVerificationError)
+}
+
+// newUnsignedLibraryServer serves a library whose signature request is answered with signatureStatus
+func newUnsignedLibraryServer(signatureStatus int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, constants.LibrarySignatureExtension) {
+			w.WriteHeader(signatureStatus)
+			return
+		}
+		w.Write([]byte(`library:
+  - min-bbe-cli: "0.0.1"
+    charts: []`))
+	}))
+}
+
+func Test_getRemoteLibrary_Fails_WhenSignatureIsMissing(t *testing.T) {
+	ts := newUnsignedLibraryServer(http.StatusNotFound)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), models.BbeConfig{})
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, constants.LibraryVerificationError)
+}
+
+func Test_getRemoteLibrary_Succeeds_WhenSignatureIsMissing_WithAllowUnsigned(t *testing.T) {
+	ts := newUnsignedLibraryServer(http.StatusNotFound)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Library.AllowUnsigned = true
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), bbeConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.1", result.MinBbeCli)
+}
+
+func Test_getRemoteLibrary_Fails_WhenSignatureCannotBeFetched(t *testing.T) {
+	ts := newUnsignedLibraryServer(http.StatusInternalServerError)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Library.AllowUnsigned = true
+
+	result, err := getRemoteLibrary(initLibraryHelperService(t), bbeConfig)
+
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "Unexpected status fetching library signature")
+}
+
+func Test_CheckLibrary_Fails_WithoutPublicKey(t *testing.T) {
+	internalLibrary := filepath.Join(t.TempDir(), "library.yaml")
+	err := os.WriteFile(internalLibrary, []byte(`library:
+  - min-bbe-cli: "0.0.1"
+    charts: []`), 0644)
+	assert.NoError(t, err)
+
+	packagesService := PackageService{}
+	err = packagesService.CheckLibrary(models.BbeConfig{}, models.LibrarySource{Name: "internal", Source: internalLibrary})
+
+	assert.ErrorIs(t, err, constants.LibraryVerificationError)
+}
+
+func Test_GetAll_Succeeds_SkipsAdditionalLibraryWithInvalidSignature(t *testing.T) {
+	ts := newLibraryServer(t, `library:
+  - min-bbe-cli: "0.0.1"
+    charts:
+      - name: "blocky"
+        version: "0.1.3"`)
+	defer ts.Close()
+
+	originalUrl := constants.BbeLibraryUrl
+	constants.BbeLibraryUrl = ts.URL
+	defer func() { constants.BbeLibraryUrl = originalUrl }()
+
+	internalLibrary := filepath.Join(t.TempDir(), "library.yaml")
+	err := os.WriteFile(internalLibrary, []byte(`library:
+  - min-bbe-cli: "0.0.1"
+    charts:
+      - name: "internal-chart"
+        version: "1.0.0"`), 0644)
+	assert.NoError(t, err)
+
+	publicKey, _, err := signing.GenerateKey()
+	assert.NoError(t, err)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Libraries = []models.LibrarySource{
+		{Name: "internal", Source: internalLibrary, PublicKey: publicKey},
+	}
+
+	packagesService := PackageService{}
+	result, err := packagesService.GetAll(initLibraryHelperService(t), bbeConfig)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "blocky", result[0].Name)
+}
+
+func Test_DetectDrift_Succeeds(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{
+		{Name: "in-sync", Namespace: "in-sync", Status: "deployed", Chart: "in-sync-1.0.0"},
+		{Name: "upgraded", Namespace: "upgraded", Status: "deployed", Chart: "upgraded-2.1.0"},
+		{Name: "failed", Namespace: "failed", Status: "failed", Chart: "failed-3.0.0"},
+		{Name: "unmanaged", Namespace: "unmanaged", Status: "deployed", Chart: "unmanaged-4.0.0"},
+		{Name: "unrelated", Namespace: "kube-system", Status: "deployed", Chart: "unrelated-5.0.0"},
+	}, nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "in-sync", Version: "1.0.0"},
+		{Name: "upgraded", Version: "2.0.0"},
+		{Name: "failed", Version: "3.0.0"},
+		{Name: "removed", Version: "6.0.0"},
+	}
+
+	allPackages := []models.ChartEntry{
+		{Name: "unmanaged", Version: "4.0.0", Library: "bbe"},
+		{Name: "unrelated", Version: "5.0.0", Library: "bbe"},
+	}
+
+	packagesService := PackageService{}
+	drifts, err := packagesService.DetectDrift(bbeConfig, allPackages, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PackageDrift{
+		{Name: "upgraded", Namespace: "upgraded", Kind: constants.DriftVersion, ConfigVersion: "2.0.0", ClusterVersion: "2.1.0", Status: "deployed"},
+		{Name: "failed", Namespace: "failed", Kind: constants.DriftStatus, ConfigVersion: "3.0.0", ClusterVersion: "3.0.0", Status: "failed"},
+		{Name: "removed", Namespace: "removed", Kind: constants.DriftMissing, ConfigVersion: "6.0.0"},
+		{Name: "unmanaged", Namespace: "unmanaged", Kind: constants.DriftUnmanaged, ClusterVersion: "4.0.0", Status: "deployed", Library: "bbe"},
+	}, drifts)
+}
+
+func Test_DetectDrift_Fails_WhenListingReleasesFails(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("ListReleases", mock.Anything).Return([]models.HelmRelease{}, errors.New("helm failed"))
+
+	packagesService := PackageService{}
+	drifts, err := packagesService.DetectDrift(models.BbeConfig{}, nil, mockHelmService)
+
+	assert.Nil(t, drifts)
+	assert.Error(t, err)
+}
+
+func Test_DiffUpgrade_Succeeds(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("GetManifest", "blocky", "blocky", "test-context").Return([]byte("kind: Deployment\nimage: blocky:0.1.2\nreplicas: 3\n"), nil)
+	mockHelmService.On("GetValues", "blocky", "blocky", "test-context").Return([]byte("replicas: 3\n"), nil)
+	mockHelmService.On("TemplateChart", "blocky", "blocky", "https://charts.example.com", "0.1.3", "blocky", []byte("replicas: 3\n")).Return([]byte("kind: Deployment\nimage: blocky:0.1.3\nreplicas: 3\n"), nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	diff, err := PackageService{}.DiffUpgrade(models.ChartEntry{Name: "blocky", Version: "0.1.3", RepositoryUrl: "https://charts.example.com"}, bbeConfig, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Contains(t, diff, "- image: blocky:0.1.2")
+	assert.Contains(t, diff, "+ image: blocky:0.1.3")
+	assert.Contains(t, diff, "  replicas: 3")
+	mockHelmService.AssertNotCalled(t, "UpgradeChart", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_DiffUpgrade_Succeeds_WithMirroredChart(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("GetManifest", "blocky", "blocky", "test-context").Return([]byte("kind: Deployment\n"), nil)
+	mockHelmService.On("GetValues", "blocky", "blocky", "test-context").Return([]byte("null\n"), nil)
+	mockHelmService.On("TemplateChart", "blocky", "/mirror/blocky-0.1.3.tgz", "", "0.1.3", "blocky", []byte("null\n")).Return([]byte("kind: Deployment\n"), nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	diff, err := PackageService{}.DiffUpgrade(models.ChartEntry{Name: "blocky", Version: "0.1.3", LocalChart: "/mirror/blocky-0.1.3.tgz"}, bbeConfig, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Empty(t, diff)
+}
+
+func Test_DiffUpgrade_Fails_WhenReleaseManifestIsUnavailable(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("GetManifest", "blocky", "blocky", "test-context").Return([]byte(nil), errors.New("release not found"))
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	_, err := PackageService{}.DiffUpgrade(models.ChartEntry{Name: "blocky", Version: "0.1.3"}, bbeConfig, mockHelmService)
+
+	assert.ErrorContains(t, err, "release not found")
+}
+
+func Test_DetectDrift_Succeeds_WithReleasesOfTheSameChart(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{
+		{Name: "postgres", Namespace: "postgres", Status: "deployed", Chart: "postgres-16.0.0"},
+		{Name: "postgres-analytics", Namespace: "analytics", Status: "deployed", Chart: "postgres-16.1.0"},
+	}, nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+	bbeConfig.Bbe.Packages = []models.LocalPackage{
+		{Name: "postgres", Version: "16.0.0"},
+		{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Version: "16.0.0"},
+	}
+
+	allPackages := []models.ChartEntry{
+		{Name: "postgres", Version: "16.1.0", Library: "bbe"},
+	}
+
+	packagesService := PackageService{}
+	drifts, err := packagesService.DetectDrift(bbeConfig, allPackages, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PackageDrift{
+		{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Kind: constants.DriftVersion, ConfigVersion: "16.0.0", ClusterVersion: "16.1.0", Status: "deployed"},
+	}, drifts)
+}
+
+func Test_DetectDrift_Succeeds_WithLibraryDefaults(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{
+		{Name: "pg", Namespace: "databases", Status: "deployed", Chart: "postgres-16.0.0"},
+	}, nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+
+	allPackages := []models.ChartEntry{
+		{Name: "postgres", Version: "16.0.0", Library: "bbe", Release: "pg", Namespace: "databases"},
+	}
+
+	packagesService := PackageService{}
+	drifts, err := packagesService.DetectDrift(bbeConfig, allPackages, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PackageDrift{
+		{Name: "pg", Chart: "postgres", Namespace: "databases", Kind: constants.DriftUnmanaged, ClusterVersion: "16.0.0", Status: "deployed", Library: "bbe"},
+	}, drifts)
+}
+
+func Test_NewLocalPackage_Succeeds(t *testing.T) {
+	assert.Equal(t, models.LocalPackage{Name: "blocky", Version: "1.0.0", Library: "bbe"},
+		NewLocalPackage(models.ChartEntry{Name: "blocky", Version: "1.0.0", Library: "bbe"}, "1.0.0"))
+	assert.Equal(t, models.LocalPackage{Name: "blocky", Version: "1.0.0", Namespace: "dns"},
+		NewLocalPackage(models.ChartEntry{Name: "blocky", Namespace: "dns"}, "1.0.0"))
+	assert.Equal(t, models.LocalPackage{Name: "postgres-analytics", Chart: "postgres", Version: "16.0.0"},
+		NewLocalPackage(models.ChartEntry{Name: "postgres", Release: "postgres-analytics"}, "16.0.0"))
+	assert.Equal(t, models.LocalPackage{Name: "postgres-analytics", Chart: "postgres", Namespace: "analytics", Version: "16.0.0"},
+		NewLocalPackage(models.ChartEntry{Name: "postgres", Release: "postgres-analytics", Namespace: "analytics"}, "16.0.0"))
+}
+
+func Test_ChartForPackage_Succeeds(t *testing.T) {
+	chart := models.ChartEntry{Name: "postgres", Version: "16.1.0", Release: "pg", Namespace: "databases"}
+
+	assert.Equal(t, models.ChartEntry{Name: "postgres", Version: "16.1.0"},
+		ChartForPackage(chart, models.LocalPackage{Name: "postgres", Version: "16.0.0"}))
+	assert.Equal(t, models.ChartEntry{Name: "postgres", Version: "16.1.0", Release: "pg", Namespace: "databases"},
+		ChartForPackage(chart, models.LocalPackage{Name: "pg", Chart: "postgres", Namespace: "databases", Version: "16.0.0"}))
+	assert.Equal(t, models.ChartEntry{Name: "postgres", Version: "16.1.0", Release: "postgres-analytics"},
+		ChartForPackage(chart, models.LocalPackage{Name: "postgres-analytics", Chart: "postgres", Version: "16.0.0"}))
+}
+
+func Test_DetectDrift_Succeeds_WithChartOfferedByTwoLibraries(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{
+		{Name: "blocky", Namespace: "blocky", Status: "deployed", Chart: "blocky-1.2.0"},
+	}, nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+
+	allPackages := []models.ChartEntry{
+		{Name: "blocky", Version: "1.0.0", Library: "bbe"},
+		{Name: "blocky", Version: "1.2.0", Library: "internal"},
+	}
+
+	packagesService := PackageService{}
+	drifts, err := packagesService.DetectDrift(bbeConfig, allPackages, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.PackageDrift{
+		{Name: "blocky", Namespace: "blocky", Kind: constants.DriftUnmanaged, ClusterVersion: "1.2.0", Status: "deployed", Library: "internal"},
+	}, drifts)
+}
+
+func Test_DetectDrift_Succeeds_WithChartOfferedByTwoLibraries_PrefersFirstLibrary(t *testing.T) {
+	mockHelmService := &mocks.MockHelmService{}
+	mockHelmService.On("ListReleases", "test-context").Return([]models.HelmRelease{
+		{Name: "blocky", Namespace: "blocky", Status: "deployed", Chart: "blocky-0.9.0"},
+	}, nil)
+
+	bbeConfig := models.BbeConfig{}
+	bbeConfig.Bbe.Cluster.Context = "test-context"
+
+	allPackages := []models.ChartEntry{
+		{Name: "blocky", Version: "1.0.0", Library: "team-a"},
+		{Name: "blocky", Version: "1.2.0", Library: "team-b"},
+	}
+
+	packagesService := PackageService{}
+	drifts, err := packagesService.DetectDrift(bbeConfig, allPackages, mockHelmService)
+
+	assert.NoError(t, err)
+	assert.Len(t, drifts, 1)
+	assert.Equal(t, "team-a", drifts[0].Library)
+}
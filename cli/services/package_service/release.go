@@ -0,0 +1,96 @@
+package package_service
+
+import (
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+// Packages are helm releases, a package in bbe.yaml is named after its release and records its chart and namespace only when they differ from that name
+
+// ChartRelease returns the release name of a chart, the release default of the library or the chart name
+func ChartRelease(chart models.ChartEntry) string {
+	if chart.Release != "" {
+		return chart.Release
+	}
+
+	return chart.Name
+}
+
+// ChartNamespace returns the namespace of a chart, the namespace default of the library or the release name
+func ChartNamespace(chart models.ChartEntry) string {
+	if chart.Namespace != "" {
+		return chart.Namespace
+	}
+
+	return ChartRelease(chart)
+}
+
+// PackageChart returns the library chart of a package in bbe.yaml
+func PackageChart(pkg models.LocalPackage) string {
+	if pkg.Chart != "" {
+		return pkg.Chart
+	}
+
+	return pkg.Name
+}
+
+// PackageLibrary returns the library a package in bbe.yaml was installed from, packages recorded without one come from the official library
+func PackageLibrary(pkg models.LocalPackage) string {
+	if pkg.Library != "" {
+		return pkg.Library
+	}
+
+	return constants.DefaultLibraryName
+}
+
+// IsPackageChart reports whether a library chart is the chart a package in bbe.yaml was installed from, libraries may offer charts of the same name
+func IsPackageChart(chart models.ChartEntry, pkg models.LocalPackage) bool {
+	library := chart.Library
+	if library == "" {
+		library = constants.DefaultLibraryName
+	}
+
+	return chart.Name == PackageChart(pkg) && library == PackageLibrary(pkg)
+}
+
+// PackageNamespace returns the namespace of a package in bbe.yaml
+func PackageNamespace(pkg models.LocalPackage) string {
+	if pkg.Namespace != "" {
+		return pkg.Namespace
+	}
+
+	return pkg.Name
+}
+
+// ChartForPackage applies the release and namespace recorded in bbe.yaml to the library chart of a package, the defaults of the library no longer apply once a package is installed
+func ChartForPackage(chart models.ChartEntry, pkg models.LocalPackage) models.ChartEntry {
+	chart.Release = ""
+	chart.Namespace = ""
+
+	if pkg.Name != chart.Name {
+		chart.Release = pkg.Name
+	}
+	if namespace := PackageNamespace(pkg); namespace != pkg.Name {
+		chart.Namespace = namespace
+	}
+
+	return chart
+}
+
+// NewLocalPackage describes a chart the way it is recorded in bbe.yaml once installed
+func NewLocalPackage(chart models.ChartEntry, version string) models.LocalPackage {
+	pkg := models.LocalPackage{
+		Name:    ChartRelease(chart),
+		Version: version,
+		Library: chart.Library,
+	}
+
+	if chart.Name != pkg.Name {
+		pkg.Chart = chart.Name
+	}
+	if namespace := ChartNamespace(chart); namespace != pkg.Name {
+		pkg.Namespace = namespace
+	}
+
+	return pkg
+}
@@ -3,6 +3,7 @@ package s3_service
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
@@ -11,12 +12,30 @@ type S3Service struct {
 	client *s3.Client
 }
 
-func Initialize(ctx context.Context) (*S3Service, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("eu-west-1"))
+// Options configures the client for AWS or any S3 compatible endpoint
+type Options struct {
+	Region    string
+	Profile   string // Named profile from the shared AWS config, empty for the default credential chain
+	Endpoint  string // Empty for AWS
+	PathStyle bool
+}
+
+func Initialize(ctx context.Context, options Options) (*S3Service, error) {
+	loadOptions := []func(*config.LoadOptions) error{config.WithRegion(options.Region)}
+	if options.Profile != "" {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(options.Profile))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
 	if err != nil {
 		return nil, err
 	}
-	s3Client := s3.NewFromConfig(cfg)
+	s3Client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if options.Endpoint != "" {
+			o.BaseEndpoint = aws.String(options.Endpoint)
+		}
+		o.UsePathStyle = options.PathStyle
+	})
 	return &S3Service{client: s3Client}, nil
 }
 
@@ -24,22 +43,42 @@ func (s *S3Service) CreateBucket(ctx context.Context, params *s3.CreateBucketInp
 	return s.client.CreateBucket(ctx, params, optFns...)
 }
 
+func (s *S3Service) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	return s.client.DeleteObject(ctx, params, optFns...)
+}
+
 func (s *S3Service) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
 	return s.client.GetObject(ctx, params, optFns...)
 }
 
+func (s *S3Service) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	return s.client.HeadObject(ctx, params, optFns...)
+}
+
 func (s *S3Service) ListBuckets(ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
 	return s.client.ListBuckets(ctx, params, optFns...)
 }
 
+func (s *S3Service) ListObjectVersions(ctx context.Context, params *s3.ListObjectVersionsInput, optFns ...func(*s3.Options)) (*s3.ListObjectVersionsOutput, error) {
+	return s.client.ListObjectVersions(ctx, params, optFns...)
+}
+
 func (s *S3Service) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error) {
 	return s.client.PutBucketEncryption(ctx, params, optFns...)
 }
 
+func (s *S3Service) PutBucketTagging(ctx context.Context, params *s3.PutBucketTaggingInput, optFns ...func(*s3.Options)) (*s3.PutBucketTaggingOutput, error) {
+	return s.client.PutBucketTagging(ctx, params, optFns...)
+}
+
 func (s *S3Service) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
 	return s.client.PutBucketVersioning(ctx, params, optFns...)
 }
 
+func (s *S3Service) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error) {
+	return s.client.PutPublicAccessBlock(ctx, params, optFns...)
+}
+
 func (s *S3Service) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
 	return s.client.PutObject(ctx, params, optFns...)
 }
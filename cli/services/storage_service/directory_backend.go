@@ -0,0 +1,156 @@
+package storage_service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+// DirectoryBackend stores objects as plain files, e.g. on an NFS share or a USB drive
+type DirectoryBackend struct {
+	path string
+}
+
+func NewDirectoryBackend(path string) *DirectoryBackend {
+	return &DirectoryBackend{path: path}
+}
+
+func (backend *DirectoryBackend) Get(name string) (*models.StorageObject, error) {
+	filePath := filepath.Join(backend.path, name)
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, backend.notFoundOr(err, name)
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, backend.notFoundOr(err, name)
+	}
+
+	return &models.StorageObject{
+		Name:    name,
+		Version: contentVersion(content),
+		ModTime: info.ModTime(),
+		Content: content,
+	}, nil
+}
+
+func (backend *DirectoryBackend) Put(name string, content []byte) error {
+	filePath := filepath.Join(backend.path, name)
+
+	err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Failed to create storage directory: %w", err)
+	}
+
+	// Write to a temporary file first so a disconnected drive never leaves a truncated config behind
+	temporaryPath := filePath + ".tmp"
+	err = os.WriteFile(temporaryPath, content, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to write `%s`: %w", name, err)
+	}
+
+	return os.Rename(temporaryPath, filePath)
+}
+
+func (backend *DirectoryBackend) List() ([]models.StorageObject, error) {
+	objects := []models.StorageObject{}
+
+	err := filepath.WalkDir(backend.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(backend.path, path)
+		if err != nil {
+			return err
+		}
+
+		object, err := backend.Version(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *object)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list storage directory `%s`: %w", backend.path, err)
+	}
+
+	return objects, nil
+}
+
+func (backend *DirectoryBackend) Version(name string) (*models.StorageObject, error) {
+	filePath := filepath.Join(backend.path, name)
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, backend.notFoundOr(err, name)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StorageObject{
+		Name:    name,
+		Version: contentVersion(content),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+// Versions only returns the current version, a plain directory keeps no history
+func (backend *DirectoryBackend) Versions(name string) ([]models.StorageObject, error) {
+	object, err := backend.Version(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return []models.StorageObject{*object}, nil
+}
+
+func (backend *DirectoryBackend) GetVersion(name string, version string) (*models.StorageObject, error) {
+	object, err := backend.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if object.Version != version {
+		return nil, fmt.Errorf("%w: %s@%s, directory storage only keeps the current version", constants.StorageObjectNotFoundError, name, version)
+	}
+
+	return object, nil
+}
+
+func (backend *DirectoryBackend) Delete(name string) error {
+	err := os.Remove(filepath.Join(backend.path, name))
+	if err != nil {
+		return backend.notFoundOr(err, name)
+	}
+
+	return nil
+}
+
+func (backend *DirectoryBackend) notFoundOr(err error, name string) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	return err
+}
+
+// contentVersion identifies a revision by its content for backends without native versioning
+func contentVersion(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,95 @@
+package storage_service
+
+import (
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DirectoryBackend_Succeeds_PutAndGet(t *testing.T) {
+	backend := NewDirectoryBackend(t.TempDir())
+
+	err := backend.Put("bbe.yaml", []byte("bbe: {}"))
+	assert.NoError(t, err)
+
+	object, err := backend.Get("bbe.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "bbe.yaml", object.Name)
+	assert.Equal(t, []byte("bbe: {}"), object.Content)
+	assert.NotEmpty(t, object.Version)
+	assert.False(t, object.ModTime.IsZero())
+}
+
+func Test_DirectoryBackend_Succeeds_VersionChangesWithContent(t *testing.T) {
+	backend := NewDirectoryBackend(t.TempDir())
+
+	err := backend.Put("bbe.yaml", []byte("first"))
+	assert.NoError(t, err)
+	first, err := backend.Version("bbe.yaml")
+	assert.NoError(t, err)
+
+	err = backend.Put("bbe.yaml", []byte("second"))
+	assert.NoError(t, err)
+	second, err := backend.Version("bbe.yaml")
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first.Version, second.Version)
+}
+
+func Test_DirectoryBackend_Succeeds_List(t *testing.T) {
+	backend := NewDirectoryBackend(t.TempDir())
+
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("bbe")))
+	assert.NoError(t, backend.Put("talosconfig", []byte("talos")))
+
+	objects, err := backend.List()
+
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "bbe.yaml", objects[0].Name)
+	assert.Equal(t, "talosconfig", objects[1].Name)
+	assert.Nil(t, objects[0].Content)
+}
+
+func Test_DirectoryBackend_Fails_WhenObjectDoesNotExist(t *testing.T) {
+	backend := NewDirectoryBackend(t.TempDir())
+
+	object, err := backend.Get("bbe.yaml")
+	assert.Nil(t, object)
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+
+	object, err = backend.Version("bbe.yaml")
+	assert.Nil(t, object)
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_DirectoryBackend_Fails_GetVersionOfPreviousContent(t *testing.T) {
+	backend := NewDirectoryBackend(t.TempDir())
+
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("one")))
+	previous, err := backend.Version("bbe.yaml")
+	assert.NoError(t, err)
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("two")))
+
+	versions, err := backend.Versions("bbe.yaml")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 1)
+
+	_, err = backend.GetVersion("bbe.yaml", previous.Version)
+
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_DirectoryBackend_Succeeds_Delete(t *testing.T) {
+	backend := NewDirectoryBackend(t.TempDir())
+	assert.NoError(t, backend.Put("etcd/home.db", []byte("etcd")))
+
+	err := backend.Delete("etcd/home.db")
+
+	assert.NoError(t, err)
+	_, err = backend.Get("etcd/home.db")
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+	assert.ErrorIs(t, backend.Delete("etcd/home.db"), constants.StorageObjectNotFoundError)
+}
@@ -0,0 +1,111 @@
+package storage_service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+// EncryptedBackend encrypts secret objects client-side before they reach the wrapped backend
+type EncryptedBackend struct {
+	backend   interfaces.StorageBackend
+	recipient string   // Empty to upload secrets unencrypted
+	keyFile   string   // Local age identity file, only read when an encrypted object is fetched
+	secrets   []string // Names of the objects to encrypt, a name ending in "/" covers every object below it
+}
+
+func NewEncryptedBackend(backend interfaces.StorageBackend, recipient string, keyFile string, secrets []string) *EncryptedBackend {
+	return &EncryptedBackend{
+		backend:   backend,
+		recipient: recipient,
+		keyFile:   keyFile,
+		secrets:   secrets,
+	}
+}
+
+// Get decrypts any encrypted object, regardless of whether encryption is enabled locally
+func (backend *EncryptedBackend) Get(name string) (*models.StorageObject, error) {
+	object, err := backend.backend.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.decrypt(name, object)
+}
+
+func (backend *EncryptedBackend) decrypt(name string, object *models.StorageObject) (*models.StorageObject, error) {
+	if !encryption.IsEncrypted(object.Content) {
+		return object, nil
+	}
+
+	identities, err := os.ReadFile(backend.keyFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s is encrypted but %s does not exist, copy it from a machine where the cluster is already configured", constants.EncryptionKeyMissingError, name, backend.keyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := encryption.Decrypt(object.Content, string(identities))
+	if errors.Is(err, encryption.NoMatchingKeyError) {
+		return nil, fmt.Errorf("Failed to decrypt %s with %s, the key may have been rotated on another machine: %w", name, backend.keyFile, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decrypt %s: %w", name, err)
+	}
+
+	object.Content = content
+	return object, nil
+}
+
+func (backend *EncryptedBackend) Put(name string, content []byte) error {
+	if backend.recipient == "" || !backend.isSecret(name) {
+		return backend.backend.Put(name, content)
+	}
+
+	encrypted, err := encryption.Encrypt(content, backend.recipient)
+	if err != nil {
+		return fmt.Errorf("Failed to encrypt %s: %w", name, err)
+	}
+
+	return backend.backend.Put(name, encrypted)
+}
+
+func (backend *EncryptedBackend) List() ([]models.StorageObject, error) {
+	return backend.backend.List()
+}
+
+func (backend *EncryptedBackend) Version(name string) (*models.StorageObject, error) {
+	return backend.backend.Version(name)
+}
+
+func (backend *EncryptedBackend) Versions(name string) ([]models.StorageObject, error) {
+	return backend.backend.Versions(name)
+}
+
+func (backend *EncryptedBackend) GetVersion(name string, version string) (*models.StorageObject, error) {
+	object, err := backend.backend.GetVersion(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.decrypt(name, object)
+}
+
+func (backend *EncryptedBackend) Delete(name string) error {
+	return backend.backend.Delete(name)
+}
+
+func (backend *EncryptedBackend) isSecret(name string) bool {
+	return slices.ContainsFunc(backend.secrets, func(secret string) bool {
+		return secret == name || (strings.HasSuffix(secret, "/") && strings.HasPrefix(name, secret))
+	})
+}
@@ -0,0 +1,117 @@
+package storage_service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/encryption"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncryptedBackend_Succeeds_EncryptsSecretsOnly(t *testing.T) {
+	identity, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	storageDir := t.TempDir()
+	backend := NewEncryptedBackend(NewDirectoryBackend(storageDir), recipient, writeKeyFile(t, identity), constants.SecretConfigFiles)
+
+	assert.NoError(t, backend.Put(constants.TalosConfigFile, []byte("secret")))
+	assert.NoError(t, backend.Put(constants.BbeConfigFile, []byte("bbe")))
+
+	stored, err := os.ReadFile(filepath.Join(storageDir, constants.TalosConfigFile))
+	assert.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(stored))
+
+	stored, err = os.ReadFile(filepath.Join(storageDir, constants.BbeConfigFile))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bbe"), stored)
+
+	object, err := backend.Get(constants.TalosConfigFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), object.Content)
+}
+
+func Test_EncryptedBackend_Succeeds_EncryptsObjectsBelowSecretDirectory(t *testing.T) {
+	identity, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	storageDir := t.TempDir()
+	backend := NewEncryptedBackend(NewDirectoryBackend(storageDir), recipient, writeKeyFile(t, identity), []string{constants.EtcdSnapshotDir})
+
+	assert.NoError(t, backend.Put("etcd/home.db", []byte("snapshot")))
+	assert.NoError(t, backend.Put("etcd.db", []byte("plain")))
+
+	stored, err := os.ReadFile(filepath.Join(storageDir, "etcd", "home.db"))
+	assert.NoError(t, err)
+	assert.True(t, encryption.IsEncrypted(stored))
+
+	stored, err = os.ReadFile(filepath.Join(storageDir, "etcd.db"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("plain"), stored)
+}
+
+func Test_EncryptedBackend_Succeeds_ReadsUnencryptedObjects(t *testing.T) {
+	storageDir := t.TempDir()
+	assert.NoError(t, NewDirectoryBackend(storageDir).Put(constants.TalosConfigFile, []byte("plain")))
+
+	backend := NewEncryptedBackend(NewDirectoryBackend(storageDir), "", filepath.Join(t.TempDir(), "storage.key"), constants.SecretConfigFiles)
+	object, err := backend.Get(constants.TalosConfigFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("plain"), object.Content)
+}
+
+func Test_EncryptedBackend_Fails_WhenKeyIsMissing(t *testing.T) {
+	_, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	storageDir := t.TempDir()
+	writer := NewEncryptedBackend(NewDirectoryBackend(storageDir), recipient, filepath.Join(t.TempDir(), "storage.key"), constants.SecretConfigFiles)
+	assert.NoError(t, writer.Put(constants.WorkerConfigFile, []byte("secret")))
+
+	object, err := writer.Get(constants.WorkerConfigFile)
+
+	assert.Nil(t, object)
+	assert.ErrorIs(t, err, constants.EncryptionKeyMissingError)
+	assert.ErrorContains(t, err, "storage.key")
+}
+
+func Test_EncryptedBackend_Fails_WithRotatedKey(t *testing.T) {
+	_, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+	staleIdentity, _, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	backend := NewEncryptedBackend(NewDirectoryBackend(t.TempDir()), recipient, writeKeyFile(t, staleIdentity), constants.SecretConfigFiles)
+	assert.NoError(t, backend.Put(constants.WorkerConfigFile, []byte("secret")))
+
+	_, err = backend.Get(constants.WorkerConfigFile)
+
+	assert.ErrorIs(t, err, encryption.NoMatchingKeyError)
+}
+
+func Test_EncryptedBackend_Succeeds_DecryptsPreviousVersions(t *testing.T) {
+	identity, recipient, err := encryption.GenerateKey()
+	assert.NoError(t, err)
+
+	backend := NewEncryptedBackend(NewDirectoryBackend(t.TempDir()), recipient, writeKeyFile(t, identity), constants.SecretConfigFiles)
+	assert.NoError(t, backend.Put(constants.TalosConfigFile, []byte("secret")))
+
+	versions, err := backend.Versions(constants.TalosConfigFile)
+	assert.NoError(t, err)
+
+	object, err := backend.GetVersion(constants.TalosConfigFile, versions[0].Version)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), object.Content)
+}
+
+func writeKeyFile(t *testing.T, identity string) string {
+	keyFile := filepath.Join(t.TempDir(), "storage.key")
+	err := os.WriteFile(keyFile, []byte(identity), 0600)
+	assert.NoError(t, err)
+
+	return keyFile
+}
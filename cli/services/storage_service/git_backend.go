@@ -0,0 +1,337 @@
+package storage_service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+var execCommand = exec.Command
+
+// GitBackend stores objects in a git repository, every Put is pushed as a separate commit
+type GitBackend struct {
+	workdir    string
+	repository string
+	branch     string
+}
+
+// NewGitBackend keeps a working copy of repository in workdir, which is owned by the backend and reset on every access
+func NewGitBackend(workdir string, repository string, branch string) *GitBackend {
+	if branch == "" {
+		branch = constants.DefaultGitStorageBranch
+	}
+
+	return &GitBackend{workdir: workdir, repository: repository, branch: branch}
+}
+
+func (backend *GitBackend) Get(name string) (*models.StorageObject, error) {
+	err := backend.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(backend.workdir, name))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+		}
+		return nil, err
+	}
+
+	object, err := backend.lastCommit("HEAD", name)
+	if err != nil {
+		return nil, err
+	}
+	object.Content = content
+
+	return object, nil
+}
+
+func (backend *GitBackend) Put(name string, content []byte) error {
+	err := backend.refresh()
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(backend.workdir, name)
+	err = os.MkdirAll(filepath.Dir(filePath), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(filePath, content, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.git("add", "--", name)
+	if err != nil {
+		return err
+	}
+
+	// Nothing to commit when the content did not change
+	if _, err := backend.git("diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
+	_, err = backend.git("commit", "--message", fmt.Sprintf("Update %s", name))
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.git("push", "origin", fmt.Sprintf("HEAD:%s", backend.branch))
+	if err != nil {
+		return fmt.Errorf("Failed to push `%s` to `%s`: %w", name, backend.repository, err)
+	}
+
+	return nil
+}
+
+// Delete removes name from the branch, it stays in the history of the repository
+func (backend *GitBackend) Delete(name string) error {
+	err := backend.refresh()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(backend.workdir, name)); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	_, err = backend.git("rm", "--quiet", "--", name)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.git("commit", "--message", fmt.Sprintf("Delete %s", name))
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.git("push", "origin", fmt.Sprintf("HEAD:%s", backend.branch))
+	if err != nil {
+		return fmt.Errorf("Failed to push the deletion of `%s` to `%s`: %w", name, backend.repository, err)
+	}
+
+	return nil
+}
+
+func (backend *GitBackend) List() ([]models.StorageObject, error) {
+	err := backend.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := backend.git("ls-files")
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []models.StorageObject{}
+	for _, name := range strings.Split(strings.TrimSpace(output), "\n") {
+		if name == "" {
+			continue
+		}
+
+		object, err := backend.lastCommit("HEAD", name)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, *object)
+	}
+
+	return objects, nil
+}
+
+func (backend *GitBackend) Version(name string) (*models.StorageObject, error) {
+	err := backend.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.lastCommit("HEAD", name)
+}
+
+func (backend *GitBackend) Versions(name string) ([]models.StorageObject, error) {
+	err := backend.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	// A repository without commits has no history to list
+	if _, err := backend.git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return nil, fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	output, err := backend.git("log", "--format=%H %ct", "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []models.StorageObject{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+
+		object, err := parseCommit(name, line)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, *object)
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	return objects, nil
+}
+
+func (backend *GitBackend) GetVersion(name string, version string) (*models.StorageObject, error) {
+	err := backend.refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	// The version comes from the user, it must name a commit and is never passed on as an option
+	if strings.HasPrefix(version, "-") {
+		return nil, fmt.Errorf("%w: %s@%s", constants.StorageObjectNotFoundError, name, version)
+	}
+	commit, err := backend.git("rev-parse", "--verify", "--quiet", "--end-of-options", version+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s@%s", constants.StorageObjectNotFoundError, name, version)
+	}
+
+	object, err := backend.lastCommit(strings.TrimSpace(commit), name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s@%s", constants.StorageObjectNotFoundError, name, version)
+	}
+
+	content, err := backend.show(object.Version, name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s@%s", constants.StorageObjectNotFoundError, name, version)
+	}
+	object.Content = []byte(content)
+
+	return object, nil
+}
+
+// lastCommit describes the commit that last touched name at revision
+func (backend *GitBackend) lastCommit(revision string, name string) (*models.StorageObject, error) {
+	output, err := backend.git("log", "-1", "--format=%H %ct", revision, "--", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCommit(name, output)
+}
+
+func parseCommit(name string, line string) (*models.StorageObject, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	timestamp, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StorageObject{
+		Name:    name,
+		Version: fields[0],
+		ModTime: time.Unix(timestamp, 0),
+	}, nil
+}
+
+// refresh clones the repository on first use and resets the working copy to the remote branch
+func (backend *GitBackend) refresh() error {
+	if _, err := os.Stat(filepath.Join(backend.workdir, ".git")); err != nil {
+		err := os.MkdirAll(filepath.Dir(backend.workdir), os.ModePerm)
+		if err != nil {
+			return err
+		}
+
+		logger.Debug(fmt.Sprintf("Cloning config repository `%s`", backend.repository))
+		_, err = run(execCommand("git", "clone", "--quiet", "--", backend.repository, backend.workdir))
+		if err != nil {
+			return fmt.Errorf("Failed to clone `%s`: %w", backend.repository, err)
+		}
+
+		err = backend.ensureIdentity()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := backend.git("fetch", "--quiet", "origin")
+	if err != nil {
+		return fmt.Errorf("Failed to fetch `%s`: %w", backend.repository, err)
+	}
+
+	remoteBranch := fmt.Sprintf("origin/%s", backend.branch)
+	if _, err := backend.git("rev-parse", "--verify", "--quiet", remoteBranch); err != nil {
+		// The branch does not exist yet, the first Put creates it
+		_, err = backend.git("checkout", "--quiet", "-B", backend.branch)
+		return err
+	}
+
+	_, err = backend.git("checkout", "--quiet", "-B", backend.branch, remoteBranch)
+	if err != nil {
+		return err
+	}
+
+	_, err = backend.git("reset", "--quiet", "--hard", remoteBranch)
+	return err
+}
+
+// ensureIdentity sets a committer for the working copy when the user has not configured one
+func (backend *GitBackend) ensureIdentity() error {
+	if _, err := backend.git("config", "user.email"); err == nil {
+		return nil
+	}
+
+	if _, err := backend.git("config", "user.name", "bbe-quest"); err != nil {
+		return err
+	}
+
+	_, err := backend.git("config", "user.email", "bbe-quest@localhost")
+	return err
+}
+
+func (backend *GitBackend) git(args ...string) (string, error) {
+	return run(execCommand("git", append([]string{"-C", backend.workdir}, args...)...))
+}
+
+// show reads name at revision, the content is left out of the log as it may hold Talos secrets
+func (backend *GitBackend) show(revision string, name string) (string, error) {
+	cmd := execCommand("git", "-C", backend.workdir, "show", fmt.Sprintf("%s:%s", revision, name))
+	output, err := cmd.Output()
+	logger.Command(cmd, nil, err)
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+func run(cmd *exec.Cmd) (string, error) {
+	output, err := cmd.Output()
+	logger.Command(cmd, output, err)
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,165 @@
+package storage_service
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GitBackend_Succeeds_PutAndGet(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+
+	err := backend.Put("bbe.yaml", []byte("bbe: {}"))
+	assert.NoError(t, err)
+
+	object, err := backend.Get("bbe.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bbe: {}"), object.Content)
+	assert.Len(t, object.Version, 40)
+	assert.False(t, object.ModTime.IsZero())
+}
+
+func Test_GitBackend_Succeeds_SharesChangesBetweenClones(t *testing.T) {
+	repository := initBareRepository(t)
+	first := NewGitBackend(filepath.Join(t.TempDir(), "first"), repository, "config")
+	second := NewGitBackend(filepath.Join(t.TempDir(), "second"), repository, "config")
+
+	assert.NoError(t, first.Put("bbe.yaml", []byte("one")))
+	assert.NoError(t, second.Put("talosconfig", []byte("two")))
+	assert.NoError(t, second.Put("bbe.yaml", []byte("three")))
+
+	object, err := first.Get("bbe.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("three"), object.Content)
+
+	objects, err := first.List()
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+}
+
+func Test_GitBackend_Succeeds_SkipsUnchangedContent(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("same")))
+	before, err := backend.Version("bbe.yaml")
+	assert.NoError(t, err)
+
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("same")))
+	after, err := backend.Version("bbe.yaml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, before.Version, after.Version)
+}
+
+func Test_GitBackend_Fails_VersionsOfEmptyRepository(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+
+	objects, err := backend.Versions("bbe.yaml")
+
+	assert.Nil(t, objects)
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_GitBackend_Succeeds_Delete(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+	assert.NoError(t, backend.Put("etcd/home.db", []byte("etcd")))
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("bbe: {}")))
+
+	err := backend.Delete("etcd/home.db")
+
+	assert.NoError(t, err)
+	objects, err := backend.List()
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+	assert.ErrorIs(t, backend.Delete("etcd/home.db"), constants.StorageObjectNotFoundError)
+}
+
+func Test_GitBackend_Fails_WhenObjectDoesNotExist(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+
+	object, err := backend.Get("bbe.yaml")
+
+	assert.Nil(t, object)
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_GitBackend_Fails_WhenRepositoryDoesNotExist(t *testing.T) {
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), filepath.Join(t.TempDir(), "missing.git"), "")
+
+	err := backend.Put("bbe.yaml", []byte("bbe"))
+
+	assert.Error(t, err)
+}
+
+func initBareRepository(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repository := filepath.Join(t.TempDir(), "config.git")
+	err := exec.Command("git", "init", "--quiet", "--bare", repository).Run()
+	assert.NoError(t, err)
+
+	return repository
+}
+
+func Test_GitBackend_Succeeds_VersionsAndGetVersion(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("one")))
+	assert.NoError(t, backend.Put("talosconfig", []byte("talos")))
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("two")))
+
+	versions, err := backend.Versions("bbe.yaml")
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	object, err := backend.GetVersion("bbe.yaml", versions[1].Version)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("one"), object.Content)
+	assert.Equal(t, versions[1].Version, object.Version)
+}
+
+func Test_GitBackend_Fails_GetVersionWithUnknownVersion(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("one")))
+
+	_, err := backend.GetVersion("bbe.yaml", "0123456789abcdef")
+
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_GitBackend_Fails_GetVersionWithOption(t *testing.T) {
+	repository := initBareRepository(t)
+	backend := NewGitBackend(filepath.Join(t.TempDir(), "clone"), repository, "")
+	assert.NoError(t, backend.Put("bbe.yaml", []byte("one")))
+	output := filepath.Join(t.TempDir(), "log")
+
+	_, err := backend.GetVersion("bbe.yaml", "--output="+output)
+
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+	assert.NoFileExists(t, output)
+}
+
+func Test_GitBackend_Fails_WithOptionAsRepository(t *testing.T) {
+	// Read as an option, the working copy path would be cloned from and the marker created
+	marker := filepath.Join(t.TempDir(), "marker")
+	backend := NewGitBackend(initBareRepository(t), "--upload-pack=touch "+marker, "")
+
+	_, err := backend.Versions("bbe.yaml")
+
+	assert.Error(t, err)
+	assert.NoFileExists(t, marker)
+}
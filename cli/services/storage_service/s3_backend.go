@@ -0,0 +1,224 @@
+package storage_service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Backend stores objects in a bucket on AWS or any S3 compatible endpoint
+type S3Backend struct {
+	client     interfaces.S3ServiceInterface
+	bucketName string
+}
+
+func NewS3Backend(client interfaces.S3ServiceInterface, bucketName string) *S3Backend {
+	return &S3Backend{client: client, bucketName: bucketName}
+}
+
+func (backend *S3Backend) Get(name string) (*models.StorageObject, error) {
+	output, err := backend.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(backend.bucketName),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, notFoundOr(err, name)
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &models.StorageObject{
+		Name:    name,
+		Version: s3Version(output.VersionId, output.ETag),
+		Content: content,
+	}
+	if output.LastModified != nil {
+		object.ModTime = *output.LastModified
+	}
+
+	return object, nil
+}
+
+func (backend *S3Backend) Put(name string, content []byte) error {
+	_, err := backend.client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(backend.bucketName),
+		Key:    aws.String(name),
+		Body:   bytes.NewReader(content),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to upload `%s` to bucket `%s`: %w", name, backend.bucketName, err)
+	}
+
+	return nil
+}
+
+// List reports the current version of every object, identified like Get and Version do
+func (backend *S3Backend) List() ([]models.StorageObject, error) {
+	objects := []models.StorageObject{}
+
+	input := &s3.ListObjectVersionsInput{Bucket: aws.String(backend.bucketName)}
+	for {
+		output, err := backend.client.ListObjectVersions(context.Background(), input)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list bucket `%s`: %w", backend.bucketName, err)
+		}
+
+		// Deleted objects only have a delete marker as their latest version and are not listed
+		for _, item := range output.Versions {
+			if !aws.ToBool(item.IsLatest) {
+				continue
+			}
+
+			object := models.StorageObject{
+				Name:    aws.ToString(item.Key),
+				Version: s3Version(item.VersionId, item.ETag),
+			}
+			if item.LastModified != nil {
+				object.ModTime = *item.LastModified
+			}
+			objects = append(objects, object)
+		}
+
+		if !aws.ToBool(output.IsTruncated) {
+			return objects, nil
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.VersionIdMarker = output.NextVersionIdMarker
+	}
+}
+
+func (backend *S3Backend) Version(name string) (*models.StorageObject, error) {
+	output, err := backend.client.HeadObject(context.Background(), &s3.HeadObjectInput{
+		Bucket: aws.String(backend.bucketName),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return nil, notFoundOr(err, name)
+	}
+
+	object := &models.StorageObject{
+		Name:    name,
+		Version: s3Version(output.VersionId, output.ETag),
+	}
+	if output.LastModified != nil {
+		object.ModTime = *output.LastModified
+	}
+
+	return object, nil
+}
+
+func (backend *S3Backend) Versions(name string) ([]models.StorageObject, error) {
+	objects := []models.StorageObject{}
+
+	input := &s3.ListObjectVersionsInput{
+		Bucket: aws.String(backend.bucketName),
+		Prefix: aws.String(name),
+	}
+	for {
+		output, err := backend.client.ListObjectVersions(context.Background(), input)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list versions of `%s` in bucket `%s`: %w", name, backend.bucketName, err)
+		}
+
+		// Versions of a key are returned newest first, the prefix may also match other keys
+		for _, item := range output.Versions {
+			if aws.ToString(item.Key) != name {
+				continue
+			}
+
+			object := models.StorageObject{
+				Name:    name,
+				Version: s3Version(item.VersionId, item.ETag),
+			}
+			if item.LastModified != nil {
+				object.ModTime = *item.LastModified
+			}
+			objects = append(objects, object)
+		}
+
+		if !aws.ToBool(output.IsTruncated) {
+			break
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.VersionIdMarker = output.NextVersionIdMarker
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	return objects, nil
+}
+
+func (backend *S3Backend) GetVersion(name string, version string) (*models.StorageObject, error) {
+	output, err := backend.client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket:    aws.String(backend.bucketName),
+		Key:       aws.String(name),
+		VersionId: aws.String(version),
+	})
+	if err != nil {
+		return nil, notFoundOr(err, fmt.Sprintf("%s@%s", name, version))
+	}
+	defer output.Body.Close()
+
+	content, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	object := &models.StorageObject{
+		Name:    name,
+		Version: version,
+		Content: content,
+	}
+	if output.LastModified != nil {
+		object.ModTime = *output.LastModified
+	}
+
+	return object, nil
+}
+
+// Delete removes the current version, a versioned bucket keeps the older versions until its lifecycle rules expire them
+func (backend *S3Backend) Delete(name string) error {
+	_, err := backend.client.DeleteObject(context.Background(), &s3.DeleteObjectInput{
+		Bucket: aws.String(backend.bucketName),
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to delete `%s` from bucket `%s`: %w", name, backend.bucketName, err)
+	}
+
+	return nil
+}
+
+// s3Version prefers the bucket version id and falls back to the ETag on buckets without versioning, which list their objects with version id "null"
+func s3Version(versionId *string, eTag *string) string {
+	if id := aws.ToString(versionId); id != "" && id != "null" {
+		return id
+	}
+
+	return aws.ToString(eTag)
+}
+
+func notFoundOr(err error, name string) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %s", constants.StorageObjectNotFoundError, name)
+	}
+
+	return err
+}
@@ -0,0 +1,156 @@
+package storage_service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/mocks"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_S3Backend_Succeeds_Get(t *testing.T) {
+	modTime := time.Now()
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("GetObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{
+		Body:         io.NopCloser(bytes.NewReader([]byte("bbe: {}"))),
+		LastModified: aws.Time(modTime),
+		VersionId:    aws.String("v2"),
+		ETag:         aws.String("etag"),
+	}, nil)
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	object, err := backend.Get("bbe.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bbe: {}"), object.Content)
+	assert.Equal(t, "v2", object.Version)
+	assert.Equal(t, modTime, object.ModTime)
+}
+
+func Test_S3Backend_Fails_GetWhenObjectDoesNotExist(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("GetObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.GetObjectOutput{}, &types.NoSuchKey{})
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	object, err := backend.Get("bbe.yaml")
+
+	assert.Nil(t, object)
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_S3Backend_Fails_PutWhenUploadFails(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("PutObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.PutObjectOutput{}, errors.New("access denied"))
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	err := backend.Put("bbe.yaml", []byte("bbe"))
+
+	assert.ErrorContains(t, err, "access denied")
+}
+
+func Test_S3Backend_Succeeds_ListAcrossPages(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListObjectVersions", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{
+			{Key: aws.String("bbe.yaml"), VersionId: aws.String("v2"), IsLatest: aws.Bool(true)},
+			{Key: aws.String("bbe.yaml"), VersionId: aws.String("v1"), IsLatest: aws.Bool(false)},
+		},
+		IsTruncated:   aws.Bool(true),
+		NextKeyMarker: aws.String("bbe.yaml"),
+	}, nil).Once()
+	mockS3Service.On("ListObjectVersions", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{{Key: aws.String("talosconfig"), VersionId: aws.String("v3"), IsLatest: aws.Bool(true)}},
+	}, nil).Once()
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	objects, err := backend.List()
+
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "v2", objects[0].Version)
+	assert.Equal(t, "talosconfig", objects[1].Name)
+}
+
+func Test_S3Backend_Succeeds_ListMatchesVersionWithoutVersioning(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListObjectVersions", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{{Key: aws.String("bbe.yaml"), VersionId: aws.String("null"), ETag: aws.String("etag"), IsLatest: aws.Bool(true)}},
+	}, nil)
+	mockS3Service.On("HeadObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+		VersionId: aws.String("null"),
+		ETag:      aws.String("etag"),
+	}, nil)
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	objects, err := backend.List()
+	assert.NoError(t, err)
+	object, err := backend.Version("bbe.yaml")
+	assert.NoError(t, err)
+
+	assert.Equal(t, object.Version, objects[0].Version)
+}
+
+func Test_S3Backend_Succeeds_VersionFallsBackToETag(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("HeadObject", mock.Anything, mock.Anything, mock.Anything).Return(&s3.HeadObjectOutput{
+		ETag: aws.String("etag"),
+	}, nil)
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	object, err := backend.Version("bbe.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "etag", object.Version)
+}
+
+func Test_S3Backend_Succeeds_VersionsSkipsOtherKeys(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListObjectVersions", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{
+		Versions: []types.ObjectVersion{
+			{Key: aws.String("bbe.yaml"), VersionId: aws.String("v2")},
+			{Key: aws.String("bbe.yaml"), VersionId: aws.String("v1")},
+			{Key: aws.String("bbe.yaml.bak"), VersionId: aws.String("v3")},
+		},
+	}, nil)
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	objects, err := backend.Versions("bbe.yaml")
+
+	assert.NoError(t, err)
+	assert.Len(t, objects, 2)
+	assert.Equal(t, "v2", objects[0].Version)
+}
+
+func Test_S3Backend_Fails_VersionsWhenObjectDoesNotExist(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("ListObjectVersions", mock.Anything, mock.Anything, mock.Anything).Return(&s3.ListObjectVersionsOutput{}, nil)
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	_, err := backend.Versions("bbe.yaml")
+
+	assert.ErrorIs(t, err, constants.StorageObjectNotFoundError)
+}
+
+func Test_S3Backend_Succeeds_GetVersion(t *testing.T) {
+	mockS3Service := &mocks.MockS3Service{}
+	mockS3Service.On("GetObject", mock.Anything, mock.MatchedBy(func(input *s3.GetObjectInput) bool {
+		return aws.ToString(input.VersionId) == "v1"
+	}), mock.Anything).Return(&s3.GetObjectOutput{
+		Body: io.NopCloser(bytes.NewReader([]byte("old"))),
+	}, nil)
+
+	backend := NewS3Backend(mockS3Service, "bucket")
+	object, err := backend.GetVersion("bbe.yaml", "v1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("old"), object.Content)
+	assert.Equal(t, "v1", object.Version)
+}
@@ -1,7 +1,11 @@
 package talos_service
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
@@ -9,6 +13,7 @@ import (
 
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/interfaces"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/clierror"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
 	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
 	"github.com/go-viper/mapstructure/v2"
@@ -18,6 +23,7 @@ import (
 var execCommand = exec.Command
 var tenSeconds = 10 * time.Second
 var fiveMinutes = 5 * time.Minute
+var healthCheckTimeout = 30 * time.Second
 
 var osReadFile = os.ReadFile
 var osWriteFile = os.WriteFile
@@ -28,7 +34,7 @@ func (talosService TalosService) Ping(nodeIp string) bool {
 	// First check that this is a Talos device by querying for disks
 	cmd := execCommand("talosctl", "-n", nodeIp, "get", "disks", "--insecure")
 	output, err := cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	if err != nil {
 		return false
@@ -37,7 +43,7 @@ func (talosService TalosService) Ping(nodeIp string) bool {
 	// If it is check if we get turned away by the machineconfig (if so it is likely to be in maintenance mode)
 	cmd = execCommand("talosctl", "-n", nodeIp, "get", "machineconfig", "--insecure")
 	output, err = cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	return err != nil
 }
@@ -45,7 +51,7 @@ func (talosService TalosService) Ping(nodeIp string) bool {
 func (talosService TalosService) GenerateConfig(helperService interfaces.HelperServiceInterface, controlPlaneIp string, clusterName string) error {
 	cmd := execCommand("talosctl", "gen", "config", clusterName, fmt.Sprintf("https://%s:6443", controlPlaneIp), "--output", helperService.GetConfigDir())
 	output, err := cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	if err != nil {
 		if strings.Contains(string(output), "already exists") {
@@ -62,7 +68,7 @@ func (talosService TalosService) JoinCluster(helperService interfaces.HelperServ
 
 	cmd := execCommand("talosctl", "apply-config", "--insecure", "-n", nodeIp, "--file", helperService.GetConfigFilePath(nodeConfigFile))
 	output, err := cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	if err != nil {
 		return err
@@ -81,14 +87,44 @@ func (talosService TalosService) BootstrapCluster(helperService interfaces.Helpe
 	for {
 		cmd := execCommand("talosctl", "bootstrap", "--nodes", nodeIp, "--endpoints", controlPlaneIp, fmt.Sprintf("--talosconfig=%s", configFilePath))
 		output, err := cmd.CombinedOutput()
-		logger.Debug(string(output))
+		logger.Command(cmd, output, err)
 
 		if err == nil {
 			return nil
 		}
 
 		if time.Since(start) > timeout {
-			return fmt.Errorf("Bootstrap failed after 5 minutes: %w", err)
+			return clierror.New(clierror.KindNodeUnreachable, fmt.Errorf("Bootstrap failed after 5 minutes: %w", err), fmt.Sprintf("Check that %s is powered on and reachable from this machine", nodeIp))
+		}
+
+		time.Sleep(tenSeconds)
+	}
+}
+
+// RecoverCluster bootstraps etcd on a fresh control plane from a snapshot instead of starting an empty cluster
+func (talosService TalosService) RecoverCluster(helperService interfaces.HelperServiceInterface, nodeIp string, controlPlaneIp string, snapshotPath string) error {
+	logger.Info("Recovering cluster from snapshot, this might take a few minutes...")
+
+	configFilePath := helperService.GetConfigFilePath(constants.TalosConfigFile)
+
+	start := time.Now()
+	timeout := fiveMinutes
+	for {
+		cmd := execCommand("talosctl", "bootstrap", fmt.Sprintf("--recover-from=%s", snapshotPath), "--nodes", nodeIp, "--endpoints", controlPlaneIp, fmt.Sprintf("--talosconfig=%s", configFilePath))
+		output, err := cmd.CombinedOutput()
+		logger.Command(cmd, output, err)
+
+		if err == nil {
+			return nil
+		}
+
+		// etcd only accepts a snapshot before it has been bootstrapped, retrying will not help
+		if strings.Contains(string(output), "AlreadyExists") {
+			return fmt.Errorf("etcd on %s is already bootstrapped, recovery requires a freshly installed control plane", nodeIp)
+		}
+
+		if time.Since(start) > timeout {
+			return clierror.New(clierror.KindNodeUnreachable, fmt.Errorf("Recovery failed after 5 minutes: %w", err), fmt.Sprintf("Check that %s is powered on and reachable from this machine", nodeIp))
 		}
 
 		time.Sleep(tenSeconds)
@@ -105,24 +141,37 @@ func (talosService TalosService) VerifyNodeHealth(helperService interfaces.Helpe
 	for {
 		cmd := execCommand("talosctl", "--nodes", nodeIp, "--endpoints", controlPlaneIp, "health", fmt.Sprintf("--talosconfig=%s", configFilePath))
 		output, err := cmd.CombinedOutput()
-		logger.Debug(string(output))
+		logger.Command(cmd, output, err)
 
 		if err == nil {
 			return nil
 		}
 
 		if time.Since(start) > timeout {
-			return fmt.Errorf("Cluster health check failed after 5 minutes: %w", err)
+			return clierror.New(clierror.KindNodeUnreachable, fmt.Errorf("Cluster health check failed after 5 minutes: %w", err), fmt.Sprintf("Check that %s is powered on and reachable, then run 'bbe status' to see the state of the cluster", nodeIp))
 		}
 
 		time.Sleep(tenSeconds)
 	}
 }
 
+// CheckHealth runs the cluster health checks of Talos once instead of waiting for the cluster to become healthy
+func (talosService TalosService) CheckHealth(helperService interfaces.HelperServiceInterface, controlPlaneIp string) error {
+	cmd := execCommand("talosctl", "--nodes", controlPlaneIp, "--endpoints", controlPlaneIp, "health", fmt.Sprintf("--wait-timeout=%s", healthCheckTimeout), fmt.Sprintf("--talosconfig=%s", helperService.GetConfigFilePath(constants.TalosConfigFile)))
+	output, err := cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
+
+	if err != nil {
+		return fmt.Errorf("Cluster health check failed: %w", err)
+	}
+
+	return nil
+}
+
 func (talosService TalosService) GetDisks(helperService interfaces.HelperServiceInterface, nodeIp string) ([]string, error) {
 	cmd := execCommand("bash", "-c", fmt.Sprintf(`talosctl -n %s get disks --insecure`, nodeIp))
 	output, err := cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	if err != nil {
 		return nil, err
@@ -137,7 +186,7 @@ func (talosService TalosService) GetDisks(helperService interfaces.HelperService
 func (talosService TalosService) GetNetworkInterface(helperService interfaces.HelperServiceInterface, nodeIp string) (string, error) {
 	cmd := execCommand("bash", "-c", fmt.Sprintf(`talosctl -n %s get addresses --talosconfig %s  --insecure |  awk '$0 ~ /%s/ {print $0}' | awk '{$1=""; print $NF}' | awk '{print $NF}'`, nodeIp, helperService.GetConfigFilePath(constants.TalosConfigFile), nodeIp))
 	output, err := cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	if err != nil {
 		return "", err
@@ -262,10 +311,11 @@ func (talosService TalosService) GetControlPlaneIp(helperService interfaces.Help
 	return endpoint, nil
 }
 
-func (talosService TalosService) DownloadKubeConfig(helperService interfaces.HelperServiceInterface, nodeIp string, controlPlaneIp string) error {
-	cmd := execCommand("talosctl", "kubeconfig", "--nodes", nodeIp, "--endpoints", controlPlaneIp, fmt.Sprintf("--talosconfig=%s", helperService.GetConfigFilePath(constants.TalosConfigFile)))
+// ExportKubeConfig writes a kubeconfig for the cluster to destination instead of merging it into ~/.kube/config
+func (talosService TalosService) ExportKubeConfig(helperService interfaces.HelperServiceInterface, controlPlaneIp string, destination string) error {
+	cmd := execCommand("talosctl", "kubeconfig", destination, "--force", "--nodes", controlPlaneIp, "--endpoints", controlPlaneIp, fmt.Sprintf("--talosconfig=%s", helperService.GetConfigFilePath(constants.TalosConfigFile)))
 	output, err := cmd.CombinedOutput()
-	logger.Debug(string(output))
+	logger.Command(cmd, output, err)
 
 	if err != nil {
 		return err
@@ -274,16 +324,71 @@ func (talosService TalosService) DownloadKubeConfig(helperService interfaces.Hel
 	return nil
 }
 
+// EtcdSnapshot streams a consistent etcd snapshot from the control plane to destination
+func (talosService TalosService) EtcdSnapshot(helperService interfaces.HelperServiceInterface, controlPlaneIp string, destination string) error {
+	cmd := execCommand("talosctl", "etcd", "snapshot", destination, "--nodes", controlPlaneIp, "--endpoints", controlPlaneIp, fmt.Sprintf("--talosconfig=%s", helperService.GetConfigFilePath(constants.TalosConfigFile)))
+	output, err := cmd.CombinedOutput()
+	logger.Command(cmd, output, err)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetMembers lists the nodes of the cluster found by Talos cluster discovery
+func (talosService TalosService) GetMembers(helperService interfaces.HelperServiceInterface, controlPlaneIp string) ([]models.ClusterMember, error) {
+	cmd := execCommand("talosctl", "get", "members", "--output", "json", "--nodes", controlPlaneIp, "--endpoints", controlPlaneIp, fmt.Sprintf("--talosconfig=%s", helperService.GetConfigFilePath(constants.TalosConfigFile)))
+	output, err := cmd.Output()
+	logger.Command(cmd, output, err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// talosctl prints one json document per resource
+	members := []models.ClusterMember{}
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	for {
+		var resource struct {
+			Spec struct {
+				Hostname        string   `json:"hostname"`
+				MachineType     string   `json:"machineType"`
+				Addresses       []string `json:"addresses"`
+				OperatingSystem string   `json:"operatingSystem"`
+			} `json:"spec"`
+		}
+
+		err := decoder.Decode(&resource)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse the cluster members: %w", err)
+		}
+
+		members = append(members, models.ClusterMember{
+			Hostname:        resource.Spec.Hostname,
+			MachineType:     resource.Spec.MachineType,
+			Addresses:       resource.Spec.Addresses,
+			OperatingSystem: resource.Spec.OperatingSystem,
+		})
+	}
+
+	return members, nil
+}
+
 func getParsedConfig(configDir string, configFile string) (*models.TalosMachineConfig, error) {
 	initialTalosConfig, err := osReadFile(fmt.Sprintf("%s/%s", configDir, configFile))
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("Failed to read %s: %w", configFile, err)
 	}
 
 	var yamlConfig map[string]interface{}
 	err = yaml.Unmarshal(initialTalosConfig, &yamlConfig)
 	if err != nil {
-		return nil, err
+		return nil, clierror.New(clierror.KindConfigInvalid, fmt.Errorf("Failed to parse %s: %w", configFile, err), fmt.Sprintf("Fix or remove %s in %s", configFile, configDir))
 	}
 
 	var parsedConfig models.TalosMachineConfig
@@ -307,5 +412,33 @@ func writeConfig(configDir string, configFile string, parsedConfig models.TalosM
 		return err
 	}
 
-	return osWriteFile(fmt.Sprintf("%s/%s", configDir, configFile), configToWrite, 0644)
+	return osWriteFile(fmt.Sprintf("%s/%s", configDir, configFile), configToWrite, 0600)
+}
+
+// GetNodeInventory reads the endpoints and nodes recorded in the talosconfig of the cluster
+func (talosService TalosService) GetNodeInventory(helperService interfaces.HelperServiceInterface) (*models.NodeInventory, error) {
+	talosconfig, err := osReadFile(helperService.GetConfigFilePath(constants.TalosConfigFile))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseNodeInventory(talosconfig)
+}
+
+// ParseNodeInventory reads the endpoints and nodes of the active context of a talosconfig
+func ParseNodeInventory(talosconfig []byte) (*models.NodeInventory, error) {
+	var config struct {
+		Context  string `yaml:"context"`
+		Contexts map[string]struct {
+			Endpoints []string `yaml:"endpoints"`
+			Nodes     []string `yaml:"nodes"`
+		} `yaml:"contexts"`
+	}
+	err := yaml.Unmarshal(talosconfig, &config)
+	if err != nil {
+		return nil, err
+	}
+
+	context := config.Contexts[config.Context]
+	return &models.NodeInventory{Endpoints: context.Endpoints, Nodes: context.Nodes}, nil
 }
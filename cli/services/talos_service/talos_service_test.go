@@ -3,6 +3,7 @@ package talos_service
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -179,6 +180,50 @@ func Test_BootstrapCluster_Fails_AfterTimeout(t *testing.T) {
 	helperService.AssertNumberOfCalls(t, "GetConfigFilePath", 1)
 }
 
+func Test_RecoverCluster_Succeeds_WaitsForRecoveryToSucceed(t *testing.T) {
+	cmdCalls := 0
+	var recoverArgs []string
+	execCommand = func(_ string, args ...string) *exec.Cmd {
+		cmdCalls++
+		recoverArgs = args
+		if cmdCalls == 1 {
+			return exec.Command("exit", "1")
+		}
+		return exec.Command("echo")
+	}
+	tenSeconds = time.Nanosecond
+	fiveMinutes = time.Minute * 5
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	err := talosService.RecoverCluster(&helperService, "127.0.0.1", "test", "/tmp/etcd.db")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, cmdCalls)
+	assert.Contains(t, recoverArgs, "--recover-from=/tmp/etcd.db")
+}
+
+func Test_RecoverCluster_Fails_WhenEtcdIsAlreadyBootstrapped(t *testing.T) {
+	cmdCalls := 0
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		cmdCalls++
+		return exec.Command("sh", "-c", "echo 'rpc error: code = AlreadyExists'; exit 1")
+	}
+	tenSeconds = time.Nanosecond
+	fiveMinutes = time.Minute * 5
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	err := talosService.RecoverCluster(&helperService, "127.0.0.1", "test", "/tmp/etcd.db")
+
+	assert.ErrorContains(t, err, "already bootstrapped")
+	assert.Equal(t, 1, cmdCalls)
+}
+
 func Test_VerifyNodeHealth_Succeeds_WaitsForBootstrapToSucceed(t *testing.T) {
 	cmdCalls := 0
 	execCommand = func(_ string, _ ...string) *exec.Cmd {
@@ -219,6 +264,37 @@ func Test_VerifyNodeHealth_Fails_AfterTimeout(t *testing.T) {
 	helperService.AssertNumberOfCalls(t, "GetConfigFilePath", 1)
 }
 
+func Test_CheckHealth_Succeeds(t *testing.T) {
+	var arguments []string
+	execCommand = func(_ string, args ...string) *exec.Cmd {
+		arguments = args
+		return exec.Command("true")
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	err := talosService.CheckHealth(&helperService, "127.0.0.1")
+
+	assert.Nil(t, err)
+	assert.Contains(t, arguments, "--wait-timeout=30s")
+}
+
+func Test_CheckHealth_Fails_WhenClusterIsUnhealthy(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	err := talosService.CheckHealth(&helperService, "127.0.0.1")
+
+	assert.ErrorContains(t, err, "Cluster health check failed")
+}
+
 func Test_GetDisks_Succeeds(t *testing.T) {
 	execCommand = func(_ string, _ ...string) *exec.Cmd {
 		return exec.Command("bash", "-c", "echo disk1")
@@ -747,7 +823,7 @@ func Test_GetControlPlaneIp_Fails_IfConfigNotValid(t *testing.T) {
 	osReadFile = os.ReadFile
 }
 
-func Test_DownloadKubeConfig_Succeeds(t *testing.T) {
+func Test_ExportKubeConfig_Succeeds(t *testing.T) {
 	execCommand = func(_ string, _ ...string) *exec.Cmd {
 		return exec.Command("echo")
 	}
@@ -756,23 +832,137 @@ func Test_DownloadKubeConfig_Succeeds(t *testing.T) {
 	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
 
 	talosService := TalosService{}
-	err := talosService.DownloadKubeConfig(&helperService, "127.0.0.1", "0.0.0.0")
+	err := talosService.ExportKubeConfig(&helperService, "192.168.1.10", "/tmp/kubeconfig")
 
 	assert.Nil(t, err)
 	helperService.AssertNumberOfCalls(t, "GetConfigFilePath", 1)
 }
 
-func Test_DownloadKubeConfig_Fails_IfTalosCtlFails(t *testing.T) {
+func Test_ExportKubeConfig_Fails_IfTalosCtlFails(t *testing.T) {
 	execCommand = func(_ string, _ ...string) *exec.Cmd {
-		return exec.Command("exit", "1")
+		return exec.Command("false")
 	}
 
 	helperService := mocks.MockHelperService{}
 	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
 
 	talosService := TalosService{}
-	err := talosService.DownloadKubeConfig(&helperService, "127.0.0.1", "0.0.0.0")
+	err := talosService.ExportKubeConfig(&helperService, "192.168.1.10", "/tmp/kubeconfig")
 
 	assert.NotNil(t, err)
-	helperService.AssertNumberOfCalls(t, "GetConfigFilePath", 1)
+}
+
+func Test_EtcdSnapshot_Succeeds(t *testing.T) {
+	var snapshotArgs []string
+	execCommand = func(_ string, args ...string) *exec.Cmd {
+		snapshotArgs = args
+		return exec.Command("echo")
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	err := talosService.EtcdSnapshot(&helperService, "192.168.1.10", "/tmp/etcd.db")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"etcd", "snapshot", "/tmp/etcd.db"}, snapshotArgs[:3])
+}
+
+func Test_EtcdSnapshot_Fails_IfTalosCtlFails(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	err := talosService.EtcdSnapshot(&helperService, "192.168.1.10", "/tmp/etcd.db")
+
+	assert.NotNil(t, err)
+}
+
+func Test_GetMembers_Succeeds(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", `{"node": "192.168.1.10", "spec": {"hostname": "cp-1", "machineType": "controlplane", "addresses": ["192.168.1.10"], "operatingSystem": "Talos (v1.9.4)"}}
+{"node": "192.168.1.10", "spec": {"hostname": "worker-1", "machineType": "worker", "addresses": ["192.168.1.11"], "operatingSystem": "Talos (v1.9.4)"}}`)
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	members, err := talosService.GetMembers(&helperService, "192.168.1.10")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []models.ClusterMember{
+		{Hostname: "cp-1", MachineType: "controlplane", Addresses: []string{"192.168.1.10"}, OperatingSystem: "Talos (v1.9.4)"},
+		{Hostname: "worker-1", MachineType: "worker", Addresses: []string{"192.168.1.11"}, OperatingSystem: "Talos (v1.9.4)"},
+	}, members)
+}
+
+func Test_GetMembers_Fails_IfOutputIsNotJson(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("echo", "NODE NAMESPACE TYPE")
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	members, err := talosService.GetMembers(&helperService, "192.168.1.10")
+
+	assert.Nil(t, members)
+	assert.ErrorContains(t, err, "Failed to parse the cluster members")
+}
+
+func Test_GetMembers_Fails_IfTalosCtlFails(t *testing.T) {
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("false")
+	}
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return("test")
+
+	talosService := TalosService{}
+	members, err := talosService.GetMembers(&helperService, "192.168.1.10")
+
+	assert.Nil(t, members)
+	assert.NotNil(t, err)
+}
+
+func Test_GetNodeInventory_Succeeds(t *testing.T) {
+	talosconfig := filepath.Join(t.TempDir(), constants.TalosConfigFile)
+	err := os.WriteFile(talosconfig, []byte(`context: home
+contexts:
+  home:
+    endpoints:
+      - 192.168.1.10
+    nodes:
+      - 192.168.1.10
+      - 192.168.1.11
+`), 0600)
+	assert.Nil(t, err)
+
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return(talosconfig)
+
+	talosService := TalosService{}
+	inventory, err := talosService.GetNodeInventory(&helperService)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"192.168.1.10"}, inventory.Endpoints)
+	assert.Equal(t, []string{"192.168.1.10", "192.168.1.11"}, inventory.Nodes)
+}
+
+func Test_GetNodeInventory_Fails_IfTalosConfigIsMissing(t *testing.T) {
+	helperService := mocks.MockHelperService{}
+	helperService.On("GetConfigFilePath", constants.TalosConfigFile).Return(filepath.Join(t.TempDir(), constants.TalosConfigFile))
+
+	talosService := TalosService{}
+	inventory, err := talosService.GetNodeInventory(&helperService)
+
+	assert.Nil(t, inventory)
+	assert.NotNil(t, err)
 }
@@ -0,0 +1,294 @@
+package ui_service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	browserTitleStyle  = lipgloss.NewStyle().Bold(true)
+	browserCursorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
+	browserMutedStyle  = lipgloss.NewStyle().Faint(true)
+	browserDetailStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+)
+
+// BrowsePackages shows the library packages with their details and returns the names of the selected packages, installed packages start out selected
+func (uiService UiService) BrowsePackages(title string, listings []models.PackageListing) ([]string, error) {
+	program := tea.NewProgram(newPackageBrowser(title, listings), tea.WithOutput(output.StatusWriter()), tea.WithAltScreen())
+
+	result, err := program.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	browser := result.(packageBrowser)
+	if !browser.confirmed {
+		return nil, constants.UserAbortError
+	}
+
+	return browser.chosen(), nil
+}
+
+// packageBrowser lists packages next to the details of the package under the cursor, space toggles a package, / filters and enter confirms
+type packageBrowser struct {
+	title     string
+	listings  []models.PackageListing
+	selected  []bool
+	visible   []int // Indexes of the listings matching the filter
+	cursor    int   // Position in visible
+	filter    textinput.Model
+	width     int
+	height    int
+	confirmed bool
+}
+
+func newPackageBrowser(title string, listings []models.PackageListing) packageBrowser {
+	filter := textinput.New()
+	filter.Prompt = "/"
+	filter.Placeholder = "name, category or description"
+
+	selected := make([]bool, len(listings))
+	for i, listing := range listings {
+		selected[i] = listing.Installed != ""
+	}
+
+	browser := packageBrowser{
+		title:    title,
+		listings: listings,
+		selected: selected,
+		filter:   filter,
+		width:    100,
+		height:   24,
+	}
+	browser.applyFilter()
+
+	return browser
+}
+
+func (browser packageBrowser) Init() tea.Cmd {
+	return nil
+}
+
+func (browser packageBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		browser.width = msg.Width
+		browser.height = msg.Height
+	case tea.KeyMsg:
+		if browser.filter.Focused() {
+			return browser.updateFilter(msg)
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return browser, tea.Quit
+		case "esc":
+			if browser.filter.Value() == "" {
+				return browser, tea.Quit
+			}
+			browser.filter.SetValue("")
+			browser.applyFilter()
+		case "enter":
+			browser.confirmed = true
+			return browser, tea.Quit
+		case "up", "k":
+			browser.cursor = max(browser.cursor-1, 0)
+		case "down", "j":
+			browser.cursor = min(browser.cursor+1, max(len(browser.visible)-1, 0))
+		case " ", "x":
+			if index, ok := browser.current(); ok {
+				browser.selected[index] = !browser.selected[index]
+			}
+		case "/":
+			return browser, browser.filter.Focus()
+		}
+	}
+
+	return browser, nil
+}
+
+// updateFilter edits the filter, enter keeps it and esc clears it
+func (browser packageBrowser) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return browser, tea.Quit
+	case tea.KeyEnter:
+		browser.filter.Blur()
+		return browser, nil
+	case tea.KeyEsc:
+		browser.filter.Blur()
+		browser.filter.SetValue("")
+		browser.applyFilter()
+		return browser, nil
+	}
+
+	var cmd tea.Cmd
+	browser.filter, cmd = browser.filter.Update(msg)
+	browser.applyFilter()
+
+	return browser, cmd
+}
+
+func (browser *packageBrowser) applyFilter() {
+	query := strings.ToLower(strings.TrimSpace(browser.filter.Value()))
+
+	browser.visible = []int{}
+	for i, listing := range browser.listings {
+		text := strings.ToLower(strings.Join([]string{listing.Name, listing.Chart.Category, listing.Chart.Description}, "\n"))
+		if strings.Contains(text, query) {
+			browser.visible = append(browser.visible, i)
+		}
+	}
+
+	browser.cursor = min(browser.cursor, max(len(browser.visible)-1, 0))
+}
+
+func (browser packageBrowser) current() (int, bool) {
+	if len(browser.visible) == 0 {
+		return 0, false
+	}
+
+	return browser.visible[browser.cursor], true
+}
+
+// chosen returns the names of the selected packages, including those hidden by the filter
+func (browser packageBrowser) chosen() []string {
+	names := []string{}
+	for i, listing := range browser.listings {
+		if browser.selected[i] {
+			names = append(names, listing.Name)
+		}
+	}
+
+	return names
+}
+
+func (browser packageBrowser) View() string {
+	var builder strings.Builder
+
+	builder.WriteString(browserTitleStyle.Render(browser.title) + "\n")
+	if browser.filter.Focused() || browser.filter.Value() != "" {
+		builder.WriteString(browser.filter.View() + "\n")
+	} else {
+		builder.WriteString(browserMutedStyle.Render(fmt.Sprintf("%d of %d packages selected", len(browser.chosen()), len(browser.listings))) + "\n")
+	}
+	builder.WriteString("\n")
+
+	listWidth := min(browser.width/2, 60)
+	list := lipgloss.NewStyle().Width(listWidth).Render(browser.viewList())
+	detail := browserDetailStyle.Width(max(browser.width-listWidth-4, 20)).Render(browser.viewDetail())
+	builder.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, list, detail) + "\n\n")
+
+	builder.WriteString(browserMutedStyle.Render("↑/↓ move • space toggle • / filter • enter confirm • q quit"))
+
+	return builder.String()
+}
+
+// viewList shows the packages around the cursor that fit the height of the terminal
+func (browser packageBrowser) viewList() string {
+	if len(browser.visible) == 0 {
+		return browserMutedStyle.Render("No packages match the filter")
+	}
+
+	rows := max(browser.height-8, 3)
+	start := max(min(browser.cursor-rows/2, len(browser.visible)-rows), 0)
+	end := min(start+rows, len(browser.visible))
+
+	nameWidth := 0
+	for _, index := range browser.visible {
+		nameWidth = max(nameWidth, len(browser.listings[index].Name))
+	}
+
+	lines := []string{}
+	for position := start; position < end; position++ {
+		index := browser.visible[position]
+		listing := browser.listings[index]
+
+		check := "[ ]"
+		if browser.selected[index] {
+			check = "[x]"
+		}
+
+		status := ""
+		if listing.Installed != "" {
+			status = "installed"
+		}
+
+		line := fmt.Sprintf("%s %-*s  %-10s  %-10s  %s", check, nameWidth, listing.Name, listing.Chart.Version, listing.Chart.Category, status)
+		if position == browser.cursor {
+			line = browserCursorStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (browser packageBrowser) viewDetail() string {
+	index, ok := browser.current()
+	if !ok {
+		return ""
+	}
+	listing := browser.listings[index]
+	chart := listing.Chart
+
+	var builder strings.Builder
+	builder.WriteString(browserTitleStyle.Render(listing.Name) + "\n")
+	if chart.Description != "" {
+		builder.WriteString(chart.Description + "\n")
+	}
+	builder.WriteString("\n")
+
+	installed := "no"
+	if listing.Installed != "" {
+		installed = listing.Installed
+	}
+
+	fields := [][2]string{
+		{"Category", chart.Category},
+		{"Version", chart.Version},
+		{"Installed", installed},
+		{"Library", chart.Library},
+		{"Homepage", chart.Homepage},
+		{"Icon", chart.Icon},
+		{"Dependencies", browser.dependencyStatus(chart)},
+	}
+	for _, field := range fields {
+		if field[1] != "" {
+			fmt.Fprintf(&builder, "%-13s %s\n", field[0], field[1])
+		}
+	}
+
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// dependencyStatus lists the dependencies of a chart and whether they are selected
+func (browser packageBrowser) dependencyStatus(chart models.ChartEntry) string {
+	dependencies := []string{}
+	for _, dependency := range chart.Dependencies {
+		index := slices.IndexFunc(browser.listings, func(listing models.PackageListing) bool {
+			return listing.Chart.Name == dependency && listing.Chart.Library == chart.Library
+		})
+
+		switch {
+		case index < 0:
+			dependencies = append(dependencies, dependency+" (not in library)")
+		case browser.selected[index]:
+			dependencies = append(dependencies, dependency)
+		default:
+			dependencies = append(dependencies, dependency+" (not selected)")
+		}
+	}
+
+	return strings.Join(dependencies, ", ")
+}
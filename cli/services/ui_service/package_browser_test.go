@@ -0,0 +1,84 @@
+package ui_service
+
+import (
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func testListings() []models.PackageListing {
+	return []models.PackageListing{
+		{Name: "blocky", Chart: models.ChartEntry{Name: "blocky", Version: "0.1.3", Category: "network", Description: "DNS proxy and ad-blocker", Library: "bbe"}, Installed: "0.1.3"},
+		{Name: "immich", Chart: models.ChartEntry{Name: "immich", Version: "0.9.0", Category: "media", Description: "Photo backup", Homepage: "https://immich.app", Dependencies: []string{"postgres", "redis"}, Library: "bbe"}},
+		{Name: "postgres", Chart: models.ChartEntry{Name: "postgres", Version: "16.1.0", Category: "database", Description: "Relational database", Library: "bbe"}},
+	}
+}
+
+func sendKeys(browser packageBrowser, keys ...string) packageBrowser {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+
+		model, _ := browser.Update(msg)
+		browser = model.(packageBrowser)
+	}
+
+	return browser
+}
+
+func Test_packageBrowser_Succeeds_PreselectsInstalledPackages(t *testing.T) {
+	browser := newPackageBrowser("Select packages", testListings())
+
+	assert.Equal(t, []string{"blocky"}, browser.chosen())
+}
+
+func Test_packageBrowser_Succeeds_TogglesPackageUnderCursor(t *testing.T) {
+	browser := sendKeys(newPackageBrowser("Select packages", testListings()), "down", " ", "enter")
+
+	assert.True(t, browser.confirmed)
+	assert.Equal(t, []string{"blocky", "immich"}, browser.chosen())
+}
+
+func Test_packageBrowser_Succeeds_FiltersByCategoryAndDescription(t *testing.T) {
+	browser := sendKeys(newPackageBrowser("Select packages", testListings()), "/", "d", "a", "t", "a")
+
+	assert.Equal(t, []int{2}, browser.visible)
+
+	browser = sendKeys(browser, "enter", " ")
+
+	assert.Equal(t, []string{"blocky", "postgres"}, browser.chosen())
+
+	browser = sendKeys(browser, "esc")
+
+	assert.Len(t, browser.visible, 3)
+}
+
+func Test_packageBrowser_Succeeds_ShowsDetailsOfPackageUnderCursor(t *testing.T) {
+	browser := sendKeys(newPackageBrowser("Select packages", testListings()), "down")
+
+	detail := browser.viewDetail()
+
+	assert.Contains(t, detail, "Photo backup")
+	assert.Contains(t, detail, "https://immich.app")
+	assert.Contains(t, detail, "postgres (not selected), redis (not in library)")
+	assert.Contains(t, browser.View(), "1 of 3 packages selected")
+}
+
+func Test_packageBrowser_Succeeds_QuitsWithoutConfirming(t *testing.T) {
+	browser := sendKeys(newPackageBrowser("Select packages", testListings()), " ", "q")
+
+	assert.False(t, browser.confirmed)
+}
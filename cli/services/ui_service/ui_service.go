@@ -1,36 +1,65 @@
 package ui_service
 
 import (
+	"errors"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/output"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cqroot/prompt"
+	"github.com/cqroot/prompt/input"
 	"github.com/cqroot/prompt/multichoose"
 )
 
 type UiService struct{}
 
+// newPrompt draws prompts on stderr when stdout is reserved for a structured result
+func newPrompt() *prompt.Prompt {
+	return prompt.New(prompt.WithTeaProgramOpts(tea.WithOutput(output.StatusWriter())))
+}
+
+// promptError reports quitting a prompt with ctrl+c or esc as UserAbortError
+func promptError(err error) error {
+	if errors.Is(err, prompt.ErrUserQuit) {
+		return constants.UserAbortError
+	}
+
+	return err
+}
+
 func (uiService UiService) CreateSelect(title string, options []string) (string, error) {
-	result, err := prompt.New().Ask(title).
+	result, err := newPrompt().Ask(title).
 		Choose(options)
 	if err != nil {
-		return "", err
+		return "", promptError(err)
 	}
 
 	return result, nil
 }
 
 func (uiService UiService) CreateInput(title string, suggestion string) (string, error) {
-	result, err := prompt.New().Ask(title).Input(suggestion)
+	result, err := newPrompt().Ask(title).Input(suggestion)
+	if err != nil {
+		return "", promptError(err)
+	}
+
+	return result, nil
+}
+
+func (uiService UiService) CreatePasswordInput(title string) (string, error) {
+	result, err := newPrompt().Ask(title).Input("", input.WithEchoMode(input.EchoPassword))
 	if err != nil {
-		return "", err
+		return "", promptError(err)
 	}
 
 	return result, nil
 }
 
 func (uiService UiService) CreateMultiChoose(title string, options []string, defaultIndex []int) ([]string, error) {
-	result, err := prompt.New().Ask(title).
+	result, err := newPrompt().Ask(title).
 		MultiChoose(options, multichoose.WithDefaultIndexes(0, defaultIndex))
 	if err != nil {
-		return nil, err
+		return nil, promptError(err)
 	}
 
 	return result, nil
@@ -0,0 +1,221 @@
+package update_service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/misc/logger"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+)
+
+type UpdateService struct{}
+
+type githubRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name               string `json:"name"`
+		BrowserDownloadUrl string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+var httpClient = &http.Client{
+	Timeout: 5 * time.Minute,
+}
+
+var goos = runtime.GOOS
+var goarch = runtime.GOARCH
+
+func releasesUrl() string {
+	if url := os.Getenv(constants.ReleasesUrlEnvVar); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+
+	return constants.BbeReleasesUrl
+}
+
+// FindRelease returns the latest release of bbe, or the release tagged version when one is given
+func (updateService UpdateService) FindRelease(version string) (*models.Release, error) {
+	releaseUrl := releasesUrl() + "/latest"
+	if version != "" {
+		releaseUrl = fmt.Sprintf("%s/tags/%s", releasesUrl(), strings.TrimPrefix(version, "v"))
+	}
+
+	logger.Debug(fmt.Sprintf("Fetching release from %s", releaseUrl))
+	resp, err := httpClient.Get(releaseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch release: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		if version == "" {
+			return nil, constants.ReleaseNotFoundError
+		}
+		return nil, fmt.Errorf("%w: %s", constants.ReleaseNotFoundError, version)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status fetching release: %s", resp.Status)
+	}
+
+	var release githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("Failed to parse release: %w", err)
+	}
+
+	result := &models.Release{
+		Version: strings.TrimPrefix(release.TagName, "v"),
+		Assets:  map[string]string{},
+	}
+	for _, asset := range release.Assets {
+		result.Assets[asset.Name] = asset.BrowserDownloadUrl
+	}
+
+	return result, nil
+}
+
+// Install downloads the release for the current platform, verifies its checksum and replaces executable with the bbe binary of the release
+func (updateService UpdateService) Install(release *models.Release, executable string) error {
+	archiveName, archiveUrl, found := platformAsset(release)
+	if !found {
+		return fmt.Errorf("Release %s has no build for %s/%s", release.Version, goos, goarch)
+	}
+
+	checksumUrl, found := release.Assets[archiveName+constants.ReleaseChecksumExtension]
+	if !found {
+		return fmt.Errorf("%w: release %s has no checksum for %s", constants.ReleaseChecksumError, release.Version, archiveName)
+	}
+
+	archive, err := download(archiveUrl)
+	if err != nil {
+		return err
+	}
+
+	checksum, err := download(checksumUrl)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyChecksum(archive, checksum); err != nil {
+		return fmt.Errorf("%w: %s", err, archiveName)
+	}
+
+	binary, err := extractBinary(archive)
+	if err != nil {
+		return err
+	}
+
+	return replaceExecutable(executable, binary)
+}
+
+func platformAsset(release *models.Release) (string, string, bool) {
+	suffix := fmt.Sprintf("-%s-%s.tar.gz", goos, goarch)
+	for name, url := range release.Assets {
+		if strings.HasSuffix(name, suffix) {
+			return name, url, true
+		}
+	}
+
+	return "", "", false
+}
+
+func download(url string) ([]byte, error) {
+	logger.Debug(fmt.Sprintf("Downloading %s", url))
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status downloading %s: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to download %s: %w", url, err)
+	}
+
+	return body, nil
+}
+
+// verifyChecksum compares the sha256 of archive with a checksum file, which holds the hex digest optionally followed by the file name
+func verifyChecksum(archive []byte, checksum []byte) error {
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: empty checksum", constants.ReleaseChecksumError)
+	}
+
+	digest := sha256.Sum256(archive)
+	if !strings.EqualFold(fields[0], hex.EncodeToString(digest[:])) {
+		return constants.ReleaseChecksumError
+	}
+
+	return nil
+}
+
+func extractBinary(archive []byte) ([]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read release archive: %w", err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("Release archive does not contain the bbe binary")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read release archive: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == "bbe" {
+			return io.ReadAll(tarReader)
+		}
+	}
+}
+
+// replaceExecutable writes binary next to executable and renames it into place, so the old binary is only replaced by a complete new one
+func replaceExecutable(executable string, binary []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(executable), ".bbe-update-*")
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("No permission to replace %s, run the update as the owner of the file or with sudo: %w", executable, err)
+		}
+		return fmt.Errorf("Failed to write the new binary: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(binary); err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to write the new binary: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("Failed to write the new binary: %w", err)
+	}
+
+	if err := os.Chmod(file.Name(), 0755); err != nil {
+		return fmt.Errorf("Failed to make the new binary executable: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), executable); err != nil {
+		return fmt.Errorf("Failed to replace %s: %w", executable, err)
+	}
+
+	return nil
+}
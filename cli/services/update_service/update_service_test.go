@@ -0,0 +1,187 @@
+package update_service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/constants"
+	"github.com/Brains-Beyond-Expectations/bbe-quest/cli/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReleaseServer serves the GitHub releases API for a single release and its assets
+func newReleaseServer(t *testing.T, tag string, archive []byte, checksum string) *httptest.Server {
+	archiveName := fmt.Sprintf("bbe-%s-%s-%s.tar.gz", tag, goos, goarch)
+
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	release := func(w http.ResponseWriter, r *http.Request) {
+		assets := fmt.Sprintf(`{"name": "%s", "browser_download_url": "%s/download/archive"}`, archiveName, server.URL)
+		if checksum != "" {
+			assets += fmt.Sprintf(`, {"name": "%s.sha256", "browser_download_url": "%s/download/checksum"}`, archiveName, server.URL)
+		}
+		fmt.Fprintf(w, `{"tag_name": "%s", "assets": [%s]}`, tag, assets)
+	}
+	mux.HandleFunc("/releases/latest", release)
+	mux.HandleFunc("/releases/tags/"+tag, release)
+	mux.HandleFunc("/download/archive", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive)
+	})
+	mux.HandleFunc("/download/checksum", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s  %s\n", checksum, archiveName)
+	})
+
+	server = httptest.NewServer(mux)
+	t.Setenv(constants.ReleasesUrlEnvVar, server.URL+"/releases")
+
+	return server
+}
+
+func createArchive(t *testing.T, name string, content []byte) []byte {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	assert.NoError(t, err)
+	_, err = tarWriter.Write(content)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tarWriter.Close())
+	assert.NoError(t, gzipWriter.Close())
+
+	return buffer.Bytes()
+}
+
+func sha256Hex(content []byte) string {
+	digest := sha256.Sum256(content)
+	return hex.EncodeToString(digest[:])
+}
+
+func createExecutable(t *testing.T) string {
+	executable := filepath.Join(t.TempDir(), "bbe")
+	err := os.WriteFile(executable, []byte("old"), 0755)
+	assert.NoError(t, err)
+
+	return executable
+}
+
+func Test_FindRelease_Succeeds_ReturnsLatestRelease(t *testing.T) {
+	ts := newReleaseServer(t, "1.4.0", nil, "abc")
+	defer ts.Close()
+
+	release, err := UpdateService{}.FindRelease("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.4.0", release.Version)
+	assert.Len(t, release.Assets, 2)
+}
+
+func Test_FindRelease_Succeeds_WithPinnedVersion(t *testing.T) {
+	ts := newReleaseServer(t, "1.2.0", nil, "abc")
+	defer ts.Close()
+
+	release, err := UpdateService{}.FindRelease("v1.2.0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0", release.Version)
+}
+
+func Test_FindRelease_Fails_WhenVersionDoesNotExist(t *testing.T) {
+	ts := newReleaseServer(t, "1.4.0", nil, "abc")
+	defer ts.Close()
+
+	release, err := UpdateService{}.FindRelease("9.9.9")
+
+	assert.Nil(t, release)
+	assert.ErrorIs(t, err, constants.ReleaseNotFoundError)
+}
+
+func Test_Install_Succeeds_ReplacesExecutable(t *testing.T) {
+	archive := createArchive(t, "bbe", []byte("new"))
+	ts := newReleaseServer(t, "1.4.0", archive, sha256Hex(archive))
+	defer ts.Close()
+	executable := createExecutable(t)
+
+	updateService := UpdateService{}
+	release, err := updateService.FindRelease("")
+	assert.NoError(t, err)
+
+	err = updateService.Install(release, executable)
+
+	assert.NoError(t, err)
+	content, _ := os.ReadFile(executable)
+	assert.Equal(t, "new", string(content))
+	info, _ := os.Stat(executable)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	entries, _ := os.ReadDir(filepath.Dir(executable))
+	assert.Len(t, entries, 1)
+}
+
+func Test_Install_Fails_WhenChecksumDoesNotMatch(t *testing.T) {
+	archive := createArchive(t, "bbe", []byte("new"))
+	ts := newReleaseServer(t, "1.4.0", archive, sha256Hex([]byte("tampered")))
+	defer ts.Close()
+	executable := createExecutable(t)
+
+	updateService := UpdateService{}
+	release, _ := updateService.FindRelease("")
+
+	err := updateService.Install(release, executable)
+
+	assert.ErrorIs(t, err, constants.ReleaseChecksumError)
+	content, _ := os.ReadFile(executable)
+	assert.Equal(t, "old", string(content))
+}
+
+func Test_Install_Fails_WhenReleaseHasNoChecksum(t *testing.T) {
+	archive := createArchive(t, "bbe", []byte("new"))
+	ts := newReleaseServer(t, "1.4.0", archive, "")
+	defer ts.Close()
+	executable := createExecutable(t)
+
+	updateService := UpdateService{}
+	release, _ := updateService.FindRelease("")
+
+	err := updateService.Install(release, executable)
+
+	assert.ErrorIs(t, err, constants.ReleaseChecksumError)
+	content, _ := os.ReadFile(executable)
+	assert.Equal(t, "old", string(content))
+}
+
+func Test_Install_Fails_WhenArchiveHasNoBinary(t *testing.T) {
+	archive := createArchive(t, "README.md", []byte("readme"))
+	ts := newReleaseServer(t, "1.4.0", archive, sha256Hex(archive))
+	defer ts.Close()
+	executable := createExecutable(t)
+
+	updateService := UpdateService{}
+	release, _ := updateService.FindRelease("")
+
+	err := updateService.Install(release, executable)
+
+	assert.ErrorContains(t, err, "does not contain the bbe binary")
+	content, _ := os.ReadFile(executable)
+	assert.Equal(t, "old", string(content))
+}
+
+func Test_Install_Fails_WhenPlatformHasNoBuild(t *testing.T) {
+	release := &models.Release{
+		Version: "1.4.0",
+		Assets:  map[string]string{"bbe-1.4.0-plan9-mips.tar.gz": "http://localhost/unused"},
+	}
+
+	err := UpdateService{}.Install(release, createExecutable(t))
+
+	assert.ErrorContains(t, err, "has no build for")
+}